@@ -79,7 +79,12 @@ func TestEveryBankQuestion(t *testing.T) {
 			for _, f := range g.AnswerFields {
 				ans[f.ID] = dsl.ValueToCanonicalString(f.Value)
 			}
-			jr, err := JudgeBankQuestion(key, seed+":"+key, salt, ans, nil)
+			// 不带版本的题键按最新版出题、按 bank-v1 判分，判题须带出题时的版本
+			rolled, err := ResolveQuestionKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jr, err := JudgeBankQuestion(rolled, seed+":"+key, salt, ans, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -236,3 +241,25 @@ func TestChapter5ThreeDiagonalAdj(t *testing.T) {
 		}
 	}
 }
+
+// TestCompileAll 全部已注册题目都能通过 dsl.Compile 的静态检查（语法、函数名、派生依赖）。
+func TestCompileAll(t *testing.T) {
+	if err := CompileAll(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidateAll 对每个题目构造器做静态检查，不允许出现 error 级别诊断。
+func TestValidateAll(t *testing.T) {
+	for _, key := range AllQuestionKeys {
+		p, err := BuildProblem(key)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		for _, d := range dsl.ValidateProblem(p) {
+			if d.Severity == dsl.SeverityError {
+				t.Errorf("%s: %s", key, d)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 )
 
 // 题库所有题的标准答案值类型须落在有理数判分路径支持的集合内；不得出现矩阵整体、字符串等。
+// 声明 judge kind "interval" 的区间空例外：值须为 *dsl.IntervalSet，且规范写法可被 ParseUserInterval 读回。
 func TestAllBankAnswerValueKinds(t *testing.T) {
 	seed := "audit-answer-value-kind"
 	salt := "audit-salt"
@@ -24,6 +25,16 @@ func TestAllBankAnswerValueKinds(t *testing.T) {
 				t.Fatal(err)
 			}
 			for _, f := range g.AnswerFields {
+				if f.Judge != nil && f.Judge.Kind == "interval" {
+					iv, ok := f.Value.(*dsl.IntervalSet)
+					if !ok {
+						t.Fatalf("interval field %s has value type %T", f.ID, f.Value)
+					}
+					if ok, note := dsl.IntervalAnswersEqual(iv, iv.String()); !ok {
+						t.Fatalf("canonical interval %s for %s does not judge correct: %s", iv, f.ID, note)
+					}
+					continue
+				}
 				typ := fmt.Sprintf("%T", f.Value)
 				if _, ok := allowed[typ]; !ok {
 					t.Fatalf("unsupported answer value type %s for field %s", typ, f.ID)
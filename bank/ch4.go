@@ -405,7 +405,7 @@ func buildChapter4_6() dsl.Problem {
 		},
 		Derived: map[string]string{
 			"system_title": "param_system_title(A)",
-			"b3":           "_param_b",
+			"b3":           "A.b",
 		},
 		Render: map[string]string{"system_title": "system_title"},
 		Answer: dsl.AnswerSchema{FieldDefs: fds},
@@ -262,6 +262,44 @@ func buildChapter6_5() dsl.Problem {
 	}
 }
 
+// buildChapter6_5V2 为 Chapter6_5 的 bank-v2：取值范围整体作为区间空（judge kind "interval"），
+// 学生填写 t>a 或 (a, +∞) 等写法，不必猜题面中 $t>$ 之后该填什么。
+func buildChapter6_5V2() dsl.Problem {
+	k := "Chapter6_5"
+	id := BlankIDs(k, 1)[0]
+	return dsl.Problem{
+		ID:      ProblemID(k),
+		Version: "bank-v2",
+		Title: fmt.Sprintf(
+			`若二次型 $f(x_1,x_2,x_3)={{expr}}$ 为正定二次型，则参数 $t$ 的取值范围是 {{blank:%s}}`,
+			id),
+		Variables: map[string]dsl.Variable{
+			"S": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "sylvester_range", "entry_min": -5, "entry_max": 5}},
+		},
+		Derived: map[string]string{
+			"expr":  "quad_expr_param(S)",
+			"range": "sylvester_interval(S)",
+		},
+		Render: map[string]string{"expr": "expr"},
+		Answer: dsl.AnswerSchema{FieldDefs: []dsl.AnswerFieldDef{
+			{ID: id, Expr: "range", Judge: &dsl.AnswerJudgeSpec{Kind: "interval"}},
+		}},
+		Meta: map[string]interface{}{
+			"bank_topic": "positive_definite_t_range",
+			"solution_zh": `**解题思路：** 实对称矩阵正定的充要条件是其各阶顺序主子式全部大于零（Sylvester 准则）。把每个主子式条件写成关于 $t$ 的不等式，它们的解集的交集就是所求的取值范围，答案应整体写成区间（或等价的不等式）。
+
+	**步骤 1：** 由 $f(x_1,x_2,x_3)={{expr}}$ 写出对称矩阵 $S$。$S_{ii}$ 为 $x_i^2$ 的系数（含 $t$），$S_{ij}=S_{ji}$ 为 $x_ix_j$ 系数的一半。本题 $S={{S}}$，其中参数 $t$ 位于 $S_{33}$（$x_3^2$ 的系数）。
+
+	**步骤 2：** 逐个写出主子式条件的解集。
+	- 一阶：$\Delta_1=S_{11}={{expr:mget(S,1,1)}}>0$，与 $t$ 无关，对一切 $t$ 成立，解集为 $(-\infty,+\infty)$。
+	- 二阶：$\Delta_2=S_{11}S_{22}-S_{12}^2=({{expr:mget(S,1,1)}})\times({{expr:mget(S,2,2)}})-({{expr:mget(S,1,2)}})^2>0$，同样与 $t$ 无关，解集为 $(-\infty,+\infty)$。
+	- 三阶：$\Delta_3=\det(S)$。$t$ 只出现在 $S_{33}$，按第三行展开得 $\Delta_3=\Delta_2\cdot t+C$，其中 $C$ 是 $S_{33}=0$ 时的行列式值。因 $\Delta_2>0$，$\Delta_3>0$ 等价于 $t>\frac{-C}{\Delta_2}={{expr:sylvester_lower(S)}}$，解集为 $({{expr:sylvester_lower(S)}},+\infty)$。
+
+	**步骤 3：** 三个解集取交集。前两个条件不限制 $t$，故取值范围就是 $\Delta_3>0$ 的解集 $t\in({{expr:sylvester_lower(S)}},+\infty)$。不等号是严格的，端点 ${{expr:sylvester_lower(S)}}$ 处 $\Delta_3=0$，$S$ 只是半正定，所以区间在该端点处为开。填写时区间写法与 $t>{{expr:sylvester_lower(S)}}$ 等价。`,
+		},
+	}
+}
+
 func buildChapter6_6() dsl.Problem {
 	k := "Chapter6_6"
 	ids := BlankIDs(k, 10)
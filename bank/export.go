@@ -0,0 +1,115 @@
+package bank
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// 导出：把 Go builder 构造的题目写成与 LoadProblemFS 相同格式的 JSON 题目文件，
+// 便于以数据形式评审题库改动、与其他工具共享题库。输出是规范的：同一题目总是得到相同的字节。
+
+// ExportProblem 返回题键（可带版本）对应的题目文件；Fixed 转为线上格式（见 dsl.FixedWire），
+// 含 Meta["solution_zh"]、判题规格与布局。
+func ExportProblem(questionKey string) (*ProblemFile, error) {
+	p, err := BuildProblem(questionKey)
+	if err != nil {
+		return nil, err
+	}
+	base, _, _ := splitVersionedKey(questionKey)
+	n, ok := ChapterNoOf(base)
+	if !ok {
+		return nil, fmt.Errorf("bank: export %s: no chapter in key", questionKey)
+	}
+	if len(p.Variables) > 0 {
+		vars := make(map[string]dsl.Variable, len(p.Variables))
+		for name, v := range p.Variables {
+			if v.Fixed != nil {
+				if v.Fixed, err = dsl.FixedWire(v); err != nil {
+					return nil, fmt.Errorf("bank: export %s: variables.%s: %w", questionKey, name, err)
+				}
+			}
+			vars[name] = v
+		}
+		p.Variables = vars
+	}
+	return &ProblemFile{Key: base, Chapter: n, ChapterTitle: ChapterTitle(n), Problem: p}, nil
+}
+
+// MarshalProblemFile 返回题目文件的规范 JSON：两格缩进、map 键按字典序、不转义 <>&，以换行结尾。
+func MarshalProblemFile(f *ProblemFile) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return nil, fmt.Errorf("bank: export %s: %w", f.Key, err)
+	}
+	return collapseScalarArrays(buf.Bytes()), nil
+}
+
+// collapseScalarArrays 把缩进输出中只含数、布尔、null 的数组写在一行，如矩阵的每一行写作 [1, 0, -2]。
+func collapseScalarArrays(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if !strings.HasSuffix(line, "[") {
+			out = append(out, line)
+			continue
+		}
+		var items []string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			item := strings.TrimSuffix(strings.TrimSpace(lines[j]), ",")
+			if item == "]" || !isJSONScalar(item) {
+				break
+			}
+			items = append(items, item)
+		}
+		if j == len(lines) || len(items) == 0 || !strings.HasPrefix(strings.TrimSpace(lines[j]), "]") {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, line+strings.Join(items, ", ")+strings.TrimSpace(lines[j]))
+		i = j
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+func isJSONScalar(s string) bool {
+	switch s {
+	case "true", "false", "null":
+		return true
+	}
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9')
+}
+
+// ExportProblems 把 keys（nil 表示 AllQuestionKeys）逐题写入 dir/<题键>.json，目录不存在时创建。
+func ExportProblems(dir string, keys []string) error {
+	if keys == nil {
+		keys = AllQuestionKeys
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		f, err := ExportProblem(key)
+		if err != nil {
+			return err
+		}
+		b, err := MarshalProblemFile(f)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, key+".json"), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package bank
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// TestExportProblem_roundTrip 全部题目：导出的 JSON 读回后再导出字节不变，出题结果、判题规格与原 builder 一致。
+func TestExportProblem_roundTrip(t *testing.T) {
+	keys := append(append([]string{}, AllQuestionKeys...), "Chapter5_2_n4", "Chapter4_8@bank-v1")
+	for _, key := range keys {
+		f, err := ExportProblem(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := MarshalProblemFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := parseProblemFile(key+".json", data)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		again, err := MarshalProblemFile(back)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Fatalf("%s: export not canonical", key)
+		}
+		orig, err := CompiledProblem(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp, err := dsl.Compile(back.Problem)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if !reflect.DeepEqual(cp.Problem.Answer, orig.Problem.Answer) || !reflect.DeepEqual(cp.Problem.Meta, orig.Problem.Meta) {
+			t.Fatalf("%s: answer schema or meta changed", key)
+		}
+		for _, seed := range []string{"export-1", "export-2"} {
+			want, err := orig.GenerateQuestion(seed, "salt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := cp.GenerateQuestion(seed, "salt")
+			if err != nil {
+				t.Fatalf("%s: %v", key, err)
+			}
+			if got.Title != want.Title || len(got.AnswerFields) != len(want.AnswerFields) {
+				t.Fatalf("%s %s: title or field count differs", key, seed)
+			}
+			for i, w := range want.AnswerFields {
+				g := got.AnswerFields[i]
+				if g.ID != w.ID || dsl.ValueToCanonicalString(g.Value) != dsl.ValueToCanonicalString(w.Value) || !reflect.DeepEqual(g.Layout, w.Layout) {
+					t.Fatalf("%s %s: field %s differs", key, seed, w.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestExportProblems(t *testing.T) {
+	dir := t.TempDir()
+	if err := ExportProblems(dir, []string{"Chapter2_6", "Chapter5_2"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Chapter2_6.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(data)
+	for _, want := range []string{
+		"{\n  \"key\": \"Chapter2_6\",\n  \"chapter\": 2,\n  \"chapter_title\": \"矩阵\",\n  \"problem\": {",
+		"\"fixed\": [\n          [1, 0, 0],\n",
+		`"solution_zh": "`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("missing %q in\n%s", want, s)
+		}
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "Chapter5_2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); strings.Contains(s, `\u0026`) || !strings.Contains(s, `\\lambda-({{expr:mget(A,1,1)}}) & `) {
+		t.Fatal("LaTeX in solution_zh not kept verbatim")
+	}
+	if err := ExportProblems(dir, []string{"nope"}); err == nil {
+		t.Fatal("expect error for unknown key")
+	}
+}
@@ -0,0 +1,208 @@
+package bank
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// 只为个别题目服务的表达式函数，在此注册而不放进 dsl。
+
+func init() {
+	register := func(name, sig string, fn dsl.FunctionImpl) {
+		dsl.RegisterFunction(name, dsl.MustParseSignature(sig), fn)
+	}
+
+	// symcode_611(S)（Chapter6_1_1 及其 n 阶版本）：正定 1，负定 0，不定 2
+	register("symcode_611", "matrix -> int", symCode(1, 0, 2))
+	// symcode_612(S)（Chapter6_1_2 / 6_1_3）：正定 1，负定 2，不定 0
+	register("symcode_612", "matrix -> int", symCode(1, 2, 0))
+
+	// vcoef123(V,rhs,k)（Chapter3_11）：用 V 的第 1,2,3 列线性表示第 rhs 列时的第 k 个系数（k=1..3）。
+	register("vcoef123", "matrix, int, int -> scalar", func(c *dsl.Call) (interface{}, error) {
+		V, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		k, err := c.Int(2)
+		if err != nil {
+			return nil, err
+		}
+		sol, err := dsl.SolveCoeffCols(V, []int{1, 2, 3}, rhs)
+		if err != nil {
+			return nil, err
+		}
+		if k < 1 || k > len(sol) {
+			return nil, fmt.Errorf("vcoef123 k out of range")
+		}
+		return sol[k-1], nil
+	})
+
+	// diag_adj(A,i)（Chapter5_3）：3×3 对角阵 A 的伴随阵第 i 个对角元 det(A)/a_ii。
+	register("diag_adj", "matrix, int -> int", func(c *dsl.Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		if m.R != 3 || m.C != 3 {
+			return nil, fmt.Errorf("diag_adj needs 3×3 matrix")
+		}
+		if i < 1 || i > 3 {
+			return nil, fmt.Errorf("diag_adj i out of range")
+		}
+		if !isDiagonal(m) {
+			return nil, fmt.Errorf("diag_adj: not diagonal")
+		}
+		det := dsl.BareissDet(m)
+		lam := m.A[i-1][i-1]
+		if lam == 0 {
+			return nil, fmt.Errorf("diag_adj: zero diagonal")
+		}
+		return new(big.Int).Quo(det, big.NewInt(lam)), nil
+	})
+
+	// det_quad_shift_diag(A,a,b)（Chapter5_3）：对角阵 A 时 det(A²+aA+bE) = Π(λ²+aλ+b)。
+	register("det_quad_shift_diag", "matrix, int, int -> int", func(c *dsl.Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		a, err := c.Int64(1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Int64(2)
+		if err != nil {
+			return nil, err
+		}
+		if m.R != m.C || !isDiagonal(m) {
+			return nil, fmt.Errorf("det_quad_shift_diag: need square diagonal matrix")
+		}
+		prod := big.NewInt(1)
+		for j := 0; j < m.R; j++ {
+			lam := m.A[j][j]
+			prod.Mul(prod, big.NewInt(lam*lam+a*lam+b))
+		}
+		return prod, nil
+	})
+
+	// triple_first_col(M)（Chapter5_6）：3×3 矩阵，三列均为 M 的第 1 列（用于 $A\alpha_k=\alpha_1$ 类构造）。
+	register("triple_first_col", "matrix -> matrix", func(c *dsl.Call) (interface{}, error) {
+		M, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		if M.R != 3 || M.C != 3 {
+			return nil, fmt.Errorf("triple_first_col: need 3×3")
+		}
+		out := dsl.NewMatrixInt(3, 3)
+		for j := 0; j < 3; j++ {
+			for i := 0; i < 3; i++ {
+				out.A[i][j] = M.A[i][0]
+			}
+		}
+		return out, nil
+	})
+
+	// orthdiag_block4x6(P,D,S)：3×3 正交 Q、对角 Λ 与对称 S 的展示块（4×6），上行左 Q 右 Λ，末行 tr(S),det(S),0,0,0,0。
+	register("orthdiag_block4x6", "matrix, matrix, matrix -> matrix", func(c *dsl.Call) (interface{}, error) {
+		var ms [3]*dsl.MatrixInt
+		for k := range ms {
+			m, err := c.Matrix(k)
+			if err != nil {
+				return nil, err
+			}
+			if m.R != 3 || m.C != 3 {
+				return nil, fmt.Errorf("orthdiag_block4x6: need three 3×3 matrices")
+			}
+			ms[k] = m
+		}
+		P, D, S := ms[0], ms[1], ms[2]
+		Z := dsl.NewMatrixInt(4, 6)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				Z.A[i][j] = P.A[i][j]
+				Z.A[i][j+3] = D.A[i][j]
+			}
+		}
+		var tr int64
+		for i := 0; i < 3; i++ {
+			tr += S.A[i][i]
+		}
+		detS := dsl.BareissDet(S)
+		if !detS.IsInt64() {
+			return nil, fmt.Errorf("orthdiag_block4x6: det(S) overflow")
+		}
+		Z.A[3][0] = tr
+		Z.A[3][1] = detS.Int64()
+		return Z, nil
+	})
+
+	// hstack34(U,w)：U 为 4×3，w 为 4 维列向量，拼成 4×4 矩阵 [U|w]。
+	register("hstack34", "matrix, vector -> matrix", func(c *dsl.Call) (interface{}, error) {
+		U, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		w, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		if U.R != 4 || U.C != 3 {
+			return nil, fmt.Errorf("hstack34: U must be 4×3")
+		}
+		if w.N != 4 {
+			return nil, fmt.Errorf("hstack34: w must be length 4")
+		}
+		out := dsl.NewMatrixInt(4, 4)
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 3; j++ {
+				out.A[i][j] = U.A[i][j]
+			}
+			out.A[i][3] = w.V[i]
+		}
+		return out, nil
+	})
+}
+
+// symCode 按 dsl.ClassifySymmetric 的正定/负定/不定分类返回题目约定的编码。
+func symCode(pd, nd, ind int64) dsl.FunctionImpl {
+	return func(c *dsl.Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		class, err := dsl.ClassifySymmetric(m)
+		if err != nil {
+			return nil, err
+		}
+		switch class {
+		case dsl.SymDefPD:
+			return pd, nil
+		case dsl.SymDefND:
+			return nd, nil
+		default:
+			return ind, nil
+		}
+	}
+}
+
+func isDiagonal(m *dsl.MatrixInt) bool {
+	for r := 0; r < m.R; r++ {
+		for c := 0; c < m.C; c++ {
+			if r != c && m.A[r][c] != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+func TestFuncs_orthdiagBlock4x6(t *testing.T) {
+	inst := &dsl.Instance{Vars: map[string]interface{}{}}
+	P := dsl.NewMatrixInt(3, 3)
+	P.A[0][0], P.A[1][1], P.A[2][2] = 1, 1, 1
+	D := dsl.NewMatrixInt(3, 3)
+	D.A[0][0], D.A[1][1], D.A[2][2] = 2, 3, 4
+	S := dsl.NewMatrixInt(3, 3)
+	for i := 0; i < 3; i++ {
+		S.A[i][i] = D.A[i][i]
+	}
+	inst.Vars["P"] = P
+	inst.Vars["D"] = D
+	inst.Vars["S"] = S
+	v, err := dsl.EvaluateExpression("orthdiag_block4x6(P,D,S)", inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Z, ok := v.(*dsl.MatrixInt)
+	if !ok || Z.R != 4 || Z.C != 6 {
+		t.Fatalf("Z shape %+v", Z)
+	}
+	if Z.A[3][0] != 9 || Z.A[3][1] != 24 {
+		t.Fatalf("tr/det row want 9,24 got %d,%d", Z.A[3][0], Z.A[3][1])
+	}
+}
+
+// 题库函数带签名注册，静态检查按签名核对参数。
+func TestFuncs_signatureChecked(t *testing.T) {
+	if sig, ok := dsl.FunctionSignature("symcode_612"); !ok || sig.String() != "matrix -> int" {
+		t.Fatalf("symcode_612 signature %v %v", sig, ok)
+	}
+	if _, err := dsl.ParseExpr("diag_adj(A)"); err == nil {
+		t.Fatal("diag_adj(A): expect arity error")
+	}
+	p := dsl.Problem{
+		Variables: map[string]dsl.Variable{"v": {Kind: "vector", Size: 3, Generator: map[string]interface{}{"rule": "range"}}},
+		Answer:    dsl.AnswerSchema{Expression: "symcode_611(v)"},
+	}
+	var msgs []string
+	for _, d := range dsl.ValidateProblem(p) {
+		msgs = append(msgs, d.Message)
+	}
+	if len(msgs) != 1 || msgs[0] != "symcode_611: argument 1 is vector(3), want matrix" {
+		t.Fatalf("diagnostics %q", msgs)
+	}
+}
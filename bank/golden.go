@@ -0,0 +1,308 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// 黄金快照：把 GenerateBankQuestion 在一组固定 seed 上的输出（题面、答案规范串、布局）写入目录，
+// 每个题键的每个版本一个 <key>@<version>.json（仍在判已发出 seed 的旧版本同样受检）；
+// 合并前用 DiffGolden 与当前代码比较，找出会改变线上题目的题键。
+
+// DefaultGoldenSeeds 为 GoldenOptions.Seeds 为空时使用的种子。
+var DefaultGoldenSeeds = []string{"golden-1", "golden-2", "golden-3"}
+
+// DefaultGoldenSalt 为 GoldenOptions.Salt 为空时使用的 salt（不要用线上 salt）。
+const DefaultGoldenSalt = "golden"
+
+// GoldenOptions 为 WriteGolden 的选项。
+type GoldenOptions struct {
+	Keys  []string // 默认 AllQuestionKeys；不带版本的题键快照其全部版本
+	Seeds []string // 默认 DefaultGoldenSeeds
+	Salt  string   // 默认 DefaultGoldenSalt
+}
+
+// GoldenFile 为单个题键某一版本的快照文件，Key 不带版本。
+type GoldenFile struct {
+	Key     string         `json:"key"`
+	Version string         `json:"version"`
+	Salt    string         `json:"salt"`
+	Seeds   []GoldenSample `json:"seeds"`
+}
+
+// GoldenSample 为一个 seed 的出题结果；出题失败时只有 Error。
+type GoldenSample struct {
+	Seed    string         `json:"seed"`
+	Title   string         `json:"title,omitempty"`
+	Answers []GoldenAnswer `json:"answers,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// GoldenAnswer 为一个空的标准答案（dsl.ValueToCanonicalString）与布局。
+type GoldenAnswer struct {
+	ID     string                 `json:"id"`
+	Value  string                 `json:"value"`
+	Layout *dsl.AnswerFieldLayout `json:"layout,omitempty"`
+}
+
+// GoldenStatus 为题键或单个 seed 的比较结果，按严重程度递增。
+type GoldenStatus string
+
+const (
+	GoldenUnchanged     GoldenStatus = "unchanged"
+	GoldenTextChanged   GoldenStatus = "text_changed"   // 题面或布局变了，答案未变
+	GoldenAnswerChanged GoldenStatus = "answer_changed" // 空的 id、个数或标准答案变了，或出题成败变了
+	GoldenNew           GoldenStatus = "new"            // 有题键但没有快照
+	GoldenRemoved       GoldenStatus = "removed"        // 有快照但题键已不存在
+)
+
+func (s GoldenStatus) rank() int {
+	switch s {
+	case GoldenTextChanged:
+		return 1
+	case GoldenAnswerChanged:
+		return 2
+	case GoldenNew, GoldenRemoved:
+		return 3
+	}
+	return 0
+}
+
+// GoldenSeedDiff 为单个 seed 的差异；Fields 为标准答案变了的空 id。
+type GoldenSeedDiff struct {
+	Seed   string       `json:"seed"`
+	Status GoldenStatus `json:"status"`
+	Fields []string     `json:"fields,omitempty"`
+}
+
+// GoldenKeyDiff 为单个题键某一版本的差异，Key 带版本（如 "Chapter6_5@bank-v1"），Status 取各 seed 中最严重的一项；Seeds 只列出有变化的 seed。
+type GoldenKeyDiff struct {
+	Key        string           `json:"key"`
+	Status     GoldenStatus     `json:"status"`
+	OldVersion string           `json:"old_version,omitempty"`
+	NewVersion string           `json:"new_version,omitempty"`
+	Seeds      []GoldenSeedDiff `json:"seeds,omitempty"`
+}
+
+// GoldenReport 为 DiffGolden 的结果，Keys 按题键排序。
+type GoldenReport struct {
+	Keys []GoldenKeyDiff `json:"keys"`
+}
+
+// Changed 返回状态不是 unchanged 的题键。
+func (r *GoldenReport) Changed() []GoldenKeyDiff {
+	var out []GoldenKeyDiff
+	for _, k := range r.Keys {
+		if k.Status != GoldenUnchanged {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// String 按行列出有变化的题键，如 "answer_changed Chapter4_8@bank-v1 seeds golden-1[x1 x2]"。
+func (r *GoldenReport) String() string {
+	var sb strings.Builder
+	for _, k := range r.Changed() {
+		fmt.Fprintf(&sb, "%s %s", k.Status, k.Key)
+		if len(k.Seeds) > 0 {
+			sb.WriteString(" seeds")
+			for _, s := range k.Seeds {
+				fmt.Fprintf(&sb, " %s", s.Seed)
+				if len(s.Fields) > 0 {
+					fmt.Fprintf(&sb, "[%s]", strings.Join(s.Fields, " "))
+				}
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// GoldenSnapshot 按当前代码生成题键 key 在给定 seeds 上的快照；key 不带版本时为最新版。
+func GoldenSnapshot(key string, seeds []string, salt string) (*GoldenFile, error) {
+	cp, err := CompiledProblem(key)
+	if err != nil {
+		return nil, err
+	}
+	base, _, _ := splitVersionedKey(key)
+	out := &GoldenFile{Key: base, Version: cp.Problem.Version, Salt: salt, Seeds: make([]GoldenSample, len(seeds))}
+	for i, seed := range seeds {
+		s := GoldenSample{Seed: seed}
+		if g, err := cp.GenerateQuestion(seed, salt); err != nil {
+			s.Error = err.Error()
+		} else {
+			s.Title = g.Title
+			s.Answers = make([]GoldenAnswer, len(g.AnswerFields))
+			for j, f := range g.AnswerFields {
+				s.Answers[j] = GoldenAnswer{ID: f.ID, Value: dsl.ValueToCanonicalString(f.Value), Layout: f.Layout}
+			}
+		}
+		out.Seeds[i] = s
+	}
+	return out, nil
+}
+
+// WriteGolden 把 opts.Keys 的快照写入 dir（不存在时创建），已有文件被覆盖。
+func WriteGolden(dir string, opts GoldenOptions) error {
+	keys, seeds, salt := opts.Keys, opts.Seeds, opts.Salt
+	if keys == nil {
+		keys = AllQuestionKeys
+	}
+	if len(seeds) == 0 {
+		seeds = DefaultGoldenSeeds
+	}
+	if salt == "" {
+		salt = DefaultGoldenSalt
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, key := range goldenKeys(keys) {
+		gf, err := GoldenSnapshot(key, seeds, salt)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(gf, "", "  ")
+		if err != nil {
+			return fmt.Errorf("bank: golden %s: %w", key, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, gf.id()+".json"), append(b, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goldenKeys 把不带版本的题键展开为其全部版本（QuestionVersions），带版本或未注册的题键原样保留。
+func goldenKeys(keys []string) []string {
+	var out []string
+	for _, key := range keys {
+		versions := QuestionVersions(key)
+		if versions == nil {
+			out = append(out, key)
+			continue
+		}
+		for _, v := range versions {
+			out = append(out, VersionedKey(key, v))
+		}
+	}
+	return out
+}
+
+// id 返回快照对应的带版本题键，也是快照文件名（不含扩展名）。
+func (gf *GoldenFile) id() string {
+	return VersionedKey(gf.Key, gf.Version)
+}
+
+// ReadGolden 读取 dir 中全部快照文件，按题键排序。
+func ReadGolden(dir string) ([]*GoldenFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	out := make([]*GoldenFile, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		gf := &GoldenFile{}
+		if err := json.Unmarshal(b, gf); err != nil {
+			return nil, fmt.Errorf("bank: golden %s: %w", path, err)
+		}
+		out = append(out, gf)
+	}
+	return out, nil
+}
+
+// DiffGolden 用快照文件记录的 seeds 与 salt 按当前代码重新出题，逐版本比较（快照的版本按该版本的 builder 出题）；
+// keys 为应有快照的题键（nil 表示 AllQuestionKeys，不带版本的含全部版本），其中没有快照的报告为 new。
+func DiffGolden(dir string, keys []string) (*GoldenReport, error) {
+	files, err := ReadGolden(dir)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = AllQuestionKeys
+	}
+	report := &GoldenReport{}
+	seen := map[string]bool{}
+	for _, old := range files {
+		id := old.id()
+		seen[id] = true
+		seeds := make([]string, len(old.Seeds))
+		for i, s := range old.Seeds {
+			seeds[i] = s.Seed
+		}
+		if _, err := ResolveQuestionKey(id); err != nil {
+			report.Keys = append(report.Keys, GoldenKeyDiff{Key: id, Status: GoldenRemoved, OldVersion: old.Version})
+			continue
+		}
+		cur, err := GoldenSnapshot(id, seeds, old.Salt)
+		if err != nil {
+			return nil, err
+		}
+		report.Keys = append(report.Keys, diffGoldenFile(old, cur))
+	}
+	for _, key := range goldenKeys(keys) {
+		if !seen[key] {
+			report.Keys = append(report.Keys, GoldenKeyDiff{Key: key, Status: GoldenNew})
+		}
+	}
+	sort.Slice(report.Keys, func(i, j int) bool { return report.Keys[i].Key < report.Keys[j].Key })
+	return report, nil
+}
+
+func diffGoldenFile(old, cur *GoldenFile) GoldenKeyDiff {
+	d := GoldenKeyDiff{Key: old.id(), Status: GoldenUnchanged, OldVersion: old.Version, NewVersion: cur.Version}
+	for i, o := range old.Seeds {
+		sd := diffGoldenSample(o, cur.Seeds[i])
+		if sd.Status == GoldenUnchanged {
+			continue
+		}
+		d.Seeds = append(d.Seeds, sd)
+		if sd.Status.rank() > d.Status.rank() {
+			d.Status = sd.Status
+		}
+	}
+	return d
+}
+
+func diffGoldenSample(old, cur GoldenSample) GoldenSeedDiff {
+	d := GoldenSeedDiff{Seed: old.Seed, Status: GoldenUnchanged}
+	if old.Error != cur.Error || len(old.Answers) != len(cur.Answers) {
+		d.Status = GoldenAnswerChanged
+		return d
+	}
+	for i, o := range old.Answers {
+		c := cur.Answers[i]
+		if o.ID != c.ID || o.Value != c.Value {
+			d.Fields = append(d.Fields, o.ID)
+		}
+	}
+	switch {
+	case len(d.Fields) > 0:
+		d.Status = GoldenAnswerChanged
+	case old.Title != cur.Title || !goldenLayoutsEqual(old.Answers, cur.Answers):
+		d.Status = GoldenTextChanged
+	}
+	return d
+}
+
+func goldenLayoutsEqual(a, b []GoldenAnswer) bool {
+	for i := range a {
+		if !reflect.DeepEqual(a[i].Layout, b[i].Layout) {
+			return false
+		}
+	}
+	return true
+}
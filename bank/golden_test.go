@@ -0,0 +1,160 @@
+package bank
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden from the current build")
+
+const goldenDir = "testdata/golden"
+
+// TestGoldenCorpus 与 testdata/golden 比较全部题目的题面与答案；有意改动题目后用
+// go test ./bank -run TestGoldenCorpus -update 重写快照，并在评审中核对 diff。
+func TestGoldenCorpus(t *testing.T) {
+	if *updateGolden {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteGolden(goldenDir, GoldenOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rep, err := DiffGolden(goldenDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(goldenKeys(AllQuestionKeys)); len(rep.Keys) != want {
+		t.Fatalf("report has %d keys, want %d", len(rep.Keys), want)
+	}
+	if changed := rep.String(); changed != "" {
+		t.Fatalf("output differs from %s (rerun with -update if intended):\n%s", goldenDir, changed)
+	}
+}
+
+func TestDiffGolden_classify(t *testing.T) {
+	dir := t.TempDir()
+	keys := []string{"Chapter1_1", "Chapter2_1", "Chapter3_1", "Chapter4_8"}
+	if err := WriteGolden(dir, GoldenOptions{Keys: keys, Seeds: []string{"a", "b"}, Salt: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	edit := func(key string, fn func(gf *GoldenFile)) {
+		path := filepath.Join(dir, VersionedKey(key, InitialVersion)+".json")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gf GoldenFile
+		if err := json.Unmarshal(b, &gf); err != nil {
+			t.Fatal(err)
+		}
+		fn(&gf)
+		if b, err = json.Marshal(gf); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	edit("Chapter2_1", func(gf *GoldenFile) { gf.Seeds[1].Title += "（旧）" })
+	edit("Chapter3_1", func(gf *GoldenFile) {
+		gf.Seeds[0].Title += "（旧）"
+		gf.Seeds[0].Answers[1].Value = "12345"
+	})
+	edit("Chapter4_8", func(gf *GoldenFile) { gf.Key = "Chapter9_9" })
+
+	rep, err := DiffGolden(dir, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]GoldenKeyDiff{}
+	for _, k := range rep.Keys {
+		base, _, _ := splitVersionedKey(k.Key)
+		got[base] = k
+	}
+	want := map[string]GoldenStatus{
+		"Chapter1_1": GoldenUnchanged, "Chapter2_1": GoldenTextChanged, "Chapter3_1": GoldenAnswerChanged,
+		"Chapter4_8": GoldenNew, "Chapter9_9": GoldenRemoved,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%+v", rep.Keys)
+	}
+	for key, st := range want {
+		if got[key].Status != st {
+			t.Fatalf("%s: got %s want %s", key, got[key].Status, st)
+		}
+	}
+	if s := got["Chapter2_1"].Seeds; len(s) != 1 || s[0].Seed != "b" {
+		t.Fatalf("Chapter2_1 seeds %+v", s)
+	}
+	ans := got["Chapter3_1"].Seeds[0]
+	if ans.Seed != "a" || len(ans.Fields) != 1 {
+		t.Fatalf("Chapter3_1 seeds %+v", got["Chapter3_1"].Seeds)
+	}
+	if len(rep.Changed()) != 4 {
+		t.Fatalf("changed %+v", rep.Changed())
+	}
+}
+
+// TestDiffGolden_versions 旧版本仍在判已发出的 seed，各版本分别快照与比较。
+func TestDiffGolden_versions(t *testing.T) {
+	const key = "Chapter1_1"
+	revisions[key] = []revision{{version: "bank-v2", build: func() dsl.Problem {
+		p := buildChapter1_1()
+		p.Version = "bank-v2"
+		p.Title = "（修订）" + p.Title
+		return p
+	}}}
+	defer delete(revisions, key)
+	defer compiled.Delete(VersionedKey(key, "bank-v2"))
+
+	dir := t.TempDir()
+	if err := WriteGolden(dir, GoldenOptions{Keys: []string{key}, Seeds: []string{"a"}, Salt: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ReadGolden(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Version != InitialVersion || files[1].Version != "bank-v2" {
+		t.Fatalf("files %+v", files)
+	}
+	for _, v := range QuestionVersions(key) {
+		if _, err := os.Stat(filepath.Join(dir, VersionedKey(key, v)+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 改动 bank-v1 的快照，模拟 v1 builder 被误改：只有 v1 报告变化
+	path := filepath.Join(dir, VersionedKey(key, InitialVersion)+".json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gf GoldenFile
+	if err := json.Unmarshal(b, &gf); err != nil {
+		t.Fatal(err)
+	}
+	gf.Seeds[0].Answers[0].Value = "12345"
+	if b, err = json.Marshal(gf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rep, err := DiffGolden(dir, []string{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rep.Changed(); len(got) != 1 || got[0].Key != VersionedKey(key, InitialVersion) || got[0].Status != GoldenAnswerChanged {
+		t.Fatalf("changed %+v", got)
+	}
+	if len(rep.Keys) != 2 {
+		t.Fatalf("keys %+v", rep.Keys)
+	}
+}
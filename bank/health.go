@@ -0,0 +1,37 @@
+package bank
+
+import (
+	"sort"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// QuestionGeneratorStats 为单个题键的生成统计。
+type QuestionGeneratorStats struct {
+	Key   string              `json:"key"`
+	Stats *dsl.GeneratorStats `json:"stats"`
+}
+
+// GeneratorHealthReport 对 AllQuestionKeys 中每个题键取 nSeeds 个种子做 dsl.SampleGeneratorStats，
+// 按失败率、最大尝试用尽概率降序排列（相同时按题键），最需要关注的题目排在最前。
+func GeneratorHealthReport(nSeeds int) ([]QuestionGeneratorStats, error) {
+	out := make([]QuestionGeneratorStats, 0, len(AllQuestionKeys))
+	for _, key := range AllQuestionKeys {
+		cp, err := CompiledProblem(key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, QuestionGeneratorStats{Key: key, Stats: cp.SampleGeneratorStats(nSeeds)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Stats, out[j].Stats
+		if a.FailureRate != b.FailureRate {
+			return a.FailureRate > b.FailureRate
+		}
+		if pa, pb := a.WorstExhaustionProb(), b.WorstExhaustionProb(); pa != pb {
+			return pa > pb
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
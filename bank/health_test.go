@@ -0,0 +1,25 @@
+package bank
+
+import "testing"
+
+// TestGeneratorHealthReport 全题库抽样：不应有出题失败，且报告按失败率、用尽概率排序。
+func TestGeneratorHealthReport(t *testing.T) {
+	rep, err := GeneratorHealthReport(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep) != len(AllQuestionKeys) {
+		t.Fatalf("report has %d keys, want %d", len(rep), len(AllQuestionKeys))
+	}
+	for i, q := range rep {
+		if q.Stats.Failures != 0 {
+			t.Errorf("%s: %d/%d seeds failed: %v", q.Key, q.Stats.Failures, q.Stats.Seeds, q.Stats.Errors)
+		}
+		if i > 0 {
+			prev := rep[i-1].Stats
+			if prev.FailureRate == q.Stats.FailureRate && prev.WorstExhaustionProb() < q.Stats.WorstExhaustionProb() {
+				t.Fatalf("report not sorted at %s", q.Key)
+			}
+		}
+	}
+}
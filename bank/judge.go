@@ -1,21 +1,46 @@
 package bank
 
-import "github.com/neumathe/la-dsl/dsl"
+import (
+	"fmt"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
 
 // JudgeBankQuestion 用与出题相同的 seed/salt 重算标准答案，并与用户提交的 id->答案字符串 比较。
+// questionKey 应带出题时的版本；不带版本时按 InitialVersion 判分（早于版本字段的后端只存了题键与 seed），不取最新版。
 func JudgeBankQuestion(questionKey, seedStr, serverSalt string, userAnswers map[string]string, opts *dsl.JudgeOptions) (*dsl.JudgeResult, error) {
-	p, err := BuildProblem(questionKey)
+	cp, err := CompiledProblem(issuedKey(questionKey))
 	if err != nil {
 		return nil, err
 	}
-	inst, err := dsl.InstantiateProblem(p, seedStr, serverSalt)
+	inst, err := cp.Instantiate(seedStr, serverSalt)
 	if err != nil {
 		return nil, err
 	}
-	g, err := dsl.GenerateQuestionFromInstance(p, inst)
+	return cp.JudgeInstance(inst, userAnswers, opts)
+}
+
+// JudgeBankSnapshot 在存档的实例快照上判分：变量与派生量取快照中的值，答案表达式与判题规则取快照所记版本的题目定义，
+// 因此题库发布新修订后，旧快照仍按出题时的版本判分。快照不带版本（早于版本字段）时按 InitialVersion 处理；
+// questionKey 已固定版本而与快照不符时报错。
+func JudgeBankSnapshot(questionKey string, snap *dsl.Instance, userAnswers map[string]string, opts *dsl.JudgeOptions) (*dsl.JudgeResult, error) {
+	if snap == nil {
+		return nil, fmt.Errorf("bank: %s: nil snapshot", questionKey)
+	}
+	base, version, _ := splitVersionedKey(questionKey)
+	want := snap.Version
+	if want == "" {
+		want = InitialVersion
+	}
+	if version != "" && version != want {
+		return nil, fmt.Errorf("bank: %s: snapshot is for version %q", questionKey, want)
+	}
+	cp, err := CompiledProblem(VersionedKey(base, want))
 	if err != nil {
 		return nil, err
 	}
-	res := dsl.JudgeGeneratedQuestionContext(g, &p, inst, userAnswers, opts)
-	return &res, nil
+	if snap.ProblemID != cp.Problem.ID {
+		return nil, fmt.Errorf("bank: %s: snapshot is for problem %d, want %d", questionKey, snap.ProblemID, cp.Problem.ID)
+	}
+	return cp.JudgeInstance(snap, userAnswers, opts)
 }
@@ -1,6 +1,9 @@
 package bank
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/neumathe/la-dsl/dsl"
@@ -32,3 +35,177 @@ func TestJudgeBankQuestion_Chapter4_8_roundTrip(t *testing.T) {
 		t.Fatalf("round-trip judge: %+v", res.Fields)
 	}
 }
+
+// TestJudgeBankSnapshot 全部题目：实例快照经 JSON 往返后，标准答案判分仍全对（含结构化判题）。
+func TestJudgeBankSnapshot(t *testing.T) {
+	seed, salt := "bank-snapshot-1", "salt"
+	for _, key := range AllQuestionKeys {
+		cp, err := CompiledProblem(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inst, err := cp.Instantiate(seed, salt)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		g, err := cp.GenerateQuestionFromInstance(inst)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		user := map[string]string{}
+		for _, f := range g.AnswerFields {
+			user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+		}
+		data, err := json.Marshal(inst)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		var snap dsl.Instance
+		if err := json.Unmarshal(data, &snap); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		res, err := JudgeBankSnapshot(key, &snap, user, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if !res.AllCorrect {
+			t.Fatalf("%s: %+v", key, res.Fields)
+		}
+	}
+}
+
+// TestChapter6_5_intervalAnswer 正定参数范围题改为一个区间空：答案取 sylvester_interval(S)，区间或不等式写法均可。
+func TestChapter6_5_intervalAnswer(t *testing.T) {
+	if v := QuestionVersions("Chapter6_5"); len(v) != 2 || v[1] != "bank-v2" {
+		t.Fatalf("versions %v", v)
+	}
+	p, err := BuildProblem("Chapter6_5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := dsl.ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	if p.Version != "bank-v2" || dsl.InputConventionOf(p) != dsl.AnswerInputConventionInterval {
+		t.Fatalf("want bank-v2 with interval input convention, got %s", p.Version)
+	}
+	id := p.Answer.FieldDefs[0].ID
+	g, err := GenerateBankQuestion("Chapter6_5", "interval-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := CompiledProblem("Chapter6_5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := cp.Instantiate("interval-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower, err := dsl.EvaluateExpression("sylvester_lower(S)", inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := dsl.ValueToCanonicalString(lower)
+	for sub, ok := range map[string]bool{"t>" + lo: true, "(" + lo + ", +∞)": true, "t>=" + lo: false, lo: false} {
+		if r := dsl.JudgeGeneratedQuestion(g, map[string]string{id: sub}, nil); r.AllCorrect != ok {
+			t.Fatalf("%q: %+v", sub, r.Fields)
+		}
+	}
+
+	// 已发出的 bank-v1 种子仍按原题判分：填下界本身
+	key := VersionedKey("Chapter6_5", InitialVersion)
+	g1, err := GenerateBankQuestion(key, "interval-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := g1.AnswerFields[0].Value
+	r, err := JudgeBankQuestion(key, "interval-1", "salt", map[string]string{id: dsl.ValueToCanonicalString(sub)}, nil)
+	if err != nil || !r.AllCorrect || !strings.Contains(g1.Title, "$t>") {
+		t.Fatalf("bank-v1: %v %+v", err, r)
+	}
+}
+
+func TestChapter4_8_spanEqualBasis(t *testing.T) {
+	p := buildChapter4_8()
+	js := &dsl.AnswerJudgeSpec{Kind: "span_equal", SpanGroup: "xi", SpanDim: 4, SpanOf: "nullspace", MatrixVar: "A"}
+	for i := 4; i < 12; i++ {
+		p.Answer.FieldDefs[i].Judge = js
+	}
+	if ds := dsl.ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	inst, err := dsl.InstantiateProblem(p, "span-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := dsl.GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 用户取 ξ₁+ξ₂ 与 ξ₁-2ξ₂ 为基础解系；取 ξ₁ 与 2ξ₁ 则线性相关
+	answer := func(u, w string) map[string]string {
+		user := map[string]string{}
+		for i, f := range g.AnswerFields[:4] {
+			user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+			for k, e := range []string{u, w} {
+				v, err := dsl.EvaluateExpression(strings.NewReplacer("$c", fmt.Sprint(i+1)).Replace(e), inst)
+				if err != nil {
+					t.Fatal(err)
+				}
+				user[g.AnswerFields[4+4*k+i].ID] = dsl.ValueToCanonicalString(v)
+			}
+		}
+		return user
+	}
+	r := dsl.JudgeGeneratedQuestionContext(g, &p, inst, answer("nullbasis_comp(A,1,$c)+nullbasis_comp(A,2,$c)", "nullbasis_comp(A,1,$c)-2*nullbasis_comp(A,2,$c)"), nil)
+	if !r.AllCorrect {
+		t.Fatalf("%+v", r.Fields)
+	}
+	r = dsl.JudgeGeneratedQuestionContext(g, &p, inst, answer("nullbasis_comp(A,1,$c)", "2*nullbasis_comp(A,1,$c)"), nil)
+	if r.CorrectCount != 4 || r.Fields[4].DetailNote != "linearly dependent" {
+		t.Fatalf("%+v", r.Fields)
+	}
+}
+
+func TestChapter4_6_generalSolution(t *testing.T) {
+	p := buildChapter4_6()
+	js := &dsl.AnswerJudgeSpec{Kind: "general_solution", SolutionGroup: "x46", SpanDim: 3, MatrixVar: "A", BVecVar: "b3"}
+	for i := 16; i < 22; i++ {
+		p.Answer.FieldDefs[i].Judge = js
+	}
+	if ds := dsl.ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	inst, err := dsl.InstantiateProblem(p, "general-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := dsl.GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 特解取 x₀+ξ，基础解系取 -2ξ，与标准答案不同但同样正确
+	user := map[string]string{}
+	for _, f := range g.AnswerFields[:16] {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	for c := 1; c <= 3; c++ {
+		for k, e := range []string{"param_x0_comp(A,%d)+param_nb_comp(A,%d)", "-2*param_nb_comp(A,%d)"} {
+			v, err := dsl.EvaluateExpression(strings.ReplaceAll(e, "%d", fmt.Sprint(c)), inst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			user[g.AnswerFields[16+3*k+c-1].ID] = dsl.ValueToCanonicalString(v)
+		}
+	}
+	if r := dsl.JudgeGeneratedQuestionContext(g, &p, inst, user, nil); !r.AllCorrect {
+		t.Fatalf("%+v", r.Fields)
+	}
+	user[g.AnswerFields[19].ID] = "0"
+	user[g.AnswerFields[20].ID] = "0"
+	user[g.AnswerFields[21].ID] = "0"
+	if r := dsl.JudgeGeneratedQuestionContext(g, &p, inst, user, nil); r.CorrectCount != 19 || r.Fields[21].DetailNote != "linearly dependent" {
+		t.Fatalf("%+v", r.Fields)
+	}
+}
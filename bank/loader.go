@@ -0,0 +1,227 @@
+package bank
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// 题目文件：不写 Go 的老师可以用 JSON / YAML 文件出题，加载后与 Go builder 一样注册到题库
+// （BuildProblem、GenerateBankQuestion、judge、AllQuestionKeys、KeysByChapter 均可见）。
+// 一个文件一道题，字段与 ProblemFile 的 json 标签一致，如：
+//
+//	key: Chapter8_1
+//	chapter: 8
+//	chapter_title: 内积空间   # 新章号必填；已有章号可省略，填写时须与现有名称一致
+//	problem:
+//	  title: '设 $A = {{vA}}$，则 $|A|$ 等于 {{blank:Chapter8_1_1}}'
+//	  variables:
+//	    A: {kind: matrix, rows: 2, cols: 2, generator: {rule: range, min: -5, max: 5}}
+//	  derived: {vA: A}
+//	  render: {vA: vA}
+//	  answer:
+//	    field_defs:
+//	      - expr: det(A)
+//
+// problem.id 留空时取 ProblemID(key)，problem.version 留空时为 InitialVersion，
+// 填空 id 留空时按顺序取 BlankIDs(key, n)；文件题目不支持修订版本（revisions 仍在 Go 中登记）。
+
+// ProblemFile 为一个题目文件的内容。
+type ProblemFile struct {
+	Key          string      `json:"key"`
+	Chapter      int         `json:"chapter"`
+	ChapterTitle string      `json:"chapter_title,omitempty"`
+	Problem      dsl.Problem `json:"problem"`
+}
+
+// ProblemFileJSONSchema 返回题目文件的 JSON Schema：dsl.ProblemJSONSchema 外加 key、chapter、chapter_title。
+func ProblemFileJSONSchema() map[string]interface{} {
+	s := dsl.ProblemJSONSchema()
+	problem := s["$ref"]
+	delete(s, "$ref")
+	s["title"] = "la-dsl bank problem file"
+	s["type"] = "object"
+	s["properties"] = map[string]interface{}{
+		"key":           map[string]interface{}{"type": "string", "pattern": chapterKeyRe.String()},
+		"chapter":       map[string]interface{}{"type": "integer", "minimum": 1},
+		"chapter_title": map[string]interface{}{"type": "string"},
+		"problem":       map[string]interface{}{"$ref": problem},
+	}
+	s["required"] = []interface{}{"key", "chapter", "problem"}
+	s["additionalProperties"] = false
+	return s
+}
+
+// problemFileExts 为加载的文件扩展名，其余文件忽略。
+var problemFileExts = map[string]bool{".json": true, ".yaml": true, ".yml": true}
+
+// LoadProblemDir 加载目录 dir（含子目录）中的题目文件，见 LoadProblemFS。
+func LoadProblemDir(dir string) ([]string, error) {
+	return LoadProblemFS(os.DirFS(dir))
+}
+
+// LoadProblemFS 加载 fsys（如 embed.FS）中全部 .json / .yaml / .yml 题目文件并注册，返回按路径排序的题键。
+// 每个文件都经过格式、题键与章号、dsl.ValidateProblem、编译与试出一题的检查；
+// 任一文件有错时返回全部错误且不注册任何题目。应在服务启动时、CompileAll 之前调用，不可与出题并发。
+func LoadProblemFS(fsys fs.FS) ([]string, error) {
+	var files []*ProblemFile
+	var errs []error
+	seen := map[string]string{} // 题键 -> 路径
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !problemFileExts[strings.ToLower(path.Ext(p))] {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		f, err := parseProblemFile(p, data)
+		if err == nil {
+			err = checkProblemFile(f)
+		}
+		if err == nil && seen[f.Key] != "" {
+			err = fmt.Errorf("duplicate key %q (also in %s)", f.Key, seen[f.Key])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("bank: %s: %w", p, err))
+			return nil
+		}
+		seen[f.Key] = p
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bank: load problems: %w", err)
+	}
+	if err := checkChapterTitles(files); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	keys := make([]string, len(files))
+	for i, f := range files {
+		registerProblemFile(f)
+		keys[i] = f.Key
+	}
+	return keys, nil
+}
+
+// parseProblemFile 按扩展名解码题目文件；未知字段报错，以免拼错的字段被静默忽略。
+func parseProblemFile(name string, data []byte) (*ProblemFile, error) {
+	if ext := strings.ToLower(path.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		var err error
+		if data, err = dsl.YAMLToJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	f := &ProblemFile{}
+	if err := dec.Decode(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// checkProblemFile 检查题键与章号、补全 ID、版本与填空 id，并校验、编译、试出一题。
+func checkProblemFile(f *ProblemFile) error {
+	if strings.Contains(f.Key, VersionSep) {
+		return fmt.Errorf("key %q must not contain %q", f.Key, VersionSep)
+	}
+	n, ok := ChapterNoOf(f.Key)
+	if !ok {
+		return fmt.Errorf("key %q is not of the form ChapterN_...", f.Key)
+	}
+	if f.Chapter != n {
+		return fmt.Errorf("chapter %d does not match key %q", f.Chapter, f.Key)
+	}
+	if _, exists := lookupInitialBuilder(f.Key); exists {
+		return fmt.Errorf("key %q is already registered", f.Key)
+	}
+	p := &f.Problem
+	switch p.Version {
+	case "":
+		p.Version = InitialVersion
+	case InitialVersion:
+	default:
+		return fmt.Errorf("version %q: problem files only support %q", p.Version, InitialVersion)
+	}
+	if p.ID == 0 {
+		p.ID = ProblemID(f.Key)
+	}
+	ids := BlankIDs(f.Key, len(p.Answer.FieldDefs))
+	for i := range p.Answer.FieldDefs {
+		if p.Answer.FieldDefs[i].ID == "" {
+			p.Answer.FieldDefs[i].ID = ids[i]
+		}
+	}
+	if diags := dsl.ValidateProblem(*p); dsl.HasErrors(diags) {
+		var msgs []string
+		for _, d := range diags {
+			if d.Severity == dsl.SeverityError {
+				msgs = append(msgs, d.String())
+			}
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	cp, err := dsl.Compile(*p)
+	if err != nil {
+		return err
+	}
+	if _, err := cp.GenerateQuestion("load-check", ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkChapterTitles 检查文件给出的章名与现有章名、文件之间互不冲突，且新章号都有章名。
+func checkChapterTitles(files []*ProblemFile) error {
+	titles := map[int]string{}
+	var errs []error
+	for _, f := range files {
+		cur := chapterTitles[f.Chapter]
+		if cur == "" {
+			cur = titles[f.Chapter]
+		}
+		switch {
+		case f.ChapterTitle == "" && cur == "":
+			errs = append(errs, fmt.Errorf("bank: %s: chapter %d has no title, set chapter_title", f.Key, f.Chapter))
+		case f.ChapterTitle != "" && cur != "" && f.ChapterTitle != cur:
+			errs = append(errs, fmt.Errorf("bank: %s: chapter_title %q conflicts with %q", f.Key, f.ChapterTitle, cur))
+		case cur == "":
+			titles[f.Chapter] = f.ChapterTitle
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// registerProblemFile 注册一道文件题目；每次构造都重新解码，调用方拿到的 Problem 互不共享 map。
+func registerProblemFile(f *ProblemFile) {
+	data, err := json.Marshal(f.Problem)
+	if err != nil {
+		panic(fmt.Sprintf("bank: %s: %v", f.Key, err))
+	}
+	builders[f.Key] = func() dsl.Problem {
+		var p dsl.Problem
+		if err := json.Unmarshal(data, &p); err != nil {
+			panic(fmt.Sprintf("bank: %s: %v", f.Key, err))
+		}
+		return p
+	}
+	if chapterTitles[f.Chapter] == "" {
+		chapterTitles[f.Chapter] = f.ChapterTitle
+	}
+	AllQuestionKeys = append(AllQuestionKeys, f.Key)
+	ExpectedAnswerFieldCount[f.Key] = len(f.Problem.Answer.FieldDefs)
+}
@@ -0,0 +1,122 @@
+package bank
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// restoreRegistry 在测试结束时撤销 LoadProblemFS 的注册。
+func restoreRegistry(t *testing.T) {
+	keys := append([]string(nil), AllQuestionKeys...)
+	titles := map[int]string{}
+	for n, s := range chapterTitles {
+		titles[n] = s
+	}
+	t.Cleanup(func() {
+		for _, k := range AllQuestionKeys[len(keys):] {
+			delete(builders, k)
+			delete(ExpectedAnswerFieldCount, k)
+		}
+		AllQuestionKeys = keys
+		chapterTitles = titles
+	})
+}
+
+func TestLoadProblemDir(t *testing.T) {
+	restoreRegistry(t)
+	keys, err := LoadProblemDir("testdata/problems")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "Chapter1_9,Chapter8_1" {
+		t.Fatalf("keys %v", keys)
+	}
+	if ChapterTitle(8) != "内积空间" || strings.Join(KeysByChapter(8), ",") != "Chapter8_1" {
+		t.Fatalf("chapter 8: %q %v", ChapterTitle(8), KeysByChapter(8))
+	}
+	p, err := BuildProblem("Chapter8_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != ProblemID("Chapter8_1") || p.Version != InitialVersion || p.Answer.FieldDefs[1].ID != "Chapter8_1_2" {
+		t.Fatalf("defaults: id=%d version=%q fields=%+v", p.ID, p.Version, p.Answer.FieldDefs)
+	}
+	for _, key := range keys {
+		g, err := GenerateBankQuestion(key, "load-1", "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.AnswerFields) != ExpectedAnswerFieldCount[key] || strings.Contains(g.Title, "{{vA}}") {
+			t.Fatalf("%s: %q %+v", key, g.Title, g.AnswerFields)
+		}
+		user := map[string]string{}
+		for _, f := range g.AnswerFields {
+			user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+		}
+		res, err := JudgeBankQuestion(key, "load-1", "salt", user, nil)
+		if err != nil || !res.AllCorrect {
+			t.Fatalf("%s: %+v %v", key, res, err)
+		}
+	}
+	if _, err := LoadProblemDir("testdata/problems"); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("reload: %v", err)
+	}
+}
+
+func TestLoadProblemFS_errors(t *testing.T) {
+	restoreRegistry(t)
+	const body = `problem:
+  variables:
+    A: {kind: matrix, rows: 2, cols: 2, generator: {rule: range, min: -3, max: 3}}
+  answer:
+    field_defs:
+      - expr: det(A)
+`
+	for name, tc := range map[string]struct {
+		files map[string]string
+		want  string
+	}{
+		"unknown field": {map[string]string{"a.yaml": "key: Chapter1_90\nchapter: 1\ntitle: x\n" + body}, `unknown field "title"`},
+		"chapter":       {map[string]string{"a.yaml": "key: Chapter1_90\nchapter: 2\n" + body}, "chapter 2 does not match"},
+		"bad key":       {map[string]string{"a.yaml": "key: Det_1\nchapter: 1\n" + body}, "not of the form"},
+		"existing":      {map[string]string{"a.yaml": "key: Chapter4_8\nchapter: 4\n" + body}, `"Chapter4_8" is already registered`},
+		"sized":         {map[string]string{"a.yaml": "key: Chapter5_2_n4\nchapter: 5\n" + body}, "already registered"},
+		"duplicate": {map[string]string{
+			"a.yaml": "key: Chapter1_90\nchapter: 1\n" + body, "b/c.yml": "key: Chapter1_90\nchapter: 1\n" + body,
+		}, "duplicate key \"Chapter1_90\" (also in a.yaml)"},
+		"version":  {map[string]string{"a.yaml": "key: Chapter1_90\nchapter: 1\n" + body + "  version: bank-v2\n"}, "only support"},
+		"validate": {map[string]string{"a.json": `{"key": "Chapter1_90", "chapter": 1, "problem": {"answer": {"field_defs": [{"expr": "det(B)"}]}}}`}, "error answer.field_defs[0]"},
+		"title":    {map[string]string{"a.yaml": "key: Chapter9_1\nchapter: 9\n" + body}, "chapter 9 has no title"},
+		"title conflict": {map[string]string{
+			"a.yaml": "key: Chapter9_1\nchapter: 9\nchapter_title: 甲\n" + body, "b.yaml": "key: Chapter9_2\nchapter: 9\nchapter_title: 乙\n" + body,
+		}, `chapter_title "乙" conflicts with "甲"`},
+		"yaml": {map[string]string{"a.yaml": "key: [Chapter1_90\n"}, "a.yaml: yaml: line 1"},
+	} {
+		fsys := fstest.MapFS{"README.md": {Data: []byte("ignored")}}
+		for p, s := range tc.files {
+			fsys[p] = &fstest.MapFile{Data: []byte(s)}
+		}
+		n := len(AllQuestionKeys)
+		_, err := LoadProblemFS(fsys)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: got %v, want %q", name, err, tc.want)
+		}
+		if len(AllQuestionKeys) != n {
+			t.Fatalf("%s: registered despite error", name)
+		}
+	}
+}
+
+func TestProblemFileJSONSchema(t *testing.T) {
+	s := ProblemFileJSONSchema()
+	props := s["properties"].(map[string]interface{})
+	if props["problem"].(map[string]interface{})["$ref"] != "#/$defs/Problem" || s["$ref"] != nil {
+		t.Fatalf("%v", s)
+	}
+	if _, ok := s["$defs"].(map[string]interface{})["generator.matrix.range"]; !ok {
+		t.Fatal("generator schemas missing")
+	}
+}
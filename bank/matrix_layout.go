@@ -27,3 +27,28 @@ func MatrixFieldDefsRectIDs(ids []string, offset, rows, cols int, matrixVar, gro
 	}
 	return fds
 }
+
+// MatrixFieldDefWhole 生成一个填写整个 rows×cols 矩阵的空（judge kind "matrix"，matrix 布局），
+// 可代替 MatrixFieldDefsSquare 的逐格填空；含此类空的题目输入约定为 dsl.AnswerInputConventionV2。
+func MatrixFieldDefWhole(id, matrixVar string, rows, cols int, groupLabel string) dsl.AnswerFieldDef {
+	return dsl.AnswerFieldDef{
+		ID:     id,
+		Expr:   matrixVar,
+		Layout: dsl.LayoutMatrix(matrixVar, rows, cols, groupLabel),
+		Judge:  &dsl.AnswerJudgeSpec{Kind: "matrix"},
+	}
+}
+
+// VectorFieldDefWhole 生成一个填写整个 n 维向量 vectorVar 的空（vector 布局）。judge 为 nil 时逐分量精确比较（judge kind "vector"），
+// 也可传入 rational_line / affine_rational 判题规格（该空须独占一组）；含此类空的题目输入约定为 dsl.AnswerInputConventionVector。
+func VectorFieldDefWhole(id, vectorVar string, n int, groupLabel string, judge *dsl.AnswerJudgeSpec) dsl.AnswerFieldDef {
+	if judge == nil {
+		judge = &dsl.AnswerJudgeSpec{Kind: "vector"}
+	}
+	return dsl.AnswerFieldDef{
+		ID:     id,
+		Expr:   vectorVar,
+		Layout: dsl.LayoutVector(vectorVar, n, groupLabel),
+		Judge:  judge,
+	}
+}
@@ -1,6 +1,8 @@
 package bank
 
 import (
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/neumathe/la-dsl/dsl"
@@ -23,3 +25,87 @@ func TestChapter2_4_1MatrixLayouts(t *testing.T) {
 		}
 	}
 }
+
+// TestMatrixFieldDefWhole 求逆题改为一个整矩阵空：校验通过、输入约定为 V2，LaTeX 与分号写法均可判对。
+func TestMatrixFieldDefWhole(t *testing.T) {
+	p := buildChapter2_4_1()
+	p.Title = `设矩阵 $A={{A}}$，则 $A^{-1}=$ {{blank:inv}}`
+	p.Answer.FieldDefs = []dsl.AnswerFieldDef{MatrixFieldDefWhole("inv", "Inv", 3, 3, "A^{-1}")}
+	if ds := dsl.ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	if dsl.InputConventionOf(p) != dsl.AnswerInputConventionV2 {
+		t.Fatal("want V2 input convention")
+	}
+	g, err := dsl.GenerateQuestion(p, "whole-1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	canon := dsl.ValueToCanonicalString(g.AnswerFields[0].Value) // [a b c]; [d e f]; …
+	latex := `\begin{bmatrix}` + strings.NewReplacer("]; [", `\\`, " ", "&", "[", "", "]", "").Replace(canon) + `\end{bmatrix}`
+	for _, ans := range []string{latex, canon} {
+		res := dsl.JudgeGeneratedQuestion(g, map[string]string{"inv": ans}, nil)
+		if !res.AllCorrect || len(res.Fields[0].Cells) != 9 {
+			t.Fatalf("%s: %+v", ans, res)
+		}
+	}
+}
+
+// TestVectorFieldDefWhole 通解题改为三个整向量空：特解按 Ax=b、基础解系向量按共线判分。
+func TestVectorFieldDefWhole(t *testing.T) {
+	p := buildChapter4_8()
+	p.Title = `设 $Ax=b$ 的三个解为 $\eta_1={{eta1}}$、$\eta_2={{eta2}}$、$\eta_3={{eta3}}$，写出通解：{{blank:p}} {{blank:x1}} {{blank:x2}}`
+	p.Answer.FieldDefs = []dsl.AnswerFieldDef{
+		VectorFieldDefWhole("p", "eta1", 4, "", &dsl.AnswerJudgeSpec{Kind: "affine_rational", AffineGroup: "p0", MatrixVar: "A", BVecVar: "b2"}),
+		VectorFieldDefWhole("x1", "nb1", 4, "", &dsl.AnswerJudgeSpec{Kind: "rational_line", LineGroup: "xi1"}),
+		VectorFieldDefWhole("x2", "nb2", 4, "", &dsl.AnswerJudgeSpec{Kind: "rational_line", LineGroup: "xi2"}),
+	}
+	if ds := dsl.ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	if dsl.InputConventionOf(p) != dsl.AnswerInputConventionVector {
+		t.Fatal("want vector input convention")
+	}
+	cp, err := dsl.Compile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := cp.Instantiate("whole-vec", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := cp.GenerateQuestionFromInstance(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec := func(i int) *dsl.VectorRat {
+		v, err := dsl.ParseUserVector(dsl.ValueToCanonicalString(g.AnswerFields[i].Value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	// 基础解系向量乘 -2，用全角标点与方括号填写
+	xi := vec(1)
+	parts := make([]string, xi.N)
+	for i, r := range xi.V {
+		parts[i] = new(big.Rat).Mul(r, big.NewRat(-2, 1)).RatString()
+	}
+	user := map[string]string{
+		"p":  dsl.ValueToCanonicalString(vec(0)),
+		"x1": "［" + strings.Join(parts, "，") + "］",
+		"x2": dsl.ValueToCanonicalString(vec(2)) + "^T",
+	}
+	res, err := cp.JudgeInstance(inst, user, nil)
+	if err != nil || !res.AllCorrect {
+		t.Fatalf("%+v %v", res, err)
+	}
+	wrong := vec(0)
+	wrong.V[0].Add(wrong.V[0], big.NewRat(1, 1))
+	user["p"] = dsl.ValueToCanonicalString(wrong)
+	user["x2"] = "(1, 2, 3)"
+	res, err = cp.JudgeInstance(inst, user, nil)
+	if err != nil || res.CorrectCount != 1 || res.Fields[0].DetailNote != "Ax!=b" || res.Fields[2].DetailNote != "dim mismatch" {
+		t.Fatalf("%+v %v", res, err)
+	}
+}
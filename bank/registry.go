@@ -1,11 +1,35 @@
 package bank
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/neumathe/la-dsl/dsl"
 )
 
+// 题库版本：题键可带版本后缀固定到某一版，如 "Chapter4_8@bank-v1"；不带版本的题键取最新版。
+// 版本号同时写在 Problem.Version 中并参与种子派生，因此不同版本同一 seed 的题目互不相干。
+const (
+	// VersionSep 分隔题键与版本。
+	VersionSep = "@"
+	// InitialVersion 为 builders 与 n 阶题目的版本。
+	InitialVersion = "bank-v1"
+)
+
+type revision struct {
+	version string
+	build   func() dsl.Problem
+}
+
+// revisions 为题目修订后的新版本，按发布先后排列，最后一个为最新版。
+// 修订题目时不要改动原 builder：复制为新函数、把 Version 改为新版本号后登记在这里，
+// 已发出的 seed 带着旧版本号判分与解析时仍使用原 builder。
+var revisions = map[string][]revision{
+	"Chapter6_5": {{version: "bank-v2", build: buildChapter6_5V2}},
+}
+
 var builders = map[string]func() dsl.Problem{
 	"Chapter1_8_1": buildChapter1_8_1, "Chapter1_4": buildChapter1_4, "Chapter1_3": buildChapter1_3,
 	"Chapter1_1": buildChapter1_1, "Chapter1_2": buildChapter1_2, "Chapter1_6": buildChapter1_6,
@@ -40,20 +64,133 @@ var builders = map[string]func() dsl.Problem{
 	"Chapter7_5_3": buildChapter7_5_3, "Chapter7_10": buildChapter7_10, "Chapter7_9": buildChapter7_9,
 }
 
+// VersionedKey 返回固定到版本 version 的题键，如 VersionedKey("Chapter4_8", "bank-v2") = "Chapter4_8@bank-v2"。
+func VersionedKey(questionKey, version string) string {
+	return questionKey + VersionSep + version
+}
+
+// QuestionVersions 返回题键（不带版本）的全部版本，按发布先后排列；题键未注册时返回 nil。
+func QuestionVersions(questionKey string) []string {
+	if _, ok := lookupInitialBuilder(questionKey); !ok {
+		return nil
+	}
+	out := []string{InitialVersion}
+	for _, r := range revisions[questionKey] {
+		out = append(out, r.version)
+	}
+	return out
+}
+
+// ResolveQuestionKey 返回题键对应的带版本题键：已带版本的原样返回，不带版本的固定到最新版。
+func ResolveQuestionKey(questionKey string) (string, error) {
+	base, version, ok := splitVersionedKey(questionKey)
+	versions := QuestionVersions(base)
+	if !ok || versions == nil {
+		return "", fmt.Errorf("bank: unknown question key %q", questionKey)
+	}
+	if version == "" {
+		return VersionedKey(base, versions[len(versions)-1]), nil
+	}
+	for _, v := range versions {
+		if v == version {
+			return questionKey, nil
+		}
+	}
+	return "", fmt.Errorf("bank: unknown version %q of question %q", version, base)
+}
+
+// issuedKey 返回判题、解析所用的题键：不带版本的题键固定到 InitialVersion，而不是最新版。
+// 引入版本之前的后端只存了题键与 seed，这些题目都按 bank-v1 发出，题库修订后仍须按 bank-v1 判分。
+func issuedKey(questionKey string) string {
+	if base, version, ok := splitVersionedKey(questionKey); ok && version == "" {
+		return VersionedKey(base, InitialVersion)
+	}
+	return questionKey
+}
+
+// splitVersionedKey 拆分 "key@version"；不带版本时 version 为空，"key@" 这类空版本 ok 为 false。
+func splitVersionedKey(questionKey string) (base, version string, ok bool) {
+	base, version, found := strings.Cut(questionKey, VersionSep)
+	return base, version, !found || version != ""
+}
+
+// lookupBuilder 查找题键的构造函数；不带版本时取最新版。
+func lookupBuilder(questionKey string) (func() dsl.Problem, bool) {
+	key, err := ResolveQuestionKey(questionKey)
+	if err != nil {
+		return nil, false
+	}
+	base, version, _ := splitVersionedKey(key)
+	for _, r := range revisions[base] {
+		if r.version == version {
+			return r.build, true
+		}
+	}
+	return lookupInitialBuilder(base)
+}
+
 // BuildProblem 返回与题库逻辑题号对应的 DSL 题目（填空 id 与 HTML 一致）。
+// 题键可带版本（见 VersionedKey），不带版本时为最新版。
 func BuildProblem(questionKey string) (dsl.Problem, error) {
-	fn, ok := builders[questionKey]
+	fn, ok := lookupBuilder(questionKey)
 	if !ok {
 		return dsl.Problem{}, fmt.Errorf("bank: unknown question key %q", questionKey)
 	}
 	return fn(), nil
 }
 
+type compiledEntry struct {
+	once sync.Once
+	cp   *dsl.CompiledProblem
+	err  error
+}
+
+var compiled sync.Map // 带版本题键 -> *compiledEntry
+
+// CompiledProblem 返回题键对应的预编译题目；每个题键的每个版本只编译一次，结果在进程内缓存。
+func CompiledProblem(questionKey string) (*dsl.CompiledProblem, error) {
+	questionKey, err := ResolveQuestionKey(questionKey)
+	if err != nil {
+		return nil, err
+	}
+	v, _ := compiled.LoadOrStore(questionKey, &compiledEntry{})
+	e := v.(*compiledEntry)
+	e.once.Do(func() {
+		p, err := BuildProblem(questionKey)
+		if err != nil {
+			e.err = err
+			return
+		}
+		if _, version, _ := splitVersionedKey(questionKey); p.Version != version {
+			e.err = fmt.Errorf("bank: %s: builder sets version %q", questionKey, p.Version)
+			return
+		}
+		e.cp, e.err = dsl.Compile(p)
+		if e.err != nil {
+			e.err = fmt.Errorf("bank: %s: %w", questionKey, e.err)
+		}
+	})
+	return e.cp, e.err
+}
+
+// CompileAll 预编译全部已注册题目的全部版本，供服务启动时调用：有问题的题目在启动阶段即报错，而不是等学生抽到。
+func CompileAll() error {
+	var errs []error
+	for key := range builders {
+		for _, version := range QuestionVersions(key) {
+			if _, err := CompiledProblem(VersionedKey(key, version)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // GenerateBankQuestion 按题库键 + 种子生成题目与答案。
 func GenerateBankQuestion(questionKey, seedStr, serverSalt string) (*dsl.GeneratedQuestion, error) {
-	p, err := BuildProblem(questionKey)
+	cp, err := CompiledProblem(questionKey)
 	if err != nil {
 		return nil, err
 	}
-	return dsl.GenerateQuestion(p, seedStr, serverSalt)
+	return cp.GenerateQuestion(seedStr, serverSalt)
 }
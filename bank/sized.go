@@ -0,0 +1,194 @@
+package bank
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// 按阶数参数化的题目：题键形如 Chapter5_2_n4，阶数 n 取 MinOrder～MaxOrder。
+// 这些题键不在 AllQuestionKeys 中（HTML 题单不变），但 BuildProblem、GenerateBankQuestion、
+// JudgeBankQuestion 均可直接使用。
+const (
+	MinOrder = 2
+	MaxOrder = 6
+)
+
+var sizedBuilders = map[string]func(key string, n int) dsl.Problem{
+	"Chapter5_2":   buildEigenPairsN,
+	"Chapter5_7":   buildOrthogonalDiagN,
+	"Chapter6_1_1": buildQuadraticDefinitenessN,
+}
+
+// SizedQuestionBases 返回支持按阶数参数化的基础题键（升序）。
+func SizedQuestionBases() []string {
+	out := make([]string, 0, len(sizedBuilders))
+	for k := range sizedBuilders {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SizedQuestionKey 返回基础题键 base 的 n 阶版本题键，如 SizedQuestionKey("Chapter5_2", 4) = "Chapter5_2_n4"。
+func SizedQuestionKey(base string, n int) string {
+	return fmt.Sprintf("%s_n%d", base, n)
+}
+
+// parseSizedKey 拆分 n 阶题键；base 未注册或 n 越界时 ok 为 false。
+func parseSizedKey(key string) (base string, n int, ok bool) {
+	i := strings.LastIndex(key, "_n")
+	if i < 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(key[i+2:])
+	if err != nil || n < MinOrder || n > MaxOrder || key[i+2:] != strconv.Itoa(n) {
+		return "", 0, false
+	}
+	base = key[:i]
+	if _, ok := sizedBuilders[base]; !ok {
+		return "", 0, false
+	}
+	return base, n, true
+}
+
+// lookupInitialBuilder 查找固定题键或 n 阶题键（不带版本）的 InitialVersion 构造函数。
+func lookupInitialBuilder(questionKey string) (func() dsl.Problem, bool) {
+	if fn, ok := builders[questionKey]; ok {
+		return fn, true
+	}
+	base, n, ok := parseSizedKey(questionKey)
+	if !ok {
+		return nil, false
+	}
+	build := sizedBuilders[base]
+	return func() dsl.Problem { return build(questionKey, n) }, true
+}
+
+// eigenValueFieldDefs 生成 ids 前 n 个空的特征值填空，与后续特征向量填空组成 eigen_pair 组 E。
+func eigenValueFieldDefs(ids []string, n int, valFn, matrixVar string) []dsl.AnswerFieldDef {
+	fds := make([]dsl.AnswerFieldDef, 0, n+n*n)
+	for i := 1; i <= n; i++ {
+		fds = append(fds, dsl.AnswerFieldDef{
+			ID:     ids[i-1],
+			Expr:   fmt.Sprintf("%s(%s,%d)", valFn, matrixVar, i),
+			Layout: dsl.LayoutVectorComponent("lambda", i, "eigenvalues"),
+			Judge: &dsl.AnswerJudgeSpec{
+				Kind: "eigen_pair", EigenGroup: "E", EigenRole: "lambda",
+				EigenColumn: i, MatrixVar: matrixVar,
+			},
+		})
+	}
+	return fds
+}
+
+// buildEigenPairsN：Chapter5_2 的 n 阶版本，求 n 个特征值及对应特征向量。
+func buildEigenPairsN(k string, n int) dsl.Problem {
+	ids := BlankIDs(k, n+n*n)
+	fds := eigenValueFieldDefs(ids, n, "eigenval", "A")
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= n; j++ {
+			fds = append(fds, dsl.AnswerFieldDef{
+				ID:     ids[n+(i-1)*n+j-1],
+				Expr:   fmt.Sprintf("eigenvec_comp(A,%d,%d)", i, j),
+				Layout: dsl.LayoutVectorComponent(fmt.Sprintf("alpha_%d", i), j, "eigenvectors"),
+				Judge: &dsl.AnswerJudgeSpec{
+					Kind: "eigen_pair", EigenGroup: "E", EigenRole: "vec",
+					EigenColumn: i, EigenComponent: j, MatrixVar: "A",
+				},
+			})
+		}
+	}
+	return dsl.Problem{
+		ID:      ProblemID(k),
+		Version: "bank-v1",
+		Title: fmt.Sprintf(
+			`设 %d 阶矩阵 $A={{A}}$，求 $A$ 的 %d 个特征值 $\lambda_1,\ldots,\lambda_%d$ 及对应的特征向量 $\alpha_1,\ldots,\alpha_%d$ 各分量（特征值与特征向量的顺序可任意对应；特征向量允许整体乘以非零整数不影响判分）：%s`,
+			n, n, n, n, joinBlankPlaceholders(ids)),
+		Variables: map[string]dsl.Variable{
+			"A": {Kind: "matrix", Rows: n, Cols: n, Generator: map[string]interface{}{"rule": "eigen_reverse"}},
+		},
+		Render: map[string]string{"A": "A"},
+		Answer: dsl.AnswerSchema{FieldDefs: fds},
+		Meta: map[string]interface{}{
+			"solution_zh": `**解题思路：** 先由特征方程 $|\lambda E - A| = 0$ 求特征值，再对每个特征值解齐次线性方程组得到特征向量。
+
+**步骤 1：** 特征多项式为 $|\lambda E - A| = {{expr:charpoly(A)}}$，其根即 $A$ 的全部特征值（本题均为互异整数）。
+
+**步骤 2：** 对每个特征值 $\lambda_i$，对 $\lambda_i E - A$ 作行化简，解 $(\lambda_i E - A)x = 0$ 得特征向量 $\alpha_i$。例如 $\lambda_1 = {{expr:eigenval(A,1)}}$ 时，$\alpha_1$ 的第一个分量可取 ${{expr:eigenvec_comp(A,1,1)}}$。
+
+**步骤 3：** 将特征值与对应的特征向量填入各空，二者可任意配对，特征向量允许整体乘以非零整数。`,
+		},
+	}
+}
+
+// buildOrthogonalDiagN：Chapter5_7 的 n 阶版本，求正交矩阵 Q 使 Q⁻¹SQ 为对角阵。
+func buildOrthogonalDiagN(k string, n int) dsl.Problem {
+	ids := BlankIDs(k, n+n*n)
+	fds := eigenValueFieldDefs(ids, n, "sym_eigenval", "S")
+	// Q_ij = sym_eigenvec_comp(S, j, i)：Q 的第 j 列为第 j 个特征向量
+	for row := 1; row <= n; row++ {
+		for col := 1; col <= n; col++ {
+			fds = append(fds, dsl.AnswerFieldDef{
+				ID:     ids[n+(row-1)*n+col-1],
+				Expr:   fmt.Sprintf("sym_eigenvec_comp(S,%d,%d)", col, row),
+				Layout: dsl.LayoutMatrixCell("Q", row, col, n, n, "Q"),
+				Judge: &dsl.AnswerJudgeSpec{
+					Kind: "eigen_pair", EigenGroup: "E", EigenRole: "vec",
+					EigenColumn: col, EigenComponent: row, MatrixVar: "S",
+				},
+			})
+		}
+	}
+	return dsl.Problem{
+		ID:      ProblemID(k),
+		Version: "bank-v1",
+		Title: fmt.Sprintf(
+			`设 %d 阶对称阵 $S={{S}}$，求正交矩阵 $Q$ 使 $Q^{-1}SQ=\Lambda$（对角阵），填写 %d 个特征值及 $Q$ 的 %d 个元素（按行自上而下、从左到右；特征值与 $Q$ 的列顺序可任意对应，每一列允许整体乘以非零整数不影响判分）：%s`,
+			n, n, n*n, joinBlankPlaceholders(ids)),
+		Variables: map[string]dsl.Variable{
+			"S": {Kind: "matrix", Rows: n, Cols: n, Generator: map[string]interface{}{"rule": "symmetric_eigen_reverse", "lambda_min": -5, "lambda_max": 5, "max_entry": 12}},
+		},
+		Render: map[string]string{"S": "S"},
+		Answer: dsl.AnswerSchema{FieldDefs: fds},
+		Meta: map[string]interface{}{
+			"solution_zh": `**解题思路：** 实对称矩阵可正交相似对角化。先求特征值和特征向量，将特征向量单位化后构成正交矩阵 $Q$。
+
+**步骤 1：** 解特征方程 $|\lambda E - S| = {{expr:charpoly(S)}} = 0$，得全部特征值，如 $\lambda_1 = {{expr:sym_eigenval(S,1)}}$。
+
+**步骤 2：** 对每个特征值 $\lambda_i$，解 $(\lambda_i E - S)x = 0$ 得特征向量 $\alpha_i$。不同特征值的特征向量天然正交。
+
+**步骤 3：** 将每个特征向量单位化得 $q_i = \dfrac{\alpha_i}{\|\alpha_i\|}$，$Q = (q_1, \ldots, q_n)$ 的第 $i$ 列为 $q_i$，其元素按行自上而下、从左到右依次填入各空。`,
+		},
+	}
+}
+
+// buildQuadraticDefinitenessN：Chapter6_1_1 的 n 阶版本，写出二次型的对称矩阵并判断正定性。
+func buildQuadraticDefinitenessN(k string, n int) dsl.Problem {
+	ids := BlankIDs(k, n*n+1)
+	fds := MatrixFieldDefsRectIDs(ids, 0, n, n, "S", "S")
+	fds = append(fds, dsl.AnswerFieldDef{ID: ids[n*n], Expr: "symcode_611(S)"})
+	return dsl.Problem{
+		ID:      ProblemID(k),
+		Version: "bank-v1",
+		Title: fmt.Sprintf(
+			`设二次型 $f(x_1,\ldots,x_%d)={{expr}}$，写出对应的对称矩阵 $S$ 的 %d 个元素（按行自上而下、从左到右），最后一空填写正定性代码（正定填1，负定填0，不定填2）：%s`,
+			n, n*n, joinBlankPlaceholders(ids)),
+		Variables: map[string]dsl.Variable{
+			"S": {Kind: "matrix", Rows: n, Cols: n, Generator: map[string]interface{}{"rule": "symmetric", "min": -8, "max": 8}},
+		},
+		Derived: map[string]string{"expr": "quad_expr(S)"},
+		Render:  map[string]string{"expr": "expr"},
+		Answer:  dsl.AnswerSchema{FieldDefs: fds},
+		Meta: map[string]interface{}{
+			"solution_zh": `**解题思路：** 二次型的对称矩阵 $S$ 中，主对角元 $S_{ii}$ 等于 $x_i^2$ 的系数，非对角元 $S_{ij}$（$i\neq j$）等于交叉项 $x_ix_j$ 系数的一半。
+
+**步骤 1：** 读出平方项系数作为主对角元，交叉项系数除以 2 作为非对角元，得 $S$ 的全部元素。
+
+**步骤 2：** 判断正定性：正惯性指数为 {{expr:inertia_pos(S)}}，负惯性指数为 {{expr:inertia_neg(S)}}。全部特征值为正则正定，全部为负则负定，否则不定；也可用各阶顺序主子式的符号判断，其中 $\Delta_n=\det(S)={{expr:det(S)}}$。`,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+// TestSizedQuestions 对每个 n 阶题键：静态检查无 error、空数、满分判题。
+func TestSizedQuestions(t *testing.T) {
+	wantFields := map[string]func(n int) int{
+		"Chapter5_2":   func(n int) int { return n + n*n },
+		"Chapter5_7":   func(n int) int { return n + n*n },
+		"Chapter6_1_1": func(n int) int { return n*n + 1 },
+	}
+	for _, base := range SizedQuestionBases() {
+		for n := MinOrder; n <= MaxOrder; n++ {
+			key := SizedQuestionKey(base, n)
+			t.Run(key, func(t *testing.T) {
+				p, err := BuildProblem(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diags := dsl.ValidateProblem(p); dsl.HasErrors(diags) {
+					t.Fatalf("validate: %v", diags)
+				}
+				for _, seed := range []string{"sized-1", "sized-2", "sized-3"} {
+					g, err := GenerateBankQuestion(key, seed, "salt")
+					if err != nil {
+						t.Fatal(err)
+					}
+					if want := wantFields[base](n); len(g.AnswerFields) != want {
+						t.Fatalf("answer field count: want %d got %d", want, len(g.AnswerFields))
+					}
+					ans := make(map[string]string, len(g.AnswerFields))
+					for _, f := range g.AnswerFields {
+						ans[f.ID] = dsl.ValueToCanonicalString(f.Value)
+					}
+					jr, err := JudgeBankQuestion(key, seed, "salt", ans, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !jr.AllCorrect {
+						t.Fatalf("judge all correct: %+v", jr.Fields)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSizedQuestionKeyParse(t *testing.T) {
+	for _, key := range []string{"Chapter5_2_n1", "Chapter5_2_n7", "Chapter5_2_n04", "Chapter1_1_n3", "Chapter5_2_n"} {
+		if _, err := BuildProblem(key); err == nil {
+			t.Fatalf("%s: expect unknown key", key)
+		}
+	}
+	if _, _, ok := parseSizedKey(SizedQuestionKey("Chapter6_1_1", 4)); !ok {
+		t.Fatal("Chapter6_1_1_n4 should parse")
+	}
+}
@@ -0,0 +1,201 @@
+package bank
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neumathe/la-dsl/dsl"
+)
+
+func TestResolveQuestionKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"Chapter4_8":            "Chapter4_8@bank-v1",
+		"Chapter4_8@bank-v1":    "Chapter4_8@bank-v1",
+		"Chapter5_2_n4":         "Chapter5_2_n4@bank-v1",
+		"Chapter5_2_n4@bank-v1": "Chapter5_2_n4@bank-v1",
+	} {
+		if got, err := ResolveQuestionKey(key); err != nil || got != want {
+			t.Fatalf("%s: got %q, %v", key, got, err)
+		}
+	}
+	for _, key := range []string{"Chapter4_8@bank-v9", "Chapter4_8@", "nope", "nope@bank-v1"} {
+		if _, err := ResolveQuestionKey(key); err == nil {
+			t.Fatalf("%s: expect err", key)
+		}
+		if _, err := BuildProblem(key); err == nil {
+			t.Fatalf("%s: BuildProblem expect err", key)
+		}
+	}
+}
+
+// TestRevision 登记新版本后：不带版本的题键出新版，带旧版本号的题键出题、判题与原来完全一致。
+func TestRevision(t *testing.T) {
+	const key, seed, salt = "Chapter1_1", "rev-seed", "salt"
+	before, err := GenerateBankQuestion(key, seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions[key] = []revision{{version: "bank-v2", build: func() dsl.Problem {
+		p := buildChapter1_1()
+		p.Version = "bank-v2"
+		p.Title = "（修订）" + p.Title
+		return p
+	}}}
+	defer delete(revisions, key)
+
+	if got := QuestionVersions(key); len(got) != 2 || got[1] != "bank-v2" {
+		t.Fatalf("versions %v", got)
+	}
+	latest, err := GenerateBankQuestion(key, seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(latest.Title, "（修订）") {
+		t.Fatalf("latest title %q", latest.Title)
+	}
+	pinned, err := GenerateBankQuestion(VersionedKey(key, InitialVersion), seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned.Title != before.Title {
+		t.Fatalf("pinned title changed:\n%s\n%s", before.Title, pinned.Title)
+	}
+	user := map[string]string{}
+	for _, f := range before.AnswerFields {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	res, err := JudgeBankQuestion(VersionedKey(key, InitialVersion), seed, salt, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.AllCorrect {
+		t.Fatalf("pinned judge: %+v", res.Fields)
+	}
+
+	revisions[key][0].build = buildChapter1_1 // 版本号与 builder 不符
+	compiled.Delete(VersionedKey(key, "bank-v2"))
+	defer compiled.Delete(VersionedKey(key, "bank-v2"))
+	if _, err := CompiledProblem(key); err == nil || !strings.Contains(err.Error(), `builder sets version "bank-v1"`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// TestRevision_unversionedIssued 修订前用不带版本的题键发出的题（后端只存了题键与 seed），
+// 登记改变答案的新版本后，不带版本的判题、解析仍按 bank-v1。
+func TestRevision_unversionedIssued(t *testing.T) {
+	const key, seed, salt = "Chapter1_1", "issued-seed", "salt"
+	issued, err := GenerateBankQuestion(key, seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]string{}
+	for _, f := range issued.AnswerFields {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	before, err := GenerateBankExplanation(key, seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revisions[key] = []revision{{version: "bank-v2", build: func() dsl.Problem {
+		p := buildChapter1_1()
+		p.Version = "bank-v2"
+		p.Answer.FieldDefs[0].Expr = "1 + (" + p.Answer.FieldDefs[0].Expr + ")"
+		return p
+	}}}
+	defer delete(revisions, key)
+	defer compiled.Delete(VersionedKey(key, "bank-v2"))
+
+	res, err := JudgeBankQuestion(key, seed, salt, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.AllCorrect {
+		t.Fatalf("unversioned judge after revision: %+v", res.Fields)
+	}
+	latest, err := JudgeBankQuestion(VersionedKey(key, "bank-v2"), seed, salt, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.AllCorrect {
+		t.Fatal("bank-v2 should change the answer")
+	}
+	after, err := GenerateBankExplanation(key, seed, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, _ := json.Marshal(before)
+	b2, _ := json.Marshal(after)
+	if string(b1) != string(b2) {
+		t.Fatalf("unversioned explanation changed:\n%s\n%s", b1, b2)
+	}
+}
+
+// TestJudgeBankSnapshot_version 快照按出题时的版本判分：题库发布新修订后，旧快照仍取原 builder 的答案表达式。
+func TestJudgeBankSnapshot_version(t *testing.T) {
+	const key = "Chapter1_1"
+	cp, err := CompiledProblem(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := cp.Instantiate("snap-version", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.Version != InitialVersion {
+		t.Fatalf("instance version %q", inst.Version)
+	}
+	g, err := cp.GenerateQuestionFromInstance(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]string{}
+	for _, f := range g.AnswerFields {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap dsl.Instance
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Version != InitialVersion {
+		t.Fatalf("snapshot version %q", snap.Version)
+	}
+
+	revisions[key] = []revision{{version: "bank-v2", build: func() dsl.Problem {
+		p := buildChapter1_1()
+		p.Version = "bank-v2"
+		p.Answer.FieldDefs[0].Expr = "1 + (" + p.Answer.FieldDefs[0].Expr + ")"
+		return p
+	}}}
+	defer delete(revisions, key)
+	defer compiled.Delete(VersionedKey(key, "bank-v2"))
+
+	for _, k := range []string{key, VersionedKey(key, InitialVersion)} {
+		res, err := JudgeBankSnapshot(k, &snap, user, nil)
+		if err != nil || !res.AllCorrect {
+			t.Fatalf("%s: %v %+v", k, err, res)
+		}
+	}
+	legacy := snap
+	legacy.Version = ""
+	if res, err := JudgeBankSnapshot(key, &legacy, user, nil); err != nil || !res.AllCorrect {
+		t.Fatalf("unversioned snapshot: %v %+v", err, res)
+	}
+	if _, err := JudgeBankSnapshot(VersionedKey(key, "bank-v2"), &snap, user, nil); err == nil || !strings.Contains(err.Error(), `snapshot is for version "bank-v1"`) {
+		t.Fatalf("got %v", err)
+	}
+	v2 := snap
+	v2.Version = "bank-v2"
+	if res, err := JudgeBankSnapshot(key, &v2, user, nil); err != nil || res.Fields[0].Correct {
+		t.Fatalf("bank-v2 snapshot should use the revised answer: %v %+v", err, res)
+	}
+	v2.Version = "bank-v9"
+	if _, err := JudgeBankSnapshot(key, &v2, user, nil); err == nil {
+		t.Fatal("unknown snapshot version should be rejected")
+	}
+}
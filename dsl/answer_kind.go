@@ -7,6 +7,16 @@ const (
 	FieldAnswerKindInteger  = "integer"  // int / int64 / 整型 big.Rat
 	FieldAnswerKindBigInt   = "bigint"   // *big.Int（行列式等，输入仍为十进制整数字符串）
 	FieldAnswerKindRational = "rational" // 非整 *big.Rat，建议展示「可填分数」
+	// FieldAnswerKindPolynomial 为 *Polynomial（如特征多项式），输入见 ParseUserPolynomial。
+	FieldAnswerKindPolynomial = "polynomial"
+	// FieldAnswerKindMatrix 为整矩阵空（*MatrixInt / *MatrixRat），输入见 ParseUserMatrix。
+	FieldAnswerKindMatrix = "matrix"
+	// FieldAnswerKindVector 为整向量空（*VectorInt / *VectorRat / []*big.Rat），输入见 ParseUserVector。
+	FieldAnswerKindVector = "vector"
+	// FieldAnswerKindSurd 为含根号的精确数（*Surd，如 √6/6），输入见 ParseUserSurd。
+	FieldAnswerKindSurd = "surd"
+	// FieldAnswerKindInterval 为取值范围（*IntervalSet，judge kind "interval"），输入见 ParseUserInterval。
+	FieldAnswerKindInterval = "interval"
 )
 
 // FieldInputHint 与单次出题实例的标准答案形态对应。
@@ -31,6 +41,16 @@ func ClassifyScalarAnswerValue(v interface{}) string {
 		return FieldAnswerKindInteger
 	case float64:
 		return FieldAnswerKindRational
+	case *Polynomial:
+		return FieldAnswerKindPolynomial
+	case *MatrixInt, *MatrixRat:
+		return FieldAnswerKindMatrix
+	case *VectorInt, *VectorRat, []*big.Rat:
+		return FieldAnswerKindVector
+	case *Surd:
+		return FieldAnswerKindSurd
+	case *IntervalSet:
+		return FieldAnswerKindInterval
 	default:
 		return "unsupported"
 	}
@@ -6,6 +6,8 @@ const AnswerLayoutSchema = "la-dsl.answer_layout.v1"
 const (
 	LayoutKindMatrixCell      = "matrix_cell"      // 矩阵第 (row,col) 格，1-based，与 mget 一致
 	LayoutKindVectorComponent = "vector_component" // 向量第 index 个分量，1-based，与 v[k] 一致
+	LayoutKindMatrix          = "matrix"           // 整矩阵空（judge kind "matrix"），rows/cols 为矩阵形状
+	LayoutKindVector          = "vector"           // 整向量空，rows 为分量个数
 )
 
 // AnswerFieldLayout 描述单个填空在版式中的语义位置，供 App/Web 渲染矩阵格/向量分量格。
@@ -44,6 +46,29 @@ func LayoutMatrixCell(matrix string, row, col, rows, cols int, groupLabel string
 	}
 }
 
+// LayoutMatrix 构造整矩阵空的布局元数据。
+func LayoutMatrix(matrix string, rows, cols int, groupLabel string) *AnswerFieldLayout {
+	return &AnswerFieldLayout{
+		Schema:     AnswerLayoutSchema,
+		Kind:       LayoutKindMatrix,
+		Matrix:     matrix,
+		Rows:       rows,
+		Cols:       cols,
+		GroupLabel: groupLabel,
+	}
+}
+
+// LayoutVector 构造整向量空的布局元数据。
+func LayoutVector(vector string, size int, groupLabel string) *AnswerFieldLayout {
+	return &AnswerFieldLayout{
+		Schema:     AnswerLayoutSchema,
+		Kind:       LayoutKindVector,
+		Vector:     vector,
+		Rows:       size,
+		GroupLabel: groupLabel,
+	}
+}
+
 // LayoutVectorComponent 构造向量分量格布局。
 func LayoutVectorComponent(vector string, index int, groupLabel string) *AnswerFieldLayout {
 	return &AnswerFieldLayout{
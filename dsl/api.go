@@ -25,21 +25,26 @@ type GeneratedQuestion struct {
 
 // GenerateQuestionFromInstance 在已实例化的 inst 上渲染题面并抽取答案（不再重复随机）。
 func GenerateQuestionFromInstance(p Problem, inst *Instance) (*GeneratedQuestion, error) {
+	return compileLenient(p).GenerateQuestionFromInstance(inst)
+}
+
+// GenerateQuestionFromInstance 同 dsl.GenerateQuestionFromInstance，但复用预编译结果。
+func (cp *CompiledProblem) GenerateQuestionFromInstance(inst *Instance) (*GeneratedQuestion, error) {
 	if inst == nil {
 		return nil, fmt.Errorf("nil instance")
 	}
-	title, err := RenderTitle(p, inst)
+	title, err := cp.RenderTitle(inst)
 	if err != nil {
 		return nil, err
 	}
-	fields, err := ExtractAnswerWithMeta(p, inst)
+	fields, err := cp.ExtractAnswerWithMeta(inst)
 	if err != nil {
 		return nil, err
 	}
 	return &GeneratedQuestion{
 		Title:        title,
 		AnswerFields: fields,
-		Meta:         cloneMeta(p.Meta),
+		Meta:         cloneMeta(cp.Problem.Meta),
 	}, nil
 }
 
@@ -61,9 +66,14 @@ func cloneMeta(m map[string]interface{}) map[string]interface{} {
 //
 // 注意：同一 Problem + seedStr + serverSalt，多次调用结果完全一致。
 func GenerateQuestion(p Problem, seedStr, serverSalt string) (*GeneratedQuestion, error) {
-	inst, err := InstantiateProblem(p, seedStr, serverSalt)
+	return compileLenient(p).GenerateQuestion(seedStr, serverSalt)
+}
+
+// GenerateQuestion 同 dsl.GenerateQuestion，但复用预编译结果。
+func (cp *CompiledProblem) GenerateQuestion(seedStr, serverSalt string) (*GeneratedQuestion, error) {
+	inst, err := cp.Instantiate(seedStr, serverSalt)
 	if err != nil {
 		return nil, err
 	}
-	return GenerateQuestionFromInstance(p, inst)
+	return cp.GenerateQuestionFromInstance(inst)
 }
@@ -0,0 +1,323 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompiledProblem 为预编译后的题目：Derived / Render / 答案表达式与 solution_zh 中的 {{expr:...}}
+// 均已解析为语法树，派生量已按依赖排好求值顺序。构造后只读，可在多个 goroutine 间共享。
+type CompiledProblem struct {
+	Problem Problem
+
+	exprs          map[string]*Expr // 以去掉首尾空白的源串为键
+	graph          *DerivedGraph
+	derivedErr     error // 派生量存在循环依赖时，实例化直接报此错
+	constraints    []compiledConstraint
+	prng           prngAlgo
+	prngErr        error // Problem.RNG 未知时，实例化直接报此错
+	paramsErr      error // 生成参数有未声明的键或类型不符时，实例化直接报此错
+	constraintsErr error // 约束有静态的类型或形状错误（结果不是布尔标量、参数类型不符）时，实例化直接报此错，不重抽
+}
+
+// DerivedNode 为派生量依赖图中的一个结点。
+type DerivedNode struct {
+	Name string   `json:"name"`
+	Expr string   `json:"expr"`
+	Deps []string `json:"deps,omitempty"` // 直接依赖的其他结点（按名字排序）
+}
+
+// DerivedGraph 为派生量依赖图，Nodes 按求值顺序排列：依赖在前，互不依赖的按名字排序。
+// rule 为 integer_solution 的变量也作为结点参与排序，其表达式为 integer_solution(A, x)。
+type DerivedGraph struct {
+	Nodes []DerivedNode `json:"nodes"`
+}
+
+// Order 返回结点名的求值顺序。
+func (g *DerivedGraph) Order() []string {
+	out := make([]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		out[i] = n.Name
+	}
+	return out
+}
+
+// CycleError 为派生量之间的循环依赖，Path 首尾为同一结点，如 [w MT w]。
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "derived cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// Compile 预编译题目并做静态检查：语法错误、未知函数、派生量循环依赖都会在此报错，
+// 而不是等到某个 seed 实例化时才暴露。返回的错误汇总了全部问题。
+func Compile(p Problem) (*CompiledProblem, error) {
+	cp, errs := compileProblem(p)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("compile problem %d: %w", p.ID, errors.Join(errs...))
+	}
+	return cp, nil
+}
+
+// Graph 返回派生量依赖图（只读）。
+func (cp *CompiledProblem) Graph() *DerivedGraph { return cp.graph }
+
+// compileLenient 供 InstantiateProblem 等旧入口使用：解析失败的表达式留到求值时再报错，行为与预编译前一致。
+func compileLenient(p Problem) *CompiledProblem {
+	cp, _ := compileProblem(p)
+	return cp
+}
+
+func compileProblem(p Problem) (*CompiledProblem, []error) {
+	cp := &CompiledProblem{Problem: p, exprs: map[string]*Expr{}}
+	var errs []error
+	if cp.prng, cp.prngErr = lookupPRNG(p.RNG); cp.prngErr != nil {
+		errs = append(errs, cp.prngErr)
+	}
+	if cp.paramsErr = generatorParamsErr(p); cp.paramsErr != nil {
+		errs = append(errs, cp.paramsErr)
+	}
+	if cp.constraintsErr = constraintTypeErr(p); cp.constraintsErr != nil {
+		errs = append(errs, cp.constraintsErr)
+	}
+	add := func(where, src string, checkFuncs bool) *Expr {
+		key := strings.TrimSpace(src)
+		if e, ok := cp.exprs[key]; ok {
+			return e
+		}
+		e, err := ParseExpr(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			return nil
+		}
+		if checkFuncs {
+			walkExpr(e.root, func(n exprNode) {
+				if c, ok := n.(*callExpr); ok {
+					if _, known := exprFuncs[c.name]; !known {
+						errs = append(errs, fmt.Errorf("%s: unknown function %s (col %d)", where, c.name, c.col))
+					}
+				}
+			})
+		}
+		cp.exprs[key] = e
+		return e
+	}
+
+	sources := graphSources(p)
+	names := sortedKeys(sources)
+	deps := make(map[string][]string, len(sources))
+	for _, name := range names {
+		e := add(fmt.Sprintf("derived %s", name), sources[name], true)
+		if e == nil {
+			continue
+		}
+		deps[name] = nodeDeps(e, name, sources, p.Variables)
+	}
+	var order []string
+	order, cp.derivedErr = topoOrder(names, deps)
+	if cp.derivedErr != nil {
+		errs = append(errs, cp.derivedErr)
+	}
+	cp.graph = &DerivedGraph{Nodes: make([]DerivedNode, len(order))}
+	for i, name := range order {
+		cp.graph.Nodes[i] = DerivedNode{Name: name, Expr: sources[name], Deps: deps[name]}
+	}
+
+	for i, src := range p.Constraints {
+		e := add(fmt.Sprintf("constraint %d", i+1), src, true)
+		cp.constraints = append(cp.constraints, compiledConstraint{
+			index: i, src: strings.TrimSpace(src), late: e == nil || len(nodeDeps(e, "", sources, nil)) > 0,
+		})
+	}
+
+	for _, key := range sortedKeys(p.Render) {
+		add(fmt.Sprintf("render %s", key), p.Render[key], true)
+	}
+	if p.Answer.Expression != "" {
+		add("answer expression", p.Answer.Expression, true)
+	}
+	for i, f := range p.Answer.Fields {
+		add(fmt.Sprintf("answer field %d", i+1), f, true)
+	}
+	for i, fd := range p.Answer.FieldDefs {
+		if fd.Expr == "" {
+			continue
+		}
+		add(fmt.Sprintf("field_def %d (%s)", i+1, fd.ID), fd.Expr, true)
+	}
+	if sol, ok := p.Meta["solution_zh"].(string); ok {
+		for _, src := range exprPlaceholders(sol) {
+			add("solution_zh {{expr:"+src+"}}", src, true)
+		}
+	}
+	return cp, errs
+}
+
+// generatorParamsErr 按规则声明检查各变量的生成参数（同 ValidateProblem），拼错的键不再被静默忽略。
+func generatorParamsErr(p Problem) error {
+	names := make([]string, 0, len(p.Variables))
+	for name := range p.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		v := p.Variables[name]
+		if v.Fixed != nil {
+			continue
+		}
+		rule, _ := v.Generator["rule"].(string)
+		if rule == "" && v.Kind != "scalar" {
+			rule = "range"
+		}
+		e, ok := lookupGenerator(v.Kind, rule)
+		if !ok {
+			continue
+		}
+		for _, msg := range checkGeneratorParams(e, v.Generator) {
+			errs = append(errs, fmt.Errorf("variable %s generator: %s", name, msg))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// graphSources 返回依赖图各结点的表达式：Derived 全部条目，加上 rule 为 integer_solution 的变量。
+func graphSources(p Problem) map[string]string {
+	out := make(map[string]string, len(p.Derived))
+	for name, src := range p.Derived {
+		out[name] = strings.TrimSpace(src)
+	}
+	for name, v := range p.Variables {
+		if rule, _ := v.Generator["rule"].(string); rule != "integer_solution" {
+			continue
+		}
+		if _, ok := out[name]; ok {
+			continue
+		}
+		a, _ := v.Generator["A"].(string)
+		x, _ := v.Generator["x"].(string)
+		out[name] = fmt.Sprintf("integer_solution(%s, %s)", a, x)
+	}
+	return out
+}
+
+// nodeDeps 返回表达式引用到的结点；结点引用与自身同名的变量（vars 中有该名字）时取变量值，不算依赖，
+// 否则自引用算作依赖自身，由 topoOrder 报告为循环。
+func nodeDeps(e *Expr, self string, nodes map[string]string, vars map[string]Variable) []string {
+	var deps []string
+	seen := map[string]bool{}
+	_, selfIsVar := vars[self]
+	walkExpr(e.root, func(n exprNode) {
+		id, ok := n.(*identRef)
+		if !ok || (id.name == self && selfIsVar) || seen[id.name] {
+			return
+		}
+		if _, ok := nodes[id.name]; ok {
+			seen[id.name] = true
+			deps = append(deps, id.name)
+		}
+	})
+	sort.Strings(deps)
+	return deps
+}
+
+// topoOrder 按依赖给出求值顺序（依赖在前）；同层按名字排序以保证确定性。
+func topoOrder(names []string, deps map[string][]string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var stack []string
+	var visit func(n string) error
+	visit = func(n string) error {
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			i := len(stack) - 1
+			for stack[i] != n {
+				i--
+			}
+			return &CycleError{Path: append(append([]string(nil), stack[i:]...), n)}
+		}
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, d := range deps[n] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+		order = append(order, n)
+		return nil
+	}
+	for _, n := range names {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// walkExpr 先序遍历语法树。
+func walkExpr(n exprNode, fn func(exprNode)) {
+	fn(n)
+	switch t := n.(type) {
+	case *callExpr:
+		for _, a := range t.args {
+			walkExpr(a, fn)
+		}
+	case *indexExpr:
+		walkExpr(t.x, fn)
+		for _, i := range t.idxs {
+			walkExpr(i, fn)
+		}
+	case *unaryExpr:
+		walkExpr(t.x, fn)
+	case *binaryExpr:
+		walkExpr(t.l, fn)
+		walkExpr(t.r, fn)
+	}
+}
+
+// exprPlaceholders 返回字符串中全部 {{expr:...}} 的表达式部分，扫描规则与 expandExprPlaceholders 一致。
+func exprPlaceholders(s string) []string {
+	var out []string
+	for {
+		start := strings.Index(s, "{{expr:")
+		if start == -1 {
+			return out
+		}
+		end := strings.Index(s[start:], "}}")
+		if end == -1 {
+			return out
+		}
+		end += start
+		out = append(out, s[start+7:end])
+		s = s[end+2:]
+	}
+}
+
+// eval 优先使用预解析的语法树；未预解析（或解析失败）的源串按原路径求值。
+func (cp *CompiledProblem) eval(src string, inst *Instance) (interface{}, error) {
+	if e, ok := cp.exprs[strings.TrimSpace(src)]; ok {
+		return e.Eval(inst)
+	}
+	return EvaluateExpression(src, inst)
+}
@@ -0,0 +1,139 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func compileTestProblem() Problem {
+	return Problem{
+		ID:    1,
+		Title: "det = {{blank:d}}, {{A}}",
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 2, Cols: 2, Fixed: [][]interface{}{{1, 2}, {3, 4}}},
+		},
+		// 名字的字典序与依赖顺序相反：c 依赖 b，b 依赖 a
+		Derived: map[string]string{
+			"a": "transpose(A)",
+			"b": "matmul(A, a)",
+			"c": "det(b) + 1",
+		},
+		Render: map[string]string{"A": "A"},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{{ID: "d", Expr: "c"}}},
+	}
+}
+
+func TestCompile_derivedOrderAndReuse(t *testing.T) {
+	cp, err := Compile(compileTestProblem())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cp.Graph().Order(), ","); got != "a,b,c" {
+		t.Fatalf("derived order %s", got)
+	}
+	for _, seed := range []string{"s1", "s2"} {
+		g, err := cp.GenerateQuestion(seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := ValueToCanonicalString(g.AnswerFields[0].Value); v != "5" {
+			t.Fatalf("det(A*A^T)+1 = %s, want 5", v)
+		}
+	}
+}
+
+func TestCompile_rejectsBrokenProblem(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(p *Problem)
+		want   string
+	}{
+		{"parse", func(p *Problem) { p.Render["A"] = "det(A" }, "render A"},
+		{"unknown function", func(p *Problem) { p.Answer.FieldDefs[0].Expr = "dett(A)" }, "unknown function dett"},
+		{"cycle", func(p *Problem) { p.Derived["a"] = "transpose(c)" }, "a -> c -> b -> a"},
+		{"self reference", func(p *Problem) { p.Derived["d"] = "d + 1" }, "d -> d"},
+		{"solution", func(p *Problem) { p.Meta = map[string]interface{}{"solution_zh": "{{expr:nosuch(A)}}"} }, "solution_zh"},
+	}
+	for _, tc := range cases {
+		p := compileTestProblem()
+		tc.modify(&p)
+		_, err := Compile(p)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: want error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	p := compileTestProblem()
+	p.Render["A"] = "det(A"
+	var pe *ParseError
+	if _, err := Compile(p); !errors.As(err, &pe) || pe.Col != 6 {
+		t.Fatalf("want ParseError at col 6, got %v", err)
+	}
+	// 旧入口保持宽松：循环依赖在实例化时报错
+	p = compileTestProblem()
+	p.Derived["a"] = "transpose(c)"
+	var ce *CycleError
+	if _, err := InstantiateProblem(p, "s", "salt"); !errors.As(err, &ce) || strings.Join(ce.Path, " -> ") != "a -> c -> b -> a" {
+		t.Fatalf("InstantiateProblem should report the derived cycle, got %v", err)
+	}
+}
+
+// 派生量引用自身：有同名变量时取变量值，否则是长度为 1 的循环，在 Compile 时报告。
+func TestCompile_selfReference(t *testing.T) {
+	p := compileTestProblem()
+	p.Derived["d"] = "d + 1"
+	var ce *CycleError
+	if _, err := Compile(p); !errors.As(err, &ce) || strings.Join(ce.Path, ",") != "d,d" {
+		t.Fatalf("want CycleError d -> d, got %v", err)
+	}
+	if ds := ValidateProblem(p); !strings.Contains(fmt.Sprint(ds), "derived cycle: d -> d") {
+		t.Fatalf("diagnostics %v", ds)
+	}
+
+	p = compileTestProblem()
+	p.Derived["A"] = "transpose(A)"
+	cp, err := Compile(p)
+	if err != nil {
+		t.Fatalf("derived shadowing variable A: %v", err)
+	}
+	if _, err := cp.Instantiate("s", "salt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstantiate_graphOnInstance(t *testing.T) {
+	p := compileTestProblem()
+	p.Variables["x"] = Variable{Kind: "vector", Size: 2, Fixed: []interface{}{1, -1}}
+	p.Variables["y"] = Variable{Kind: "vector", Size: 2, Generator: map[string]interface{}{"rule": "integer_solution", "A": "b", "x": "x"}}
+	p.Derived["bb"] = "integer_solution(A, x)"
+	inst, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(inst.Graph.Order(), ","); got != "a,b,bb,c,y" {
+		t.Fatalf("graph order %s", got)
+	}
+	// b = A·Aᵀ = [[5 11] [11 25]]
+	if got := ValueToExplainString(inst.Vars["y"]); got != "(-6, -14)" {
+		t.Fatalf("y = %s", got)
+	}
+	if got := ValueToExplainString(inst.Derived["bb"]); got != "(-1, -1)" {
+		t.Fatalf("bb = %s", got)
+	}
+	if _, ok := inst.Derived["y"]; ok {
+		t.Fatal("integer_solution variable should stay out of Derived")
+	}
+	ex, err := GenerateExplanation(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range ex.Derived {
+		names = append(names, d.Name+"("+strings.Join(d.DependsOn, " ")+")")
+	}
+	if got := strings.Join(names, ","); got != "a(),b(a),bb(),c(b)" {
+		t.Fatalf("explanation derived order %s", got)
+	}
+}
@@ -0,0 +1,175 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxAttempts 为 Problem.MaxAttempts 未设置时满足约束的尝试次数上限。
+const DefaultMaxAttempts = 200
+
+type compiledConstraint struct {
+	index int // 在 Problem.Constraints 中的下标
+	src   string
+	late  bool // 引用了派生量，须在派生量求值后检查
+}
+
+// ConstraintError 表示尝试次数用尽仍有约束不成立。
+// Failures 与 Problem.Constraints 对齐，记录每条约束作为首个不成立约束的次数（求值出错也算不成立）；
+// Index / Constraint 为其中次数最多的一条（并列取靠前的）。
+// EvalErrors 为因约束求值出错（如 inv 遇到奇异矩阵）而失败的尝试数，Err 为最后一次这样的错误。
+// 类型与形状错误不重抽，不会出现在这里：能静态推断的在 Compile 时报告（见 constraintTypeErr），其余在首次求值时直接返回。
+type ConstraintError struct {
+	Attempts   int
+	Index      int
+	Constraint string
+	Failures   []int
+	EvalErrors int
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	msg := fmt.Sprintf("constraints not satisfied after %d attempts: constraint %d %q failed %d times",
+		e.Attempts, e.Index+1, e.Constraint, e.Failures[e.Index])
+	if e.Err != nil {
+		msg += fmt.Sprintf(" (%d attempts failed to evaluate; last: %v)", e.EvalErrors, e.Err)
+	}
+	return msg
+}
+
+func (e *ConstraintError) Unwrap() error { return e.Err }
+
+// instantiateConstrained 重抽直到约束全部成立。
+// 第一次尝试沿用主 RNG，约束一次即成立时与未加约束的出题结果相同；此后每次尝试使用独立 RNG，
+// 其种子依次取自按域分离的种子流（与 full_rank 逐次尝试独立 RNG 的做法一致；v2 下由 seed 与尝试序号哈希得到），
+// 因而第 k 次尝试的样本只取决于 seed 与 k，与前面尝试消耗了多少随机数无关。
+func (cp *CompiledProblem) instantiateConstrained(seed int64, seedStr, serverSalt string, tr *generationTrace) (*Instance, error) {
+	p := cp.Problem
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	failures := make([]int, len(p.Constraints))
+	evalErrors := 0
+	var lastErr error
+	var attemptSeeds *rand.Rand
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		s := seed
+		switch {
+		case attempt == 0:
+		case cp.prng == prngV2:
+			s = streamSeed(seed, "constraints", strconv.Itoa(attempt))
+		default:
+			if attemptSeeds == nil {
+				base := deriveSeed(seedStr, fmt.Sprintf("%d|constraints", p.ID), p.Version, serverSalt)
+				attemptSeeds = rand.New(rand.NewSource(base))
+			}
+			s = attemptSeeds.Int63()
+		}
+		if tr != nil {
+			tr.constraintAttempts, tr.constraintLimit = attempt+1, maxAttempts
+		}
+		inst, failed, err := cp.instantiateAttempt(s, seedStr, tr)
+		if failed < 0 {
+			if err != nil {
+				return nil, err
+			}
+			return inst, nil
+		}
+		failures[failed]++
+		if err != nil {
+			evalErrors++
+			lastErr = err
+		}
+	}
+	worst := 0
+	for i, n := range failures {
+		if n > failures[worst] {
+			worst = i
+		}
+	}
+	if tr != nil {
+		tr.constraintFailed = true
+	}
+	return nil, &ConstraintError{
+		Attempts:   maxAttempts,
+		Index:      worst,
+		Constraint: p.Constraints[worst],
+		Failures:   failures,
+		EvalErrors: evalErrors,
+		Err:        lastErr,
+	}
+}
+
+// checkConstraints 按书写顺序检查 late 标记与参数一致的约束，返回第一条不成立约束的下标，全部成立时返回 -1。
+// 求值出错（如奇异矩阵求逆、除以零，取决于样本）时视为该约束不成立，同时返回其下标与错误，由调用方计入重抽；
+// 结果不是标量与样本无关，返回 -1 与错误，出题直接失败。
+func (cp *CompiledProblem) checkConstraints(inst *Instance, late bool) (int, error) {
+	for _, c := range cp.constraints {
+		if c.late != late {
+			continue
+		}
+		v, err := cp.eval(c.src, inst)
+		if err != nil {
+			return c.index, fmt.Errorf("constraint %d %q: %w", c.index+1, c.src, err)
+		}
+		ok, err := truthValue(v)
+		if err != nil {
+			return -1, fmt.Errorf("constraint %d %q: %w", c.index+1, c.src, err)
+		}
+		if !ok {
+			return c.index, nil
+		}
+	}
+	return -1, nil
+}
+
+// constraintTypeErr 用 ValidateProblem 的形状推断静态检查约束：结果不是布尔标量、函数参数类型不符、引用未定义的名字。
+// 这些错误与样本无关，重抽只会耗尽尝试次数后报出误导性的 ConstraintError，因此在编译期报告。
+func constraintTypeErr(p Problem) error {
+	if len(p.Constraints) == 0 {
+		return nil
+	}
+	// 语法错误与未知函数已由 compileProblem 报告，这些约束换成恒真的 1，保持下标不变
+	q := p
+	q.Constraints = make([]string, len(p.Constraints))
+	for i, src := range p.Constraints {
+		q.Constraints[i] = "1"
+		if e, err := ParseExpr(strings.TrimSpace(src)); err == nil && funcsKnown(e) {
+			q.Constraints[i] = src
+		}
+	}
+	v := &validator{p: q, names: map[string]bool{}, shapes: map[string]shape{}}
+	v.checkVariables()
+	v.checkDerived()
+	v.diags = nil // 只报告约束本身的问题
+	v.checkConstraints()
+	var errs []error
+	for _, d := range v.diags {
+		if d.Severity != SeverityError {
+			continue
+		}
+		if d.Col > 0 {
+			errs = append(errs, fmt.Errorf("%s col %d: %s", d.Location, d.Col, d.Message))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %s", d.Location, d.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// funcsKnown 报告表达式调用的函数是否都已注册。
+func funcsKnown(e *Expr) bool {
+	known := true
+	walkExpr(e.root, func(n exprNode) {
+		if c, ok := n.(*callExpr); ok {
+			if _, ok := exprFuncs[c.name]; !ok {
+				known = false
+			}
+		}
+	})
+	return known
+}
@@ -0,0 +1,185 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func constraintTestProblem(constraints ...string) Problem {
+	return Problem{
+		ID:    7,
+		Title: "{{A}}",
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "range", "min": -5, "max": 5}},
+		},
+		Derived:     map[string]string{"Ainv": "inv(A)", "d": "det(A)"},
+		Render:      map[string]string{"A": "A"},
+		Answer:      AnswerSchema{Expression: "d"},
+		Constraints: constraints,
+	}
+}
+
+func TestInstantiate_constraintsHold(t *testing.T) {
+	p := constraintTestProblem("-30 <= det(A) <= 30", "det(A) != 0", "rank(A + eye(3)) == 2")
+	p.MaxAttempts = 2000
+	cp, err := Compile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s := 0; s < 20; s++ {
+		seed := fmt.Sprint("c", s)
+		inst, err := cp.Instantiate(seed, "salt")
+		if err != nil {
+			t.Fatalf("%s: %v", seed, err)
+		}
+		d, _ := scalarToInt64(inst.Vars["d"])
+		if d == 0 || d < -30 || d > 30 {
+			t.Fatalf("%s: det %d", seed, d)
+		}
+		if r, _ := EvaluateExpression("rank(A + eye(3))", inst); ValueToCanonicalString(r) != "2" {
+			t.Fatalf("%s: rank(A+E) %v", seed, r)
+		}
+		again, err := cp.Instantiate(seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ValueToCanonicalString(again.Vars["A"]) != ValueToCanonicalString(inst.Vars["A"]) {
+			t.Fatalf("%s: not deterministic", seed)
+		}
+	}
+}
+
+func TestInstantiate_constraintFirstAttemptMatchesUnconstrained(t *testing.T) {
+	plain := constraintTestProblem()
+	plain.Derived = nil
+	plain.Answer = AnswerSchema{Expression: "det(A)"}
+	constrained := plain
+	constrained.Constraints = []string{"rank(A) >= 0"}
+	for s := 0; s < 10; s++ {
+		seed := fmt.Sprint("c", s)
+		a, err := InstantiateProblem(plain, seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := InstantiateProblem(constrained, seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ValueToCanonicalString(a.Vars["A"]) != ValueToCanonicalString(b.Vars["A"]) {
+			t.Fatalf("%s: always-true constraint changed the sample", seed)
+		}
+	}
+}
+
+// 只引用变量的约束先于派生量检查：det(A) != 0 排除奇异矩阵，inv(A) 不会出错。
+func TestInstantiate_constraintGuardsDerived(t *testing.T) {
+	p := constraintTestProblem("det(A) != 0")
+	p.Variables["A"] = Variable{Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "range", "min": 0, "max": 1}}
+	for s := 0; s < 30; s++ {
+		if _, err := InstantiateProblem(p, fmt.Sprint("g", s), "salt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstantiate_constraintBudgetExhausted(t *testing.T) {
+	p := constraintTestProblem("det(A) != 0", "d == 100000", "rank(A) == 3")
+	p.MaxAttempts = 50
+	_, err := InstantiateProblem(p, "s", "salt")
+	var ce *ConstraintError
+	if !errors.As(err, &ce) {
+		t.Fatalf("want ConstraintError, got %v", err)
+	}
+	if ce.Attempts != 50 || ce.Index != 1 || ce.Constraint != "d == 100000" {
+		t.Fatalf("%+v", ce)
+	}
+	if ce.Failures[0]+ce.Failures[1]+ce.Failures[2] != 50 || ce.Failures[2] != 0 {
+		t.Fatalf("failures %v", ce.Failures)
+	}
+}
+
+func TestInstantiate_constraintNotScalar(t *testing.T) {
+	p := constraintTestProblem("A")
+	if _, err := InstantiateProblem(p, "s", "salt"); err == nil {
+		t.Fatal("expect err")
+	}
+	ds := ValidateProblem(p)
+	if got := diagStrings(ds); got != "error constraints[0]: constraint is 3×3, want a boolean (scalar) expression" {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
+
+// 类型与形状错误与样本无关：Compile 直接报告，出题也不重抽到尝试次数用尽。
+func TestInstantiate_constraintTypeErrorNotResampled(t *testing.T) {
+	for src, want := range map[string]string{
+		"A":             "constraints[0]: constraint is 3×3, want a boolean (scalar) expression",
+		"det(d) != 0":   "constraints[0] col 1: det: argument 1 is scalar, want matrix",
+		"rank(B) == 3":  "constraints[0] col 6: undefined name B",
+		"Ainv + A == 1": "constraints[0] col 10: ==: 3×3 and scalar",
+	} {
+		p := constraintTestProblem(src)
+		if _, err := Compile(p); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: Compile got %v, want %q", src, err, want)
+		}
+		_, err := InstantiateProblem(p, "s", "salt")
+		var ce *ConstraintError
+		if err == nil || errors.As(err, &ce) || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: InstantiateProblem got %v", src, err)
+		}
+	}
+	if _, err := Compile(constraintTestProblem("det(A) != 0", "rank(Ainv) == 3")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 形状无法静态推断时，首次求值发现结果不是标量即报错，不计入重抽
+	RegisterFunction("test_anymat", MustParseSignature("-> any"), func(c *Call) (interface{}, error) {
+		return NewMatrixInt(2, 2), nil
+	})
+	defer delete(exprFuncs, "test_anymat")
+	p := constraintTestProblem("test_anymat()")
+	if _, err := Compile(p); err != nil {
+		t.Fatal(err)
+	}
+	_, err := InstantiateProblem(p, "s", "salt")
+	var ce *ConstraintError
+	if err == nil || errors.As(err, &ce) || !strings.Contains(err.Error(), "condition expects scalar") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// 约束求值出错（奇异矩阵求逆）计为一次不成立的尝试，继续重抽，而不是让出题失败。
+func TestInstantiate_constraintEvalErrorResamples(t *testing.T) {
+	p := constraintTestProblem("det(inv(A)) != 0")
+	p.Derived = nil
+	p.Variables["A"] = Variable{Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "range", "min": 0, "max": 1}}
+	p.Answer = AnswerSchema{Expression: "det(A)"}
+	singular := 0
+	for s := 0; s < 30; s++ {
+		inst, err := InstantiateProblem(p, fmt.Sprint("e", s), "salt")
+		if err != nil {
+			t.Fatalf("e%d: %v", s, err)
+		}
+		if det, _ := EvaluateExpression("det(A)", inst); ValueToCanonicalString(det) == "0" {
+			t.Fatalf("e%d: singular A accepted", s)
+		}
+		plain := p
+		plain.Constraints = nil
+		first, _ := InstantiateProblem(plain, fmt.Sprint("e", s), "salt")
+		if det, _ := EvaluateExpression("det(A)", first); ValueToCanonicalString(det) == "0" {
+			singular++
+		}
+	}
+	if singular == 0 {
+		t.Fatal("no seed drew a singular matrix first; test does not exercise resampling")
+	}
+
+	p.Constraints = []string{"det(inv(A - A)) != 0"}
+	p.MaxAttempts = 10
+	_, err := InstantiateProblem(p, "s", "salt")
+	var ce *ConstraintError
+	if !errors.As(err, &ce) || ce.EvalErrors != 10 || ce.Failures[0] != 10 || ce.Err == nil || !strings.Contains(err.Error(), "last:") {
+		t.Fatalf("%v", err)
+	}
+}
@@ -28,10 +28,12 @@ type ExplainNamedValue struct {
 }
 
 // ExplainDerivedValue 派生量：DSL 表达式及其在本实例下的值。
+// QuestionExplanation.Derived 按求值顺序排列，DependsOn 为其直接依赖的派生量。
 type ExplainDerivedValue struct {
-	Name  string `json:"name"`
-	Expr  string `json:"expr"`
-	Value string `json:"value"`
+	Name      string   `json:"name"`
+	Expr      string   `json:"expr"`
+	Value     string   `json:"value"`
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // AnswerExplainStep 单个填空的计算式与标准答案（规范字符串，与判分一致）。
@@ -63,6 +65,22 @@ func ValueToExplainString(v interface{}) string {
 			cells[i] = strconv.FormatInt(t.V[i], 10)
 		}
 		return "(" + strings.Join(cells, ", ") + ")"
+	case *MatrixRat:
+		rows := make([]string, 0, t.R)
+		for i := 0; i < t.R; i++ {
+			cells := make([]string, t.C)
+			for j := 0; j < t.C; j++ {
+				cells[j] = t.A[i][j].RatString()
+			}
+			rows = append(rows, "["+strings.Join(cells, " ")+"]")
+		}
+		return strings.Join(rows, "; ")
+	case *VectorRat:
+		cells := make([]string, t.N)
+		for i := 0; i < t.N; i++ {
+			cells[i] = t.V[i].RatString()
+		}
+		return "(" + strings.Join(cells, ", ") + ")"
 	case []*big.Rat:
 		parts := make([]string, 0, len(t))
 		for _, r := range t {
@@ -80,22 +98,28 @@ func ValueToExplainString(v interface{}) string {
 
 // GenerateExplanation 与 GenerateQuestion 使用相同实例化路径，额外输出变量、派生量与各空解析。
 func GenerateExplanation(p Problem, seedStr, serverSalt string) (*QuestionExplanation, error) {
-	inst, err := InstantiateProblem(p, seedStr, serverSalt)
+	return compileLenient(p).GenerateExplanation(seedStr, serverSalt)
+}
+
+// GenerateExplanation 同 dsl.GenerateExplanation，但复用预编译结果。
+func (cp *CompiledProblem) GenerateExplanation(seedStr, serverSalt string) (*QuestionExplanation, error) {
+	inst, err := cp.Instantiate(seedStr, serverSalt)
 	if err != nil {
 		return nil, err
 	}
-	title, err := RenderTitle(p, inst)
+	title, err := cp.RenderTitle(inst)
 	if err != nil {
 		return nil, err
 	}
-	fields, err := ExtractAnswerWithMeta(p, inst)
+	fields, err := cp.ExtractAnswerWithMeta(inst)
 	if err != nil {
 		return nil, err
 	}
-	return buildQuestionExplanation(p, inst, title, fields), nil
+	return cp.buildExplanation(inst, title, fields), nil
 }
 
-func buildQuestionExplanation(p Problem, inst *Instance, title string, fields []AnswerField) *QuestionExplanation {
+func (cp *CompiledProblem) buildExplanation(inst *Instance, title string, fields []AnswerField) *QuestionExplanation {
+	p := cp.Problem
 	out := &QuestionExplanation{
 		ProblemID:   p.ID,
 		Version:     p.Version,
@@ -108,7 +132,7 @@ func buildQuestionExplanation(p Problem, inst *Instance, title string, fields []
 
 	renderStrings := map[string]string{}
 	if len(p.Render) > 0 {
-		r, err := RenderInst(p, inst)
+		r, err := cp.RenderInst(inst)
 		if err == nil {
 			out.TitlePlugs = make(map[string]string, len(r))
 			keys := make([]string, 0, len(r))
@@ -145,22 +169,21 @@ func buildQuestionExplanation(p Problem, inst *Instance, title string, fields []
 		}
 	}
 
-	derivedNames := make([]string, 0, len(p.Derived))
-	for name := range p.Derived {
-		derivedNames = append(derivedNames, name)
-	}
-	sort.Strings(derivedNames)
-	for _, name := range derivedNames {
-		expr := strings.TrimSpace(p.Derived[name])
+	for _, node := range cp.graph.Nodes {
+		name := node.Name
+		if _, ok := p.Derived[name]; !ok {
+			continue
+		}
 		val, ok := inst.Derived[name]
 		if !ok {
 			continue
 		}
 		s := ValueToExplainString(val)
 		out.Derived = append(out.Derived, ExplainDerivedValue{
-			Name:  name,
-			Expr:  expr,
-			Value: s,
+			Name:      name,
+			Expr:      node.Expr,
+			Value:     s,
+			DependsOn: node.Deps,
 		})
 		// 也把派生量的文本值加入占位符池，方便 solution_steps 引用。
 		renderStrings[name] = placeholderPoolString(val)
@@ -187,7 +210,7 @@ func buildQuestionExplanation(p Problem, inst *Instance, title string, fields []
 		if sol, ok := p.Meta["solution_zh"]; ok {
 			if s, ok2 := sol.(string); ok2 {
 				s = expandPlaceholders(s, renderStrings)
-				s = expandExprPlaceholders(s, p, inst)
+				s = cp.expandExprPlaceholders(s, inst)
 				out.Solution = s
 			}
 		}
@@ -201,7 +224,7 @@ func buildQuestionExplanation(p Problem, inst *Instance, title string, fields []
 // 不能用 ValueToExplainString 的 "[a b c]; [..]" 调试形式（在 $...$ 内非法）。
 func placeholderPoolString(val interface{}) string {
 	switch val.(type) {
-	case *MatrixInt, *VectorInt:
+	case *MatrixInt, *VectorInt, *MatrixRat, *VectorRat, *Polynomial:
 		return FormatValueForTitle(val)
 	default:
 		return ValueToExplainString(val)
@@ -220,7 +243,7 @@ func expandPlaceholders(s string, vars map[string]string) string {
 // 例如 {{expr:mget(A,1,1)}} 在运行时对实例化的 A 求其 (1,1) 元素，
 // {{expr:det(A)}} 求行列式值。这使得 solution_zh 可以引用任意中间计算量，
 // 无需为每个中间量单独加 Derived 条目。
-func expandExprPlaceholders(s string, p Problem, inst *Instance) string {
+func (cp *CompiledProblem) expandExprPlaceholders(s string, inst *Instance) string {
 	for {
 		start := strings.Index(s, "{{expr:")
 		if start == -1 {
@@ -232,7 +255,7 @@ func expandExprPlaceholders(s string, p Problem, inst *Instance) string {
 		}
 		end += start             // absolute position
 		expr := s[start+7 : end] // extract expression between {{expr: and }}
-		val, err := EvaluateExpression(expr, inst)
+		val, err := cp.eval(expr, inst)
 		var replacement string
 		if err != nil {
 			replacement = "⟨求值失败:" + expr + "⟩"
@@ -1,6 +1,7 @@
 package dsl
 
 import (
+	"math"
 	"math/big"
 	"testing"
 )
@@ -32,30 +33,52 @@ func TestEvaluateExpression_rank_nullity_rank_hstack(t *testing.T) {
 	}
 }
 
-func TestInertiaCounts22(t *testing.T) {
-	pos, neg, err := inertiaCounts22(nil)
-	if err == nil {
-		t.Fatal("nil expect err")
+func TestInertia(t *testing.T) {
+	if _, _, _, err := Inertia(NewMatrixInt(2, 3)); err == nil {
+		t.Fatal("non-square expect err")
 	}
-	_ = pos
-	_ = neg
 	S := NewMatrixInt(2, 2)
 	S.A[0] = []int64{1, 0}
 	S.A[1] = []int64{0, 1}
-	p, n, err := inertiaCounts22(S)
+	p, n, _, err := Inertia(S)
 	if err != nil || p != 2 || n != 0 {
 		t.Fatalf("I pos=%d neg=%d err=%v", p, n, err)
 	}
 	S.A[0][0], S.A[0][1], S.A[1][0], S.A[1][1] = -1, 0, 0, -1
-	p, n, err = inertiaCounts22(S)
+	p, n, _, err = Inertia(S)
 	if err != nil || p != 0 || n != 2 {
 		t.Fatalf("neg def pos=%d neg=%d", p, n)
 	}
 	S.A[0][0], S.A[0][1], S.A[1][0], S.A[1][1] = 1, 2, 2, 1
-	p, n, err = inertiaCounts22(S)
+	p, n, _, err = Inertia(S)
 	if err != nil || p != 1 || n != 1 {
 		t.Fatalf("indef pos=%d neg=%d", p, n)
 	}
+	// 4 阶块对角 diag([[2 1] [1 2]], 0, -1)：特征值 3, 1, 0, -1
+	T := NewMatrixInt(4, 4)
+	T.A[0] = []int64{2, 1, 0, 0}
+	T.A[1] = []int64{1, 2, 0, 0}
+	T.A[2] = []int64{0, 0, 0, 0}
+	T.A[3] = []int64{0, 0, 0, -1}
+	p, n, z, err := Inertia(T)
+	if err != nil || p != 2 || n != 1 || z != 1 {
+		t.Fatalf("4x4 pos=%d neg=%d zero=%d err=%v", p, n, z, err)
+	}
+	if c, err := ClassifySymmetric(T); err != nil || c != SymDefInd {
+		t.Fatalf("4x4 class %v err=%v", c, err)
+	}
+	T.A[2][2], T.A[3][3] = 1, 5
+	if c, err := ClassifySymmetric(T); err != nil || c != SymDefPD {
+		t.Fatalf("4x4 PD class %v err=%v", c, err)
+	}
+	for i := range T.A {
+		for j := range T.A[i] {
+			T.A[i][j] = -T.A[i][j]
+		}
+	}
+	if c, err := ClassifySymmetric(T); err != nil || c != SymDefND {
+		t.Fatalf("4x4 ND class %v err=%v", c, err)
+	}
 }
 
 func TestEvaluateExpression_inertia_expr(t *testing.T) {
@@ -74,32 +97,6 @@ func TestEvaluateExpression_inertia_expr(t *testing.T) {
 	}
 }
 
-func TestEvaluateExpression_orthdiag_block4x6(t *testing.T) {
-	inst := &Instance{Vars: map[string]interface{}{}}
-	P := NewMatrixInt(3, 3)
-	P.A[0][0], P.A[1][1], P.A[2][2] = 1, 1, 1
-	D := NewMatrixInt(3, 3)
-	D.A[0][0], D.A[1][1], D.A[2][2] = 2, 3, 4
-	S := NewMatrixInt(3, 3)
-	for i := 0; i < 3; i++ {
-		S.A[i][i] = D.A[i][i]
-	}
-	inst.Vars["P"] = P
-	inst.Vars["D"] = D
-	inst.Vars["S"] = S
-	v, err := EvaluateExpression("orthdiag_block4x6(P,D,S)", inst)
-	if err != nil {
-		t.Fatal(err)
-	}
-	Z, ok := v.(*MatrixInt)
-	if !ok || Z.R != 4 || Z.C != 6 {
-		t.Fatalf("Z shape %+v", Z)
-	}
-	if Z.A[3][0] != 9 || Z.A[3][1] != 24 {
-		t.Fatalf("tr/det row want 9,24 got %d,%d", Z.A[3][0], Z.A[3][1])
-	}
-}
-
 func TestGramSchmidtColsOrthogRatOrthogonal(t *testing.T) {
 	V := NewMatrixInt(3, 3)
 	V.A[0] = []int64{1, 1, 0}
@@ -140,3 +137,23 @@ func TestGramSchmidtColsOrthogRatOrthogonal(t *testing.T) {
 		t.Fatal("4x2 GS not orthogonal")
 	}
 }
+
+// float64 变量（如 YAML 中的小数）按精确有理数参与运算，不截断。
+func TestEvaluateExpression_floatScalar(t *testing.T) {
+	inst := &Instance{Vars: map[string]interface{}{"h": 0.5, "n": 3.0, "x": math.Inf(1)}}
+	for expr, want := range map[string]string{"h + 1": "3/2", "2 * h": "1", "n / 2": "3/2", "h * h": "1/4"} {
+		v, err := EvaluateExpression(expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if got := ValueToCanonicalString(v); got != want {
+			t.Fatalf("%s: got %s want %s", expr, got, want)
+		}
+	}
+	if _, err := EvaluateExpression("col(I(2), h)", inst); err == nil {
+		t.Fatal("non-integer index: expect err")
+	}
+	if _, err := EvaluateExpression("x + 1", inst); err == nil {
+		t.Fatal("inf: expect err")
+	}
+}
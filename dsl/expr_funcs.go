@@ -0,0 +1,1232 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// matrixFn1 包装只接收一个矩阵参数的函数。
+func matrixFn1(fn func(m *MatrixInt) (interface{}, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(m)
+	}
+}
+
+// ratMatrixFn1 包装接收一个整数或有理矩阵参数的函数：*MatrixInt 走 intFn，*MatrixRat 走 ratFn。
+func ratMatrixFn1(intFn func(m *MatrixInt) (interface{}, error), ratFn func(m *MatrixRat) (interface{}, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		v, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		switch m := v.(type) {
+		case *MatrixInt:
+			return intFn(m)
+		case *MatrixRat:
+			return ratFn(m)
+		}
+		return nil, fmt.Errorf("%s expects matrix, got %T", c.name, v)
+	}
+}
+
+// matrixIntFn 包装 (A, i) 形式的函数。
+func matrixIntFn(fn func(m *MatrixInt, i int) (interface{}, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(m, i)
+	}
+}
+
+// matrixIntIntFn 包装 (A, i, j) 形式的函数。
+func matrixIntIntFn(fn func(m *MatrixInt, i, j int) (interface{}, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		j, err := c.Int(2)
+		if err != nil {
+			return nil, err
+		}
+		return fn(m, i, j)
+	}
+}
+
+// anchorFn 包装只读取生成器附带输出的函数：第一个参数仅作锚点（生成该输出的变量名），不参与求值；
+// nIdx 为其后整数下标参数的个数。
+func anchorFn(nIdx int, fn func(out GeneratorOutputs, idx []int) (interface{}, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		idx := make([]int, nIdx)
+		for k := 0; k < nIdx; k++ {
+			v, err := c.Int(1 + k)
+			if err != nil {
+				return nil, err
+			}
+			idx[k] = v
+		}
+		out, err := c.Outputs(0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(out, idx)
+	}
+}
+
+func init() {
+	registerExprFunc("zero", "-> int", func(c *Call) (interface{}, error) {
+		return int64(0), nil
+	})
+
+	// zero_vec(n)：返回 n 维零向量
+	registerExprFunc("zero_vec", "int -> vector", func(c *Call) (interface{}, error) {
+		n, err := c.Int(0)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("zero_vec: negative size")
+		}
+		return NewVectorInt(n), nil
+	})
+
+	// eye(n)：返回 n 阶单位阵，便于在约束中书写 rank(A + eye(3)) == 2
+	registerExprFunc("eye", "int -> matrix", func(c *Call) (interface{}, error) {
+		n, err := c.Int(0)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("eye: size must be positive")
+		}
+		return identityInt(n), nil
+	})
+
+	// abs(x)：标量的绝对值
+	registerExprFunc("abs", "scalar -> scalar", func(c *Call) (interface{}, error) {
+		x, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := x.(*Surd); ok {
+			if s.Sign() < 0 {
+				return s.Neg(), nil
+			}
+			return s, nil
+		}
+		r, ok := scalarRat(x)
+		if !ok {
+			return nil, fmt.Errorf("abs expects scalar, got %T", x)
+		}
+		if r.Sign() >= 0 {
+			return x, nil
+		}
+		return negValue(x)
+	})
+
+	// sqrt(x)：非负有理数的算术平方根；结果不是有理数时为 *Surd（如 sqrt(8) = 2√2）
+	registerExprFunc("sqrt", "scalar -> surd", func(c *Call) (interface{}, error) {
+		x, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := scalarRat(x)
+		if !ok {
+			return nil, fmt.Errorf("sqrt expects rational scalar, got %T", x)
+		}
+		s, err := SurdSqrt(r)
+		if err != nil {
+			return nil, err
+		}
+		return surdValue(s), nil
+	})
+
+	// norm(v)：向量的欧氏长度 √(v·v)，供单位化：v[1]/norm(v)
+	registerExprFunc("norm", "vector -> surd", func(c *Call) (interface{}, error) {
+		x, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		v, _, ok := vectorOperand(x)
+		if !ok {
+			return nil, fmt.Errorf("norm expects vector, got %T", x)
+		}
+		sum := new(big.Rat)
+		for _, e := range v {
+			sum.Add(sum, new(big.Rat).Mul(e, e))
+		}
+		s, err := SurdSqrt(sum)
+		if err != nil {
+			return nil, err
+		}
+		return surdValue(s), nil
+	})
+
+	// lambda_bmatrix(A)：将含参矩阵 A 以带 λ 符号的 bmatrix LaTeX 字符串渲染，
+	// A 须由 lambda_linear_det_zero 生成（读取其附带输出 lambda_row/lambda_col/lambda_const）。
+	// 供 Chapter4_4 类"齐次方程组有非零解，求 λ"题目的 Render 使用。
+	registerExprFunc("lambda_bmatrix", "matrix -> string", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		row, col, constC, err := lambdaParamMeta(c, "lambda_bmatrix")
+		if err != nil {
+			return nil, err
+		}
+		return formatLambdaBmatrixTitle(A, row, col, constC), nil
+	})
+
+	// scmul(a,b)：两个标量（int64 或 *big.Int）的乘积，返回 *big.Int
+	registerExprFunc("scmul", "int, int -> int", func(c *Call) (interface{}, error) {
+		a, err := c.BigInt(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.BigInt(1)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Mul(a, b), nil
+	})
+
+	// trace(A)：方阵的迹（主对角和）；trace2(A) 另要求 2×2。
+	registerExprFunc("trace", "matrix -> scalar", ratMatrixFn1(func(A *MatrixInt) (interface{}, error) {
+		return traceInt(A, "trace")
+	}, func(A *MatrixRat) (interface{}, error) {
+		if A.R != A.C {
+			return nil, fmt.Errorf("trace: need square matrix")
+		}
+		tr := new(big.Rat)
+		for i := 0; i < A.R; i++ {
+			tr.Add(tr, A.A[i][i])
+		}
+		return ratToScalar(tr, true), nil
+	}))
+	registerExprFunc("trace2", "matrix -> int", matrixFn1(func(A *MatrixInt) (interface{}, error) {
+		if A.R != 2 || A.C != 2 {
+			return nil, fmt.Errorf("trace2: need 2×2 matrix")
+		}
+		return traceInt(A, "trace2")
+	}))
+
+	// diagmin(A) / diagmax(A)：方阵且非对角元全为 0 时，主对角最小/最大元。
+	registerExprFunc("diagmin", "matrix -> int", matrixFn1(func(A *MatrixInt) (interface{}, error) {
+		mn, _, err := diagMinMax(A)
+		if err != nil {
+			return nil, err
+		}
+		return mn, nil
+	}))
+	registerExprFunc("diagmax", "matrix -> int", matrixFn1(func(A *MatrixInt) (interface{}, error) {
+		_, mx, err := diagMinMax(A)
+		if err != nil {
+			return nil, err
+		}
+		return mx, nil
+	}))
+
+	// ranklt(A,k)：rank(A) < k 则 1，否则 0（k 为整数阈值）。
+	registerExprFunc("ranklt", "matrix, int -> int", matrixIntFn(func(A *MatrixInt, k int) (interface{}, error) {
+		if matrixRankRat(A) < k {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}))
+
+	// basis_index(A,i)：返回由 A 的列向量组成的向量空间中，
+	// 第 i 个列主元下标（1-based），即极大无关组中第 i 个向量在原向量组中的下标。
+	// 若 i > rank(A)，返回 0（表示"多余的空不填"）。
+	registerExprFunc("basis_index", "matrix, int -> int", matrixIntFn(func(A *MatrixInt, i int) (interface{}, error) {
+		pivots := columnPivotsRat(A)
+		if i < 1 || i > len(pivots) {
+			return int64(0), nil // "多余的空不填"
+		}
+		return int64(pivots[i-1]), nil
+	}))
+
+	// space_rank(A)：返回矩阵 A 的秩（即列向量空间的维数）
+	registerExprFunc("space_rank", "matrix -> int", matrixFn1(func(A *MatrixInt) (interface{}, error) {
+		return int64(matrixRankRat(A)), nil
+	}))
+
+	// gs_comp(V,col,row)：V 的列做 Gram-Schmidt 正交化后第 col 个向量的第 row 个分量。
+	// gs(V)：对 V 的列做 Gram-Schmidt 正交化（不单位化），返回各列为正交向量的矩阵。
+	registerExprFunc("gs", "matrix -> matrix", matrixFn1(func(V *MatrixInt) (interface{}, error) {
+		u, err := GramSchmidtColsOrthogRat(V)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeMatrixRat(ratColumnsToMatrix(u, V.R)), nil
+	}))
+
+	registerExprFunc("gs_comp", "matrix, int, int -> scalar", matrixIntIntFn(func(V *MatrixInt, col, row int) (interface{}, error) {
+		u, err := GramSchmidtColsOrthogRat(V)
+		if err != nil {
+			return nil, err
+		}
+		if col < 1 || col > V.C || row < 1 || row > V.R {
+			return nil, fmt.Errorf("gs_comp index out of range")
+		}
+		return u[col-1][row-1], nil
+	}))
+
+	// inertia_pos(S) / inertia_neg(S)：n 阶对称阵的正、负特征值个数（按重数计，零特征值不计入）；
+	// inertia_pos_22 / inertia_neg_22 另要求 2×2。
+	registerExprFunc("inertia_pos", "matrix -> int", inertiaFn(0, false))
+	registerExprFunc("inertia_neg", "matrix -> int", inertiaFn(0, true))
+	registerExprFunc("inertia_pos_22", "matrix -> int", inertiaFn(2, false))
+	registerExprFunc("inertia_neg_22", "matrix -> int", inertiaFn(2, true))
+
+	// nullbasis_comp(A,k,i)：零空间有理基第 k 个向量的第 i 个分量。
+	// nullbasis(A)：零空间基础解系，各列为一个基向量（列数 = n - rank(A)）。
+	registerExprFunc("nullbasis", "matrix -> matrix", matrixFn1(func(A *MatrixInt) (interface{}, error) {
+		basis, err := NullspaceBasisRational(A)
+		if err != nil {
+			return nil, err
+		}
+		if len(basis) == 0 {
+			return nil, fmt.Errorf("nullbasis: trivial nullspace")
+		}
+		return normalizeMatrixRat(ratColumnsToMatrix(basis, A.C)), nil
+	}))
+
+	registerExprFunc("nullbasis_comp", "matrix, int, int -> scalar", matrixIntIntFn(func(A *MatrixInt, k, i int) (interface{}, error) {
+		basis, err := NullspaceBasisRational(A)
+		if err != nil {
+			return nil, err
+		}
+		if k < 1 || k > len(basis) {
+			return nil, fmt.Errorf("nullbasis_comp k out of range")
+		}
+		if i < 1 || i > len(basis[k-1]) {
+			return nil, fmt.Errorf("nullbasis_comp i out of range")
+		}
+		return basis[k-1][i-1], nil
+	}))
+
+	// pow(M,n)：矩阵幂，n 为整数常量或标量表达式（与 M^n 等价）。
+	registerExprFunc("pow", "matrix, int -> matrix", func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		n, err := c.Int64(1)
+		if err != nil {
+			return nil, err
+		}
+		return matrixPowInt(m, n)
+	})
+
+	registerExprFunc("matmul", "matrix, matrix -> matrix", twoMatrixFn(matrixMulInt, matrixRatMul))
+	registerExprFunc("matadd", "matrix, matrix -> matrix", twoMatrixFn(matrixAddInt, matrixRatAdd))
+	registerExprFunc("matsub", "matrix, matrix -> matrix", twoMatrixFn(matrixSubInt, matrixRatSub))
+
+	// smmul(k,M)：数乘矩阵。
+	registerExprFunc("smmul", "int, matrix -> matrix", func(c *Call) (interface{}, error) {
+		k, err := c.Int64(0)
+		if err != nil {
+			return nil, err
+		}
+		m, err := c.Matrix(1)
+		if err != nil {
+			return nil, err
+		}
+		return scalarMulMatrixInt(k, m), nil
+	})
+
+	// symdef3(A)：正定 0，负定 1，不定 2
+	registerExprFunc("symdef3", "matrix -> int", symCodeFn(0, 1, 2))
+
+	// mget(A,i,j)：矩阵元素（1-based），与 A[i,j] 等价。
+	registerExprFunc("mget", "matrix, int, int -> scalar", func(c *Call) (interface{}, error) {
+		v, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		j, err := c.Int(2)
+		if err != nil {
+			return nil, err
+		}
+		switch m := v.(type) {
+		case *MatrixInt, *MatrixRat:
+			out, err := indexValue(m, []int{i, j})
+			if err != nil {
+				return nil, fmt.Errorf("mget index out of range")
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("mget expects matrix, got %T", v)
+	})
+
+	registerExprFunc("transpose", "matrix -> matrix", ratMatrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return transposeInt(m), nil
+	}, func(m *MatrixRat) (interface{}, error) {
+		return normalizeMatrixRat(matrixRatTranspose(m)), nil
+	}))
+
+	// inv(A)：逆矩阵；逆为整数矩阵时返回 *MatrixInt，否则返回 *MatrixRat。
+	invRat := func(m *MatrixRat) (interface{}, error) {
+		out, err := MatrixRatInverse(m)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeMatrixRat(out), nil
+	}
+	registerExprFunc("inv", "matrix -> matrix", ratMatrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return invRat(MatrixIntToRat(m))
+	}, invRat))
+
+	registerExprFunc("det", "matrix -> scalar", ratMatrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return BareissDet(m), nil
+	}, func(m *MatrixRat) (interface{}, error) {
+		d, err := MatrixRatDet(m)
+		if err != nil {
+			return nil, err
+		}
+		return ratToScalar(d, true), nil
+	}))
+
+	registerExprFunc("nullvec", "matrix -> vector", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return IntegerKernelVectorOne(m)
+	}))
+
+	registerExprFunc("rank", "matrix -> int", ratMatrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return int64(matrixRankRat(m)), nil
+	}, func(m *MatrixRat) (interface{}, error) {
+		return int64(MatrixRatRank(m)), nil
+	}))
+
+	// rank_hstack(A,b)：增广矩阵 [A|b] 的秩（b 为与 A 行数相同的列向量）。
+	registerExprFunc("rank_hstack", "matrix, vector -> int", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		if A.R != b.N {
+			return nil, fmt.Errorf("rank_hstack: need matrix A and vector b with len(b)=rows(A)")
+		}
+		aug := NewMatrixInt(A.R, A.C+1)
+		for i := 0; i < A.R; i++ {
+			for j := 0; j < A.C; j++ {
+				aug.A[i][j] = A.A[i][j]
+			}
+			aug.A[i][A.C] = b.V[i]
+		}
+		return int64(matrixRankRat(aug)), nil
+	})
+
+	// nullity(A)：零度 dim ker(A) = 列数 − rank(A)。
+	registerExprFunc("nullity", "matrix -> int", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return int64(m.C - matrixRankRat(m)), nil
+	}))
+
+	// dep3(A)：3 个列向量（3×3 矩阵列）是否线性相关，相关为 1，否则为 0
+	registerExprFunc("dep3", "matrix -> int", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		if m.R != 3 || m.C != 3 {
+			return nil, fmt.Errorf("dep3 expects 3×3 matrix")
+		}
+		if matrixRankRat(m) < 3 {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}))
+
+	// dep_cols(M)：m×n 矩阵 M 的列是否线性相关（rank < cols 则相关），相关为 1，否则为 0
+	registerExprFunc("dep_cols", "matrix -> int", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		if matrixRankRat(m) < m.C {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}))
+
+	// vecdiv(v,k)：向量 v 的各分量除以整数 k（要求各分量可被 k 整除），返回整数向量
+	registerExprFunc("vecdiv", "vector, int -> vector", func(c *Call) (interface{}, error) {
+		vec, err := c.Vector(0)
+		if err != nil {
+			return nil, err
+		}
+		k, err := c.Int64(1)
+		if err != nil {
+			return nil, err
+		}
+		if k == 0 {
+			return nil, fmt.Errorf("vecdiv: division by zero")
+		}
+		out := NewVectorInt(vec.N)
+		for i := 0; i < vec.N; i++ {
+			if vec.V[i]%k != 0 {
+				return nil, fmt.Errorf("vecdiv: component %d (= %d) not divisible by %d", i+1, vec.V[i], k)
+			}
+			out.V[i] = vec.V[i] / k
+		}
+		return out, nil
+	})
+
+	// vecadd(a,b)：两个整数向量相加
+	registerExprFunc("vecadd", "vector, vector -> vector", func(c *Call) (interface{}, error) {
+		a, err := c.Vector(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		if a.N != b.N {
+			return nil, fmt.Errorf("vecadd: vector length mismatch")
+		}
+		out := NewVectorInt(a.N)
+		for i := 0; i < a.N; i++ {
+			out.V[i] = a.V[i] + b.V[i]
+		}
+		return out, nil
+	})
+
+	// nullbasis_vec(A,k)：返回 A 的零空间第 k 个基向量（整数向量，取最小整数倍使得各分量为整数）
+	registerExprFunc("nullbasis_vec", "matrix, int -> vector", matrixIntFn(func(A *MatrixInt, k int) (interface{}, error) {
+		basis, err := NullspaceBasisRational(A)
+		if err != nil {
+			return nil, err
+		}
+		if k < 1 || k > len(basis) {
+			return nil, fmt.Errorf("nullbasis_vec k out of range")
+		}
+		return ratVecToIntVec(basis[k-1]), nil
+	}))
+
+	registerEigenFuncs()
+	registerPolyFuncs()
+	registerParamFuncs()
+	registerTitleFuncs()
+	registerSystemFuncs()
+}
+
+func twoMatrixFn(op func(a, b *MatrixInt) (*MatrixInt, error), ratOp func(a, b *MatrixRat) (*MatrixRat, error)) FunctionImpl {
+	return func(c *Call) (interface{}, error) {
+		va, err := c.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := c.Value(1)
+		if err != nil {
+			return nil, err
+		}
+		a, aInt := va.(*MatrixInt)
+		b, bInt := vb.(*MatrixInt)
+		if aInt && bInt {
+			return op(a, b)
+		}
+		ra, ok1 := matrixOperand(va)
+		rb, ok2 := matrixOperand(vb)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%s expects matrix, got %T and %T", c.name, va, vb)
+		}
+		out, err := ratOp(ra, rb)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeMatrixRat(out), nil
+	}
+}
+
+// symCodeFn 按 ClassifySymmetric 的正定/负定/不定分类返回题目约定的编码。
+func symCodeFn(pd, nd, ind int64) FunctionImpl {
+	return matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		c, err := ClassifySymmetric(m)
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case SymDefPD:
+			return pd, nil
+		case SymDefND:
+			return nd, nil
+		default:
+			return ind, nil
+		}
+	})
+}
+
+func registerEigenFuncs() {
+	// eigenval(A,i)：返回由 eigen_reverse / diagonalizable 系列规则生成的矩阵 A 的第 i 个特征值（1-based），即 A.lambdas[i]
+	registerExprFunc("eigenval", "matrix, int -> int", func(c *Call) (interface{}, error) {
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return outputVectorComp(c, "lambdas", i)
+	})
+
+	// eigenvec_comp(A,i,j)：返回 A 的第 i 个特征向量的第 j 个分量（1-based）
+	// 使用生成器发布的 A.P（列为特征向量）
+	registerExprFunc("eigenvec_comp", "matrix, int, int -> int", func(c *Call) (interface{}, error) {
+		return outputColumnComp(c, "P")
+	})
+
+	// sym_eigenval(S,i)：返回由 symmetric_eigen_reverse 系列规则生成的对称矩阵 S 的第 i 个特征值（1-based）
+	registerExprFunc("sym_eigenval", "matrix, int -> int", func(c *Call) (interface{}, error) {
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return outputVectorComp(c, "lambdas", i)
+	})
+
+	// sym_eigenvec_comp(S,i,j)：返回正交变换矩阵 Q 的第 (j,i) 元素
+	// 即正交变换 x = Qy 中 Q 的 j 行 i 列（1-based），
+	// 对应第 i 个特征向量的第 j 个分量。
+	registerExprFunc("sym_eigenvec_comp", "matrix, int, int -> int", func(c *Call) (interface{}, error) {
+		return outputColumnComp(c, "Q")
+	})
+
+	// is_similar(A) / is_congruent(A)：返回由 similarity_congruence_pair 生成的相似/合同判断结果（0 或 1）
+	for _, name := range []string{"is_similar", "is_congruent"} {
+		key := name
+		registerExprFunc(name, "matrix -> int", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+			return out.int64Value(key, key)
+		}))
+	}
+
+	// sc_diag_comp(B,i)：返回对角阵 B 的第 i 个对角元素（1-based），B 通常为 sc_diag_matrix(A)
+	registerExprFunc("sc_diag_comp", "matrix, int -> int", func(c *Call) (interface{}, error) {
+		B, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		if i < 1 || i > B.R || i > B.C {
+			return nil, fmt.Errorf("sc_diag_comp index out of range")
+		}
+		return B.A[i-1][i-1], nil
+	})
+
+	// sc_diag_matrix(A)：返回由 similarity_congruence_pair 生成的对角阵 B，即 A.B
+	registerExprFunc("sc_diag_matrix", "any -> matrix", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return out.matrixValue("sc_diag_matrix", "B")
+	}))
+
+	// sym_npos(S)：对称矩阵的正惯性指数（正特征值个数）
+	registerExprFunc("sym_npos", "matrix -> int", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		pos, _, _, err := Inertia(m)
+		if err != nil {
+			return nil, err
+		}
+		return int64(pos), nil
+	}))
+
+	// eigenval_rank(S,i)：由秩条件推断的第 i 个特征值（1-based，按升序）
+	registerExprFunc("eigenval_rank", "any, int -> int", anchorFn(1, func(out GeneratorOutputs, idx []int) (interface{}, error) {
+		return evalEigenvalRank(out, idx[0])
+	}))
+
+	// eigenval_rowsum(S,i)：由行和+秩条件推断的第 i 个特征值（1-based，按升序）
+	registerExprFunc("eigenval_rowsum", "any, int -> int", anchorFn(1, func(out GeneratorOutputs, idx []int) (interface{}, error) {
+		return evalEigenvalRowsum(out, idx[0])
+	}))
+
+	// eigen_rank_condition_text(S)：秩条件题面的 LaTeX 字符串
+	registerExprFunc("eigen_rank_condition_text", "any -> string", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return evalEigenRankConditionText(out)
+	}))
+
+	// eigen_rowsum_condition_text(S)：行和+秩条件题面的 LaTeX 字符串
+	registerExprFunc("eigen_rowsum_condition_text", "any -> string", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return evalEigenRowsumConditionText(out)
+	}))
+
+	// eigen_rowsum_r/s/k(S)：行和+秩条件中的秩/行和/k值，即 S.r、S.s、S.k
+	for _, name := range []string{"r", "s", "k"} {
+		fname, key := "eigen_rowsum_"+name, name
+		registerExprFunc(fname, "any -> int", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+			return out.int64Value(fname, key)
+		}))
+	}
+}
+
+// outputVectorComp 读取第一个参数所指变量的向量输出 key 的第 i 个分量（1-based）。
+func outputVectorComp(c *Call, key string, i int) (interface{}, error) {
+	out, err := c.Outputs(0)
+	if err != nil {
+		return nil, err
+	}
+	v, err := out.vectorValue(c.name, key)
+	if err != nil {
+		return nil, err
+	}
+	if i < 1 || i > v.N {
+		return nil, fmt.Errorf("%s: index out of range", c.name)
+	}
+	return v.V[i-1], nil
+}
+
+// outputColumnComp 读取第一个参数所指变量的矩阵输出 key 中第 i 列第 j 行的元素，i、j 为第 2、3 个参数（1-based）。
+func outputColumnComp(c *Call, key string) (interface{}, error) {
+	i, err := c.Int(1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := c.Int(2)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.Outputs(0)
+	if err != nil {
+		return nil, err
+	}
+	M, err := out.matrixValue(c.name, key)
+	if err != nil {
+		return nil, err
+	}
+	if i < 1 || i > M.C || j < 1 || j > M.R {
+		return nil, fmt.Errorf("%s index out of range", c.name)
+	}
+	return M.A[j-1][i-1], nil
+}
+
+func registerPolyFuncs() {
+	// charpoly(A)：特征多项式 |λE-A|（首一）。
+	registerExprFunc("charpoly", "matrix -> polynomial", func(c *Call) (interface{}, error) {
+		m, err := c.RatMatrix(0)
+		if err != nil {
+			return nil, err
+		}
+		return CharPoly(m)
+	})
+
+	// minpoly(A)：最小多项式（首一）。
+	registerExprFunc("minpoly", "matrix -> polynomial", func(c *Call) (interface{}, error) {
+		m, err := c.RatMatrix(0)
+		if err != nil {
+			return nil, err
+		}
+		return MinPoly(m)
+	})
+
+	// coeff(p,k)：λ^k 的系数。
+	registerExprFunc("coeff", "polynomial, int -> scalar", func(c *Call) (interface{}, error) {
+		p, err := c.Poly(0)
+		if err != nil {
+			return nil, err
+		}
+		k, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		if k < 0 {
+			return nil, fmt.Errorf("coeff: negative degree %d", k)
+		}
+		return ratToScalar(p.Coeff(k), false), nil
+	})
+
+	// roots_rational(p)：全部有理根（按重数重复，升序）组成的向量。
+	registerExprFunc("roots_rational", "polynomial -> vector", func(c *Call) (interface{}, error) {
+		p, err := c.Poly(0)
+		if err != nil {
+			return nil, err
+		}
+		roots := RationalRoots(p)
+		return normalizeVectorRat(&VectorRat{N: len(roots), V: roots}), nil
+	})
+
+	// polyeval(p,x)：x 为标量时返回 p(x)，为方阵时返回 p(A)（如 Cayley–Hamilton 验证）。
+	registerExprFunc("polyeval", "polynomial, scalar|matrix -> scalar|matrix", func(c *Call) (interface{}, error) {
+		p, err := c.Poly(0)
+		if err != nil {
+			return nil, err
+		}
+		x, err := c.Value(1)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := scalarRat(x); ok {
+			return ratToScalar(p.Eval(r), false), nil
+		}
+		m, ok := matrixOperand(x)
+		if !ok {
+			return nil, fmt.Errorf("polyeval expects scalar or matrix, got %T", x)
+		}
+		out, err := polyEvalMatrix(p, m)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeMatrixRat(out), nil
+	})
+}
+
+func registerParamFuncs() {
+	// quad_expr_param(S)：由含参数 t 的 3×3 对称矩阵 Sbase 渲染二次型表达式的 LaTeX 字符串
+	// S 的某个对角位置为参数 t 占位符（值为 0），位置取自 S.t_row
+	registerExprFunc("quad_expr_param", "matrix -> string", func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		out, err := c.Outputs(0)
+		if err != nil {
+			return nil, err
+		}
+		return formatQuadraticExprWithParam(m, out), nil
+	})
+
+	// param_t(S)：返回含参数 t 的对称矩阵中 t 的值（由 trace 条件推导），即 S.t
+	registerExprFunc("param_t", "any -> int", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return out.int64Value("param_t", "t")
+	}))
+
+	// param_eigenval(S,i)：返回含参数 t 的对称矩阵的第 i 个特征值
+	registerExprFunc("param_eigenval", "any, int -> int", func(c *Call) (interface{}, error) {
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return outputVectorComp(c, "lambdas", i)
+	})
+
+	// param_eigenvec_comp(S,i,j)：返回含参数 t 的对称矩阵的正交变换矩阵 Q 的元素
+	registerExprFunc("param_eigenvec_comp", "any, int, int -> int", func(c *Call) (interface{}, error) {
+		return outputColumnComp(c, "Q")
+	})
+
+	// sylvester_lower(S) / sylvester_upper(S)：正定参数范围的下界/上界（S.lower、S.upper；
+	// sylvester_range 只给出下界，上界为 +∞ 时 sylvester_upper 报错）
+	for _, name := range []string{"lower", "upper"} {
+		fname, key := "sylvester_"+name, name
+		registerExprFunc(fname, "any -> scalar", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+			v, err := out.lookup(fname, key)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := v.(*big.Rat)
+			if !ok {
+				return nil, fmt.Errorf("%s: unexpected type %T", fname, v)
+			}
+			return r, nil
+		}))
+	}
+
+	// sylvester_interval(S)：正定参数范围 (S.lower, S.upper)，没有 upper 输出时上界为 +∞
+	registerExprFunc("sylvester_interval", "any -> interval", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		p := Interval{}
+		for key, dst := range map[string]**big.Rat{"lower": &p.Lo, "upper": &p.Hi} {
+			v, ok := out[key]
+			if !ok {
+				continue
+			}
+			r, ok := v.(*big.Rat)
+			if !ok {
+				return nil, fmt.Errorf("sylvester_interval: unexpected %s type %T", key, v)
+			}
+			*dst = r
+		}
+		if p.Lo == nil && p.Hi == nil {
+			return nil, fmt.Errorf("sylvester_interval: output lower not found")
+		}
+		return NewIntervalSet(p), nil
+	}))
+
+	// interval(a,b) / interval_closed(a,b)：开区间 (a,b)、闭区间 [a,b]；
+	// interval_gt(a)、interval_ge(a)、interval_lt(b)、interval_le(b)：t>a、t≥a、t<b、t≤b；interval_union(I,J)：并集。
+	// 结果为 *IntervalSet，供 judge kind "interval" 的取值范围答案使用。
+	for name, closed := range map[string]bool{"interval": false, "interval_closed": true} {
+		closed := closed
+		registerExprFunc(name, "scalar, scalar -> interval", func(c *Call) (interface{}, error) {
+			a, err := c.Rat(0)
+			if err != nil {
+				return nil, err
+			}
+			b, err := c.Rat(1)
+			if err != nil {
+				return nil, err
+			}
+			return NewIntervalSet(Interval{Lo: a, Hi: b, LoClosed: closed, HiClosed: closed}), nil
+		})
+	}
+	for name, ray := range map[string]func(r *big.Rat) Interval{
+		"interval_gt": func(r *big.Rat) Interval { return Interval{Lo: r} },
+		"interval_ge": func(r *big.Rat) Interval { return Interval{Lo: r, LoClosed: true} },
+		"interval_lt": func(r *big.Rat) Interval { return Interval{Hi: r} },
+		"interval_le": func(r *big.Rat) Interval { return Interval{Hi: r, HiClosed: true} },
+	} {
+		ray := ray
+		registerExprFunc(name, "scalar -> interval", func(c *Call) (interface{}, error) {
+			r, err := c.Rat(0)
+			if err != nil {
+				return nil, err
+			}
+			return NewIntervalSet(ray(r)), nil
+		})
+	}
+	registerExprFunc("interval_union", "interval, interval -> interval", func(c *Call) (interface{}, error) {
+		a, err := c.Interval(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Interval(1)
+		if err != nil {
+			return nil, err
+		}
+		return a.Union(b), nil
+	})
+
+	// poly_schmidt_comp(P,j,k)：第 j 个 Schmidt 正交多项式的第 k 个系数
+	// j=1,2,3 对应 g₁,g₂,g₃; k=1 对应常数项, k=2 对应 x 系数, k=3 对应 x² 系数
+	registerExprFunc("poly_schmidt_comp", "any, int, int -> int", anchorFn(2, func(out GeneratorOutputs, idx []int) (interface{}, error) {
+		return evalPolySchmidtComp(out, idx[0], idx[1])
+	}))
+
+	// poly_schmidt_input_comp(P,j,k)：第 j 个输入多项式的第 k 个系数
+	registerExprFunc("poly_schmidt_input_comp", "any, int, int -> int", anchorFn(2, func(out GeneratorOutputs, idx []int) (interface{}, error) {
+		return evalPolySchmidtInputComp(out, idx[0], idx[1])
+	}))
+
+	// poly_schmidt_input_text(P)：输入多项式的 LaTeX 字符串
+	registerExprFunc("poly_schmidt_input_text", "any -> string", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return evalPolySchmidtInputText(out)
+	}))
+
+	// param_lambda_val(A) / param_mu_val(A)：含参方程组的 λ、μ 值，即 A.lambda、A.mu
+	registerExprFunc("param_lambda_val", "any -> int", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return out.int64Value("param_lambda_val", "lambda")
+	}))
+	registerExprFunc("param_mu_val", "any -> int", anchorFn(0, func(out GeneratorOutputs, _ []int) (interface{}, error) {
+		return out.int64Value("param_mu_val", "mu")
+	}))
+
+	// param_rref_comp(A,i,j)：增广矩阵行最简型的第 (i,j) 元素（1-based）
+	registerExprFunc("param_rref_comp", "any, int, int -> int", anchorFn(2, func(out GeneratorOutputs, idx []int) (interface{}, error) {
+		i, j := idx[0], idx[1]
+		rref, err := out.matrixValue("param_rref_comp", "rref")
+		if err != nil {
+			return nil, err
+		}
+		if i < 1 || i > rref.R || j < 1 || j > rref.C {
+			return nil, fmt.Errorf("param_rref_comp: index out of range")
+		}
+		return rref.A[i-1][j-1], nil
+	}))
+
+	// param_x0_comp(A,i)：含参方程组特解的第 i 个分量（1-based）
+	registerExprFunc("param_x0_comp", "any, int -> int", func(c *Call) (interface{}, error) {
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return outputVectorComp(c, "x0", i)
+	})
+
+	// param_nb_comp(A,i)：含参方程组基础解系向量的第 i 个分量（1-based）
+	registerExprFunc("param_nb_comp", "any, int -> int", func(c *Call) (interface{}, error) {
+		i, err := c.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return outputVectorComp(c, "nb1", i)
+	})
+
+	// param_rank_A(A) / param_rank_aug(A)：含参方程组系数矩阵与增广矩阵的秩
+	// （该生成器下 rank(A)=rank(A|b)=2 恒成立）
+	registerExprFunc("param_rank_A", "any -> int", anchorFn(0, func(GeneratorOutputs, []int) (interface{}, error) {
+		return int64(2), nil
+	}))
+	registerExprFunc("param_rank_aug", "any -> int", anchorFn(0, func(GeneratorOutputs, []int) (interface{}, error) {
+		return int64(2), nil
+	}))
+
+	// param_system_title(A)：含参方程组题面 LaTeX
+	registerExprFunc("param_system_title", "matrix -> string", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		out, err := c.Outputs(0)
+		if err != nil {
+			return nil, err
+		}
+		return evalParamSystemTitle(A, out)
+	})
+}
+
+func registerTitleFuncs() {
+	// quad_expr(S)：由 n×n 对称矩阵 S 渲染二次型表达式的 LaTeX 字符串
+	registerExprFunc("quad_expr", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return formatQuadraticExpr(m), nil
+	}))
+
+	// lambda_cases_title(A)：将含参数 λ 的齐次方程组 Ax=0 渲染为 \begin{cases} 格式
+	// 在参数位置显示 λ 符号而非数值
+	registerExprFunc("lambda_cases_title", "matrix -> string", func(c *Call) (interface{}, error) {
+		m, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		row, col, constC, err := lambdaParamMeta(c, "lambda_cases_title")
+		if err != nil {
+			return nil, err
+		}
+		return formatLambdaCasesTitle(m, row, col, constC), nil
+	})
+
+	// vmatrix_title(A)：将矩阵 A 渲染为 \begin{vmatrix}...\end{vmatrix} 的 LaTeX 字符串
+	registerExprFunc("vmatrix_title", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return formatVmatrixTitle(m), nil
+	}))
+
+	// equidiagonal_title(A)：将等对角矩阵渲染为带 \cdots 提示的 vmatrix 格式
+	registerExprFunc("equidiagonal_title", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return formatEquidiagonalTitle(m), nil
+	}))
+
+	// cases_title(A, b)：将 Ax=b 渲染为 \begin{cases} 方程组的 LaTeX 字符串
+	registerExprFunc("cases_title", "matrix, vector -> string", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		return formatCasesTitle(A, b), nil
+	})
+
+	// poly_from_vec(v)：将向量 [a,b,c] 渲染为多项式 a+bx+cx² 的 LaTeX 字符串
+	registerExprFunc("poly_from_vec", "vector -> string", func(c *Call) (interface{}, error) {
+		vec, err := c.Vector(0)
+		if err != nil {
+			return nil, err
+		}
+		return formatPolynomialFromVec(vec), nil
+	})
+
+	// poly_from_matcol(M,j)：将矩阵 M 的第 j 列渲染为多项式 a+bx+cx² 的 LaTeX 字符串
+	registerExprFunc("poly_from_matcol", "matrix, int -> string", matrixIntFn(func(M *MatrixInt, j int) (interface{}, error) {
+		if j < 1 || j > M.C {
+			return nil, fmt.Errorf("poly_from_matcol: column index out of range")
+		}
+		vec := NewVectorInt(M.R)
+		for i := 0; i < M.R; i++ {
+			vec.V[i] = M.A[i][j-1]
+		}
+		return formatPolynomialFromVec(vec), nil
+	}))
+
+	// eigenval_list_text(A)：从对角矩阵 A 的对角元（特征值）渲染为 LaTeX 列表字符串
+	// 格式如 "-5, 4, -2" — 用于题面"已知三阶矩阵A的三个特征值分别为..."
+	registerExprFunc("eigenval_list_text", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		var vals []string
+		for i := 0; i < m.R; i++ {
+			vals = append(vals, fmt.Sprintf("%d", m.A[i][i]))
+		}
+		return strings.Join(vals, ", "), nil
+	}))
+
+	// linear_transform_title(A0)：将 3×3 矩阵 A₀ 渲染为 T(x₁,x₂,x₃)ᵀ=(...)ᵀ 的 LaTeX 字符串
+	registerExprFunc("linear_transform_title", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return formatLinearTransformTitle(m), nil
+	}))
+
+	// basis_linear_combo_title(B)：将 3×3 上三角矩阵 B 的各列渲染为新基向量的线性组合表示
+	// 第1列: ε₁, 第2列: c₂₁ε₁+c₂₂ε₂, 第3列: c₃₁ε₁+c₃₂ε₂+c₃₃ε₃
+	registerExprFunc("basis_linear_combo_title", "matrix -> string", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		return formatBasisLinearComboTitle(m), nil
+	}))
+}
+
+func registerSystemFuncs() {
+	// solve(A,b)：唯一解的有理解向量
+	registerExprFunc("solve", "matrix, vector -> vector", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		return solveLinearSystemRat(A, b)
+	})
+
+	// integer_solution(A,x)：b = A·x（整数），使 Ax = b 以 x 为整数解
+	registerExprFunc("integer_solution", "matrix, vector -> vector", func(c *Call) (interface{}, error) {
+		A, err := c.Matrix(0)
+		if err != nil {
+			return nil, err
+		}
+		x, err := c.Vector(1)
+		if err != nil {
+			return nil, err
+		}
+		if A.C != x.N {
+			return nil, fmt.Errorf("integer_solution: %d×%d matrix with vector of length %d", A.R, A.C, x.N)
+		}
+		b := NewVectorInt(A.R)
+		for i := 0; i < A.R; i++ {
+			for j := 0; j < A.C; j++ {
+				b.V[i] += A.A[i][j] * x.V[j]
+			}
+		}
+		return b, nil
+	})
+
+	// cofactor(A,i,j)：代数余子式 (-1)^{i+j} M_ij
+	registerExprFunc("cofactor", "matrix, int, int -> int", matrixIntIntFn(func(A *MatrixInt, i, j int) (interface{}, error) {
+		if i < 1 || j < 1 || i > A.R || j > A.C {
+			return nil, fmt.Errorf("index out of range")
+		}
+		minor := NewMatrixInt(A.R-1, A.C-1)
+		ri := 0
+		for r := 0; r < A.R; r++ {
+			if r == i-1 {
+				continue
+			}
+			ci := 0
+			for col := 0; col < A.C; col++ {
+				if col == j-1 {
+					continue
+				}
+				minor.A[ri][ci] = A.A[r][col]
+				ci++
+			}
+			ri++
+		}
+		d := BareissDet(minor)
+		if (i+j)%2 == 1 {
+			d.Neg(d)
+		}
+		return d, nil
+	}))
+
+	// basis_cols(A)：主元列下标向量，多余位置保持为 0，表示“无”
+	registerExprFunc("basis_cols", "matrix -> vector", matrixFn1(func(m *MatrixInt) (interface{}, error) {
+		pivots := columnPivotsRat(m)
+		vec := NewVectorInt(m.C)
+		for i := 0; i < len(pivots) && i < m.C; i++ {
+			vec.V[i] = int64(pivots[i])
+		}
+		return vec, nil
+	}))
+
+	// col(A,k)：第 k 列
+	registerExprFunc("col", "matrix, int -> vector", matrixIntFn(func(A *MatrixInt, k int) (interface{}, error) {
+		if k < 1 || k > A.C {
+			return nil, fmt.Errorf("col index out of range")
+		}
+		vec := NewVectorInt(A.R)
+		for i := 0; i < A.R; i++ {
+			vec.V[i] = A.A[i][k-1]
+		}
+		return vec, nil
+	}))
+}
+
+// lambdaParamMeta 读取第一个参数所指变量（由 lambda_linear_det_zero 生成）的参数位置输出。
+func lambdaParamMeta(c *Call, fname string) (row, col, constC int64, err error) {
+	out, err := c.Outputs(0)
+	if err != nil {
+		return
+	}
+	if row, err = out.int64Value(fname, "lambda_row"); err != nil {
+		return
+	}
+	if col, err = out.int64Value(fname, "lambda_col"); err != nil {
+		return
+	}
+	constC, err = out.int64Value(fname, "lambda_const")
+	return
+}
+
+func transposeInt(m *MatrixInt) *MatrixInt {
+	out := NewMatrixInt(m.C, m.R)
+	for i := 0; i < m.R; i++ {
+		for j := 0; j < m.C; j++ {
+			out.A[j][i] = m.A[i][j]
+		}
+	}
+	return out
+}
+
+func isDiagonalInt(m *MatrixInt) bool {
+	for r := 0; r < m.R; r++ {
+		for c := 0; c < m.C; c++ {
+			if r != c && m.A[r][c] != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inertiaFn 返回对称阵的正（neg=false）或负惯性指数；order > 0 时要求阶数恰为 order。
+func inertiaFn(order int, neg bool) FunctionImpl {
+	return matrixFn1(func(S *MatrixInt) (interface{}, error) {
+		if order > 0 && (S.R != order || S.C != order) {
+			return nil, fmt.Errorf("inertia%d%d: need %d×%d matrix", order, order, order, order)
+		}
+		p, n, _, err := Inertia(S)
+		if err != nil {
+			return nil, err
+		}
+		if neg {
+			return int64(n), nil
+		}
+		return int64(p), nil
+	})
+}
+
+func traceInt(A *MatrixInt, fname string) (interface{}, error) {
+	if A.R != A.C {
+		return nil, fmt.Errorf("%s: need square matrix", fname)
+	}
+	var tr int64
+	for i := 0; i < A.R; i++ {
+		tr += A.A[i][i]
+	}
+	return tr, nil
+}
+
+func diagMinMax(A *MatrixInt) (int64, int64, error) {
+	if A.R != A.C {
+		return 0, 0, fmt.Errorf("diagmin/max: need square matrix")
+	}
+	if !isDiagonalInt(A) {
+		return 0, 0, fmt.Errorf("diagmin/max: not diagonal")
+	}
+	mn, mx := A.A[0][0], A.A[0][0]
+	for i := 0; i < A.R; i++ {
+		v := A.A[i][i]
+		if v < mn {
+			mn = v
+		}
+		if v > mx {
+			mx = v
+		}
+	}
+	return mn, mx, nil
+}
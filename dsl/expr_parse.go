@@ -0,0 +1,491 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// 表达式语法（优先级由低到高）：
+//
+//	expr    := and ('||' and)*
+//	and     := not ('&&' not)*
+//	not     := '!' not | cmp
+//	cmp     := sum (('==' | '!=' | '<' | '<=' | '>' | '>=') sum)*   // 链式比较 a <= b < c 即 a <= b && b < c
+//	sum     := term (('+' | '-') term)*
+//	term    := unary (('*' | '/') unary)*
+//	unary   := '-' unary | power
+//	power   := postfix ('^' unary)?          // 右结合：2^3^2 = 2^(3^2)
+//	postfix := primary ('[' expr (',' expr)* ']')*
+//	primary := NUMBER | IDENT | IDENT '.' IDENT | IDENT '(' [expr (',' expr)*] ')' | '(' expr ')'
+//
+// IDENT '.' IDENT 引用变量的生成器附带输出，如 A.lambda_row（见 GeneratorOutputs）。
+// 标识符允许以数字开头（如 0Bi、3A），只要其中含字母或下划线；纯数字为整数字面量。
+// 整数字面量之间的 '/' 与一元负号在解析期折叠为有理数字面量，故 -3/4 是一个常量。
+// 比较与逻辑运算的结果为整数 1（真）或 0（假），非零标量视为真。
+
+// ParseError 为表达式解析错误，Col 为出错位置的列号（从 1 开始，按字符计）。
+type ParseError struct {
+	Expr string
+	Col  int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse %q: col %d: %s", e.Expr, e.Col, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+func tokenize(src string) ([]token, error) {
+	rs := []rune(src)
+	var toks []token
+	isIdentRune := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(rs) && isIdentRune(rs[i]) {
+				i++
+			}
+			text := string(rs[start:i])
+			kind := tokNumber
+			for _, c := range text {
+				if !unicode.IsDigit(c) {
+					kind = tokIdent
+					break
+				}
+			}
+			toks = append(toks, token{kind: kind, text: text, col: start + 1})
+		case i+1 < len(rs) && isTwoCharOp(string(rs[i:i+2])):
+			toks = append(toks, token{kind: tokPunct, text: string(rs[i : i+2]), col: i + 1})
+			i += 2
+		case r == '=':
+			return nil, &ParseError{Expr: src, Col: i + 1, Msg: "unexpected '=' (use == for equality)"}
+		case strings.ContainsRune("()[],+-*/^<>!.", r):
+			toks = append(toks, token{kind: tokPunct, text: string(r), col: i + 1})
+			i++
+		default:
+			return nil, &ParseError{Expr: src, Col: i + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, col: len(rs) + 1})
+	return toks, nil
+}
+
+func isTwoCharOp(s string) bool {
+	switch s {
+	case "==", "!=", "<=", ">=", "&&", "||":
+		return true
+	}
+	return false
+}
+
+// isCompareOp 判断是否为比较运算符。
+func isCompareOp(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+// exprNode 为表达式 AST 节点。
+type exprNode interface {
+	pos() int
+}
+
+type numLit struct {
+	col int
+	val *big.Rat
+}
+
+type identRef struct {
+	col  int
+	name string
+}
+
+// outputRef 为变量 name 的生成器附带输出 key（A.key）。
+type outputRef struct {
+	col       int
+	name, key string
+}
+
+type callExpr struct {
+	col  int
+	name string
+	args []exprNode
+}
+
+type indexExpr struct {
+	col  int
+	x    exprNode
+	idxs []exprNode
+}
+
+type unaryExpr struct {
+	col int
+	op  string
+	x   exprNode
+}
+
+type binaryExpr struct {
+	col  int
+	op   string
+	l, r exprNode
+}
+
+func (n *numLit) pos() int     { return n.col }
+func (n *identRef) pos() int   { return n.col }
+func (n *outputRef) pos() int  { return n.col }
+func (n *callExpr) pos() int   { return n.col }
+func (n *indexExpr) pos() int  { return n.col }
+func (n *unaryExpr) pos() int  { return n.col }
+func (n *binaryExpr) pos() int { return n.col }
+
+type exprParser struct {
+	src  string
+	toks []token
+	i    int
+}
+
+func (ps *exprParser) peek() token { return ps.toks[ps.i] }
+
+func (ps *exprParser) next() token {
+	t := ps.toks[ps.i]
+	if t.kind != tokEOF {
+		ps.i++
+	}
+	return t
+}
+
+func (ps *exprParser) isPunct(s string) bool {
+	t := ps.peek()
+	return t.kind == tokPunct && t.text == s
+}
+
+func (ps *exprParser) errorf(col int, format string, args ...interface{}) error {
+	return &ParseError{Expr: ps.src, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// checkArity 按已注册函数的签名检查实参个数；未注册的函数留给编译与静态检查报告。
+func (ps *exprParser) checkArity(call *callExpr) error {
+	sig, ok := FunctionSignature(call.name)
+	if ok && len(call.args) != len(sig.Args) {
+		return ps.errorf(call.col, "%s expects %d args, got %d", call.name, len(sig.Args), len(call.args))
+	}
+	return nil
+}
+
+func (ps *exprParser) expect(s string) (token, error) {
+	t := ps.peek()
+	if t.kind != tokPunct || t.text != s {
+		return t, ps.errorf(t.col, "expected %q, got %s", s, describeToken(t))
+	}
+	return ps.next(), nil
+}
+
+func describeToken(t token) string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (ps *exprParser) parseExpr() (exprNode, error) {
+	left, err := ps.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isPunct("||") {
+		op := ps.next()
+		right, err := ps.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{col: op.col, op: op.text, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseAnd() (exprNode, error) {
+	left, err := ps.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isPunct("&&") {
+		op := ps.next()
+		right, err := ps.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{col: op.col, op: op.text, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseNot() (exprNode, error) {
+	if ps.isPunct("!") {
+		op := ps.next()
+		x, err := ps.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{col: op.col, op: "!", x: x}, nil
+	}
+	return ps.parseCompare()
+}
+
+// parseCompare 将链式比较 a < b <= c 展开为 (a < b) && (b <= c)。
+func (ps *exprParser) parseCompare() (exprNode, error) {
+	left, err := ps.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	var out exprNode
+	for t := ps.peek(); t.kind == tokPunct && isCompareOp(t.text); t = ps.peek() {
+		op := ps.next()
+		right, err := ps.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		var cmp exprNode = &binaryExpr{col: op.col, op: op.text, l: left, r: right}
+		if out != nil {
+			cmp = &binaryExpr{col: op.col, op: "&&", l: out, r: cmp}
+		}
+		out, left = cmp, right
+	}
+	if out == nil {
+		return left, nil
+	}
+	return out, nil
+}
+
+func (ps *exprParser) parseSum() (exprNode, error) {
+	left, err := ps.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isPunct("+") || ps.isPunct("-") {
+		op := ps.next()
+		right, err := ps.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = foldBinary(&binaryExpr{col: op.col, op: op.text, l: left, r: right})
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseTerm() (exprNode, error) {
+	left, err := ps.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isPunct("*") || ps.isPunct("/") {
+		op := ps.next()
+		right, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = foldBinary(&binaryExpr{col: op.col, op: op.text, l: left, r: right})
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseUnary() (exprNode, error) {
+	if ps.isPunct("-") {
+		op := ps.next()
+		x, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if lit, ok := x.(*numLit); ok {
+			return &numLit{col: op.col, val: new(big.Rat).Neg(lit.val)}, nil
+		}
+		return &unaryExpr{col: op.col, op: "-", x: x}, nil
+	}
+	return ps.parsePower()
+}
+
+func (ps *exprParser) parsePower() (exprNode, error) {
+	base, err := ps.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if ps.isPunct("^") {
+		op := ps.next()
+		exp, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{col: op.col, op: "^", l: base, r: exp}, nil
+	}
+	return base, nil
+}
+
+func (ps *exprParser) parsePostfix() (exprNode, error) {
+	x, err := ps.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isPunct("[") {
+		open := ps.next()
+		var idxs []exprNode
+		for {
+			idx, err := ps.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			idxs = append(idxs, idx)
+			if !ps.isPunct(",") {
+				break
+			}
+			ps.next()
+		}
+		if _, err := ps.expect("]"); err != nil {
+			return nil, err
+		}
+		x = &indexExpr{col: open.col, x: x, idxs: idxs}
+	}
+	return x, nil
+}
+
+func (ps *exprParser) parsePrimary() (exprNode, error) {
+	t := ps.next()
+	switch t.kind {
+	case tokNumber:
+		v, ok := new(big.Rat).SetString(t.text)
+		if !ok {
+			return nil, ps.errorf(t.col, "bad number %q", t.text)
+		}
+		return &numLit{col: t.col, val: v}, nil
+	case tokIdent:
+		if ps.isPunct(".") {
+			ps.next()
+			k := ps.next()
+			if k.kind != tokIdent {
+				return nil, ps.errorf(k.col, "expected output name after %s., got %s", t.text, describeToken(k))
+			}
+			return &outputRef{col: t.col, name: t.text, key: k.text}, nil
+		}
+		if !ps.isPunct("(") {
+			return &identRef{col: t.col, name: t.text}, nil
+		}
+		ps.next()
+		call := &callExpr{col: t.col, name: t.text}
+		if ps.isPunct(")") {
+			ps.next()
+			return call, nil
+		}
+		for {
+			arg, err := ps.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if !ps.isPunct(",") {
+				break
+			}
+			ps.next()
+		}
+		if _, err := ps.expect(")"); err != nil {
+			return nil, err
+		}
+		if err := ps.checkArity(call); err != nil {
+			return nil, err
+		}
+		return call, nil
+	case tokPunct:
+		if t.text == "(" {
+			x, err := ps.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := ps.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, ps.errorf(t.col, "unexpected %s", describeToken(t))
+}
+
+// foldBinary 将两个字面量之间的 + - * / 在解析期折叠为有理数字面量（除数为 0 时保留原节点，留到求值期报错）。
+func foldBinary(b *binaryExpr) exprNode {
+	l, ok1 := b.l.(*numLit)
+	r, ok2 := b.r.(*numLit)
+	if !ok1 || !ok2 {
+		return b
+	}
+	v := new(big.Rat)
+	switch b.op {
+	case "+":
+		v.Add(l.val, r.val)
+	case "-":
+		v.Sub(l.val, r.val)
+	case "*":
+		v.Mul(l.val, r.val)
+	case "/":
+		if r.val.Sign() == 0 {
+			return b
+		}
+		v.Quo(l.val, r.val)
+	default:
+		return b
+	}
+	return &numLit{col: l.col, val: v}
+}
+
+// Expr 为解析后的表达式，可对不同实例重复求值。
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// ParseExpr 解析表达式字符串；语法错误返回 *ParseError。
+func ParseExpr(src string) (*Expr, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &exprParser{src: src, toks: toks}
+	if ps.peek().kind == tokEOF {
+		return nil, ps.errorf(ps.peek().col, "empty expression")
+	}
+	root, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := ps.peek(); t.kind != tokEOF {
+		return nil, ps.errorf(t.col, "unexpected %s", describeToken(t))
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String 返回表达式源串。
+func (e *Expr) String() string { return e.src }
+
+// Eval 在实例上求值。
+func (e *Expr) Eval(inst *Instance) (interface{}, error) {
+	return evalNode(e.root, inst)
+}
@@ -0,0 +1,152 @@
+package dsl
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func exprTestInst() *Instance {
+	A := NewMatrixInt(2, 2)
+	A.A[0] = []int64{1, 2}
+	A.A[1] = []int64{3, 4}
+	B := NewMatrixInt(2, 2)
+	B.A[0] = []int64{0, 1}
+	B.A[1] = []int64{1, 1}
+	x := NewVectorInt(3)
+	x.V[0], x.V[1], x.V[2] = 5, -1, 2
+	b := NewVectorInt(2)
+	b.V[0], b.V[1] = 1, 1
+	return &Instance{Vars: map[string]interface{}{"A": A, "B": B, "x": x, "b": b, "0Bi": B}}
+}
+
+func TestEvaluateExpression_nestedAndInfix(t *testing.T) {
+	inst := exprTestInst()
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"det(matmul(A,transpose(B)))", "2"},
+		{"det(A * transpose(B))", "2"},
+		{"2*x[1]-x[3]", "8"},
+		{"-x[2]^2", "-1"},
+		{"(-x[2])^2", "1"},
+		{"2^3^2", "512"},
+		{"3/4 + 1/4", "1"},
+		{"-3/4", "-3/4"},
+		{"x[1] / 2", "5/2"},
+		{"det(A) + 1", "-1"},
+		{"solve(A,b)[1]", "-1"},
+		{"(A^2)[2,1]", "15"},
+		{"mget(pow(A,2),2,1)", "15"},
+		{"cofactor(A, 1, 2)", "-3"},
+		{"mget(0Bi, 2, 2)", "1"},
+		{"rank(smmul(2, A) - A * 2)", "0"},
+	}
+	for _, tc := range cases {
+		v, err := EvaluateExpression(tc.expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := ValueToCanonicalString(v); got != tc.want {
+			t.Fatalf("%s = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluateExpression_compareAndLogic(t *testing.T) {
+	inst := exprTestInst()
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"det(A) == -2", "1"},
+		{"det(A) != -2", "0"},
+		{"-3 <= det(A) < -2", "0"},
+		{"-3 <= det(A) <= -2", "1"},
+		{"1/2 < 2/3", "1"},
+		{"!(x[1] > 4)", "0"},
+		{"det(A) > 0 || rank(A) == 2 && x[2] < 0", "1"},
+		{"det(A) > 0 && det(inv(B - B)) > 0", "0"}, // 短路：右侧不求值
+		{"A == transpose(transpose(A))", "1"},
+		{"A != B", "1"},
+		{"b == solve(A, A * b)", "1"},
+		{"abs(det(A)) + abs(3)", "5"},
+		{"rank(A - A + eye(2)) == 2", "1"},
+	}
+	for _, tc := range cases {
+		v, err := EvaluateExpression(tc.expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := ValueToCanonicalString(v); got != tc.want {
+			t.Fatalf("%s = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+	for _, expr := range []string{"A < B", "x && 1", "!A"} {
+		if _, err := EvaluateExpression(expr, inst); err == nil {
+			t.Fatalf("%s: expect err", expr)
+		}
+	}
+}
+
+func TestEvaluateExpression_resultTypes(t *testing.T) {
+	inst := exprTestInst()
+	if v, _ := EvaluateExpression("x[1] + 1", inst); v != int64(6) {
+		t.Fatalf("int64 arithmetic: %T %v", v, v)
+	}
+	if v, _ := EvaluateExpression("det(A) * 2", inst); v.(*big.Int).Int64() != -4 {
+		t.Fatalf("big.Int arithmetic: %v", v)
+	}
+	v, err := EvaluateExpression("A * x", inst)
+	if err == nil {
+		t.Fatalf("expected shape error, got %v", v)
+	}
+	v, err = EvaluateExpression("A * b + b", inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vec, ok := v.(*VectorInt); !ok || vec.V[0] != 4 || vec.V[1] != 8 {
+		t.Fatalf("A*b+b = %#v", v)
+	}
+}
+
+func TestParseExpr_errorColumn(t *testing.T) {
+	cases := []struct {
+		expr string
+		col  int
+	}{
+		{"det(A", 6},
+		{"det(A))", 7},
+		{"2 * * x", 5},
+		{"mget(A,1,)", 10},
+		{"x[1", 4},
+		{"a $ b", 3},
+		{"det(A) = 1", 8},
+		{"1 < ", 5},
+		{"", 1},
+	}
+	for _, tc := range cases {
+		_, err := ParseExpr(tc.expr)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%q: want ParseError, got %v", tc.expr, err)
+		}
+		if pe.Col != tc.col {
+			t.Fatalf("%q: col %d, want %d (%v)", tc.expr, pe.Col, tc.col, err)
+		}
+	}
+}
+
+func TestEvaluateExpression_unknownNames(t *testing.T) {
+	inst := exprTestInst()
+	if _, err := EvaluateExpression("nosuch(A)", inst); err == nil {
+		t.Fatal("unknown function should fail")
+	}
+	if _, err := EvaluateExpression("det(Z)", inst); err == nil {
+		t.Fatal("unknown variable should fail")
+	}
+	if _, err := EvaluateExpression("x[4]", inst); err == nil {
+		t.Fatal("index out of range should fail")
+	}
+}
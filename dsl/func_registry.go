@@ -0,0 +1,182 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValueType 为函数签名中的值类型；可用 "|" 组合多个类型（如 "scalar|matrix"）。
+type ValueType string
+
+const (
+	TypeAny        ValueType = "any"
+	TypeScalar     ValueType = "scalar"
+	TypeInt        ValueType = "int" // 整数标量
+	TypeVector     ValueType = "vector"
+	TypeMatrix     ValueType = "matrix"
+	TypePolynomial ValueType = "polynomial"
+	TypeString     ValueType = "string"
+	TypeInterval   ValueType = "interval" // 取值范围（*IntervalSet）
+	TypeSurd       ValueType = "surd"     // 可能为根式（*Surd）的标量；返回该类型的函数使答案需要 la-dsl.surd.v1
+)
+
+// Signature 为表达式函数的参数与返回类型声明，解析器与 ValidateProblem 据此检查参数个数与类型。
+type Signature struct {
+	Args    []ValueType
+	Returns ValueType
+}
+
+// ParseSignature 解析 "matrix, int -> scalar" 形式的签名；无参函数写作 "-> int"。
+func ParseSignature(s string) (Signature, error) {
+	args, ret, ok := strings.Cut(s, "->")
+	if !ok {
+		return Signature{}, fmt.Errorf("signature %q: missing ->", s)
+	}
+	var sig Signature
+	if args = strings.TrimSpace(args); args != "" {
+		for _, a := range strings.Split(args, ",") {
+			t, err := parseValueType(a)
+			if err != nil {
+				return Signature{}, fmt.Errorf("signature %q: %w", s, err)
+			}
+			sig.Args = append(sig.Args, t)
+		}
+	}
+	t, err := parseValueType(ret)
+	if err != nil {
+		return Signature{}, fmt.Errorf("signature %q: %w", s, err)
+	}
+	sig.Returns = t
+	return sig, nil
+}
+
+// MustParseSignature 同 ParseSignature，出错时 panic，供 init 中注册使用。
+func MustParseSignature(s string) Signature {
+	sig, err := ParseSignature(s)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func (s Signature) String() string {
+	args := make([]string, len(s.Args))
+	for i, a := range s.Args {
+		args[i] = string(a)
+	}
+	if len(args) == 0 {
+		return "-> " + string(s.Returns)
+	}
+	return strings.Join(args, ", ") + " -> " + string(s.Returns)
+}
+
+func parseValueType(s string) (ValueType, error) {
+	s = strings.TrimSpace(s)
+	for _, alt := range strings.Split(s, "|") {
+		switch ValueType(alt) {
+		case TypeAny, TypeScalar, TypeInt, TypeVector, TypeMatrix, TypePolynomial, TypeString, TypeInterval, TypeSurd:
+		default:
+			return "", fmt.Errorf("unknown type %q", alt)
+		}
+	}
+	return ValueType(s), nil
+}
+
+// accepts 报告形状为 s 的值能否作为类型 t 的参数；形状未知时总是接受。
+func (t ValueType) accepts(s shape) bool {
+	if s.kind == shapeUnknown {
+		return true
+	}
+	for _, alt := range strings.Split(string(t), "|") {
+		switch ValueType(alt) {
+		case TypeAny:
+			return true
+		case TypeScalar, TypeInt, TypeSurd:
+			if s.kind == shapeScalar {
+				return true
+			}
+		case TypeVector:
+			if s.kind == shapeVector {
+				return true
+			}
+		case TypeMatrix:
+			if s.kind == shapeMatrix {
+				return true
+			}
+		case TypePolynomial:
+			if s.kind == shapePoly {
+				return true
+			}
+		case TypeString:
+			if s.kind == shapeString {
+				return true
+			}
+		case TypeInterval:
+			if s.kind == shapeInterval {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// includes 报告组合类型 t 是否含 u。
+func (t ValueType) includes(u ValueType) bool {
+	for _, alt := range strings.Split(string(t), "|") {
+		if ValueType(alt) == u {
+			return true
+		}
+	}
+	return false
+}
+
+// shape 返回类型 t 的值的静态形状（维数未知）；组合类型与 any 为 unknown。
+func (t ValueType) shape() shape {
+	switch t {
+	case TypeScalar, TypeInt, TypeSurd:
+		return scalarShape
+	case TypeVector:
+		return shape{kind: shapeVector}
+	case TypeMatrix:
+		return shape{kind: shapeMatrix}
+	case TypePolynomial:
+		return shape{kind: shapePoly}
+	case TypeString:
+		return shape{kind: shapeString}
+	case TypeInterval:
+		return shape{kind: shapeInterval}
+	}
+	return unknownShape
+}
+
+// FunctionImpl 为表达式函数的实现。参数个数已按签名检查，参数本身经 Call 按需求值。
+type FunctionImpl func(c *Call) (interface{}, error)
+
+type funcEntry struct {
+	sig Signature
+	fn  FunctionImpl
+}
+
+// exprFuncs 为函数表（函数名 → 签名与实现），在 init 中注册以避免与 evalNode 的初始化环。
+// 与 generators 相同不加锁：注册应在 init 中完成，之后只读。
+var exprFuncs = map[string]funcEntry{}
+
+// RegisterFunction 注册表达式函数 name，同名函数后注册者覆盖先注册者。应在 init 中调用。
+// 题目专用函数宜由题库包在自己的 init 中注册，而不放进 dsl。
+func RegisterFunction(name string, sig Signature, impl FunctionImpl) {
+	if !isIdentName(name) || impl == nil {
+		panic(fmt.Sprintf("RegisterFunction: bad name %q or nil impl", name))
+	}
+	exprFuncs[name] = funcEntry{sig: sig, fn: impl}
+}
+
+// FunctionSignature 返回已注册函数的签名。
+func FunctionSignature(name string) (Signature, bool) {
+	e, ok := exprFuncs[name]
+	return e.sig, ok
+}
+
+// registerExprFunc 为内置函数的注册简写，签名按 ParseSignature 的格式书写。
+func registerExprFunc(name, sig string, fn FunctionImpl) {
+	RegisterFunction(name, MustParseSignature(sig), fn)
+}
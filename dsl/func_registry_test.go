@@ -0,0 +1,69 @@
+package dsl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSignature(t *testing.T) {
+	for _, src := range []string{"matrix, int -> scalar", "-> int", "polynomial, scalar|matrix -> scalar|matrix"} {
+		sig, err := ParseSignature(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if sig.String() != src {
+			t.Fatalf("round trip: %q -> %q", src, sig.String())
+		}
+	}
+	for _, src := range []string{"matrix", "matrix -> set", "matrix,, int -> int"} {
+		if _, err := ParseSignature(src); err == nil {
+			t.Fatalf("%s: expect err", src)
+		}
+	}
+}
+
+func TestRegisterFunction_custom(t *testing.T) {
+	RegisterFunction("test_twice", MustParseSignature("int -> int"), func(c *Call) (interface{}, error) {
+		n, err := c.Int64(0)
+		if err != nil {
+			return nil, err
+		}
+		return 2 * n, nil
+	})
+	defer delete(exprFuncs, "test_twice")
+
+	v, err := EvaluateExpression("test_twice(3) + 1", &Instance{Vars: map[string]interface{}{}})
+	if err != nil || v != int64(7) {
+		t.Fatalf("got %v %v", v, err)
+	}
+	_, err = ParseExpr("test_twice(1, 2)")
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Col != 1 || pe.Msg != "test_twice expects 1 args, got 2" {
+		t.Fatalf("arity: %v", err)
+	}
+}
+
+func TestValidateProblem_functionSignatures(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "range"}},
+			"v": {Kind: "vector", Size: 3, Generator: map[string]interface{}{"rule": "range"}},
+		},
+		Derived: map[string]string{
+			"p": "charpoly(A)",
+			"s": "quad_expr(A)",
+		},
+		Answer: AnswerSchema{Fields: []string{"rank(v)", "col(A, 1/2)", "coeff(A, 1)", "s + 1", "det(p)"}},
+	}
+	want := []string{
+		"error answer.fields[0] col 1: rank: argument 1 is vector(3), want matrix",
+		"error answer.fields[1] col 1: col: argument 2 must be an integer",
+		"error answer.fields[2] col 1: coeff: argument 1 is 3×3, want polynomial",
+		"error answer.fields[3] col 3: +: string and scalar",
+		"error answer.fields[4] col 1: det: argument 1 is polynomial, want matrix",
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
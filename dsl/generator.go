@@ -4,429 +4,604 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"math/rand"
 )
 
-// generateVariable 按变量类型和生成规则生成值
-func generateVariable(rng *rand.Rand, name string, v Variable, inst *Instance) (interface{}, error) {
+// generateVariable 按变量类型和生成规则生成值；规则在 generators 注册表中查找。
+func generateVariable(rng Rand, name string, v Variable, inst *Instance) (interface{}, error) {
+	rule, ok := v.Generator["rule"].(string)
 	switch v.Kind {
 	case "scalar":
-		return genScalar(rng, v, inst)
+		if !ok {
+			return nil, errors.New("scalar generator rule missing")
+		}
 	case "vector":
-		return genVector(rng, v)
+		if rule == "" {
+			rule = "range"
+		}
 	case "matrix":
-		return genMatrix(rng, v, inst)
+		if v.Rows == 0 || v.Cols == 0 {
+			return nil, errors.New("matrix rows/cols required")
+		}
+		if rule == "" {
+			rule = "range"
+		}
 	default:
 		return nil, fmt.Errorf("unknown kind: %s", v.Kind)
 	}
+	e, ok := lookupGenerator(v.Kind, rule)
+	if !ok {
+		return nil, fmt.Errorf("unsupported %s generator rule: %s", v.Kind, rule)
+	}
+	out := GeneratorOutputs{}
+	if tr := inst.trace; tr != nil {
+		tr.begin()
+	}
+	val, err := e.fn(rng, v, inst, GeneratorParams(v.Generator), out)
+	if tr := inst.trace; tr != nil {
+		tr.end(name, v, rule, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkOutputs(out); err != nil {
+		return nil, fmt.Errorf("%s generator rule %s: %w", v.Kind, rule, err)
+	}
+	if len(out) > 0 {
+		if inst.Outputs == nil {
+			inst.Outputs = map[string]GeneratorOutputs{}
+		}
+		inst.Outputs[name] = out
+	}
+	return val, nil
 }
 
-func genScalar(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	g, ok := v.Generator["rule"].(string)
-	if !ok {
-		return nil, errors.New("scalar generator rule missing")
-	}
-	switch g {
-	case "range":
-		min := int64(defaultInt(v.Generator, "min", -5))
-		max := int64(defaultInt(v.Generator, "max", 5))
-		return int64(rng.Intn(int(max-min+1)) + int(min)), nil
-	case "from_set":
-		set := interfaceToIntSlice(v.Generator["set"])
-		if len(set) == 0 {
-			return nil, errors.New("from_set empty")
-		}
-		return set[rng.Intn(len(set))], nil
-	default:
-		return nil, fmt.Errorf("unsupported scalar generator: %s", g)
-	case "eigen_rank_inference_5":
-		return genScalarEigenRankInference5(rng, v, inst)
-	case "eigen_row_sum_rank4":
-		return genScalarEigenRowSumRank4(rng, v, inst)
+func init() {
+	RegisterGenerator("scalar", "range", genScalarRange, rangeParams(-5, 5)...)
+	RegisterGenerator("scalar", "from_set", genScalarFromSet, setParam)
+	RegisterGenerator("scalar", "eigen_rank_inference_5", genScalarEigenRankInference5, lambdaParams(-8, 8)...)
+	RegisterGenerator("scalar", "eigen_row_sum_rank4", genScalarEigenRowSumRank4,
+		intParam("row_sum_min", 2, "行和下界（含）"),
+		intParam("row_sum_max", 6, "行和上界（含）"),
+		intParam("k_min", 2, "k 下界（含）"),
+		intParam("k_max", 10, "k 上界（含）"))
+
+	RegisterGenerator("vector", "range", genVectorRange, rangeParams(-5, 5)...)
+	RegisterGenerator("vector", "from_set", genVectorFromSet, setParam)
+	RegisterGenerator("vector", "integer_solution", genIntegerSolution, integerSolutionParams...)
+
+	RegisterGenerator("matrix", "range", genMatrixRange, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "from_set", genMatrixFromSet, setParam)
+	RegisterGenerator("matrix", "sparse", genMatrixSparse,
+		GeneratorParam{Name: "values", Type: "int_list", Doc: "非零元候选取值，须非空"},
+		GeneratorParam{Name: "density", Type: "float", Default: 0.3, Doc: "每个元素取非零值的概率"})
+	RegisterGenerator("matrix", "full_rank", genMatrixFullRank, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "orthogonal_signed_perm", genMatrixOrthogonalSignedPerm)
+	RegisterGenerator("matrix", "rank_minus_one_square", genMatrixRankMinusOneSquare, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "integer_solution", genIntegerSolution, integerSolutionParams...)
+	RegisterGenerator("matrix", "scalar_identity", genMatrixScalarIdentity,
+		intParam("lambda_min", -5, "λ 下界（含），λ 非零"),
+		intParam("lambda_max", 5, "λ 上界（含）"),
+		GeneratorParam{Name: "lambda_var", Type: "string", Doc: "把 λ 写入实例的标量变量名（可选）"})
+	RegisterGenerator("matrix", "lambda_linear_det_zero", genMatrixLambdaLinearDetZero,
+		GeneratorParam{Name: "param_var", Type: "string", Default: "lambda", Doc: "参数名，解写入实例"},
+		GeneratorParam{Name: "param_row", Type: "int", Doc: "含参元素所在行（1 起），默认末行"},
+		GeneratorParam{Name: "param_col", Type: "int", Doc: "含参元素所在列（1 起），默认末列"},
+		intParam("entry_min", -5, "其它元素与常数项下界（含）"),
+		intParam("entry_max", 5, "其它元素与常数项上界（含）"),
+		intParam("lambda_min", -10, "参数解下界（含）"),
+		intParam("lambda_max", 10, "参数解上界（含）"),
+		intParam("max_attempts", 200, "最大重试次数"))
+	RegisterGenerator("matrix", "upper_unit", genMatrixUpperUnit, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "lower_unit", genMatrixLowerUnit, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "equidiagonal", genMatrixEquidiagonal,
+		intParam("n", 3, "阶数（rows/cols 未设时使用）"),
+		intParam("diag_min", -3, "对角常数下界（含）"),
+		intParam("diag_max", 3, "对角常数上界（含）"),
+		intParam("off_min", -5, "非对角常数下界（含）"),
+		intParam("off_max", 5, "非对角常数上界（含）"))
+	RegisterGenerator("matrix", "upper_triangular", genMatrixUpperTriangular, rangeParams(-6, 6)...)
+	RegisterGenerator("matrix", "upper_triangular_nonzero_diag", genMatrixUpperTriangularNonzeroDiag, rangeParams(-4, 4)...)
+	RegisterGenerator("matrix", "diagonal_distinct", genMatrixDiagonalDistinct, rangeParams(-6, 6)...)
+	RegisterGenerator("matrix", "rank3_coin", genMatrixRank3Coin, rangeParams(-5, 5)...)
+	RegisterGenerator("matrix", "symmetric", genMatrixSymmetric, rangeParams(-6, 6)...)
+	RegisterGenerator("matrix", "rank2_2x4", genMatrixRank2x4, rangeParams(-4, 4)...)
+	RegisterGenerator("matrix", "rank2_3x4", genMatrixRank2In3x4, rangeParams(-4, 4)...)
+	RegisterGenerator("matrix", "rank1_outer", genMatrixRank1Outer, rangeParams(-4, 4)...)
+	RegisterGenerator("matrix", "rank334_last_dep", genMatrixRank334LastDep, rangeParams(-4, 4)...)
+	diagParams := append(lambdaParams(-5, 5),
+		GeneratorParam{Name: "entry_min", Type: "int", Doc: "V 的元素下界，默认 -3（n≥4 时 -2）"},
+		GeneratorParam{Name: "entry_max", Type: "int", Doc: "V 的元素上界，默认 3（n≥4 时 2）"},
+		intParam("max_entry", 15, "A 的元素绝对值上限"))
+	RegisterGenerator("matrix", "diagonalizable", genMatrixDiagonalizableN, diagParams...)
+	RegisterGenerator("matrix", "diagonalizable_2x2", genMatrixDiagonalizable2x2, diagParams...)
+	eigenParams := append(lambdaParams(-5, 5),
+		intParam("v_entry_max", 12, "V 与 V⁻¹ 的元素绝对值上限"),
+		intParam("max_entry", 15, "A 的元素绝对值上限"))
+	RegisterGenerator("matrix", "eigen_reverse", genMatrixEigenReverseN, eigenParams...)
+	RegisterGenerator("matrix", "eigen_reverse_3x3", genMatrixEigenReverse3x3, eigenParams...)
+	symParams := append(lambdaParams(-5, 5), intParam("max_entry", 15, "S 的元素绝对值上限"))
+	RegisterGenerator("matrix", "symmetric_eigen_reverse", genMatrixSymmetricEigenReverseN, symParams...)
+	RegisterGenerator("matrix", "symmetric_eigen_reverse_3x3", genMatrixSymmetricEigenReverse3x3, symParams...)
+	RegisterGenerator("matrix", "similarity_congruence_pair", genMatrixSimilarityCongruencePair,
+		append(lambdaParams(-6, 6), intParam("max_entry", 15, "A 的元素绝对值上限"))...)
+	RegisterGenerator("matrix", "sylvester_range", genMatrixSylvesterRange,
+		intParam("entry_min", -5, "元素下界（含）"),
+		intParam("entry_max", 5, "元素上界（含）"))
+	RegisterGenerator("matrix", "param_orthogonal_diag", genMatrixParamOrthogonalDiag,
+		append(lambdaParams(-5, 5), intParam("max_entry", 15, "S 的元素绝对值上限"))...)
+	RegisterGenerator("matrix", "poly_schmidt_integral", genPolySchmidtIntegral,
+		intParam("coef_min", -5, "多项式系数下界（含）"),
+		intParam("coef_max", 5, "多项式系数上界（含）"))
+	RegisterGenerator("matrix", "param_infinit_solution", genMatrixParamInfinitSolution,
+		intParam("entry_min", -5, "元素下界（含）"),
+		intParam("entry_max", 5, "元素上界（含）"),
+		intParam("lambda_min", -10, "参数解下界（含）"),
+		intParam("lambda_max", 10, "参数解上界（含）"))
+
+	declareOutputs()
+}
+
+// declareOutputs 声明内置规则的附带输出（表达式中写作 A.key）。
+func declareOutputs() {
+	DeclareGeneratorOutputs("scalar", "eigen_rank_inference_5",
+		outputDecl("lambdas", TypeVector, "三个互异特征值（升序）"),
+		outputDecl("mults", TypeVector, "对应的重数"),
+		outputDecl("ranks", TypeVector, "对应的秩 R(A-λE)"))
+	DeclareGeneratorOutputs("scalar", "eigen_row_sum_rank4",
+		outputDecl("lambdas", TypeVector, "四个特征值（升序，含重数）"),
+		outputDecl("s", TypeInt, "行和"),
+		outputDecl("r", TypeInt, "rank(A)"),
+		outputDecl("k", TypeInt, "det(A+kE)=0 中的 k"))
+
+	DeclareGeneratorOutputs("matrix", "lambda_linear_det_zero",
+		outputDecl("lambda", TypeInt, "使 det(A)=0 的参数值"),
+		outputDecl("lambda_row", TypeInt, "含参元素所在行（1 起）"),
+		outputDecl("lambda_col", TypeInt, "含参元素所在列（1 起）"),
+		outputDecl("lambda_const", TypeInt, "含参元素为 λ+c 时的常数 c"))
+	eigen := []GeneratorOutput{
+		outputDecl("lambdas", TypeVector, "特征值，与 P 的列对应"),
+		outputDecl("P", TypeMatrix, "相似变换矩阵，列为特征向量，A = PΛP⁻¹"),
+		outputDecl("P_inv", TypeMatrix, "P⁻¹"),
+		outputDecl("Lambda", TypeMatrix, "对角阵 Λ"),
+	}
+	for _, rule := range []string{"diagonalizable", "diagonalizable_2x2", "eigen_reverse", "eigen_reverse_3x3"} {
+		DeclareGeneratorOutputs("matrix", rule, eigen...)
 	}
+	sym := []GeneratorOutput{
+		outputDecl("lambdas", TypeVector, "特征值，与 Q 的列对应"),
+		outputDecl("Q", TypeMatrix, "正交矩阵，S = QΛQᵀ"),
+		outputDecl("Lambda", TypeMatrix, "对角阵 Λ"),
+	}
+	for _, rule := range []string{"symmetric_eigen_reverse", "symmetric_eigen_reverse_3x3"} {
+		DeclareGeneratorOutputs("matrix", rule, sym...)
+	}
+	DeclareGeneratorOutputs("matrix", "similarity_congruence_pair",
+		outputDecl("B", TypeMatrix, "与 A 比较的对角阵"),
+		outputDecl("is_similar", TypeInt, "A 与 B 相似为 1，否则为 0"),
+		outputDecl("is_congruent", TypeInt, "A 与 B 合同为 1，否则为 0"))
+	DeclareGeneratorOutputs("matrix", "sylvester_range",
+		outputDecl("lower", TypeScalar, "S 正定时 t 的下界（t > lower）"),
+		outputDecl("t_row", TypeInt, "参数 t 所在的对角位置（1 起）"))
+	DeclareGeneratorOutputs("matrix", "param_orthogonal_diag",
+		outputDecl("Q", TypeMatrix, "正交矩阵，S = QΛQᵀ"),
+		outputDecl("Lambda", TypeMatrix, "对角阵 Λ"),
+		outputDecl("lambdas", TypeVector, "特征值，与 Q 的列对应"),
+		outputDecl("t", TypeInt, "参数 t 的值"),
+		outputDecl("t_row", TypeInt, "参数 t 所在的对角位置（1 起）"))
+	DeclareGeneratorOutputs("matrix", "poly_schmidt_integral",
+		outputDecl("input", TypeMatrix, "第 j 行为 f_j 的系数（常数项、x、x²）"),
+		outputDecl("output", TypeMatrix, "第 j 行为正交化结果 g_j 的整数化系数"))
+	DeclareGeneratorOutputs("matrix", "param_infinit_solution",
+		outputDecl("lambda", TypeInt, "使方程组有无穷多解的 λ"),
+		outputDecl("mu", TypeInt, "使方程组有无穷多解的 μ"),
+		outputDecl("b", TypeVector, "右端向量"),
+		outputDecl("lambda_col", TypeInt, "λ 在第 3 行所在的列（1 起）"),
+		outputDecl("rref", TypeMatrix, "增广矩阵的行最简形"),
+		outputDecl("x0", TypeVector, "特解"),
+		outputDecl("nb1", TypeVector, "基础解系向量"),
+		outputDecl("k1", TypeInt, "第 3 行 = k1·第 1 行 + k2·第 2 行"),
+		outputDecl("k2", TypeInt, "同上"))
 }
 
-func genVector(rng *rand.Rand, v Variable) (interface{}, error) {
-	n := v.Size
-	if n == 0 {
-		n = v.Rows
-	}
-	g, _ := v.Generator["rule"].(string)
-	switch g {
-	case "", "range":
-		min := int64(defaultInt(v.Generator, "min", -5))
-		max := int64(defaultInt(v.Generator, "max", 5))
-		vec := NewVectorInt(n)
-		for i := 0; i < n; i++ {
-			vec.V[i] = int64(rng.Intn(int(max-min+1)) + int(min))
-		}
-		return vec, nil
-	case "from_set":
-		set := interfaceToIntSlice(v.Generator["set"])
-		if len(set) == 0 {
-			return nil, errors.New("from_set empty")
-		}
-		vec := NewVectorInt(n)
-		for i := 0; i < n; i++ {
-			vec.V[i] = set[rng.Intn(len(set))]
-		}
-		return vec, nil
-	default:
-		return nil, fmt.Errorf("unsupported vector generator rule: %s", g)
+var integerSolutionParams = []GeneratorParam{
+	{Name: "A", Type: "string", Doc: "系数矩阵变量名"},
+	{Name: "x", Type: "string", Doc: "解向量变量名"},
+}
+
+// genIntegerSolution：integer_solution 由依赖图在 A、x 就绪后计算为 A·x，不经生成器。
+func genIntegerSolution(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	return nil, errors.New("integer_solution should be handled as derived/builder (use derived or orchestrator)")
+}
+
+func genScalarRange(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
+	return int64(rng.Intn(int(max-min+1)) + int(min)), nil
+}
+
+func genScalarFromSet(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	set := params.Ints("set")
+	if len(set) == 0 {
+		return nil, errors.New("from_set empty")
 	}
+	return set[rng.Intn(len(set))], nil
 }
 
-func genMatrix(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	r := v.Rows
-	c := v.Cols
-	if r == 0 || c == 0 {
-		return nil, errors.New("matrix rows/cols required")
+// vectorSize 为向量长度：size，未设时取 rows。
+func vectorSize(v Variable) int {
+	if v.Size == 0 {
+		return v.Rows
 	}
-	g, _ := v.Generator["rule"].(string)
-	switch g {
-	case "", "range":
-		min := int64(defaultInt(v.Generator, "min", -5))
-		max := int64(defaultInt(v.Generator, "max", 5))
-		m := NewMatrixInt(r, c)
-		for i := 0; i < r; i++ {
-			for j := 0; j < c; j++ {
-				m.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
-			}
+	return v.Size
+}
+
+func genVectorRange(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n := vectorSize(v)
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
+	vec := NewVectorInt(n)
+	for i := 0; i < n; i++ {
+		vec.V[i] = int64(rng.Intn(int(max-min+1)) + int(min))
+	}
+	return vec, nil
+}
+
+func genVectorFromSet(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n := vectorSize(v)
+	set := params.Ints("set")
+	if len(set) == 0 {
+		return nil, errors.New("from_set empty")
+	}
+	vec := NewVectorInt(n)
+	for i := 0; i < n; i++ {
+		vec.V[i] = set[rng.Intn(len(set))]
+	}
+	return vec, nil
+}
+
+func genMatrixRange(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
+	m := NewMatrixInt(r, c)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			m.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
 		}
-		return m, nil
-	case "from_set":
-		set := interfaceToIntSlice(v.Generator["set"])
-		if len(set) == 0 {
-			return nil, errors.New("from_set empty")
+	}
+	return m, nil
+}
+
+func genMatrixFromSet(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	set := params.Ints("set")
+	if len(set) == 0 {
+		return nil, errors.New("from_set empty")
+	}
+	m := NewMatrixInt(r, c)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			m.A[i][j] = set[rng.Intn(len(set))]
 		}
-		m := NewMatrixInt(r, c)
-		for i := 0; i < r; i++ {
-			for j := 0; j < c; j++ {
-				m.A[i][j] = set[rng.Intn(len(set))]
+	}
+	return m, nil
+}
+
+func genMatrixSparse(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	values := params.Ints("values")
+	density := params.Float("density", 0.3)
+	if len(values) == 0 {
+		return nil, errors.New("sparse values empty")
+	}
+	m := NewMatrixInt(r, c)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if rng.Float64() <= density {
+				m.A[i][j] = values[rng.Intn(len(values))]
+			} else {
+				m.A[i][j] = 0
 			}
 		}
-		return m, nil
-	case "sparse":
-		values := interfaceToIntSlice(v.Generator["values"])
-		density := defaultFloat(v.Generator, "density", 0.3)
-		if len(values) == 0 {
-			return nil, errors.New("sparse values empty")
-		}
+	}
+	return m, nil
+}
+
+func genMatrixFullRank(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
+	// 使用确定性方法：每次尝试用独立的 RNG，避免失败尝试影响随机数序列
+	for attempt := 0; attempt < 200; attempt++ {
+		inst.noteAttempt(200)
+		// 为每次尝试创建独立的 RNG，基于主 RNG 的下一个 Int63
+		attemptRng := inst.subRand(rng)
+
 		m := NewMatrixInt(r, c)
 		for i := 0; i < r; i++ {
 			for j := 0; j < c; j++ {
-				if rng.Float64() <= density {
-					m.A[i][j] = values[rng.Intn(len(values))]
-				} else {
-					m.A[i][j] = 0
-				}
+				m.A[i][j] = int64(attemptRng.Intn(int(max-min+1)) + int(min))
 			}
 		}
-		return m, nil
-	case "full_rank":
-		min := int64(defaultInt(v.Generator, "min", -5))
-		max := int64(defaultInt(v.Generator, "max", 5))
-		// 使用确定性方法：每次尝试用独立的 RNG，避免失败尝试影响随机数序列
-		for attempt := 0; attempt < 200; attempt++ {
-			// 为每次尝试创建独立的 RNG，基于主 RNG 的下一个 Int63
-			attemptSeed := rng.Int63()
-			attemptRng := rand.New(rand.NewSource(attemptSeed))
-
-			m := NewMatrixInt(r, c)
-			for i := 0; i < r; i++ {
-				for j := 0; j < c; j++ {
-					m.A[i][j] = int64(attemptRng.Intn(int(max-min+1)) + int(min))
-				}
-			}
-			if r == c {
-				d := BareissDet(m)
-				if d.Sign() != 0 {
-					return m, nil
-				}
-			} else {
-				if matrixRankRat(m) == minInt(r, c) {
-					return m, nil
-				}
+		if r == c {
+			d := BareissDet(m)
+			if d.Sign() != 0 {
+				return m, nil
 			}
-		}
-		return nil, errors.New("failed to generate full_rank matrix after attempts")
-	case "orthogonal_signed_perm":
-		// 3×3：随机带符号置换矩阵（正交、det=±1，元素仅为 -1,0,1）
-		if r != 3 || c != 3 {
-			return nil, errors.New("orthogonal_signed_perm: need 3×3")
-		}
-		perm := []int{0, 1, 2}
-		rng.Shuffle(3, func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
-		m := NewMatrixInt(3, 3)
-		for j := 0; j < 3; j++ {
-			sign := int64(1)
-			if rng.Intn(2) == 0 {
-				sign = -1
+		} else {
+			if matrixRankRat(m) == minInt(r, c) {
+				return m, nil
 			}
-			m.A[perm[j]][j] = sign
 		}
-		return m, nil
-	case "rank_minus_one_square":
-		// n×n 秩恰为 n-1：前 n-1 行随机，末行复制首行（必相关），再筛秩为 n-1。
-		if r != c || r < 2 {
-			return nil, errors.New("rank_minus_one_square: need n×n with n≥2")
-		}
-		n := r
-		min := int64(defaultInt(v.Generator, "min", -5))
-		max := int64(defaultInt(v.Generator, "max", 5))
-		if max < min {
-			min, max = max, min
-		}
-		want := n - 1
-		for attempt := 0; attempt < 500; attempt++ {
-			attemptSeed := rng.Int63()
-			attemptRng := rand.New(rand.NewSource(attemptSeed))
-			m := NewMatrixInt(n, n)
-			for i := 0; i < n-1; i++ {
-				for j := 0; j < n; j++ {
-					m.A[i][j] = int64(attemptRng.Intn(int(max-min+1)) + int(min))
-				}
-			}
+	}
+	return nil, errors.New("failed to generate full_rank matrix after attempts")
+}
+
+// genMatrixOrthogonalSignedPerm：n×n（2 ≤ n ≤ 6）随机带符号置换矩阵（正交、det=±1，元素仅为 -1,0,1）。
+func genMatrixOrthogonalSignedPerm(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n, err := squareOrder(v, "orthogonal_signed_perm")
+	if err != nil {
+		return nil, err
+	}
+	return randomSignedPerm(rng, n), nil
+}
+
+// genMatrixRankMinusOneSquare：n×n 秩恰为 n-1，前 n-1 行随机，末行复制首行（必相关），再筛秩为 n-1。
+func genMatrixRankMinusOneSquare(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	if v.Rows != v.Cols || v.Rows < 2 {
+		return nil, errors.New("rank_minus_one_square: need n×n with n≥2")
+	}
+	n := v.Rows
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
+	if max < min {
+		min, max = max, min
+	}
+	want := n - 1
+	for attempt := 0; attempt < 500; attempt++ {
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
+		m := NewMatrixInt(n, n)
+		for i := 0; i < n-1; i++ {
 			for j := 0; j < n; j++ {
-				m.A[n-1][j] = m.A[0][j]
-			}
-			if matrixRankRat(m) == want {
-				return m, nil
+				m.A[i][j] = int64(attemptRng.Intn(int(max-min+1)) + int(min))
 			}
 		}
-		return nil, errors.New("rank_minus_one_square: failed")
-	case "integer_solution":
-		// 该规则在更高层被特殊处理，不在这里直接生成
-		return nil, errors.New("integer_solution should be handled as derived/builder (use derived or orchestrator)")
-	case "scalar_identity":
-		// 生成 λI 型矩阵，所有对角线为同一随机整数 λ，其余为 0
-		// 配置：
-		//   lambda_min, lambda_max: λ 取值范围（含端点，默认 [-5,5]）
-		//   lambda_var: 写入到实例中的标量变量名（可选），例如 "lambda"
-		cfg := v.Generator
-		lmin := int64(defaultInt(cfg, "lambda_min", -5))
-		lmax := int64(defaultInt(cfg, "lambda_max", 5))
-		if lmax < lmin {
-			lmin, lmax = lmax, lmin
-		}
-		var lambda int64
-		for {
-			lambda = int64(rng.Intn(int(lmax-lmin+1)) + int(lmin))
-			if lambda != 0 {
-				break
-			}
+		for j := 0; j < n; j++ {
+			m.A[n-1][j] = m.A[0][j]
 		}
-		m := NewMatrixInt(r, c)
-		for i := 0; i < r && i < c; i++ {
-			m.A[i][i] = lambda
+		if matrixRankRat(m) == want {
+			return m, nil
 		}
-		if name, ok := cfg["lambda_var"].(string); ok && name != "" && inst != nil {
-			// 记录 λ，供后续表达式或答案使用
-			if inst.Vars == nil {
-				inst.Vars = map[string]interface{}{}
-			}
-			inst.Vars[name] = lambda
+	}
+	return nil, errors.New("rank_minus_one_square: failed")
+}
+
+// genMatrixScalarIdentity 生成 λI 型矩阵，所有对角线为同一随机非零整数 λ，其余为 0；
+// 设置 lambda_var 时把 λ 写入实例，供后续表达式或答案使用。
+func genMatrixScalarIdentity(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	lmin := int64(params.Int("lambda_min", -5))
+	lmax := int64(params.Int("lambda_max", 5))
+	if lmax < lmin {
+		lmin, lmax = lmax, lmin
+	}
+	var lambda int64
+	for {
+		lambda = int64(rng.Intn(int(lmax-lmin+1)) + int(lmin))
+		if lambda != 0 {
+			break
 		}
-		return m, nil
-	case "lambda_linear_det_zero":
-		// 为 det(A)=0 类型题目生成含单一参数 λ 的 3x3 矩阵，并保证 λ 为整数解
-		return genMatrixLambdaLinearDetZero(rng, v, inst)
-	case "upper_unit":
-		// 上三角、主对角全 1，严格上三角随机整数（det=1，便于 Cramer / 整数解）
-		return genMatrixUpperUnit(rng, v)
-	case "lower_unit":
-		// 下三角、主对角全 1，严格下三角随机整数（det=1，逆仍为整数矩阵）
-		return genMatrixLowerUnit(rng, v)
-	case "equidiagonal":
-		// n×n：主对角同一常数 a，非对角同一常数 b（如「对角为 -1、其余为 -3」类题）
-		return genMatrixEquidiagonal(rng, v)
-	case "upper_triangular":
-		// 上三角矩阵，主对角非零随机，det = 对角乘积
-		return genMatrixUpperTriangular(rng, v)
-	case "upper_triangular_nonzero_diag":
-		// 上三角矩阵，主对角元为非零整数（不含 ±1 以避免平凡化），严格上三角随机整数
-		return genMatrixUpperTriangularNonzeroDiag(rng, v)
-	case "diagonal_distinct":
-		// 对角矩阵，主对角元两两不同且非零
-		return genMatrixDiagonalDistinct(rng, v)
-	case "rank3_coin":
-		// 3×3：随机一半概率秩为 2（列相关），一半满秩
-		return genMatrixRank3Coin(rng, v)
-	case "symmetric":
-		// 整数对称矩阵（上三角随机，下三角镜像）
-		return genMatrixSymmetric(rng, v)
-	case "rank2_2x4":
-		if r != 2 || c != 4 {
-			return nil, errors.New("rank2_2x4: need 2×4")
-		}
-		min := int64(defaultInt(v.Generator, "min", -4))
-		max := int64(defaultInt(v.Generator, "max", 4))
-		if max < min {
-			min, max = max, min
-		}
-		for attempt := 0; attempt < 200; attempt++ {
-			m := NewMatrixInt(2, 4)
-			for i := 0; i < 2; i++ {
-				for j := 0; j < 4; j++ {
-					m.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
-				}
-			}
-			if matrixRankRat(m) == 2 {
-				return m, nil
-			}
+	}
+	m := NewMatrixInt(r, c)
+	for i := 0; i < r && i < c; i++ {
+		m.A[i][i] = lambda
+	}
+	if name := params.String("lambda_var", ""); name != "" && inst != nil {
+		if inst.Vars == nil {
+			inst.Vars = map[string]interface{}{}
 		}
-		return nil, errors.New("rank2_2x4: failed")
-	case "rank2_3x4":
-		// 3×4 秩 2：先取 2×4 秩 2 的两行，第三行为前两行之和（必落在行张成内），故整体秩为 2。
-		if r != 3 || c != 4 {
-			return nil, errors.New("rank2_3x4: need 3×4")
-		}
-		min := int64(defaultInt(v.Generator, "min", -4))
-		max := int64(defaultInt(v.Generator, "max", 4))
-		if max < min {
-			min, max = max, min
-		}
-		for attempt := 0; attempt < 400; attempt++ {
-			top := NewMatrixInt(2, 4)
-			for i := 0; i < 2; i++ {
-				for j := 0; j < 4; j++ {
-					top.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
-				}
-			}
-			if matrixRankRat(top) != 2 {
-				continue
-			}
-			m := NewMatrixInt(3, 4)
+		inst.Vars[name] = lambda
+	}
+	return m, nil
+}
+
+func genMatrixRank2x4(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	if v.Rows != 2 || v.Cols != 4 {
+		return nil, errors.New("rank2_2x4: need 2×4")
+	}
+	min := int64(params.Int("min", -4))
+	max := int64(params.Int("max", 4))
+	if max < min {
+		min, max = max, min
+	}
+	for attempt := 0; attempt < 200; attempt++ {
+		inst.noteAttempt(200)
+		m := NewMatrixInt(2, 4)
+		for i := 0; i < 2; i++ {
 			for j := 0; j < 4; j++ {
-				m.A[0][j] = top.A[0][j]
-				m.A[1][j] = top.A[1][j]
-				m.A[2][j] = top.A[0][j] + top.A[1][j]
-			}
-			if matrixRankRat(m) == 2 {
-				return m, nil
+				m.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
 			}
 		}
-		return nil, errors.New("rank2_3x4: failed")
-	case "rank1_outer":
-		// m×n 秩 1：外积 u v^T（u 为 m 维列，v 为 n 维行向量随机整数）。
-		if r <= 0 || c <= 0 {
-			return nil, errors.New("rank1_outer: bad size")
-		}
-		min := int64(defaultInt(v.Generator, "min", -4))
-		max := int64(defaultInt(v.Generator, "max", 4))
-		if max < min {
-			min, max = max, min
-		}
-		for attempt := 0; attempt < 80; attempt++ {
-			u := NewVectorInt(r)
-			w := NewVectorInt(c)
-			for i := 0; i < r; i++ {
-				u.V[i] = int64(rng.Intn(int(max-min+1)) + int(min))
+		if matrixRankRat(m) == 2 {
+			return m, nil
+		}
+	}
+	return nil, errors.New("rank2_2x4: failed")
+}
+
+// genMatrixRank2In3x4：3×4 秩 2，先取 2×4 秩 2 的两行，第三行为前两行之和（必落在行张成内），故整体秩为 2。
+func genMatrixRank2In3x4(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	if v.Rows != 3 || v.Cols != 4 {
+		return nil, errors.New("rank2_3x4: need 3×4")
+	}
+	min := int64(params.Int("min", -4))
+	max := int64(params.Int("max", 4))
+	if max < min {
+		min, max = max, min
+	}
+	for attempt := 0; attempt < 400; attempt++ {
+		inst.noteAttempt(400)
+		top := NewMatrixInt(2, 4)
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 4; j++ {
+				top.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
 			}
-			for j := 0; j < c; j++ {
-				w.V[j] = int64(rng.Intn(int(max-min+1)) + int(min))
+		}
+		if matrixRankRat(top) != 2 {
+			continue
+		}
+		m := NewMatrixInt(3, 4)
+		for j := 0; j < 4; j++ {
+			m.A[0][j] = top.A[0][j]
+			m.A[1][j] = top.A[1][j]
+			m.A[2][j] = top.A[0][j] + top.A[1][j]
+		}
+		if matrixRankRat(m) == 2 {
+			return m, nil
+		}
+	}
+	return nil, errors.New("rank2_3x4: failed")
+}
+
+// genMatrixRank1Outer：m×n 秩 1，外积 u v^T（u 为 m 维列，v 为 n 维行向量随机整数）。
+func genMatrixRank1Outer(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	r, c := v.Rows, v.Cols
+	if r <= 0 || c <= 0 {
+		return nil, errors.New("rank1_outer: bad size")
+	}
+	min := int64(params.Int("min", -4))
+	max := int64(params.Int("max", 4))
+	if max < min {
+		min, max = max, min
+	}
+	for attempt := 0; attempt < 80; attempt++ {
+		inst.noteAttempt(80)
+		u := NewVectorInt(r)
+		w := NewVectorInt(c)
+		for i := 0; i < r; i++ {
+			u.V[i] = int64(rng.Intn(int(max-min+1)) + int(min))
+		}
+		for j := 0; j < c; j++ {
+			w.V[j] = int64(rng.Intn(int(max-min+1)) + int(min))
+		}
+		allu0, allw0 := true, true
+		for i := 0; i < r; i++ {
+			if u.V[i] != 0 {
+				allu0 = false
 			}
-			allu0, allw0 := true, true
-			for i := 0; i < r; i++ {
-				if u.V[i] != 0 {
-					allu0 = false
-				}
+		}
+		for j := 0; j < c; j++ {
+			if w.V[j] != 0 {
+				allw0 = false
 			}
+		}
+		if allu0 || allw0 {
+			continue
+		}
+		M := NewMatrixInt(r, c)
+		for i := 0; i < r; i++ {
 			for j := 0; j < c; j++ {
-				if w.V[j] != 0 {
-					allw0 = false
-				}
-			}
-			if allu0 || allw0 {
-				continue
-			}
-			M := NewMatrixInt(r, c)
-			for i := 0; i < r; i++ {
-				for j := 0; j < c; j++ {
-					M.A[i][j] = u.V[i] * w.V[j]
-				}
-			}
-			if matrixRankRat(M) == 1 {
-				return M, nil
+				M.A[i][j] = u.V[i] * w.V[j]
 			}
 		}
-		return nil, errors.New("rank1_outer: failed")
-	case "rank334_last_dep":
-		// 4×4 列秩 3：最后一列为前三列的整系数线性组合（用于极大无关组/表示式类题）。
-		if r != 4 || c != 4 {
-			return nil, errors.New("rank334_last_dep: need 4×4")
-		}
-		min := int64(defaultInt(v.Generator, "min", -4))
-		max := int64(defaultInt(v.Generator, "max", 4))
-		if max < min {
-			min, max = max, min
-		}
-		for attempt := 0; attempt < 200; attempt++ {
-			U := NewMatrixInt(4, 3)
-			for i := 0; i < 4; i++ {
-				for j := 0; j < 3; j++ {
-					U.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
-				}
-			}
-			if matrixRankRat(U) < 3 {
-				continue
-			}
-			a := int64(rng.Intn(int(max-min+1)) + int(min))
-			b := int64(rng.Intn(int(max-min+1)) + int(min))
-			cc := int64(rng.Intn(int(max-min+1)) + int(min))
-			if a == 0 && b == 0 && cc == 0 {
-				continue
-			}
-			V := NewMatrixInt(4, 4)
-			for i := 0; i < 4; i++ {
-				for j := 0; j < 3; j++ {
-					V.A[i][j] = U.A[i][j]
-				}
-				V.A[i][3] = a*U.A[i][0] + b*U.A[i][1] + cc*U.A[i][2]
+		if matrixRankRat(M) == 1 {
+			return M, nil
+		}
+	}
+	return nil, errors.New("rank1_outer: failed")
+}
+
+// genMatrixRank334LastDep：4×4 列秩 3，最后一列为前三列的整系数线性组合（用于极大无关组/表示式类题）。
+func genMatrixRank334LastDep(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	if v.Rows != 4 || v.Cols != 4 {
+		return nil, errors.New("rank334_last_dep: need 4×4")
+	}
+	min := int64(params.Int("min", -4))
+	max := int64(params.Int("max", 4))
+	if max < min {
+		min, max = max, min
+	}
+	for attempt := 0; attempt < 200; attempt++ {
+		inst.noteAttempt(200)
+		U := NewMatrixInt(4, 3)
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 3; j++ {
+				U.A[i][j] = int64(rng.Intn(int(max-min+1)) + int(min))
 			}
-			if matrixRankRat(V) != 3 {
-				continue
+		}
+		if matrixRankRat(U) < 3 {
+			continue
+		}
+		a := int64(rng.Intn(int(max-min+1)) + int(min))
+		b := int64(rng.Intn(int(max-min+1)) + int(min))
+		cc := int64(rng.Intn(int(max-min+1)) + int(min))
+		if a == 0 && b == 0 && cc == 0 {
+			continue
+		}
+		V := NewMatrixInt(4, 4)
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 3; j++ {
+				V.A[i][j] = U.A[i][j]
 			}
-			return V, nil
-		}
-		return nil, errors.New("rank334_last_dep: failed")
-	case "diagonalizable_2x2":
-		// 反向生成：随机选择 2 个互异整数特征值 λ₁,λ₂ 和 2×2 可逆矩阵 V（det=±1），
-		// 计算 A = V Λ V⁻¹，要求 A 为整数矩阵且不是对角阵/标量阵。
-		// 返回 A 并将 eigenvalues/eigenvectors 写入 inst.Vars。
-		return genMatrixDiagonalizable2x2(rng, v, inst)
-	case "eigen_reverse_3x3":
-		// 反向生成：随机选择 3 个互异整数特征值 λ₁,λ₂,λ₃ 和 3 个整数特征向量 v₁,v₂,v₃，
-		// 计算 A = V Λ V⁻¹，要求 A 为整数矩阵（V⁻¹ 各元为整数）。
-		// 返回 A 并将 eigenvalues/eigenvectors 写入 inst.Vars。
-		return genMatrixEigenReverse3x3(rng, v, inst)
-	case "symmetric_eigen_reverse_3x3":
-		// 对称矩阵反向生成：随机选择 3 个互异整数特征值 λ₁,λ₂,λ₃，
-		// 用带符号置换正交矩阵 Q（det=±1，Qᵀ=Q⁻¹）构造 S = QΛQᵀ（整数对称矩阵）。
-		// 返回 S 并将特征值和正交矩阵 Q 写入 inst.Vars。
-		return genMatrixSymmetricEigenReverse3x3(rng, v, inst)
-	case "similarity_congruence_pair":
-		// 生成 3×3 对称阵 A 和对角阵 B，并判断它们是否相似/合同。
-		// 策略：约一半概率"相似且合同"，约一半概率"不相似但合同"或"不相似不合同"。
-		// 将 is_similar、is_congruent 写入 inst.Vars。
-		return genMatrixSimilarityCongruencePair(rng, v, inst)
-	case "sylvester_range":
-		// 生成含参数 t 的 3×3 对称阵，使正定二次型 t 的取值范围为有限开区间 (a,b)。
-		// 将 lower, upper 写入 inst.Vars。
-		return genMatrixSylvesterRange(rng, v, inst)
-	case "param_orthogonal_diag":
-		// 生成含参数 t 的 3×3 对称阵 S=QΛQᵀ（S[3][3]=t），给出标准型系数，
-		// 学生由 trace 条件推断 t，并求正交变换矩阵 Q。
-		return genMatrixParamOrthogonalDiag(rng, v, inst)
-	case "poly_schmidt_integral":
-		// 生成 3 个多项式（常/线/二次），在积分内积 ∫₋₁¹ f·g dx 下做 Schmidt 正交化。
-		// 存储 _poly_schmidt_input/output 在 inst.Vars。
-		return genPolySchmidtIntegral(rng, v, inst)
-	case "param_infinit_solution":
-		return genMatrixParamInfinitSolution(rng, v, inst)
-	default:
-		return nil, fmt.Errorf("unsupported matrix generator rule: %s", g)
+			V.A[i][3] = a*U.A[i][0] + b*U.A[i][1] + cc*U.A[i][2]
+		}
+		if matrixRankRat(V) != 3 {
+			continue
+		}
+		return V, nil
+	}
+	return nil, errors.New("rank334_last_dep: failed")
+}
+
+// 反向生成：随机选择 n 个互异整数特征值和 det=1 的可逆矩阵 V，
+// 计算 A = V Λ V⁻¹，要求 A 为整数矩阵且不是对角阵/标量阵。
+// diagonalizable_2x2 固定 n=2；diagonalizable 取 rows（2～6）。
+// 返回 A，并发布附带输出 lambdas、P、P_inv、Lambda。
+func genMatrixDiagonalizable2x2(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	return genMatrixDiagonalizable(rng, v, inst, params, out, 2, "diagonalizable_2x2")
+}
+
+func genMatrixDiagonalizableN(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n, err := squareOrder(v, "diagonalizable")
+	if err != nil {
+		return nil, err
 	}
+	return genMatrixDiagonalizable(rng, v, inst, params, out, n, "diagonalizable")
+}
+
+// 反向生成：随机选择 n 个互异非零整数特征值和整数幺模矩阵 V（列为特征向量），
+// 计算 A = V Λ V⁻¹（V⁻¹ 各元为整数）。eigen_reverse_3x3 固定 n=3；eigen_reverse 取 rows（2～6）。
+// 返回 A，并发布附带输出 lambdas、P、P_inv、Lambda。
+func genMatrixEigenReverse3x3(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	return genMatrixEigenReverse(rng, v, inst, params, out, 3, "eigen_reverse_3x3")
+}
+
+func genMatrixEigenReverseN(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n, err := squareOrder(v, "eigen_reverse")
+	if err != nil {
+		return nil, err
+	}
+	return genMatrixEigenReverse(rng, v, inst, params, out, n, "eigen_reverse")
+}
+
+// 对称矩阵反向生成：随机选择 n 个互异整数特征值，
+// 用带符号置换正交矩阵 Q（det=±1，Qᵀ=Q⁻¹）构造 S = QΛQᵀ（整数对称矩阵）。
+// symmetric_eigen_reverse_3x3 要求 3×3；symmetric_eigen_reverse 取 rows（2～6）。
+// 返回 S，并发布附带输出 lambdas、Q、Lambda。
+func genMatrixSymmetricEigenReverse3x3(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	if v.Rows != 3 || v.Cols != 3 {
+		return nil, errors.New("symmetric_eigen_reverse_3x3: need 3×3")
+	}
+	return genMatrixSymmetricEigenReverse(rng, v, inst, params, out, 3, "symmetric_eigen_reverse_3x3")
+}
+
+func genMatrixSymmetricEigenReverseN(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	n, err := squareOrder(v, "symmetric_eigen_reverse")
+	if err != nil {
+		return nil, err
+	}
+	return genMatrixSymmetricEigenReverse(rng, v, inst, params, out, n, "symmetric_eigen_reverse")
 }
 
 // genMatrixLambdaLinearDetZero
@@ -436,6 +611,7 @@ func genMatrix(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error)
 //
 // 其它元素为整数随机数。通过 det(A(λ))=0 求解 λ，保证 λ 为给定区间内的整数，
 // 并将解写入 inst.Vars[paramVar]，返回的是在该 λ 下的数值矩阵 A(λ)。
+// λ 与其位置另作附带输出发布：A.lambda、A.lambda_row、A.lambda_col（1-based）、A.lambda_const（c）。
 //
 // 配置项（写在 Variable.Generator 里）：
 //
@@ -448,42 +624,39 @@ func genMatrix(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error)
 //	lambda_min: int     // λ 取值下界，默认 -10
 //	lambda_max: int     // 上界，默认 10
 //	max_attempts: int   // 最大重试次数，默认 200
-func genMatrixLambdaLinearDetZero(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
+func genMatrixLambdaLinearDetZero(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 {
 		return nil, errors.New("lambda_linear_det_zero: matrix rows/cols required")
 	}
 
-	cfg := v.Generator
-	paramVar, _ := cfg["param_var"].(string)
-	if paramVar == "" {
-		paramVar = "lambda"
-	}
-	paramRow := defaultInt(cfg, "param_row", r)
-	paramCol := defaultInt(cfg, "param_col", c)
+	paramVar := params.String("param_var", "lambda")
+	paramRow := params.Int("param_row", r)
+	paramCol := params.Int("param_col", c)
 	if paramRow < 1 || paramRow > r || paramCol < 1 || paramCol > c {
 		return nil, errors.New("lambda_linear_det_zero: param_row/param_col out of range")
 	}
 
-	entryMin := defaultInt(cfg, "entry_min", -5)
-	entryMax := defaultInt(cfg, "entry_max", 5)
+	entryMin := params.Int("entry_min", -5)
+	entryMax := params.Int("entry_max", 5)
 	if entryMax < entryMin {
 		entryMin, entryMax = entryMax, entryMin
 	}
 
-	lambdaMin := defaultInt(cfg, "lambda_min", -10)
-	lambdaMax := defaultInt(cfg, "lambda_max", 10)
+	lambdaMin := params.Int("lambda_min", -10)
+	lambdaMax := params.Int("lambda_max", 10)
 	if lambdaMax < lambdaMin {
 		lambdaMin, lambdaMax = lambdaMax, lambdaMin
 	}
 
-	maxAttempts := defaultInt(cfg, "max_attempts", 200)
+	maxAttempts := params.Int("max_attempts", 200)
 	if maxAttempts <= 0 {
 		maxAttempts = 200
 	}
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		inst.noteAttempt(maxAttempts)
 		base := NewMatrixInt(r, c)
 		// 随机填充除参数位置外的元素
 		for i := 0; i < r; i++ {
@@ -556,23 +729,24 @@ func genMatrixLambdaLinearDetZero(rng *rand.Rand, v Variable, inst *Instance) (i
 
 		// 将 λ 写入实例，供答案与渲染使用
 		inst.Vars[paramVar] = lambdaVal
-		inst.Vars["_lambda_param_row"] = int64(paramRow)
-		inst.Vars["_lambda_param_col"] = int64(paramCol)
-		inst.Vars["_lambda_param_constC"] = constC
+		out.Set("lambda", lambdaVal)
+		out.Set("lambda_row", int64(paramRow))
+		out.Set("lambda_col", int64(paramCol))
+		out.Set("lambda_const", constC)
 		return A, nil
 	}
 
 	return nil, errors.New("lambda_linear_det_zero: failed to generate suitable matrix after attempts")
 }
 
-func genMatrixUpperUnit(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixUpperUnit(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 || r != c {
 		return nil, errors.New("upper_unit: need square n×n")
 	}
-	min := int64(defaultInt(v.Generator, "min", -5))
-	max := int64(defaultInt(v.Generator, "max", 5))
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
 	if max < min {
 		min, max = max, min
 	}
@@ -591,14 +765,14 @@ func genMatrixUpperUnit(rng *rand.Rand, v Variable) (interface{}, error) {
 	return m, nil
 }
 
-func genMatrixLowerUnit(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixLowerUnit(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 || r != c {
 		return nil, errors.New("lower_unit: need square n×n")
 	}
-	min := int64(defaultInt(v.Generator, "min", -5))
-	max := int64(defaultInt(v.Generator, "max", 5))
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
 	if max < min {
 		min, max = max, min
 	}
@@ -617,11 +791,10 @@ func genMatrixLowerUnit(rng *rand.Rand, v Variable) (interface{}, error) {
 	return m, nil
 }
 
-func genMatrixEquidiagonal(rng *rand.Rand, v Variable) (interface{}, error) {
-	cfg := v.Generator
+func genMatrixEquidiagonal(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	n := v.Rows
 	if n == 0 {
-		n = defaultInt(cfg, "n", 3)
+		n = params.Int("n", 3)
 	}
 	if v.Cols != 0 && v.Cols != n {
 		return nil, errors.New("equidiagonal: rows must equal cols")
@@ -629,10 +802,10 @@ func genMatrixEquidiagonal(rng *rand.Rand, v Variable) (interface{}, error) {
 	if n <= 0 {
 		return nil, errors.New("equidiagonal: n must be positive")
 	}
-	dmin := int64(defaultInt(cfg, "diag_min", -3))
-	dmax := int64(defaultInt(cfg, "diag_max", 3))
-	omin := int64(defaultInt(cfg, "off_min", -5))
-	omax := int64(defaultInt(cfg, "off_max", 5))
+	dmin := int64(params.Int("diag_min", -3))
+	dmax := int64(params.Int("diag_max", 3))
+	omin := int64(params.Int("off_min", -5))
+	omax := int64(params.Int("off_max", 5))
 	if dmax < dmin {
 		dmin, dmax = dmax, dmin
 	}
@@ -640,6 +813,7 @@ func genMatrixEquidiagonal(rng *rand.Rand, v Variable) (interface{}, error) {
 		omin, omax = omax, omin
 	}
 	for attempt := 0; attempt < 200; attempt++ {
+		inst.noteAttempt(200)
 		diag := int64(rng.Intn(int(dmax-dmin+1)) + int(dmin))
 		off := int64(rng.Intn(int(omax-omin+1)) + int(omin))
 		if diag == off {
@@ -660,18 +834,19 @@ func genMatrixEquidiagonal(rng *rand.Rand, v Variable) (interface{}, error) {
 	return nil, errors.New("equidiagonal: failed to pick diag!=off")
 }
 
-func genMatrixUpperTriangular(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixUpperTriangular(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 || r != c {
 		return nil, errors.New("upper_triangular: need square n×n")
 	}
-	min := int64(defaultInt(v.Generator, "min", -6))
-	max := int64(defaultInt(v.Generator, "max", 6))
+	min := int64(params.Int("min", -6))
+	max := int64(params.Int("max", 6))
 	if max < min {
 		min, max = max, min
 	}
 	for attempt := 0; attempt < 100; attempt++ {
+		inst.noteAttempt(100)
 		m := NewMatrixInt(r, c)
 		for i := 0; i < r; i++ {
 			for j := 0; j < c; j++ {
@@ -694,14 +869,14 @@ func genMatrixUpperTriangular(rng *rand.Rand, v Variable) (interface{}, error) {
 	return nil, errors.New("upper_triangular: failed non-singular")
 }
 
-func genMatrixUpperTriangularNonzeroDiag(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixUpperTriangularNonzeroDiag(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 || r != c {
 		return nil, errors.New("upper_triangular_nonzero_diag: need square n×n")
 	}
-	min := int64(defaultInt(v.Generator, "min", -4))
-	max := int64(defaultInt(v.Generator, "max", 4))
+	min := int64(params.Int("min", -4))
+	max := int64(params.Int("max", 4))
 	if max < min {
 		min, max = max, min
 	}
@@ -718,6 +893,7 @@ func genMatrixUpperTriangularNonzeroDiag(rng *rand.Rand, v Variable) (interface{
 		diagChoices = []int64{-3, -2, 2, 3}
 	}
 	for attempt := 0; attempt < 100; attempt++ {
+		inst.noteAttempt(100)
 		m := NewMatrixInt(r, c)
 		for i := 0; i < r; i++ {
 			for j := 0; j < c; j++ {
@@ -736,18 +912,19 @@ func genMatrixUpperTriangularNonzeroDiag(rng *rand.Rand, v Variable) (interface{
 	}
 	return nil, errors.New("upper_triangular_nonzero_diag: failed non-singular")
 }
-func genMatrixDiagonalDistinct(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixDiagonalDistinct(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r == 0 || c == 0 || r != c {
 		return nil, errors.New("diagonal_distinct: need square n×n")
 	}
-	min := int64(defaultInt(v.Generator, "min", -6))
-	max := int64(defaultInt(v.Generator, "max", 6))
+	min := int64(params.Int("min", -6))
+	max := int64(params.Int("max", 6))
 	if max <= min {
 		return nil, errors.New("diagonal_distinct: need min < max")
 	}
 	for attempt := 0; attempt < 300; attempt++ {
+		inst.noteAttempt(300)
 		m := NewMatrixInt(r, c)
 		used := map[int64]struct{}{}
 		ok := true
@@ -786,14 +963,14 @@ func genMatrixDiagonalDistinct(rng *rand.Rand, v Variable) (interface{}, error)
 	return nil, errors.New("diagonal_distinct: failed")
 }
 
-func genMatrixRank3Coin(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixRank3Coin(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r != 3 || c != 3 {
 		return nil, errors.New("rank3_coin: need 3×3")
 	}
-	min := int64(defaultInt(v.Generator, "min", -5))
-	max := int64(defaultInt(v.Generator, "max", 5))
+	min := int64(params.Int("min", -5))
+	max := int64(params.Int("max", 5))
 	if max < min {
 		min, max = max, min
 	}
@@ -807,6 +984,7 @@ func genMatrixRank3Coin(rng *rand.Rand, v Variable) (interface{}, error) {
 		return m, nil
 	}
 	for attempt := 0; attempt < 120; attempt++ {
+		inst.noteAttempt(120)
 		m := NewMatrixInt(3, 3)
 		for i := 0; i < 3; i++ {
 			for j := 0; j < 3; j++ {
@@ -820,13 +998,13 @@ func genMatrixRank3Coin(rng *rand.Rand, v Variable) (interface{}, error) {
 	return nil, errors.New("rank3_coin: failed full rank")
 }
 
-func genMatrixSymmetric(rng *rand.Rand, v Variable) (interface{}, error) {
+func genMatrixSymmetric(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	n := v.Rows
 	if n == 0 || n != v.Cols {
 		return nil, errors.New("symmetric: need n×n square")
 	}
-	min := int64(defaultInt(v.Generator, "min", -6))
-	max := int64(defaultInt(v.Generator, "max", 6))
+	min := int64(params.Int("min", -6))
+	max := int64(params.Int("max", 6))
 	if max < min {
 		min, max = max, min
 	}
@@ -905,83 +1083,143 @@ func defaultFloat(m map[string]interface{}, key string, def float64) float64 {
 	return def
 }
 
-func genMatrixDiagonalizable2x2(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	// Pick 2 distinct nonzero eigenvalues
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -5))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 5))
+// squareOrder 读取 n×n 规则的阶数，要求 2 ≤ n ≤ 6。
+func squareOrder(v Variable, rule string) (int, error) {
+	if v.Rows != v.Cols || v.Rows < 2 || v.Rows > 6 {
+		return 0, fmt.Errorf("%s: need n×n with 2 ≤ n ≤ 6, got %d×%d", rule, v.Rows, v.Cols)
+	}
+	return v.Rows, nil
+}
+
+// pickDistinctNonzero 从 [lmin,lmax] 依次抽取 n 个互异非零整数，每个位置最多试 80 次。
+func pickDistinctNonzero(r Rand, n int, lmin, lmax int64) ([]int64, bool) {
+	lambdas := make([]int64, n)
+	used := map[int64]bool{}
+	for i := 0; i < n; i++ {
+		found := false
+		for t := 0; t < 80; t++ {
+			l := int64(r.Intn(int(lmax-lmin+1)) + int(lmin))
+			if l == 0 || used[l] {
+				continue
+			}
+			used[l] = true
+			lambdas[i] = l
+			found = true
+			break
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return lambdas, true
+}
+
+// randomSignedPerm 生成 n 阶随机带符号置换矩阵（正交、det=±1，元素仅为 -1,0,1）。
+func randomSignedPerm(r Rand, n int) *MatrixInt {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	r.Shuffle(n, func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+	m := NewMatrixInt(n, n)
+	for j := 0; j < n; j++ {
+		sign := int64(1)
+		if r.Intn(2) == 0 {
+			sign = -1
+		}
+		m.A[perm[j]][j] = sign
+	}
+	return m
+}
+
+func diagInt(d []int64) *MatrixInt {
+	m := NewMatrixInt(len(d), len(d))
+	for i, x := range d {
+		m.A[i][i] = x
+	}
+	return m
+}
+
+func maxAbsEntry(m *MatrixInt) int64 {
+	mx := int64(0)
+	for i := 0; i < m.R; i++ {
+		for j := 0; j < m.C; j++ {
+			x := m.A[i][j]
+			if x < 0 {
+				x = -x
+			}
+			if x > mx {
+				mx = x
+			}
+		}
+	}
+	return mx
+}
+
+// storeEigenData 发布 A = PΛP⁻¹ 的特征数据，供 A.lambdas、eigenval、eigenvec_comp 读取。
+func storeEigenData(out GeneratorOutputs, V, Vinv *MatrixInt, lambdas []int64) {
+	out.Set("lambdas", intVector(lambdas))
+	out.Set("P", V)
+	out.Set("P_inv", Vinv)
+	out.Set("Lambda", diagInt(lambdas))
+}
+
+// intVector 把整数切片包装为向量（复制）。
+func intVector(xs []int64) *VectorInt {
+	return &VectorInt{N: len(xs), V: append([]int64(nil), xs...)}
+}
+
+// genMatrixDiagonalizable 反向生成 n 阶可对角化整数矩阵 A = VΛV⁻¹：
+// Λ 为 n 个互异非零整数特征值，V = U·L（U、L 分别为单位上、下二对角阵，det V = 1），
+// 要求 A 不是对角阵且元素绝对值不超过 max_entry（默认 15）。
+func genMatrixDiagonalizable(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs, n int, rule string) (interface{}, error) {
+	lmin := int64(params.Int("lambda_min", -5))
+	lmax := int64(params.Int("lambda_max", 5))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
-	emin := int64(defaultInt(v.Generator, "entry_min", -3))
-	emax := int64(defaultInt(v.Generator, "entry_max", 3))
+	// n ≥ 4 时 V⁻¹ 的元素增长较快，默认把 U、L 的元素范围收窄到 [-2,2]
+	e := 3
+	if n >= 4 {
+		e = 2
+	}
+	emin := int64(params.Int("entry_min", -e))
+	emax := int64(params.Int("entry_max", e))
 	if emax < emin {
 		emin, emax = emax, emin
 	}
+	maxEntry := int64(params.Int("max_entry", 15))
 
 	for attempt := 0; attempt < 500; attempt++ {
+		inst.noteAttempt(500)
 		// Use independent RNG for each attempt to avoid exhaustion
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		attemptRng := inst.subRand(rng)
 
-		// Pick 2 distinct nonzero eigenvalues
-		lambdas := make([]int64, 2)
-		used := map[int64]bool{}
-		ok := true
-		for i := 0; i < 2; i++ {
-			found := false
-			for t := 0; t < 80; t++ {
-				l := int64(attemptRng.Intn(int(lmax-lmin+1)) + int(lmin))
-				if l == 0 || used[l] {
-					continue
-				}
-				used[l] = true
-				lambdas[i] = l
-				found = true
-				break
-			}
-			if !found {
-				ok = false
-				break
-			}
-		}
+		lambdas, ok := pickDistinctNonzero(attemptRng, n, lmin, lmax)
 		if !ok {
 			continue
 		}
 
-		// Generate V with det=±1: compose upper_unit × lower_unit
-		// This guarantees det(V) = 1 without needing to check
-		U := NewMatrixInt(2, 2)
-		U.A[0][0] = 1
-		U.A[1][1] = 1
-		U.A[1][0] = 0
-		U.A[0][1] = int64(attemptRng.Intn(int(emax-emin+1)) + int(emin))
-
-		L := NewMatrixInt(2, 2)
-		L.A[0][0] = 1
-		L.A[1][1] = 1
-		L.A[0][1] = 0
-		L.A[1][0] = int64(attemptRng.Intn(int(emax-emin+1)) + int(emin))
-
 		// V = U × L, det(V) = det(U)×det(L) = 1×1 = 1
+		U := identityInt(n)
+		for i := 0; i+1 < n; i++ {
+			U.A[i][i+1] = int64(attemptRng.Intn(int(emax-emin+1)) + int(emin))
+		}
+		L := identityInt(n)
+		for i := 1; i < n; i++ {
+			L.A[i][i-1] = int64(attemptRng.Intn(int(emax-emin+1)) + int(emin))
+		}
 		V, err := matrixMulInt(U, L)
 		if err != nil {
 			continue
 		}
-
-		// Compute V⁻¹
 		Vinv, err := MatrixInverseInt(V)
 		if err != nil {
 			continue
 		}
 
-		// Construct Λ = diag(λ₁, λ₂)
-		Lambda := NewMatrixInt(2, 2)
-		for i := 0; i < 2; i++ {
-			Lambda.A[i][i] = lambdas[i]
-		}
-
 		// A = V Λ V⁻¹
-		VL, err := matrixMulInt(V, Lambda)
+		VL, err := matrixMulInt(V, diagInt(lambdas))
 		if err != nil {
 			continue
 		}
@@ -989,56 +1227,39 @@ func genMatrixDiagonalizable2x2(rng *rand.Rand, v Variable, inst *Instance) (int
 		if err != nil {
 			continue
 		}
-
-		// Verify A has integer entries within reasonable bounds
-		maxEntry := int64(0)
-		for i := 0; i < 2; i++ {
-			for j := 0; j < 2; j++ {
-				abs := A.A[i][j]
-				if abs < 0 {
-					abs = -abs
-				}
-				if abs > maxEntry {
-					maxEntry = abs
-				}
-			}
-		}
-		if maxEntry > 15 {
+		if maxAbsEntry(A) > maxEntry {
 			continue
 		}
-
 		// Reject if A is diagonal or scalar matrix (would be trivial)
-		if A.A[0][1] == 0 && A.A[1][0] == 0 {
+		if isDiagonalInt(A) {
 			continue
 		}
 
-		// Store eigenvalues and eigenvectors for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_eigen_V"] = V
-		inst.Vars["_eigen_lambda1"] = lambdas[0]
-		inst.Vars["_eigen_lambda2"] = lambdas[1]
-
+		storeEigenData(out, V, Vinv, lambdas)
 		return A, nil
 	}
-	return nil, errors.New("diagonalizable_2x2: failed")
+	return nil, fmt.Errorf("%s: failed", rule)
 }
 
-// randomUnimodular3x3FromElementary 从单位阵经若干次「行_i += k·行_j」得到 det=1 的整数幺模阵。
+func identityInt(n int) *MatrixInt {
+	m := NewMatrixInt(n, n)
+	for i := 0; i < n; i++ {
+		m.A[i][i] = 1
+	}
+	return m
+}
+
+// randomUnimodularFromElementary 从单位阵经若干次「行_i += k·行_j」得到 det=1 的 n 阶整数幺模阵。
 // 纯随机填元几乎不可能满足 |det|=1，故用初等阵乘积构造。
-func randomUnimodular3x3FromElementary(r *rand.Rand, vMax int64) *MatrixInt {
+func randomUnimodularFromElementary(r Rand, n int, vMax int64) *MatrixInt {
 	for t := 0; t < 80; t++ {
-		V := NewMatrixInt(3, 3)
-		for i := 0; i < 3; i++ {
-			V.A[i][i] = 1
-		}
+		V := identityInt(n)
 		steps := 4 + r.Intn(6)
 		for s := 0; s < steps; s++ {
-			i := r.Intn(3)
-			j := r.Intn(3)
+			i := r.Intn(n)
+			j := r.Intn(n)
 			for j == i {
-				j = r.Intn(3)
+				j = r.Intn(n)
 			}
 			k := int64(1)
 			if r.Intn(2) == 0 {
@@ -1047,23 +1268,11 @@ func randomUnimodular3x3FromElementary(r *rand.Rand, vMax int64) *MatrixInt {
 			if r.Intn(4) == 0 {
 				k *= 2
 			}
-			for c := 0; c < 3; c++ {
+			for c := 0; c < n; c++ {
 				V.A[i][c] += k * V.A[j][c]
 			}
 		}
-		maxV := int64(0)
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				x := V.A[i][j]
-				if x < 0 {
-					x = -x
-				}
-				if x > maxV {
-					maxV = x
-				}
-			}
-		}
-		if maxV > vMax {
+		if maxAbsEntry(V) > vMax {
 			continue
 		}
 		detV := BareissDet(V)
@@ -1078,65 +1287,39 @@ func randomUnimodular3x3FromElementary(r *rand.Rand, vMax int64) *MatrixInt {
 	return nil
 }
 
-func genMatrixEigenReverse3x3(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	// Choose 3 distinct nonzero eigenvalues
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -5))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 5))
+// genMatrixEigenReverse 反向生成 n 阶整数矩阵 A = VΛV⁻¹：Λ 为 n 个互异非零整数特征值，
+// V 为幺模矩阵（列为整数特征向量，V⁻¹ 亦为整数），要求 A 的元素绝对值不超过 max_entry（默认 15）。
+func genMatrixEigenReverse(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs, n int, rule string) (interface{}, error) {
+	lmin := int64(params.Int("lambda_min", -5))
+	lmax := int64(params.Int("lambda_max", 5))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
+	vEntryMax := int64(params.Int("v_entry_max", 12))
+	maxEntry := int64(params.Int("max_entry", 15))
 
 	for attempt := 0; attempt < 500; attempt++ {
+		inst.noteAttempt(500)
 		// Use independent RNG for each attempt to avoid exhaustion
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		attemptRng := inst.subRand(rng)
 
-		// Pick 3 distinct nonzero eigenvalues
-		lambdas := make([]int64, 3)
-		used := map[int64]bool{}
-		ok := true
-		for i := 0; i < 3; i++ {
-			found := false
-			for t := 0; t < 80; t++ {
-				l := int64(attemptRng.Intn(int(lmax-lmin+1)) + int(lmin))
-				if l == 0 || used[l] {
-					continue
-				}
-				used[l] = true
-				lambdas[i] = l
-				found = true
-				break
-			}
-			if !found {
-				ok = false
-				break
-			}
-		}
+		lambdas, ok := pickDistinctNonzero(attemptRng, n, lmin, lmax)
 		if !ok {
 			continue
 		}
 
 		// 列向量为特征向量：需 det(V)=±1 才有整数 V⁻¹。随机小整数几乎不满足，用初等变换从 I 生成幺模 V。
-		vEntryMax := int64(defaultInt(v.Generator, "v_entry_max", 12))
-		V := randomUnimodular3x3FromElementary(attemptRng, vEntryMax)
+		V := randomUnimodularFromElementary(attemptRng, n, vEntryMax)
 		if V == nil {
 			continue
 		}
-
-		// Compute V⁻¹
 		Vinv, err := MatrixInverseInt(V)
 		if err != nil {
 			continue
 		}
 
-		// Construct Λ = diag(λ₁, λ₂, λ₃)
-		Lambda := NewMatrixInt(3, 3)
-		for i := 0; i < 3; i++ {
-			Lambda.A[i][i] = lambdas[i]
-		}
-
 		// A = V Λ V⁻¹
-		VL, err := matrixMulInt(V, Lambda)
+		VL, err := matrixMulInt(V, diagInt(lambdas))
 		if err != nil {
 			continue
 		}
@@ -1144,159 +1327,58 @@ func genMatrixEigenReverse3x3(rng *rand.Rand, v Variable, inst *Instance) (inter
 		if err != nil {
 			continue
 		}
-
-		// Verify A entries are reasonable size
-		maxEntry := int64(0)
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if A.A[i][j] > maxEntry {
-					maxEntry = A.A[i][j]
-				}
-				if -A.A[i][j] > maxEntry {
-					maxEntry = -A.A[i][j]
-				}
-			}
-		}
-		if maxEntry > 15 {
+		if maxAbsEntry(A) > maxEntry {
 			continue
 		}
 
-		// Store eigenvalues and eigenvectors for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_eigen_V"] = V
-		inst.Vars["_eigen_Vinv"] = Vinv
-		inst.Vars["_eigen_Lambda"] = Lambda
-		inst.Vars["_eigen_lambda1"] = lambdas[0]
-		inst.Vars["_eigen_lambda2"] = lambdas[1]
-		inst.Vars["_eigen_lambda3"] = lambdas[2]
-
+		storeEigenData(out, V, Vinv, lambdas)
 		return A, nil
 	}
-	return nil, errors.New("eigen_reverse_3x3: failed")
+	return nil, fmt.Errorf("%s: failed", rule)
 }
 
-// genMatrixSymmetricEigenReverse3x3 生成 3×3 整数对称矩阵 S = Q Λ Qᵀ，
-// 其中 Λ = diag(λ₁,λ₂,λ₃) 是随机互异非零整数特征值，
+// genMatrixSymmetricEigenReverse 生成 n 阶整数对称矩阵 S = Q Λ Qᵀ，
+// 其中 Λ = diag(λ₁,…,λₙ) 是随机互异非零整数特征值，
 // Q 是行列式 ±1 的带符号置换正交矩阵（保证 S 为整数且对称）。
-// 将 λ₁,λ₂,λ₃、Q、Λ 写入 inst.Vars 供答案提取。
-func genMatrixSymmetricEigenReverse3x3(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	r := v.Rows
-	c := v.Cols
-	if r != 3 || c != 3 {
-		return nil, errors.New("symmetric_eigen_reverse_3x3: need 3×3")
-	}
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -5))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 5))
+// 特征值与 Q、Λ 作为附带输出发布，供答案提取。
+func genMatrixSymmetricEigenReverse(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs, n int, rule string) (interface{}, error) {
+	lmin := int64(params.Int("lambda_min", -5))
+	lmax := int64(params.Int("lambda_max", 5))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
-	maxEntry := int64(defaultInt(v.Generator, "max_entry", 15))
+	maxEntry := int64(params.Int("max_entry", 15))
 
 	for attempt := 0; attempt < 500; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
 
-		// Pick 3 distinct nonzero eigenvalues
-		lambdas := make([]int64, 3)
-		used := map[int64]bool{}
-		ok := true
-		for i := 0; i < 3; i++ {
-			found := false
-			for t := 0; t < 80; t++ {
-				l := int64(attemptRng.Intn(int(lmax-lmin+1)) + int(lmin))
-				if l == 0 || used[l] {
-					continue
-				}
-				used[l] = true
-				lambdas[i] = l
-				found = true
-				break
-			}
-			if !found {
-				ok = false
-				break
-			}
-		}
+		lambdas, ok := pickDistinctNonzero(attemptRng, n, lmin, lmax)
 		if !ok {
 			continue
 		}
-
-		// Generate a signed permutation orthogonal matrix Q (det = ±1)
-		perm := []int{0, 1, 2}
-		attemptRng.Shuffle(3, func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
-		Q := NewMatrixInt(3, 3)
-		for j := 0; j < 3; j++ {
-			sign := int64(1)
-			if attemptRng.Intn(2) == 0 {
-				sign = -1
-			}
-			Q.A[perm[j]][j] = sign
-		}
-
-		// Λ = diag(λ₁, λ₂, λ₃)
-		Lambda := NewMatrixInt(3, 3)
-		for i := 0; i < 3; i++ {
-			Lambda.A[i][i] = lambdas[i]
-		}
+		Q := randomSignedPerm(attemptRng, n)
+		Lambda := diagInt(lambdas)
 
 		// S = Q Λ Qᵀ（Qᵀ = Q⁻¹ for signed-perm matrices）
-		// Compute QΛ first, then (QΛ)·Qᵀ
 		QLambda, err := matrixMulInt(Q, Lambda)
 		if err != nil {
 			continue
 		}
-		// Qᵀ: transpose of Q (since Q is signed-perm, Qᵀ = Q⁻¹)
-		Qt := NewMatrixInt(3, 3)
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				Qt.A[i][j] = Q.A[j][i]
-			}
-		}
-		S, err := matrixMulInt(QLambda, Qt)
+		S, err := matrixMulInt(QLambda, transposeInt(Q))
 		if err != nil {
 			continue
 		}
-
-		// Verify S is symmetric
-		for i := 0; i < 3; i++ {
-			for j := i + 1; j < 3; j++ {
-				if S.A[i][j] != S.A[j][i] {
-					continue // shouldn't happen but just in case
-				}
-			}
-		}
-
-		// Check entries are reasonable
-		me := int64(0)
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if S.A[i][j] > me {
-					me = S.A[i][j]
-				}
-				if -S.A[i][j] > me {
-					me = -S.A[i][j]
-				}
-			}
-		}
-		if me > maxEntry {
+		if maxAbsEntry(S) > maxEntry {
 			continue
 		}
 
-		// Store eigenvalues and Q for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_sym_eigen_Q"] = Q
-		inst.Vars["_sym_eigen_Lambda"] = Lambda
-		inst.Vars["_sym_eigen_lambda1"] = lambdas[0]
-		inst.Vars["_sym_eigen_lambda2"] = lambdas[1]
-		inst.Vars["_sym_eigen_lambda3"] = lambdas[2]
-
+		out.Set("lambdas", intVector(lambdas))
+		out.Set("Q", Q)
+		out.Set("Lambda", Lambda)
 		return S, nil
 	}
-	return nil, errors.New("symmetric_eigen_reverse_3x3: failed")
+	return nil, fmt.Errorf("%s: failed", rule)
 }
 
 // genMatrixSimilarityCongruencePair 生成 3×3 对称阵 A 和对角阵 B，
@@ -1306,22 +1388,23 @@ func genMatrixSymmetricEigenReverse3x3(rng *rand.Rand, v Variable, inst *Instanc
 //   - 约 1/3 概率"不相似但合同"（B 与 A 惯性指数相同但特征值不同）
 //   - 约 1/3 概率"既不相似也不合同"（B 与 A 惯性指数不同）
 //
-// 将 is_similar、is_congruent (int64, 0 或 1) 写入 inst.Vars。
+// 将 B 与 is_similar、is_congruent（int64，0 或 1）作为附带输出发布。
 // 返回 A（对称矩阵），B 通过 derived 生成。
-func genMatrixSimilarityCongruencePair(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
+func genMatrixSimilarityCongruencePair(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r != 3 || c != 3 {
 		return nil, errors.New("similarity_congruence_pair: need 3×3")
 	}
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -6))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 6))
+	lmin := int64(params.Int("lambda_min", -6))
+	lmax := int64(params.Int("lambda_max", 6))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
-	maxEntry := int64(defaultInt(v.Generator, "max_entry", 15))
+	maxEntry := int64(params.Int("max_entry", 15))
 
 	for attempt := 0; attempt < 300; attempt++ {
+		inst.noteAttempt(300)
 		// Pick 3 distinct nonzero eigenvalues for A
 		lambdas := make([]int64, 3)
 		used := map[int64]bool{}
@@ -1531,13 +1614,9 @@ func genMatrixSimilarityCongruencePair(rng *rand.Rand, v Variable, inst *Instanc
 		}
 
 		// Store for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_sc_A"] = A
-		inst.Vars["_sc_B"] = B
-		inst.Vars["_sc_is_similar"] = isSimilar
-		inst.Vars["_sc_is_congruent"] = isCongruent
+		out.Set("B", B)
+		out.Set("is_similar", isSimilar)
+		out.Set("is_congruent", isCongruent)
 
 		return A, nil
 	}
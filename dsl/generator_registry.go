@@ -0,0 +1,266 @@
+package dsl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GeneratorFunc 按 Variable.Generator 生成变量值。
+// inst 为已生成到当前变量为止的实例（按变量名字典序），生成器可读取其中的变量，
+// 也可把附带结果（如 scalar_identity 的 λ）写入 inst.Vars。
+// 构造过程中的中间量（特征值、变换矩阵、参数位置等）写入 out，生成成功后挂在该变量名下，
+// 表达式中以 A.key 引用；out 中的键须先用 DeclareGeneratorOutputs 声明。
+// 须只从 rng 取随机数，保证同一种子下结果可复现。
+type GeneratorFunc func(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error)
+
+// GeneratorOutputs 为生成器发布的附带输出（键 → 值），值须为表达式可用的类型
+// （int64、*big.Int、*big.Rat、*VectorInt、*MatrixInt 等）。
+type GeneratorOutputs map[string]interface{}
+
+// Set 写入一个附带输出；生成器重试时后写入者覆盖先写入者。
+func (o GeneratorOutputs) Set(key string, v interface{}) {
+	o[key] = v
+}
+
+// Output 读取变量 name 的生成器附带输出 key（表达式 name.key 的值）。
+func (inst *Instance) Output(name, key string) (interface{}, error) {
+	out, ok := inst.Outputs[name]
+	if !ok {
+		if _, isVar := inst.Vars[name]; !isVar {
+			return nil, fmt.Errorf("unknown variable %s", name)
+		}
+		return nil, fmt.Errorf("variable %s has no generator outputs", name)
+	}
+	v, ok := out[key]
+	if !ok {
+		return nil, fmt.Errorf("%s has no output %s", name, key)
+	}
+	return v, nil
+}
+
+// GeneratorOutput 声明生成规则的一个附带输出，Type 为其值类型。
+type GeneratorOutput struct {
+	Name string    `json:"name"`
+	Type ValueType `json:"type"`
+	Doc  string    `json:"doc,omitempty"`
+}
+
+// GeneratorParams 为 Variable.Generator 的类型化只读视图；参数缺失或类型不符时返回默认值。
+type GeneratorParams map[string]interface{}
+
+// Int 读取整数参数（JSON 中的数字为 float64，按整数截断）。
+func (p GeneratorParams) Int(name string, def int) int {
+	return defaultInt(p, name, def)
+}
+
+// Float 读取浮点参数。
+func (p GeneratorParams) Float(name string, def float64) float64 {
+	return defaultFloat(p, name, def)
+}
+
+// String 读取字符串参数，空串视为缺失。
+func (p GeneratorParams) String(name, def string) string {
+	if s, ok := p[name].(string); ok && s != "" {
+		return s
+	}
+	return def
+}
+
+// Ints 读取整数列表参数，缺失时返回 nil。
+func (p GeneratorParams) Ints(name string) []int64 {
+	return interfaceToIntSlice(p[name])
+}
+
+// Has 报告参数是否出现。
+func (p GeneratorParams) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// GeneratorParam 描述生成规则的一个参数。Type 取 "int"、"float"、"string"、"int_list"；
+// Default 为 nil 表示无默认值（或默认值取决于变量尺寸，见 Doc）。
+type GeneratorParam struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Default interface{} `json:"default,omitempty"`
+	Doc     string      `json:"doc,omitempty"`
+}
+
+// GeneratorInfo 为已注册生成规则的说明，由 Generators 返回。
+type GeneratorInfo struct {
+	Kind    string            `json:"kind"`
+	Rule    string            `json:"rule"`
+	Params  []GeneratorParam  `json:"params,omitempty"`
+	Outputs []GeneratorOutput `json:"outputs,omitempty"`
+}
+
+type generatorEntry struct {
+	fn      GeneratorFunc
+	params  []GeneratorParam
+	outputs []GeneratorOutput
+}
+
+// generators 按 kind（scalar/vector/matrix）、rule 索引生成规则。
+// 与 exprFuncs 相同不加锁：注册应在 init 中完成，之后只读。
+var generators = map[string]map[string]generatorEntry{}
+
+// RegisterGenerator 为变量类型 kind 注册生成规则 rule，params 为参数说明（供 Generators、ProblemJSONSchema 列出），
+// ValidateProblem 据此拒绝未声明的参数与类型不符的值。
+// 同名规则后注册者覆盖先注册者，可用于替换内置规则。应在 init 中调用。
+func RegisterGenerator(kind, rule string, fn GeneratorFunc, params ...GeneratorParam) {
+	switch kind {
+	case "scalar", "vector", "matrix":
+	default:
+		panic(fmt.Sprintf("RegisterGenerator: unknown kind %q", kind))
+	}
+	if rule == "" || fn == nil {
+		panic("RegisterGenerator: rule and fn required")
+	}
+	if generators[kind] == nil {
+		generators[kind] = map[string]generatorEntry{}
+	}
+	generators[kind][rule] = generatorEntry{fn: fn, params: params}
+}
+
+// DeclareGeneratorOutputs 声明已注册规则的附带输出。未声明的键在生成时报错，
+// ValidateProblem 也据此检查 A.key 引用与其类型。应在 RegisterGenerator 之后、同一 init 中调用。
+func DeclareGeneratorOutputs(kind, rule string, outs ...GeneratorOutput) {
+	e, ok := generators[kind][rule]
+	if !ok {
+		panic(fmt.Sprintf("DeclareGeneratorOutputs: %s rule %q not registered", kind, rule))
+	}
+	for _, o := range outs {
+		if !isIdentName(o.Name) {
+			panic(fmt.Sprintf("DeclareGeneratorOutputs: bad output name %q", o.Name))
+		}
+		if _, err := parseValueType(string(o.Type)); err != nil {
+			panic(fmt.Sprintf("DeclareGeneratorOutputs: %s: %v", o.Name, err))
+		}
+	}
+	e.outputs = append(e.outputs, outs...)
+	generators[kind][rule] = e
+}
+
+// output 查找规则声明的附带输出。
+func (e generatorEntry) output(name string) (GeneratorOutput, bool) {
+	for _, o := range e.outputs {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return GeneratorOutput{}, false
+}
+
+// checkOutputs 检查生成器写入的附带输出均已声明且类型相符。
+func (e generatorEntry) checkOutputs(out GeneratorOutputs) error {
+	keys := make([]string, 0, len(out))
+	for k := range out {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o, ok := e.output(key)
+		if !ok {
+			return fmt.Errorf("undeclared output %q", key)
+		}
+		if s := valueShape(out[key]); s.kind == shapeUnknown || !o.Type.accepts(s) {
+			return fmt.Errorf("output %q is %T, want %s", key, out[key], o.Type)
+		}
+	}
+	return nil
+}
+
+func outputDecl(name string, t ValueType, doc string) GeneratorOutput {
+	return GeneratorOutput{Name: name, Type: t, Doc: doc}
+}
+
+// Generators 列出全部已注册的生成规则，按 kind、rule 排序。
+func Generators() []GeneratorInfo {
+	var out []GeneratorInfo
+	for kind, rules := range generators {
+		for rule, e := range rules {
+			out = append(out, GeneratorInfo{
+				Kind:    kind,
+				Rule:    rule,
+				Params:  append([]GeneratorParam(nil), e.params...),
+				Outputs: append([]GeneratorOutput(nil), e.outputs...),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
+
+// lookupGenerator 查找 kind 下的生成规则。
+func lookupGenerator(kind, rule string) (generatorEntry, bool) {
+	e, ok := generators[kind][rule]
+	return e, ok
+}
+
+func intParam(name string, def int, doc string) GeneratorParam {
+	return GeneratorParam{Name: name, Type: "int", Default: def, Doc: doc}
+}
+
+func rangeParams(min, max int) []GeneratorParam {
+	return []GeneratorParam{
+		intParam("min", min, "元素下界（含）"),
+		intParam("max", max, "元素上界（含）"),
+	}
+}
+
+func lambdaParams(min, max int) []GeneratorParam {
+	return []GeneratorParam{
+		intParam("lambda_min", min, "特征值下界（含）"),
+		intParam("lambda_max", max, "特征值上界（含）"),
+	}
+}
+
+var setParam = GeneratorParam{Name: "set", Type: "int_list", Doc: "候选取值，须非空"}
+
+// 以下为按类型读取附带输出的辅助方法，fname 为出错时报告的函数名。
+
+func (o GeneratorOutputs) lookup(fname, key string) (interface{}, error) {
+	v, ok := o[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: output %s not found", fname, key)
+	}
+	return v, nil
+}
+
+func (o GeneratorOutputs) int64Value(fname, key string) (int64, error) {
+	v, err := o.lookup(fname, key)
+	if err != nil {
+		return 0, err
+	}
+	if x, ok := v.(int64); ok {
+		return x, nil
+	}
+	return 0, fmt.Errorf("%s: output %s is %T, want int64", fname, key, v)
+}
+
+func (o GeneratorOutputs) vectorValue(fname, key string) (*VectorInt, error) {
+	v, err := o.lookup(fname, key)
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := v.(*VectorInt); ok {
+		return x, nil
+	}
+	return nil, fmt.Errorf("%s: output %s is %T, want vector", fname, key, v)
+}
+
+func (o GeneratorOutputs) matrixValue(fname, key string) (*MatrixInt, error) {
+	v, err := o.lookup(fname, key)
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := v.(*MatrixInt); ok {
+		return x, nil
+	}
+	return nil, fmt.Errorf("%s: output %s is %T, want matrix", fname, key, v)
+}
@@ -0,0 +1,173 @@
+package dsl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// generationTrace 记录一次出题（含约束重抽）中每次 generateVariable 的尝试次数与结果，
+// 仅在 SampleGeneratorStats 中启用；正常出题时 Instance.trace 为 nil，不做任何记录。
+type generationTrace struct {
+	calls              []generatorCall
+	constraintAttempts int // 约束重抽的实例化次数
+	constraintLimit    int
+	constraintFailed   bool // 尝试用尽仍有约束不成立
+
+	attempts, limit int // 当前 generateVariable 调用内的计数
+}
+
+type generatorCall struct {
+	name, kind, rule string
+	attempts, limit  int
+	err              error
+}
+
+// noteAttempt 由带重试循环的生成规则在每次尝试开始时调用，limit 为该循环的尝试上限。
+func (inst *Instance) noteAttempt(limit int) {
+	if tr := inst.trace; tr != nil {
+		tr.attempts++
+		tr.limit = limit
+	}
+}
+
+// begin 在调用生成函数前清零当前计数。
+func (tr *generationTrace) begin() {
+	tr.attempts, tr.limit = 0, 0
+}
+
+// end 记录一次生成结果；没有重试循环的规则记为 1 次尝试。
+func (tr *generationTrace) end(name string, v Variable, rule string, err error) {
+	n := tr.attempts
+	if n == 0 {
+		n = 1
+	}
+	tr.calls = append(tr.calls, generatorCall{name: name, kind: v.Kind, rule: rule, attempts: n, limit: tr.limit, err: err})
+}
+
+// AttemptStats 为一类带尝试上限的随机过程（生成规则或约束重抽）在多个种子上的统计。
+type AttemptStats struct {
+	Calls         int `json:"calls"`           // 调用次数（约束重抽时一个种子可能调用多次）
+	Failures      int `json:"failures"`        // 返回错误的次数（含尝试用尽）
+	Attempts      int `json:"attempts"`        // 尝试总数
+	WorstAttempts int `json:"worst_attempts"`  // 单次调用用到的最多尝试数
+	Limit         int `json:"limit,omitempty"` // 尝试上限，0 表示没有重试循环
+	// AcceptanceRate 为成功次数 / 尝试总数，即单次尝试被接受的概率估计
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	// FailureRate 为 Failures / Calls
+	FailureRate float64 `json:"failure_rate"`
+	// ExhaustionProb 按 AcceptanceRate 估计的尝试用尽概率 (1-p)^Limit；没有重试循环时为 0
+	ExhaustionProb float64 `json:"exhaustion_prob"`
+}
+
+func (s *AttemptStats) add(attempts, limit int, failed bool) {
+	s.Calls++
+	s.Attempts += attempts
+	if attempts > s.WorstAttempts {
+		s.WorstAttempts = attempts
+	}
+	if limit > s.Limit {
+		s.Limit = limit
+	}
+	if failed {
+		s.Failures++
+	}
+}
+
+func (s *AttemptStats) finish() {
+	if s.Calls == 0 {
+		return
+	}
+	s.FailureRate = float64(s.Failures) / float64(s.Calls)
+	if s.Attempts > 0 {
+		s.AcceptanceRate = float64(s.Calls-s.Failures) / float64(s.Attempts)
+	}
+	if s.Limit > 0 {
+		s.ExhaustionProb = math.Pow(1-s.AcceptanceRate, float64(s.Limit))
+	}
+}
+
+// VariableGeneratorStats 为单个变量的生成统计。
+type VariableGeneratorStats struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Rule string `json:"rule"`
+	AttemptStats
+}
+
+// GeneratorStats 为 SampleGeneratorStats 的结果。
+type GeneratorStats struct {
+	Seeds       int     `json:"seeds"`
+	Failures    int     `json:"failures"` // 出题失败的种子数
+	FailureRate float64 `json:"failure_rate"`
+	// Errors 为出题失败的错误信息及其出现次数
+	Errors map[string]int `json:"errors,omitempty"`
+	// Constraints 为约束重抽的统计，题目没有 Constraints 时为 nil
+	Constraints *AttemptStats            `json:"constraints,omitempty"`
+	Variables   []VariableGeneratorStats `json:"variables"` // 按变量名排序
+}
+
+// WorstExhaustionProb 返回各变量与约束重抽中最大的 ExhaustionProb。
+func (s *GeneratorStats) WorstExhaustionProb() float64 {
+	worst := 0.0
+	if s.Constraints != nil {
+		worst = s.Constraints.ExhaustionProb
+	}
+	for _, v := range s.Variables {
+		worst = math.Max(worst, v.ExhaustionProb)
+	}
+	return worst
+}
+
+// SampleGeneratorStats 用种子 "stats-0" … "stats-(n-1)" 实例化题目 p，统计每个变量的生成尝试次数、
+// 接受率与失败率，以及约束重抽的情况，用于发布前找出偶尔会出题失败的题目。
+func SampleGeneratorStats(p Problem, nSeeds int) *GeneratorStats {
+	return compileLenient(p).SampleGeneratorStats(nSeeds)
+}
+
+// SampleGeneratorStats 同 dsl.SampleGeneratorStats，使用已编译的题目。
+func (cp *CompiledProblem) SampleGeneratorStats(nSeeds int) *GeneratorStats {
+	out := &GeneratorStats{Seeds: nSeeds}
+	vars := map[string]*VariableGeneratorStats{}
+	var constraints AttemptStats
+	for i := 0; i < nSeeds; i++ {
+		tr := &generationTrace{}
+		if _, err := cp.instantiate(fmt.Sprintf("stats-%d", i), "", tr); err != nil {
+			out.Failures++
+			if out.Errors == nil {
+				out.Errors = map[string]int{}
+			}
+			out.Errors[err.Error()]++
+		}
+		for _, c := range tr.calls {
+			vs := vars[c.name]
+			if vs == nil {
+				vs = &VariableGeneratorStats{Name: c.name, Kind: c.kind, Rule: c.rule}
+				vars[c.name] = vs
+			}
+			vs.add(c.attempts, c.limit, c.err != nil)
+		}
+		if tr.constraintAttempts > 0 {
+			constraints.add(tr.constraintAttempts, tr.constraintLimit, tr.constraintFailed)
+		}
+	}
+	if nSeeds > 0 {
+		out.FailureRate = float64(out.Failures) / float64(nSeeds)
+	}
+	if constraints.Calls > 0 {
+		constraints.finish()
+		out.Constraints = &constraints
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out.Variables = make([]VariableGeneratorStats, 0, len(names))
+	for _, name := range names {
+		vs := vars[name]
+		vs.finish()
+		out.Variables = append(out.Variables, *vs)
+	}
+	return out
+}
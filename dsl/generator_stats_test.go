@@ -0,0 +1,89 @@
+package dsl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSampleGeneratorStats_attempts(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			// 元素只取 0/1 的 3×3 满秩矩阵，单次尝试的接受率明显小于 1
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "full_rank", "min": 0, "max": 1}},
+			"k": {Kind: "scalar", Generator: map[string]interface{}{"rule": "range", "min": 1, "max": 9}},
+		},
+		Answer: AnswerSchema{Expression: "det(A) + k"},
+	}
+	st := SampleGeneratorStats(p, 50)
+	if st.Seeds != 50 || st.Failures != 0 || st.Constraints != nil {
+		t.Fatalf("%+v", st)
+	}
+	if len(st.Variables) != 2 || st.Variables[0].Name != "A" || st.Variables[1].Name != "k" {
+		t.Fatalf("variables %+v", st.Variables)
+	}
+	a, k := st.Variables[0], st.Variables[1]
+	if a.Rule != "full_rank" || a.Calls != 50 || a.Limit != 200 || a.Attempts <= 50 || a.WorstAttempts < 2 {
+		t.Fatalf("A %+v", a)
+	}
+	if a.AcceptanceRate <= 0 || a.AcceptanceRate >= 1 || a.ExhaustionProb >= 1e-6 {
+		t.Fatalf("A %+v", a)
+	}
+	if k.Attempts != 50 || k.Limit != 0 || k.AcceptanceRate != 1 || k.ExhaustionProb != 0 {
+		t.Fatalf("k %+v", k)
+	}
+
+	// 正常出题不记录统计
+	inst, err := InstantiateProblem(p, "stats-3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.trace != nil {
+		t.Fatal("trace leaked into normal instance")
+	}
+}
+
+func TestSampleGeneratorStats_failures(t *testing.T) {
+	RegisterGenerator("scalar", "test_flaky", func(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+		for attempt := 0; attempt < 3; attempt++ {
+			inst.noteAttempt(3)
+			if rng.Intn(4) == 0 {
+				return int64(1), nil
+			}
+		}
+		return nil, fmt.Errorf("test_flaky: exhausted")
+	})
+	defer delete(generators["scalar"], "test_flaky")
+
+	p := Problem{
+		Variables: map[string]Variable{"x": {Kind: "scalar", Generator: map[string]interface{}{"rule": "test_flaky"}}},
+		Answer:    AnswerSchema{Expression: "x"},
+	}
+	st := SampleGeneratorStats(p, 200)
+	x := st.Variables[0]
+	if st.Failures == 0 || st.Failures != x.Failures || x.Calls != 200 || x.WorstAttempts != 3 || x.Limit != 3 {
+		t.Fatalf("%+v %+v", st, x)
+	}
+	if len(st.Errors) != 1 || st.FailureRate != float64(st.Failures)/200 {
+		t.Fatalf("errors %v rate %v", st.Errors, st.FailureRate)
+	}
+	// 真实接受率 1/4，用尽概率 (3/4)^3 ≈ 0.42
+	if x.ExhaustionProb < 0.25 || x.ExhaustionProb > 0.6 {
+		t.Fatalf("exhaustion %v", x.ExhaustionProb)
+	}
+}
+
+func TestSampleGeneratorStats_constraints(t *testing.T) {
+	p := constraintTestProblem("det(A) != 0", "d > 0")
+	st := SampleGeneratorStats(p, 30)
+	c := st.Constraints
+	if c == nil || st.Failures != 0 {
+		t.Fatalf("%+v", st)
+	}
+	if c.Calls != 30 || c.Limit != DefaultMaxAttempts || c.Attempts <= 30 || c.AcceptanceRate >= 1 {
+		t.Fatalf("constraints %+v", c)
+	}
+	// 约束重抽时每次实例化都会重新生成变量
+	if st.Variables[0].Calls != c.Attempts {
+		t.Fatalf("A calls %d, constraint attempts %d", st.Variables[0].Calls, c.Attempts)
+	}
+}
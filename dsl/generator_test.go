@@ -0,0 +1,250 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func instantiateMatrix(t *testing.T, n int, gen map[string]interface{}, seed string) (*MatrixInt, *Instance) {
+	t.Helper()
+	p := Problem{
+		Variables: map[string]Variable{"A": {Kind: "matrix", Rows: n, Cols: n, Generator: gen}},
+		Answer:    AnswerSchema{Expression: "det(A)"},
+	}
+	inst, err := InstantiateProblem(p, seed, "salt")
+	if err != nil {
+		t.Fatalf("%v n=%d seed=%s: %v", gen["rule"], n, seed, err)
+	}
+	return inst.Vars["A"].(*MatrixInt), inst
+}
+
+func mustMul(t *testing.T, a, b *MatrixInt) *MatrixInt {
+	t.Helper()
+	m, err := matrixMulInt(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func equalInt(a, b *MatrixInt) bool {
+	if a.R != b.R || a.C != b.C {
+		return false
+	}
+	for i := range a.A {
+		for j := range a.A[i] {
+			if a.A[i][j] != b.A[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestGenerator_eigenRulesNxN(t *testing.T) {
+	for n := 2; n <= 6; n++ {
+		for _, rule := range []string{"diagonalizable", "eigen_reverse"} {
+			for s := 0; s < 20; s++ {
+				A, inst := instantiateMatrix(t, n, map[string]interface{}{"rule": rule}, fmt.Sprint("s", s))
+				V := inst.Outputs["A"]["P"].(*MatrixInt)
+				Lambda := inst.Outputs["A"]["Lambda"].(*MatrixInt)
+				lambdas := inst.Outputs["A"]["lambdas"].(*VectorInt)
+				if !equalInt(mustMul(t, A, V), mustMul(t, V, Lambda)) {
+					t.Fatalf("%s n=%d: AV != VΛ", rule, n)
+				}
+				if maxAbsEntry(A) > 15 {
+					t.Fatalf("%s n=%d: entry too large %v", rule, n, A.A)
+				}
+				seen := map[int64]bool{}
+				for i := 1; i <= n; i++ {
+					l := lambdas.V[i-1]
+					if l == 0 || seen[l] || Lambda.A[i-1][i-1] != l {
+						t.Fatalf("%s n=%d: eigenvalues %v", rule, n, Lambda.A)
+					}
+					seen[l] = true
+				}
+			}
+		}
+	}
+}
+
+func TestGenerator_symmetricEigenReverseNxN(t *testing.T) {
+	for n := 2; n <= 6; n++ {
+		for s := 0; s < 20; s++ {
+			S, inst := instantiateMatrix(t, n, map[string]interface{}{"rule": "symmetric_eigen_reverse"}, fmt.Sprint("s", s))
+			Q := inst.Outputs["A"]["Q"].(*MatrixInt)
+			Lambda := inst.Outputs["A"]["Lambda"].(*MatrixInt)
+			if !equalInt(S, transposeInt(S)) {
+				t.Fatalf("n=%d: S not symmetric", n)
+			}
+			if !equalInt(mustMul(t, transposeInt(Q), Q), identityInt(n)) {
+				t.Fatalf("n=%d: Q not orthogonal", n)
+			}
+			if !equalInt(mustMul(t, S, Q), mustMul(t, Q, Lambda)) {
+				t.Fatalf("n=%d: SQ != QΛ", n)
+			}
+		}
+	}
+}
+
+func TestGenerator_orthogonalSignedPermNxN(t *testing.T) {
+	for n := 2; n <= 6; n++ {
+		P, _ := instantiateMatrix(t, n, map[string]interface{}{"rule": "orthogonal_signed_perm"}, "s")
+		if !equalInt(mustMul(t, transposeInt(P), P), identityInt(n)) {
+			t.Fatalf("n=%d: not orthogonal", n)
+		}
+	}
+}
+
+func TestGenerator_squareOrderBounds(t *testing.T) {
+	for _, dims := range [][2]int{{1, 1}, {7, 7}, {3, 4}} {
+		p := Problem{
+			Variables: map[string]Variable{"A": {Kind: "matrix", Rows: dims[0], Cols: dims[1], Generator: map[string]interface{}{"rule": "eigen_reverse"}}},
+			Answer:    AnswerSchema{Expression: "det(A)"},
+		}
+		if _, err := InstantiateProblem(p, "s", "salt"); err == nil {
+			t.Fatalf("%d×%d: expect err", dims[0], dims[1])
+		}
+	}
+}
+
+func TestRegisterGenerator_custom(t *testing.T) {
+	RegisterGenerator("vector", "test_arith", func(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+		// 首项取自已生成的 a，公差取自参数
+		a := inst.Vars["a"].(int64)
+		vec := NewVectorInt(v.Size)
+		for i := range vec.V {
+			vec.V[i] = a + int64(i*params.Int("step", 1))
+		}
+		return vec, nil
+	}, GeneratorParam{Name: "step", Type: "int", Default: 1})
+	defer delete(generators["vector"], "test_arith")
+
+	p := Problem{
+		Variables: map[string]Variable{
+			"a": {Kind: "scalar", Generator: map[string]interface{}{"rule": "range", "min": 1, "max": 9}},
+			"v": {Kind: "vector", Size: 3, Generator: map[string]interface{}{"rule": "test_arith", "step": float64(2)}},
+		},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{{ID: "v", Expr: "v", Judge: &AnswerJudgeSpec{Kind: "vector"}}}},
+	}
+	if ds := ValidateProblem(p); HasErrors(ds) {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	inst, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := inst.Vars["a"].(int64)
+	if got := inst.Vars["v"].(*VectorInt).V; got[0] != a || got[1] != a+2 || got[2] != a+4 {
+		t.Fatalf("a=%d v=%v", a, got)
+	}
+}
+
+func TestGeneratorOutputs_refs(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "lambda_linear_det_zero"}},
+		},
+		Render: map[string]string{"pos": "A.lambda_row * 10 + A.lambda_col"},
+		Answer: AnswerSchema{Expression: "A.lambda"},
+	}
+	if ds := ValidateProblem(p); HasErrors(ds) {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	inst, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range inst.Vars {
+		if name != "A" && name != "lambda" {
+			t.Fatalf("side output leaked into Vars: %s", name)
+		}
+	}
+	r, err := RenderInst(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r["pos"] != int64(33) {
+		t.Fatalf("pos = %v, want 33", r["pos"])
+	}
+	ans, err := ExtractAnswer(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ans != inst.Vars["lambda"] {
+		t.Fatalf("A.lambda = %v, lambda = %v", ans, inst.Vars["lambda"])
+	}
+	if _, err := EvaluateExpression("A.P", inst); err == nil || !strings.Contains(err.Error(), "A has no output P") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestGeneratorOutputs_undeclared(t *testing.T) {
+	RegisterGenerator("scalar", "test_outputs", func(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+		out.Set("half", int64(21))
+		if params.Has("extra") {
+			out.Set("extra", int64(1))
+		}
+		return int64(42), nil
+	}, GeneratorParam{Name: "extra", Type: "int"})
+	DeclareGeneratorOutputs("scalar", "test_outputs", GeneratorOutput{Name: "half", Type: TypeInt})
+	defer delete(generators["scalar"], "test_outputs")
+
+	p := Problem{
+		Variables: map[string]Variable{"a": {Kind: "scalar", Generator: map[string]interface{}{"rule": "test_outputs"}}},
+		Answer:    AnswerSchema{Expression: "a - a.half"},
+	}
+	inst, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ExtractAnswer(p, inst); got != int64(21) {
+		t.Fatalf("a - a.half = %v", got)
+	}
+	p.Variables["a"].Generator["extra"] = 1
+	if _, err := InstantiateProblem(p, "s", "salt"); err == nil || !strings.Contains(err.Error(), `undeclared output "extra"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestGenerators_list(t *testing.T) {
+	infos := Generators()
+	for i := 1; i < len(infos); i++ {
+		a, b := infos[i-1], infos[i]
+		if a.Kind > b.Kind || (a.Kind == b.Kind && a.Rule >= b.Rule) {
+			t.Fatalf("not sorted: %s/%s before %s/%s", a.Kind, a.Rule, b.Kind, b.Rule)
+		}
+	}
+	found := false
+	for _, info := range infos {
+		if info.Kind == "matrix" && info.Rule == "lambda_linear_det_zero" {
+			found = true
+			names := map[string]bool{}
+			for _, p := range info.Params {
+				names[p.Name] = true
+			}
+			for _, n := range []string{"param_var", "entry_min", "entry_max", "lambda_min", "lambda_max"} {
+				if !names[n] {
+					t.Fatalf("lambda_linear_det_zero: param %s undocumented", n)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Fatal("lambda_linear_det_zero not listed")
+	}
+}
+
+func TestGenerator_unknownRule(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{"A": {Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "no_such_rule"}}},
+		Answer:    AnswerSchema{Expression: "det(A)"},
+	}
+	if _, err := InstantiateProblem(p, "s", "salt"); err == nil || !strings.Contains(err.Error(), "unsupported matrix generator rule: no_such_rule") {
+		t.Fatalf("err %v", err)
+	}
+	if got := diagStrings(ValidateProblem(p)); got != `error variables.A: unknown matrix generator rule "no_such_rule"` {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
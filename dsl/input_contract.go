@@ -1,5 +1,7 @@
 package dsl
 
+import "strings"
+
 // AnswerInputConventionV1 与 JudgeGeneratedQuestion / ParseUserRational / NormalizeUserAnswer 对齐的约定标识。
 const AnswerInputConventionV1 = "la-dsl.scalar_rational.v1"
 
@@ -28,6 +30,182 @@ func AnswerInputContractV1() AnswerInputContractDoc {
 	}
 }
 
+// 扩展约定：各自在 V1 之上只增加一类空，客户端按 InputConventionsOf 逐项确认支持后再出该题。
+const (
+	// AnswerInputConventionV2 增加整矩阵空（judge kind "matrix"）。
+	AnswerInputConventionV2 = "la-dsl.rational_matrix.v2"
+	// AnswerInputConventionVector 增加整向量空：judge kind "vector"、独占一组的 rational_line / affine_rational 向量空、
+	// 不设 span_dim 的 span_equal / general_solution 向量空。
+	AnswerInputConventionVector = "la-dsl.rational_vector.v1"
+	// AnswerInputConventionSurd 增加含根号的空（标准答案为 *Surd）。
+	AnswerInputConventionSurd = "la-dsl.surd.v1"
+	// AnswerInputConventionInterval 增加区间空（judge kind "interval"）。
+	AnswerInputConventionInterval = "la-dsl.interval.v1"
+)
+
+// extendContractV1 在 V1 说明之上追加一类空的写法，得到扩展约定的完整说明；标量空的规则同 V1。
+func extendContractV1(ext AnswerInputContractDoc) AnswerInputContractDoc {
+	d := AnswerInputContractV1()
+	d.ID = ext.ID
+	d.Summary = "标量空同 V1：单个有理数，算术相等即判对。" + ext.Summary
+	d.AcceptedFormats = append(d.AcceptedFormats, ext.AcceptedFormats...)
+	d.RejectedFormats = append(d.RejectedFormats, ext.RejectedFormats...)
+	d.Normalization = append(d.Normalization, ext.Normalization...)
+	d.JudgeImplementation += "；" + ext.JudgeImplementation
+	return d
+}
+
+// AnswerInputContractV2 为 AnswerInputConventionV2 的输入规范说明：标量空同 V1，另列整矩阵空的写法。
+func AnswerInputContractV2() AnswerInputContractDoc {
+	return extendContractV1(AnswerInputContractDoc{
+		ID:      AnswerInputConventionV2,
+		Summary: "整矩阵空（题目中 judge 为 matrix）一空填写整个矩阵，逐元素按有理数比较，全部相等判对，判分结果的 cells 给出逐格对错。",
+		AcceptedFormats: []string{
+			"整矩阵空，嵌套数组：[[1,2],[3,4]]、[[1, -1/2], [0, 3]]",
+			"整矩阵空，分号分行：1 2; 3 4、[1 2; 3 4]、[1 2]; [3 4]（同行元素以空格或逗号分隔，换行等同分号）",
+			"整矩阵空，LaTeX：\\begin{bmatrix}1&2\\\\3&4\\end{bmatrix}（也接受 pmatrix、Bmatrix、matrix，元素可写 \\frac{1}{2}）",
+		},
+		RejectedFormats:     []string{"整矩阵空：各行元素个数不一致、元素为算式（如 1+1）、vmatrix（行列式记号）"},
+		Normalization:       []string{"整矩阵空中逗号（含全角 ，）与分号（含全角 ；）是分隔符，不删除；全角方括号统一为 [ ]"},
+		JudgeImplementation: "MatrixAnswersEqual + big.Rat",
+	})
+}
+
+// AnswerInputContractVector 为 AnswerInputConventionVector 的输入规范说明。
+func AnswerInputContractVector() AnswerInputContractDoc {
+	return extendContractV1(AnswerInputContractDoc{
+		ID: AnswerInputConventionVector,
+		Summary: "整向量空一空填写整个向量：judge 为 vector 时逐分量比较（cells 的 row 为分量下标），为 rational_line / affine_rational 时按共线或 Ax=b 判分，" +
+			"为 span_equal 时同组各空合起来须是参考子空间的一组基，为 general_solution 时同组各空依次为特解与基础解系。",
+		AcceptedFormats:     []string{"整向量空：(1, -2, 3/4)、[1,-2,3/4]、1 -2 3/4、（1，−2，3/4）、1、-2、3/4，可带转置记号 (1,2,3)^T；也可写成单行或单列矩阵"},
+		RejectedFormats:     []string{"整向量空：多行多列的矩阵、分量个数与标准答案不同"},
+		Normalization:       []string{"整向量空中逗号（含全角 ， 与顿号 、）与分号（含全角 ；）是分隔符，不删除；全角方括号统一为 [ ]"},
+		JudgeImplementation: "VectorAnswersEqual + big.Rat",
+	})
+}
+
+// AnswerInputContractSurd 为 AnswerInputConventionSurd 的输入规范说明。
+func AnswerInputContractSurd() AnswerInputContractDoc {
+	return extendContractV1(AnswerInputContractDoc{
+		ID:                  AnswerInputConventionSurd,
+		Summary:             "标准答案含根号的空（如单位向量的分量）可填根式，按 ℚ(√d) 精确比较；V1 中拒收根号与 LaTeX 的规则只对有理数标量空成立。",
+		AcceptedFormats:     []string{"含根号的空：1/sqrt(2)、√3/3、sqrt(6)/6、2√3、(1+√5)/2、1/(√3-√2)、\\frac{\\sqrt{6}}{6}（可用 + - * / ^ 与括号，相邻因子可省略乘号）"},
+		RejectedFormats:     []string{"含根号的空：小数近似值（如 0.7071，判为不相等）、立方根等非平方根、被开方数为负"},
+		JudgeImplementation: "SurdAnswersEqual + Surd",
+	})
+}
+
+// AnswerInputContractInterval 为 AnswerInputConventionInterval 的输入规范说明。
+func AnswerInputContractInterval() AnswerInputContractDoc {
+	return extendContractV1(AnswerInputContractDoc{
+		ID:      AnswerInputConventionInterval,
+		Summary: "区间空（judge 为 interval）填写参数的取值范围，按集合相等判分，端点开闭须一致。",
+		AcceptedFormats: []string{
+			"区间空，区间记号：(-1/2, 3)、[0, 1)、(-∞, 1)、(2, +inf)、(-\\infty, \\frac{1}{2}]，多段用 ∪、U、\\cup 或「或」连接",
+			"区间空，不等式：t>2、t≥-1、-1<t<4/3、t<0 或 t>1，可带前缀 t∈；单点 {1}、空集 ∅、全体实数 R",
+		},
+		RejectedFormats:     []string{"区间空：∞ 一侧写成闭端点（如 [2, +∞]）、左端点大于右端点、各段变量名不一致"},
+		JudgeImplementation: "IntervalAnswersEqual + IntervalSet",
+	})
+}
+
+// AnswerInputContracts 按 V1、整矩阵、整向量、根式、区间的顺序返回全部输入约定的说明，上层可一次下发或缓存。
+func AnswerInputContracts() []AnswerInputContractDoc {
+	return []AnswerInputContractDoc{
+		AnswerInputContractV1(), AnswerInputContractV2(), AnswerInputContractVector(),
+		AnswerInputContractSurd(), AnswerInputContractInterval(),
+	}
+}
+
+// InputConventionsOf 返回题目所需的全部输入约定，首项总是 V1，其后依次为用到的整矩阵、整向量、根式、区间约定；
+// 客户端须支持列出的每一项才可出该题。
+// rational_line / affine_rational 组内只有一个空、span_equal / general_solution 不设 span_dim 时视为整向量空（见 AnswerJudgeSpec）；
+// 答案表达式（含其引用的派生变量）调用 sqrt 或 norm 时视为可能含根号。
+func InputConventionsOf(p Problem) []string {
+	groupSize := map[string]int{}
+	for _, fd := range p.Answer.FieldDefs {
+		groupSize[vectorGroupKey(fd.Judge)]++
+	}
+	var matrix, vector, interval bool
+	for _, fd := range p.Answer.FieldDefs {
+		if fd.Judge == nil {
+			continue
+		}
+		switch fd.Judge.Kind {
+		case "matrix":
+			matrix = true
+		case "vector":
+			vector = true
+		case "interval":
+			interval = true
+		case "span_equal", "general_solution":
+			vector = vector || fd.Judge.SpanDim == 0
+		}
+		if key := vectorGroupKey(fd.Judge); key != "" && groupSize[key] == 1 {
+			vector = true
+		}
+	}
+	srcs := append([]string{p.Answer.Expression}, p.Answer.Fields...)
+	for _, fd := range p.Answer.FieldDefs {
+		srcs = append(srcs, fd.Expr)
+	}
+	surd := false
+	seen := map[string]bool{}
+	for _, src := range srcs {
+		if src != "" && !surd {
+			surd = exprMayBeSurd(p, src, seen)
+		}
+	}
+	out := []string{AnswerInputConventionV1}
+	for _, c := range []struct {
+		need bool
+		id   string
+	}{
+		{matrix, AnswerInputConventionV2},
+		{vector, AnswerInputConventionVector},
+		{surd, AnswerInputConventionSurd},
+		{interval, AnswerInputConventionInterval},
+	} {
+		if c.need {
+			out = append(out, c.id)
+		}
+	}
+	return out
+}
+
+// InputConventionOf 返回题目的单个输入约定标识：只含有理数标量空时为 V1，只用到一种扩展时为该扩展的标识，
+// 用到多种扩展时为各扩展标识以 "+" 连接（不等于任何单个约定，只认单个标识的客户端会跳过该题）。
+func InputConventionOf(p Problem) string {
+	ids := InputConventionsOf(p)
+	if len(ids) == 1 {
+		return ids[0]
+	}
+	return strings.Join(ids[1:], "+")
+}
+
+// exprMayBeSurd 报告表达式或其引用的派生变量是否调用返回类型含 surd 的函数（如 sqrt、norm）；seen 记录已检查的派生变量。
+func exprMayBeSurd(p Problem, src string, seen map[string]bool) bool {
+	e, err := ParseExpr(src)
+	if err != nil {
+		return false
+	}
+	found := false
+	walkExpr(e.root, func(n exprNode) {
+		switch t := n.(type) {
+		case *callExpr:
+			if sig, ok := FunctionSignature(t.name); ok && sig.Returns.includes(TypeSurd) {
+				found = true
+			}
+		case *identRef:
+			if d, ok := p.Derived[t.name]; ok && !seen[t.name] {
+				seen[t.name] = true
+				found = found || exprMayBeSurd(p, d, seen)
+			}
+		}
+	})
+	return found
+}
+
 // AnswerInputContractDoc 与前端/客户端约定的可序列化说明。
 type AnswerInputContractDoc struct {
 	ID                  string   `json:"id"`
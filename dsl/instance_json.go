@@ -0,0 +1,388 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// 实例快照：Instance 的 JSON 形式，每个值带类型标签，反序列化后与原实例的值类型一致，
+// 可用于存档学生看到的题目、事后重新判分（见 JudgeGeneratedQuestionContext）。
+//
+//	{"problem_id": 7, "seed": "s", "version": "bank-v1", "vars": {"A": {"type": "matrix_int", "rows": 2, "cols": 2, "value": [[1, 2], [3, 4]]}},
+//	 "derived": {"d": {"type": "bigint", "value": "-2"}}, "outputs": {"A": {"lambda": {"type": "int64", "value": 3}}}}
+//
+// Graph 属于题目而非实例，不写入快照；反序列化后为 nil。
+
+// 值的类型标签。
+const (
+	valueTagNull       = "null"
+	valueTagInt        = "int"
+	valueTagInt64      = "int64"
+	valueTagFloat      = "float64"
+	valueTagBool       = "bool"
+	valueTagString     = "string"
+	valueTagBigInt     = "bigint"     // 十进制字符串
+	valueTagRat        = "rat"        // "p/q" 或整数字符串
+	valueTagRatList    = "rat_list"   // []*big.Rat
+	valueTagMatrixInt  = "matrix_int" // 带 rows / cols
+	valueTagVectorInt  = "vector_int"
+	valueTagMatrixRat  = "matrix_rat" // 带 rows / cols，元素为 rat 字符串
+	valueTagVectorRat  = "vector_rat"
+	valueTagPolynomial = "polynomial" // 升幂系数，rat 字符串
+	valueTagSurd       = "surd"       // [{coeff, radicand}]，见 Surd
+	valueTagInterval   = "interval"   // [{lo, hi, lo_closed, hi_closed}]，无穷端点省略 lo / hi
+)
+
+type taggedValue struct {
+	Type  string          `json:"type"`
+	Rows  int             `json:"rows,omitempty"`
+	Cols  int             `json:"cols,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type instanceJSON struct {
+	ProblemID int64                             `json:"problem_id"`
+	Seed      string                            `json:"seed"`
+	Version   string                            `json:"version,omitempty"`
+	Vars      map[string]taggedValue            `json:"vars"`
+	Derived   map[string]taggedValue            `json:"derived"`
+	Outputs   map[string]map[string]taggedValue `json:"outputs,omitempty"`
+}
+
+// MarshalJSON 将实例编码为带类型标签的快照。
+func (inst *Instance) MarshalJSON() ([]byte, error) {
+	out := instanceJSON{ProblemID: inst.ProblemID, Seed: inst.Seed, Version: inst.Version}
+	var err error
+	if out.Vars, err = encodeValues(inst.Vars); err != nil {
+		return nil, fmt.Errorf("instance: vars.%w", err)
+	}
+	if out.Derived, err = encodeValues(inst.Derived); err != nil {
+		return nil, fmt.Errorf("instance: derived.%w", err)
+	}
+	if len(inst.Outputs) > 0 {
+		out.Outputs = make(map[string]map[string]taggedValue, len(inst.Outputs))
+		for name, o := range inst.Outputs {
+			if out.Outputs[name], err = encodeValues(o); err != nil {
+				return nil, fmt.Errorf("instance: outputs.%s.%w", name, err)
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 由 MarshalJSON 产生的快照还原实例；未知类型标签或数据与标签不符时报错。
+func (inst *Instance) UnmarshalJSON(data []byte) error {
+	var in instanceJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("instance: %w", err)
+	}
+	vars, err := decodeValues(in.Vars)
+	if err != nil {
+		return fmt.Errorf("instance: vars.%w", err)
+	}
+	derived, err := decodeValues(in.Derived)
+	if err != nil {
+		return fmt.Errorf("instance: derived.%w", err)
+	}
+	outputs := make(map[string]GeneratorOutputs, len(in.Outputs))
+	for name, o := range in.Outputs {
+		m, err := decodeValues(o)
+		if err != nil {
+			return fmt.Errorf("instance: outputs.%s.%w", name, err)
+		}
+		outputs[name] = m
+	}
+	*inst = Instance{ProblemID: in.ProblemID, Seed: in.Seed, Version: in.Version, Vars: vars, Derived: derived, Outputs: outputs}
+	return nil
+}
+
+func encodeValues(m map[string]interface{}) (map[string]taggedValue, error) {
+	out := make(map[string]taggedValue, len(m))
+	for name, v := range m {
+		tv, err := encodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = tv
+	}
+	return out, nil
+}
+
+func decodeValues(m map[string]taggedValue) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for name, tv := range m {
+		v, err := decodeValue(tv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+func ratStrings(rs []*big.Rat) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.RatString()
+	}
+	return out
+}
+
+func parseRats(ss []string) ([]*big.Rat, error) {
+	out := make([]*big.Rat, len(ss))
+	for i, s := range ss {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("bad rational %q", s)
+		}
+		out[i] = r
+	}
+	return out, nil
+}
+
+func encodeValue(v interface{}) (taggedValue, error) {
+	var tag string
+	var payload interface{}
+	var rows, cols int
+	switch t := v.(type) {
+	case nil:
+		return taggedValue{Type: valueTagNull}, nil
+	case int:
+		tag, payload = valueTagInt, t
+	case int64:
+		tag, payload = valueTagInt64, t
+	case float64:
+		tag, payload = valueTagFloat, t
+	case bool:
+		tag, payload = valueTagBool, t
+	case string:
+		tag, payload = valueTagString, t
+	case *big.Int:
+		tag, payload = valueTagBigInt, t.String()
+	case *big.Rat:
+		tag, payload = valueTagRat, t.RatString()
+	case []*big.Rat:
+		tag, payload = valueTagRatList, ratStrings(t)
+	case *MatrixInt:
+		tag, payload, rows, cols = valueTagMatrixInt, t.A, t.R, t.C
+	case *VectorInt:
+		tag, payload = valueTagVectorInt, t.V
+	case *MatrixRat:
+		cells := make([][]string, t.R)
+		for i := range cells {
+			cells[i] = ratStrings(t.A[i])
+		}
+		tag, payload, rows, cols = valueTagMatrixRat, cells, t.R, t.C
+	case *VectorRat:
+		tag, payload = valueTagVectorRat, ratStrings(t.V)
+	case *Polynomial:
+		tag, payload = valueTagPolynomial, ratStrings(t.Coeffs)
+	case *Surd:
+		terms := make([]surdTermJSON, len(t.Terms))
+		for i, x := range t.Terms {
+			terms[i] = surdTermJSON{Coeff: x.Coeff.RatString(), Radicand: x.Radicand}
+		}
+		tag, payload = valueTagSurd, terms
+	case *IntervalSet:
+		parts := make([]intervalJSON, len(t.Parts))
+		for i, p := range t.Parts {
+			parts[i] = intervalJSON{LoClosed: p.LoClosed, HiClosed: p.HiClosed}
+			if p.Lo != nil {
+				parts[i].Lo = p.Lo.RatString()
+			}
+			if p.Hi != nil {
+				parts[i].Hi = p.Hi.RatString()
+			}
+		}
+		tag, payload = valueTagInterval, parts
+	default:
+		return taggedValue{}, fmt.Errorf("cannot encode %T", v)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return taggedValue{}, err
+	}
+	return taggedValue{Type: tag, Rows: rows, Cols: cols, Value: raw}, nil
+}
+
+func decodeValue(tv taggedValue) (interface{}, error) {
+	into := func(dst interface{}) error {
+		if err := json.Unmarshal(tv.Value, dst); err != nil {
+			return fmt.Errorf("%s: %w", tv.Type, err)
+		}
+		return nil
+	}
+	switch tv.Type {
+	case valueTagNull:
+		return nil, nil
+	case valueTagInt:
+		var n int
+		err := into(&n)
+		return n, err
+	case valueTagInt64:
+		var n int64
+		err := into(&n)
+		return n, err
+	case valueTagFloat:
+		var f float64
+		err := into(&f)
+		return f, err
+	case valueTagBool:
+		var b bool
+		err := into(&b)
+		return b, err
+	case valueTagString:
+		var s string
+		err := into(&s)
+		return s, err
+	case valueTagBigInt, valueTagRat:
+		var s string
+		if err := into(&s); err != nil {
+			return nil, err
+		}
+		if tv.Type == valueTagBigInt {
+			n, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return nil, fmt.Errorf("bad integer %q", s)
+			}
+			return n, nil
+		}
+		rs, err := parseRats([]string{s})
+		if err != nil {
+			return nil, err
+		}
+		return rs[0], nil
+	case valueTagRatList, valueTagVectorRat, valueTagPolynomial:
+		var ss []string
+		if err := into(&ss); err != nil {
+			return nil, err
+		}
+		rs, err := parseRats(ss)
+		if err != nil {
+			return nil, err
+		}
+		switch tv.Type {
+		case valueTagVectorRat:
+			return &VectorRat{N: len(rs), V: rs}, nil
+		case valueTagPolynomial:
+			return NewPolynomial(rs...), nil
+		}
+		return rs, nil
+	case valueTagMatrixInt:
+		// 先按 rows / cols 核对载荷再建矩阵，篡改的快照不能触发 panic 或按声明的尺寸分配
+		var cells [][]int64
+		if tv.Rows > 0 {
+			if err := into(&cells); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkCells(len(cells), tv.Rows, tv.Cols, func(i int) int { return len(cells[i]) }); err != nil {
+			return nil, err
+		}
+		if tv.Rows == 0 {
+			return NewMatrixInt(0, tv.Cols), nil
+		}
+		return &MatrixInt{R: tv.Rows, C: tv.Cols, A: cells}, nil
+	case valueTagVectorInt:
+		var vs []int64
+		if err := into(&vs); err != nil {
+			return nil, err
+		}
+		return &VectorInt{N: len(vs), V: vs}, nil
+	case valueTagMatrixRat:
+		var cells [][]string
+		if tv.Rows > 0 {
+			if err := into(&cells); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkCells(len(cells), tv.Rows, tv.Cols, func(i int) int { return len(cells[i]) }); err != nil {
+			return nil, err
+		}
+		m := NewMatrixRat(tv.Rows, tv.Cols)
+		for i, row := range cells {
+			rs, err := parseRats(row)
+			if err != nil {
+				return nil, err
+			}
+			m.A[i] = rs
+		}
+		return m, nil
+	case valueTagSurd:
+		var terms []surdTermJSON
+		if err := into(&terms); err != nil {
+			return nil, err
+		}
+		st := make([]SurdTerm, len(terms))
+		for i, x := range terms {
+			rs, err := parseRats([]string{x.Coeff})
+			if err != nil {
+				return nil, err
+			}
+			st[i] = SurdTerm{Coeff: rs[0], Radicand: x.Radicand}
+		}
+		return NewSurd(st...)
+	case valueTagInterval:
+		var parts []intervalJSON
+		if err := into(&parts); err != nil {
+			return nil, err
+		}
+		ps := make([]Interval, len(parts))
+		for i, x := range parts {
+			lo, err := parseOptionalRat(x.Lo)
+			if err != nil {
+				return nil, err
+			}
+			hi, err := parseOptionalRat(x.Hi)
+			if err != nil {
+				return nil, err
+			}
+			ps[i] = Interval{Lo: lo, Hi: hi, LoClosed: x.LoClosed, HiClosed: x.HiClosed}
+		}
+		return NewIntervalSet(ps...), nil
+	}
+	return nil, fmt.Errorf("unknown value type %q", tv.Type)
+}
+
+// surdTermJSON 为 Surd 一项的快照形式。
+type surdTermJSON struct {
+	Coeff    string `json:"coeff"`
+	Radicand int64  `json:"radicand"`
+}
+
+// intervalJSON 为 IntervalSet 一段的快照形式；lo / hi 为空表示 ∓∞。
+type intervalJSON struct {
+	Lo       string `json:"lo,omitempty"`
+	Hi       string `json:"hi,omitempty"`
+	LoClosed bool   `json:"lo_closed,omitempty"`
+	HiClosed bool   `json:"hi_closed,omitempty"`
+}
+
+// parseOptionalRat 解析区间端点；空串表示无穷，返回 nil。
+func parseOptionalRat(s string) (*big.Rat, error) {
+	if s == "" {
+		return nil, nil
+	}
+	rs, err := parseRats([]string{s})
+	if err != nil {
+		return nil, err
+	}
+	return rs[0], nil
+}
+
+// checkCells 检查矩阵数据的行数、各行列数与 rows / cols 一致。
+func checkCells(n, rows, cols int, rowLen func(i int) int) error {
+	if rows < 0 || cols < 0 {
+		return fmt.Errorf("bad matrix size %dx%d", rows, cols)
+	}
+	if n != rows {
+		return fmt.Errorf("matrix has %d rows, want %d", n, rows)
+	}
+	for i := 0; i < n; i++ {
+		if rowLen(i) != cols {
+			return fmt.Errorf("matrix row %d has %d cells, want %d", i+1, rowLen(i), cols)
+		}
+	}
+	return nil
+}
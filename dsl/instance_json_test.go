@@ -0,0 +1,136 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestInstanceJSON_roundTrip(t *testing.T) {
+	m := NewMatrixInt(2, 3)
+	m.A[0] = []int64{1, -2, 3}
+	m.A[1] = []int64{4, 5, 9007199254740993} // 超过 float64 精度
+	mr := NewMatrixRat(1, 2)
+	mr.A[0][0].SetFrac64(-1, 3)
+	q, _ := SurdSqrt(big.NewRat(2, 3))
+	inst := &Instance{
+		ProblemID: 7,
+		Version:   "v2",
+		Seed:      "s",
+		Vars: map[string]interface{}{
+			"A": m, "e": NewMatrixInt(0, 0), "v": &VectorInt{N: 2, V: []int64{3, -4}}, "k": int64(-5), "n": 3,
+			"b": true, "s": "\\lambda", "z": nil, "f": 0.5,
+		},
+		Derived: map[string]interface{}{
+			"d": new(big.Int).Lsh(big.NewInt(1), 80), "r": big.NewRat(-3, 4), "x": []*big.Rat{big.NewRat(1, 2), big.NewRat(2, 1)},
+			"M": mr, "w": &VectorRat{N: 1, V: []*big.Rat{big.NewRat(5, 6)}}, "p": NewPolynomial(big.NewRat(1, 1), big.NewRat(0, 1), big.NewRat(-2, 3)),
+			"q": q.Add(SurdFromRat(big.NewRat(1, 2))),
+			"I": NewIntervalSet(Interval{Hi: big.NewRat(-1, 2), HiClosed: true}, Interval{Lo: big.NewRat(3, 1)}),
+		},
+		Outputs: map[string]GeneratorOutputs{"A": {"lambdas": &VectorInt{N: 1, V: []int64{2}}, "t_row": int64(2)}},
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Instance
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.ProblemID != 7 || back.Version != "v2" || back.Seed != "s" {
+		t.Fatalf("%+v", back)
+	}
+	same := func(where string, a, b map[string]interface{}) {
+		if len(a) != len(b) {
+			t.Fatalf("%s: %d values, want %d", where, len(b), len(a))
+		}
+		for name, va := range a {
+			vb := b[name]
+			if fmt.Sprintf("%T", va) != fmt.Sprintf("%T", vb) || ValueToCanonicalString(va) != ValueToCanonicalString(vb) {
+				t.Fatalf("%s.%s: %T %v, want %T %v", where, name, vb, vb, va, va)
+			}
+		}
+	}
+	same("vars", inst.Vars, back.Vars)
+	same("derived", inst.Derived, back.Derived)
+	same("outputs.A", inst.Outputs["A"], back.Outputs["A"])
+	if got := back.Vars["A"].(*MatrixInt); got.R != 2 || got.C != 3 || got.A[1][2] != 9007199254740993 {
+		t.Fatalf("A %+v", got)
+	}
+	// 再次编码结果不变
+	again, err := json.Marshal(&back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Fatalf("re-encode differs:\n%s\n%s", data, again)
+	}
+}
+
+func TestInstanceJSON_errors(t *testing.T) {
+	if _, err := json.Marshal(&Instance{Vars: map[string]interface{}{"x": []int{1}}}); err == nil || !strings.Contains(err.Error(), "vars.x: cannot encode []int") {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, c := range []struct{ data, want string }{
+		{`{"vars":{"x":{"type":"quaternion","value":1}}}`, `vars.x: unknown value type "quaternion"`},
+		{`{"vars":{"A":{"type":"matrix_int","rows":2,"cols":2,"value":[[1,2]]}}}`, "vars.A: matrix has 1 rows, want 2"},
+		{`{"derived":{"r":{"type":"rat","value":"1/0"}}}`, `derived.r: bad rational "1/0"`},
+		{`{"outputs":{"A":{"k":{"type":"int64","value":"3"}}}}`, "outputs.A.k: int64:"},
+		// 尺寸与载荷不符的快照报错，不 panic、不按声明的尺寸分配
+		{`{"vars":{"A":{"type":"matrix_int","rows":-1,"cols":2,"value":[[1,2]]}}}`, "vars.A: bad matrix size -1x2"},
+		{`{"vars":{"A":{"type":"matrix_int","rows":1,"cols":-2,"value":[[1,2]]}}}`, "vars.A: bad matrix size 1x-2"},
+		{`{"vars":{"A":{"type":"matrix_int","cols":-2}}}`, "vars.A: bad matrix size 0x-2"},
+		{`{"vars":{"A":{"type":"matrix_int","rows":2000000000,"cols":2000000000,"value":[[1]]}}}`, "vars.A: matrix has 1 rows, want 2000000000"},
+		{`{"vars":{"A":{"type":"matrix_int","rows":1,"cols":3,"value":[[1,2]]}}}`, "vars.A: matrix row 1 has 2 cells, want 3"},
+		{`{"vars":{"A":{"type":"matrix_rat","rows":-3,"cols":-3}}}`, "vars.A: bad matrix size -3x-3"},
+		{`{"vars":{"A":{"type":"matrix_rat","rows":2000000000,"cols":2000000000,"value":[["1"]]}}}`, "vars.A: matrix has 1 rows, want 2000000000"},
+		{`{"derived":{"M":{"type":"matrix_rat","rows":1,"cols":2,"value":[["1","2","3"]]}}}`, "derived.M: matrix row 1 has 3 cells, want 2"},
+	} {
+		var inst Instance
+		if err := json.Unmarshal([]byte(c.data), &inst); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("%s: got %v, want %q", c.data, err, c.want)
+		}
+	}
+}
+
+// 快照上判分与原实例一致，且不需要重新随机。
+func TestJudgeInstance_snapshot(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "full_rank", "min": -5, "max": 5}},
+		},
+		Derived: map[string]string{"Ainv": "inv(A)"},
+		Answer:  AnswerSchema{FieldDefs: []AnswerFieldDef{{ID: "d", Expr: "det(A)"}, {ID: "x", Expr: "Ainv[1,1]"}}},
+	}
+	inst, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]string{}
+	for _, f := range g.AnswerFields {
+		user[f.ID] = ValueToCanonicalString(f.Value)
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap Instance
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	// 改动生成规则不影响快照判分
+	p.Variables["A"] = Variable{Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "full_rank", "min": 10, "max": 20}}
+	res, err := JudgeInstance(p, &snap, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.AllCorrect || res.TotalFields != 2 {
+		t.Fatalf("%+v", res)
+	}
+}
@@ -0,0 +1,173 @@
+package dsl
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// IntervalSet 为实数轴上有限个区间之并，端点为有理数或 ±∞，用于“求参数取值范围”类答案。
+// 保存为规范形式：区间非空、按左端点升序，相交或相接（接点至少一侧为闭）的区间已合并，
+// 因此同一集合只有一种表示，可逐段比较。
+type IntervalSet struct {
+	Parts []Interval
+}
+
+// Interval 为一个区间；Lo 为 nil 表示 -∞，Hi 为 nil 表示 +∞（无穷端点总是开的）。
+type Interval struct {
+	Lo, Hi             *big.Rat
+	LoClosed, HiClosed bool
+}
+
+// NewIntervalSet 返回若干区间的并（规范形式）；空区间如 (1, 1)、(2, 1) 被丢弃。
+func NewIntervalSet(parts ...Interval) *IntervalSet {
+	ps := make([]Interval, 0, len(parts))
+	for _, p := range parts {
+		if p.Lo == nil {
+			p.LoClosed = false
+		} else {
+			p.Lo = new(big.Rat).Set(p.Lo)
+		}
+		if p.Hi == nil {
+			p.HiClosed = false
+		} else {
+			p.Hi = new(big.Rat).Set(p.Hi)
+		}
+		if !p.empty() {
+			ps = append(ps, p)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool { return lowerBefore(ps[i], ps[j]) })
+	out := &IntervalSet{}
+	for _, p := range ps {
+		n := len(out.Parts)
+		if n == 0 || !out.Parts[n-1].joins(p) {
+			out.Parts = append(out.Parts, p)
+			continue
+		}
+		if last := &out.Parts[n-1]; upperBefore(*last, p) {
+			last.Hi, last.HiClosed = p.Hi, p.HiClosed
+		}
+	}
+	return out
+}
+
+func (p Interval) empty() bool {
+	if p.Lo == nil || p.Hi == nil {
+		return false
+	}
+	c := p.Lo.Cmp(p.Hi)
+	return c > 0 || c == 0 && !(p.LoClosed && p.HiClosed)
+}
+
+// lowerBefore 报告 a 的左端点是否严格先于 b 的（同一点处闭端点在前）。
+func lowerBefore(a, b Interval) bool {
+	switch {
+	case b.Lo == nil:
+		return false
+	case a.Lo == nil:
+		return true
+	}
+	if c := a.Lo.Cmp(b.Lo); c != 0 {
+		return c < 0
+	}
+	return a.LoClosed && !b.LoClosed
+}
+
+// upperBefore 报告 a 的右端点是否严格先于 b 的（同一点处开端点在前）。
+func upperBefore(a, b Interval) bool {
+	switch {
+	case a.Hi == nil:
+		return false
+	case b.Hi == nil:
+		return true
+	}
+	if c := a.Hi.Cmp(b.Hi); c != 0 {
+		return c < 0
+	}
+	return !a.HiClosed && b.HiClosed
+}
+
+// joins 报告左端点不先于 p 的区间 p 能否与 a 合并成一个区间。
+func (a Interval) joins(p Interval) bool {
+	if a.Hi == nil || p.Lo == nil {
+		return true
+	}
+	c := p.Lo.Cmp(a.Hi)
+	return c < 0 || c == 0 && (a.HiClosed || p.LoClosed)
+}
+
+// Union 返回 s ∪ t。
+func (s *IntervalSet) Union(t *IntervalSet) *IntervalSet {
+	return NewIntervalSet(append(append([]Interval(nil), s.Parts...), t.Parts...)...)
+}
+
+// Equal 判断两个集合是否相等（含端点开闭）。
+func (s *IntervalSet) Equal(t *IntervalSet) bool {
+	return s.equal(t, true)
+}
+
+// equal 逐段比较；closed 为 false 时忽略端点开闭，供判分给出“只错开闭”的提示。
+func (s *IntervalSet) equal(t *IntervalSet, closed bool) bool {
+	if len(s.Parts) != len(t.Parts) {
+		return false
+	}
+	same := func(a, b *big.Rat) bool { return a == nil && b == nil || a != nil && b != nil && a.Cmp(b) == 0 }
+	for i, a := range s.Parts {
+		b := t.Parts[i]
+		if !same(a.Lo, b.Lo) || !same(a.Hi, b.Hi) || closed && (a.LoClosed != b.LoClosed || a.HiClosed != b.HiClosed) {
+			return false
+		}
+	}
+	return true
+}
+
+// intervalNotation 为区间的一种书写方式（ASCII 或 LaTeX）。
+type intervalNotation struct {
+	num                                      func(*big.Rat) string
+	negInf, posInf, sep, cup, lbrace, rbrace string
+	empty                                    string
+}
+
+var (
+	intervalASCII = intervalNotation{func(r *big.Rat) string { return r.RatString() }, "-inf", "+inf", ", ", " U ", "{", "}", "{}"}
+	intervalLatex = intervalNotation{FormatRatLatex, `-\infty`, `+\infty`, ",", `\cup `, `\{`, `\}`, `\varnothing`}
+)
+
+// String 返回可被 ParseUserInterval 读回的写法，如 (-1/2, 3)、(-inf, 1) U [2, +inf)、{3}；空集为 {}。
+func (s *IntervalSet) String() string {
+	return s.format(intervalASCII)
+}
+
+// Latex 返回 LaTeX 形式，如 (-\frac{1}{2},3)、(-\infty,1)\cup [2,+\infty)；空集为 \varnothing。
+func (s *IntervalSet) Latex() string {
+	return s.format(intervalLatex)
+}
+
+func (s *IntervalSet) format(n intervalNotation) string {
+	if len(s.Parts) == 0 {
+		return n.empty
+	}
+	parts := make([]string, len(s.Parts))
+	for i, p := range s.Parts {
+		if p.Lo != nil && p.Hi != nil && p.Lo.Cmp(p.Hi) == 0 {
+			parts[i] = n.lbrace + n.num(p.Lo) + n.rbrace
+			continue
+		}
+		lo, hi, l, r := n.negInf, n.posInf, "(", ")"
+		if p.Lo != nil {
+			lo = n.num(p.Lo)
+		}
+		if p.Hi != nil {
+			hi = n.num(p.Hi)
+		}
+		if p.LoClosed {
+			l = "["
+		}
+		if p.HiClosed {
+			r = "]"
+		}
+		parts[i] = l + lo + n.sep + hi + r
+	}
+	return strings.Join(parts, n.cup)
+}
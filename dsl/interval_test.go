@@ -0,0 +1,55 @@
+package dsl
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIntervalSet(t *testing.T) {
+	r := func(a, b int64) *big.Rat { return big.NewRat(a, b) }
+	for _, c := range []struct {
+		parts      []Interval
+		ascii, tex string
+	}{
+		{[]Interval{{Lo: r(-1, 2), Hi: r(3, 1)}}, "(-1/2, 3)", `(-\frac{1}{2},3)`},
+		{[]Interval{{Lo: r(2, 1)}, {Hi: r(1, 1), HiClosed: true}}, "(-inf, 1] U (2, +inf)", `(-\infty,1]\cup (2,+\infty)`},
+		// 相交、相接（接点一侧闭）的区间合并；两侧都开的接点保留缺口
+		{[]Interval{{Lo: r(0, 1), Hi: r(2, 1)}, {Lo: r(1, 1), Hi: r(3, 1), HiClosed: true}}, "(0, 3]", `(0,3]`},
+		{[]Interval{{Lo: r(0, 1), Hi: r(1, 1), HiClosed: true}, {Lo: r(1, 1), Hi: r(2, 1)}}, "(0, 2)", `(0,2)`},
+		{[]Interval{{Lo: r(0, 1), Hi: r(1, 1)}, {Lo: r(1, 1), Hi: r(2, 1)}}, "(0, 1) U (1, 2)", `(0,1)\cup (1,2)`},
+		{[]Interval{{Lo: r(1, 1), Hi: r(1, 1), LoClosed: true, HiClosed: true}, {Lo: r(2, 1), Hi: r(1, 1)}}, "{1}", `\{1\}`},
+		{[]Interval{{Lo: r(1, 1), Hi: r(1, 1)}}, "{}", `\varnothing`},
+		{[]Interval{{Lo: r(5, 1)}, {}, {Hi: r(0, 1), LoClosed: true}}, "(-inf, +inf)", `(-\infty,+\infty)`},
+	} {
+		s := NewIntervalSet(c.parts...)
+		if s.String() != c.ascii || s.Latex() != c.tex {
+			t.Fatalf("got %s / %s, want %s / %s", s, s.Latex(), c.ascii, c.tex)
+		}
+		if back, err := ParseUserInterval(s.String()); err != nil || !back.Equal(s) {
+			t.Fatalf("%s does not parse back: %v", s, err)
+		}
+	}
+}
+
+func TestEvaluateExpression_interval(t *testing.T) {
+	inst := &Instance{Vars: map[string]interface{}{"a": int64(-2), "b": big.NewRat(4, 3)}}
+	for src, want := range map[string]string{
+		"interval(a, b)":          "(-2, 4/3)",
+		"interval_closed(a, 2*b)": "[-2, 8/3]",
+		"interval_ge(b)":          "[4/3, +inf)",
+		"interval_union(interval_lt(a), interval_gt(b))":                   "(-inf, -2) U (4/3, +inf)",
+		"interval_union(interval_le(0), interval_ge(0))":                   "(-inf, +inf)",
+		"interval_gt(a) == interval_union(interval(a, 0), interval_ge(0))": "1",
+	} {
+		v, err := EvaluateExpression(src, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if got := ValueToCanonicalString(v); got != want {
+			t.Fatalf("%s = %s, want %s", src, got, want)
+		}
+	}
+	if _, err := EvaluateExpression("interval_gt(sqrt(2))", inst); err == nil {
+		t.Fatal("irrational endpoint accepted")
+	}
+}
@@ -22,6 +22,17 @@ type FieldJudgement struct {
 	Weight     float64 `json:"weight"`
 	Score      float64 `json:"score"`
 	DetailNote string  `json:"detail_note,omitempty"`
+	// Cells 为整矩阵空（judge kind "matrix"）的逐格结果，按行优先；输入无法解析或形状不符时为空。
+	Cells []CellJudgement `json:"cells,omitempty"`
+}
+
+// CellJudgement 整矩阵空中单个矩阵元的判分结果，行列 1 起。
+type CellJudgement struct {
+	Row       int    `json:"row"`
+	Col       int    `json:"col"`
+	Correct   bool   `json:"correct"`
+	Expected  string `json:"expected"`
+	Submitted string `json:"submitted"`
 }
 
 // JudgeResult 整题判分结果。
@@ -52,6 +63,10 @@ func ValueToCanonicalString(v interface{}) string {
 			return t.Num().String()
 		}
 		return t.String()
+	case *MatrixInt, *VectorInt, *MatrixRat, *VectorRat, []*big.Rat:
+		return ValueToExplainString(t)
+	case *Polynomial, *Surd, *IntervalSet:
+		return fmt.Sprint(t)
 	default:
 		return fmt.Sprintf("%v", v)
 	}
@@ -111,9 +126,11 @@ func ParseUserRational(s string) (*big.Rat, error) {
 	if _, ok := r.SetString(s); ok {
 		return r, nil
 	}
-	// 小数
+	// 小数（ParseFloat 也接受 inf、nan，SetFloat64 对其返回 nil）
 	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		return new(big.Rat).SetFloat64(f), nil
+		if r := new(big.Rat).SetFloat64(f); r != nil {
+			return r, nil
+		}
 	}
 	return nil, fmt.Errorf("cannot parse %q", s)
 }
@@ -138,11 +155,41 @@ func ScalarAnswersEqual(expected interface{}, submitted string) (bool, string) {
 	return false, "value mismatch"
 }
 
+// fieldAnswersEqual 按标准答案的值类型比较：多项式逐系数比较，根式按 ℚ(√d) 精确比较，其余按有理数标量比较。
+func fieldAnswersEqual(expected interface{}, submitted string) (bool, string) {
+	if p, ok := expected.(*Polynomial); ok {
+		return PolynomialAnswersEqual(p, submitted)
+	}
+	if s, ok := expected.(*Surd); ok {
+		return SurdAnswersEqual(s, submitted)
+	}
+	return ScalarAnswersEqual(expected, submitted)
+}
+
 // JudgeGeneratedQuestion 根据 GenerateQuestion 的结果与用户提交的 id->字符串 判分。
 func JudgeGeneratedQuestion(g *GeneratedQuestion, user map[string]string, opts *JudgeOptions) JudgeResult {
 	return JudgeGeneratedQuestionContext(g, nil, nil, user, opts)
 }
 
+// JudgeInstance 在实例 inst 上重算标准答案并判分，不再重新随机。
+// inst 通常是存档的实例快照（见 Instance.UnmarshalJSON）：题目的生成规则改动后，仍按学生当时看到的数值判分。
+func JudgeInstance(p Problem, inst *Instance, user map[string]string, opts *JudgeOptions) (*JudgeResult, error) {
+	return compileLenient(p).JudgeInstance(inst, user, opts)
+}
+
+// JudgeInstance 同 dsl.JudgeInstance，但复用预编译结果。实例记录的版本与题目不同时报错。
+func (cp *CompiledProblem) JudgeInstance(inst *Instance, user map[string]string, opts *JudgeOptions) (*JudgeResult, error) {
+	if inst != nil && inst.Version != "" && inst.Version != cp.Problem.Version {
+		return nil, fmt.Errorf("instance is for version %q, problem is %q", inst.Version, cp.Problem.Version)
+	}
+	g, err := cp.GenerateQuestionFromInstance(inst)
+	if err != nil {
+		return nil, err
+	}
+	res := JudgeGeneratedQuestionContext(g, &cp.Problem, inst, user, opts)
+	return &res, nil
+}
+
 func fieldWeight(id string, opts *JudgeOptions) float64 {
 	w := 1.0
 	if opts != nil && opts.WeightByID != nil {
@@ -154,6 +201,7 @@ func fieldWeight(id string, opts *JudgeOptions) float64 {
 }
 
 // JudgeGeneratedQuestionContext 判分；若题目含结构化 Judge（共线、仿射、列基等价），需传入同一实例的 inst。
+// inst 也可以是 Instance.UnmarshalJSON 还原的快照，其值类型与出题时一致。
 func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Instance, user map[string]string, opts *JudgeOptions) JudgeResult {
 	if g == nil {
 		return JudgeResult{}
@@ -206,6 +254,28 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 		}
 		return m
 	}
+	collectSpanGroups := func() map[string][]int {
+		m := map[string][]int{}
+		for i := 0; i < n; i++ {
+			j := g.AnswerFields[i].Judge
+			if j == nil || j.Kind != "span_equal" || j.SpanGroup == "" {
+				continue
+			}
+			m[j.SpanGroup] = append(m[j.SpanGroup], i)
+		}
+		return m
+	}
+	collectSolutionGroups := func() map[string][]int {
+		m := map[string][]int{}
+		for i := 0; i < n; i++ {
+			j := g.AnswerFields[i].Judge
+			if j == nil || j.Kind != "general_solution" || j.SolutionGroup == "" {
+				continue
+			}
+			m[j.SolutionGroup] = append(m[j.SolutionGroup], i)
+		}
+		return m
+	}
 	// eigen_pair：按 EigenGroup 汇总所有 λ / vec 字段。
 	collectEigenGroups := func() map[string][]int {
 		m := map[string][]int{}
@@ -236,7 +306,7 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 				ID:         f.ID,
 				Correct:    ok,
 				Expected:   ValueToCanonicalString(f.Value),
-				Submitted:  NormalizeUserAnswer(sub),
+				Submitted:  submittedString(f, sub),
 				Weight:     w,
 				Score:      sc,
 				DetailNote: note,
@@ -264,6 +334,17 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 			ok, note := judgePermutationMultisetGroup(g, user, idxs)
 			appendGroup(idxs, ok, note)
 		}
+		for _, idxs := range collectSpanGroups() {
+			ok, note := judgeSpanEqualGroup(g, inst, user, idxs)
+			appendGroup(idxs, ok, note)
+		}
+		// general_solution：特解与基础解系分别判分。
+		for _, idxs := range collectSolutionGroups() {
+			particular, basis := generalSolutionParts(g, idxs)
+			okP, noteP, okB, noteB := judgeGeneralSolutionGroup(g, inst, user, particular, basis)
+			appendGroup(particular, okP, noteP)
+			appendGroup(basis, okB, noteB)
+		}
 		// eigen_pair：需要先收集所有 EigenGroup，再处理，因为 vec-only 组可能引用其他组的 λ。
 		eigenAll := collectEigenGroups()
 		eigenLambdasByGroup := map[string][]int{} // group -> 按 EigenColumn 排序的 lambda field 下标
@@ -294,7 +375,7 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 				}
 				out.Fields = append(out.Fields, FieldJudgement{
 					ID: f.ID, Correct: false, Expected: ValueToCanonicalString(f.Value),
-					Submitted: NormalizeUserAnswer(sub), Weight: w, Score: 0,
+					Submitted: submittedString(f, sub), Weight: w, Score: 0,
 					DetailNote: "need instance for rational_line judge",
 				})
 			}
@@ -310,7 +391,7 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 				}
 				out.Fields = append(out.Fields, FieldJudgement{
 					ID: f.ID, Correct: false, Expected: ValueToCanonicalString(f.Value),
-					Submitted: NormalizeUserAnswer(sub), Weight: w, Score: 0,
+					Submitted: submittedString(f, sub), Weight: w, Score: 0,
 					DetailNote: "need instance for affine_rational judge",
 				})
 			}
@@ -326,7 +407,7 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 				}
 				out.Fields = append(out.Fields, FieldJudgement{
 					ID: f.ID, Correct: false, Expected: ValueToCanonicalString(f.Value),
-					Submitted: NormalizeUserAnswer(sub), Weight: w, Score: 0,
+					Submitted: submittedString(f, sub), Weight: w, Score: 0,
 					DetailNote: "need instance for sorted_basis_columns judge",
 				})
 			}
@@ -336,6 +417,18 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 			ok, note := judgePermutationMultisetGroup(g, user, idxs)
 			appendGroup(idxs, ok, note)
 		}
+		// span_equal 以标准答案自身为参考时不依赖 inst；参考为矩阵的零空间、列空间时需要实例。
+		for _, idxs := range collectSpanGroups() {
+			if g.AnswerFields[idxs[0]].Judge.SpanOf != "" {
+				appendGroup(idxs, false, "need instance for span_equal judge")
+				continue
+			}
+			ok, note := judgeSpanEqualGroup(g, nil, user, idxs)
+			appendGroup(idxs, ok, note)
+		}
+		for _, idxs := range collectSolutionGroups() {
+			appendGroup(idxs, false, "need instance for general_solution judge")
+		}
 		for _, idxs := range collectEigenGroups() {
 			for _, i := range idxs {
 				handled[i] = true
@@ -347,7 +440,7 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 				}
 				out.Fields = append(out.Fields, FieldJudgement{
 					ID: f.ID, Correct: false, Expected: ValueToCanonicalString(f.Value),
-					Submitted: NormalizeUserAnswer(sub), Weight: w, Score: 0,
+					Submitted: submittedString(f, sub), Weight: w, Score: 0,
 					DetailNote: "need instance for eigen_pair judge",
 				})
 			}
@@ -364,7 +457,11 @@ func JudgeGeneratedQuestionContext(g *GeneratedQuestion, p *Problem, inst *Insta
 		if user != nil {
 			sub = user[f.ID]
 		}
-		ok, note := ScalarAnswersEqual(f.Value, sub)
+		if fj, ok := judgeWholeField(f, sub, w); ok {
+			out.Fields = append(out.Fields, fj)
+			continue
+		}
+		ok, note := fieldAnswersEqual(f.Value, sub)
 		sc := 0.0
 		if ok {
 			sc = w
@@ -412,19 +509,7 @@ func fieldOrderIndex(g *GeneratedQuestion, id string) int {
 }
 
 func judgeRationalLineGroup(g *GeneratedQuestion, user map[string]string, idxs []int) (bool, string) {
-	expVals := make([]interface{}, len(idxs))
-	subs := make([]string, len(idxs))
-	for k, i := range idxs {
-		expVals[k] = g.AnswerFields[i].Value
-		if user != nil {
-			subs[k] = user[g.AnswerFields[i].ID]
-		}
-	}
-	er, err := interfaceSliceToRatVec(expVals)
-	if err != nil {
-		return false, err.Error()
-	}
-	ur, err := parseUserRatVector(subs)
+	er, ur, err := groupVectors(g, user, idxs)
 	if err != nil {
 		return false, err.Error()
 	}
@@ -451,13 +536,7 @@ func judgeAffineRationalGroup(g *GeneratedQuestion, inst *Instance, user map[str
 	if !ok {
 		return false, "b type"
 	}
-	subs := make([]string, len(idxs))
-	for k, i := range idxs {
-		if user != nil {
-			subs[k] = user[g.AnswerFields[i].ID]
-		}
-	}
-	x, err := parseUserRatVector(subs)
+	_, x, err := groupVectors(g, user, idxs)
 	if err != nil {
 		return false, err.Error()
 	}
@@ -0,0 +1,198 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// 区间填空（judge kind "interval"）：一个空填写参数的取值范围，按集合精确比较（含端点开闭），输入约定见 AnswerInputContractInterval。
+
+var intervalInputReplacer = strings.NewReplacer(
+	"$", "", `\left`, "", `\right`, "", `\,`, "", `\;`, "", `\!`, "",
+	`\infty`, "inf", "infinity", "inf", "∞", "inf",
+	`\leqslant`, "<=", `\leq`, "<=", `\le`, "<=", "≤", "<=", "⩽", "<=",
+	`\geqslant`, ">=", `\geq`, ">=", `\ge`, ">=", "≥", ">=", "⩾", ">=",
+	`\lt`, "<", `\gt`, ">", "＜", "<", "＞", ">",
+	`\cup`, "∪", "U", "∪", "或", "∪", "or", "∪",
+	`\mathbb{R}`, "R", "ℝ", "R", `\in`, "∈",
+	`\varnothing`, "{}", `\emptyset`, "{}", "∅", "{}", `\{`, "{", `\}`, "}",
+	"（", "(", "）", ")", "［", "[", "］", "]", "，", ",",
+	"−", "-", "－", "-", "⁻", "-", "⁄", "/", "／", "/",
+)
+
+// inequalityOpRe 拆分不等式链 a<t<=b 中的比较符。
+var inequalityOpRe = regexp.MustCompile(`<=|>=|<|>`)
+
+// ParseUserInterval 解析用户输入的取值范围，结果为规范形式的区间并集：
+//   - 区间：(-1/2, 3)、[0, 1)、(-∞, 1)、(2, +inf)，无穷可写 ∞、inf、\infty；
+//   - 不等式：t>2、t≥-1、-1<t<4/3、3>t，变量名任意，但各段须一致；
+//   - 单点或有限集 {1}、{1, 2}，空集 ∅，全体实数 R、ℝ；
+//   - 以上用 ∪、U、\cup、或、or 连接表示并集，可带前缀 t∈。
+//
+// 端点可为整数、分数、小数或 \frac{a}{b}；∞ 一侧必须是开的。
+func ParseUserInterval(s string) (*IntervalSet, error) {
+	s = latexFracRe.ReplaceAllString(s, "${1}${2}/${3}${4}")
+	s = strings.Join(strings.Fields(intervalInputReplacer.Replace(s)), "")
+	if i := strings.LastIndex(s, "∈"); i >= 0 {
+		s = s[i+len("∈"):]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	var parts []Interval
+	varName := ""
+	for _, piece := range strings.Split(s, "∪") {
+		var ps []Interval
+		var err error
+		switch {
+		case piece == "":
+			return nil, fmt.Errorf("empty piece in union")
+		case piece == "R":
+			ps = []Interval{{}}
+		case strings.ContainsAny(piece, "<>"):
+			ps, err = parseInequality(piece, &varName)
+		case piece[0] == '{':
+			ps, err = parseFiniteSet(piece)
+		default:
+			ps, err = parseIntervalPiece(piece)
+		}
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, ps...)
+	}
+	return NewIntervalSet(parts...), nil
+}
+
+// parseIntervalPiece 解析 (a, b)、[a, b) 等区间写法。
+func parseIntervalPiece(s string) ([]Interval, error) {
+	if len(s) < 2 || !strings.ContainsRune("([", rune(s[0])) || !strings.ContainsRune(")]", rune(s[len(s)-1])) {
+		return nil, fmt.Errorf("cannot parse %q", s)
+	}
+	ends := strings.Split(s[1:len(s)-1], ",")
+	if len(ends) != 2 {
+		return nil, fmt.Errorf("interval %q needs two endpoints", s)
+	}
+	p := Interval{LoClosed: s[0] == '[', HiClosed: s[len(s)-1] == ']'}
+	var err error
+	if p.Lo, err = parseEndpoint(ends[0], "-inf"); err != nil {
+		return nil, err
+	}
+	if p.Hi, err = parseEndpoint(ends[1], "+inf"); err != nil {
+		return nil, err
+	}
+	if p.Lo == nil && p.LoClosed || p.Hi == nil && p.HiClosed {
+		return nil, fmt.Errorf("∞ cannot be a closed endpoint in %q", s)
+	}
+	if p.Lo != nil && p.Hi != nil && p.Lo.Cmp(p.Hi) > 0 {
+		return nil, fmt.Errorf("left endpoint %s exceeds right endpoint %s", p.Lo.RatString(), p.Hi.RatString())
+	}
+	return []Interval{p}, nil
+}
+
+// parseEndpoint 解析端点；inf 为该侧允许的无穷写法（-inf 或 +inf，+ 可省略），此时返回 nil。
+func parseEndpoint(s, inf string) (*big.Rat, error) {
+	if s == inf || inf == "+inf" && s == "inf" {
+		return nil, nil
+	}
+	if strings.HasSuffix(s, "inf") {
+		return nil, fmt.Errorf("%s on the wrong side", s)
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty endpoint")
+	}
+	return ParseUserRational(s)
+}
+
+// parseFiniteSet 解析 {a, b, …}，每个元素为一个单点区间；{} 为空集。
+func parseFiniteSet(s string) ([]Interval, error) {
+	if !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("cannot parse %q", s)
+	}
+	inner := s[1 : len(s)-1]
+	if inner == "" {
+		return nil, nil
+	}
+	var out []Interval
+	for _, e := range strings.Split(inner, ",") {
+		r, err := ParseUserRational(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Interval{Lo: r, Hi: r, LoClosed: true, HiClosed: true})
+	}
+	return out, nil
+}
+
+// parseInequality 解析 t>a、a<=t、a<t<b、b>t>a；varName 记录并检查各段的变量名一致。
+func parseInequality(s string, varName *string) ([]Interval, error) {
+	ops := inequalityOpRe.FindAllString(s, -1)
+	terms := inequalityOpRe.Split(s, -1)
+	if len(ops) > 2 {
+		return nil, fmt.Errorf("too many comparisons in %q", s)
+	}
+	vi := -1
+	nums := make([]*big.Rat, len(terms))
+	for i, t := range terms {
+		if t == "" {
+			return nil, fmt.Errorf("missing operand in %q", s)
+		}
+		r, err := ParseUserRational(t)
+		if err == nil {
+			nums[i] = r
+			continue
+		}
+		if vi >= 0 {
+			return nil, fmt.Errorf("cannot parse %q", t)
+		}
+		vi = i
+	}
+	if vi < 0 || len(ops) == 2 && vi != 1 {
+		return nil, fmt.Errorf("%q: want the variable between or beside the bounds", s)
+	}
+	if *varName != "" && *varName != terms[vi] {
+		return nil, fmt.Errorf("mixed variables %s and %s", *varName, terms[vi])
+	}
+	*varName = terms[vi]
+	var p Interval
+	// bound 把“数 op 变量”或“变量 op 数”落到区间一侧：变量大于该数时为左端点
+	bound := func(n *big.Rat, op string, varOnLeft bool) {
+		greater := strings.HasPrefix(op, ">") == varOnLeft
+		closed := strings.HasSuffix(op, "=")
+		if greater {
+			p.Lo, p.LoClosed = n, closed
+		} else {
+			p.Hi, p.HiClosed = n, closed
+		}
+	}
+	if vi > 0 {
+		bound(nums[vi-1], ops[vi-1], false)
+	}
+	if vi < len(ops) {
+		bound(nums[vi+1], ops[vi], true)
+	}
+	if len(ops) == 2 && (p.Lo == nil || p.Hi == nil) {
+		return nil, fmt.Errorf("inconsistent comparisons in %q", s)
+	}
+	return []Interval{p}, nil
+}
+
+// IntervalAnswersEqual 比较标准取值范围与用户输入；集合相同但端点开闭不同时给出提示。
+func IntervalAnswersEqual(expected *IntervalSet, submitted string) (bool, string) {
+	if strings.TrimSpace(submitted) == "" {
+		return false, "empty"
+	}
+	got, err := ParseUserInterval(submitted)
+	if err != nil {
+		return false, err.Error()
+	}
+	switch {
+	case got.Equal(expected):
+		return true, ""
+	case got.equal(expected, false):
+		return false, "open/closed endpoints differ"
+	}
+	return false, "interval mismatch"
+}
@@ -0,0 +1,99 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseUserInterval(t *testing.T) {
+	for in, want := range map[string]string{
+		"(-1/2, 3)":                              "(-1/2, 3)",
+		"t>2":                                    "(2, +inf)",
+		"t ≥ -1":                                 "[-1, +inf)",
+		"3>t":                                    "(-inf, 3)",
+		"-1<t<4/3":                               "(-1, 4/3)",
+		"2 >= x > 0.5":                           "(1/2, 2]",
+		"(-∞, 1)":                                "(-inf, 1)",
+		"（−∞，1）∪［2，+∞）":                          "(-inf, 1) U [2, +inf)",
+		"t<0 或 t>1":                              "(-inf, 0) U (1, +inf)",
+		"t<0 or t>=1":                            "(-inf, 0) U [1, +inf)",
+		`$t\in\left(-\infty,\frac{1}{2}\right]$`: "(-inf, 1/2]",
+		`-\frac12 < t \leq 3`:                    "(-1/2, 3]",
+		"(2, inf)":                               "(2, +inf)",
+		"[0,1) U (1,2]":                          "[0, 1) U (1, 2]",
+		"[0,1] U [1,2]":                          "[0, 2]",
+		"{1, 3}":                                 "{1} U {3}",
+		"∅":                                      "{}",
+		"R":                                      "(-inf, +inf)",
+		"t∈ℝ":                                    "(-inf, +inf)",
+	} {
+		s, err := ParseUserInterval(in)
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if got := s.String(); got != want {
+			t.Fatalf("%q: got %s, want %s", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"":          "empty",
+		"[2, +∞]":   "closed endpoint",
+		"(3, 1)":    "exceeds",
+		"(1, 2, 3)": "two endpoints",
+		"(+inf, 1)": "wrong side",
+		"t>1 U s<0": "mixed variables",
+		"1<t>3":     "inconsistent",
+		"t>x":       "cannot parse",
+		"1<2":       "want the variable",
+		"t>inf":     `cannot parse "inf"`,
+		"(1, 2) U ": "empty piece",
+	} {
+		if _, err := ParseUserInterval(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestJudgeIntervalField(t *testing.T) {
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "t", Value: NewIntervalSet(Interval{Lo: big.NewRat(-1, 2)}), Judge: &AnswerJudgeSpec{Kind: "interval"}},
+	}}
+	for sub, note := range map[string]string{
+		"t>-1/2":        "",
+		"(-0.5, +∞)":    "",
+		"t>=-1/2":       "open/closed endpoints differ",
+		"t>1/2":         "interval mismatch",
+		"t>-1/2 或 t<-3": "interval mismatch",
+		"t>>1":          `missing operand in "t>>1"`,
+	} {
+		f := JudgeGeneratedQuestion(g, map[string]string{"t": sub}, nil).Fields[0]
+		if f.Correct != (note == "") || f.DetailNote != note || f.Expected != "(-1/2, +inf)" {
+			t.Fatalf("%q: %+v", sub, f)
+		}
+	}
+	if f := JudgeGeneratedQuestion(g, map[string]string{"t": "t > -1/2"}, nil).Fields[0]; f.Submitted != "(-1/2, +inf)" {
+		t.Fatalf("%+v", f)
+	}
+	if h := FieldInputHintsFromGenerated(g); h[0].Kind != FieldAnswerKindInterval {
+		t.Fatalf("%+v", h)
+	}
+	p := compileTestProblem()
+	p.Title += ", {{blank:r}}"
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: "r", Expr: "interval_gt(c)", Judge: &AnswerJudgeSpec{Kind: "interval"}})
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics: %v", ds)
+	}
+	if InputConventionOf(p) != AnswerInputConventionInterval {
+		t.Fatal("interval blank should need V2")
+	}
+	p.Answer.FieldDefs[1].Judge = nil
+	p.Answer.FieldDefs[0].Judge = &AnswerJudgeSpec{Kind: "interval"}
+	msgs := ""
+	for _, d := range ValidateProblem(p) {
+		msgs += d.Message + "\n"
+	}
+	if !strings.Contains(msgs, `answer is an interval; use judge kind "interval"`) || !strings.Contains(msgs, "judge interval: answer is scalar, want interval") {
+		t.Fatalf("diagnostics: %s", msgs)
+	}
+}
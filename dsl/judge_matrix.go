@@ -0,0 +1,196 @@
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 整矩阵填空（judge kind "matrix"）：一个空填写整个矩阵，逐元素按有理数比较，输入约定见 AnswerInputContractV2。
+
+var matrixInputReplacer = strings.NewReplacer(
+	"\u2212", "-", "－", "-", "⁻", "-",
+	"，", ",", "、", ",", "；", ";", "［", "[", "］", "]", "（", "(", "）", ")",
+	"\u2044", "/", "／", "/", "\u00a0", " ", "\r\n", "\n",
+)
+
+// latexMatrixRe 匹配整段 LaTeX 矩阵环境，可带 \left[ … \right]；不接受 vmatrix（行列式记号）。
+var latexMatrixRe = regexp.MustCompile(`(?s)^(?:\\left\s*[\[(]\s*)?\\begin\{([bBp]?matrix)\}(.*)\\end\{([bBp]?matrix)\}(?:\s*\\right\s*[\])])?$`)
+
+// latexFracRe 匹配 \frac{a}{b}、\dfrac{a}{b}，以及省略花括号的单个数字 \frac12。
+var latexFracRe = regexp.MustCompile(`\\[dt]?frac\s*(?:\{([^{}]*)\}|(\d))\s*(?:\{([^{}]*)\}|(\d))`)
+
+var latexCellReplacer = strings.NewReplacer(`\,`, "", `\;`, "", `\!`, "", `\ `, "", `\left`, "", `\right`, "", "{", "", "}", "")
+
+// ParseUserMatrix 解析用户输入的整个矩阵，元素为整数、分数或小数。接受三种写法：
+//   - 嵌套数组：[[1,2],[3,4]]（行之间、元素之间以逗号或空格分隔，括号也可为圆括号）
+//   - 分号分行：1 2; 3 4、[1 2; 3 4]、[1 2]; [3 4]（换行等同分号）
+//   - LaTeX：\begin{bmatrix}1&2\\3&4\end{bmatrix}（也接受 pmatrix、Bmatrix、matrix，元素可写 \frac{1}{2}）
+//
+// 各行元素个数必须相同。
+func ParseUserMatrix(s string) (*MatrixRat, error) {
+	s = strings.TrimSpace(matrixInputReplacer.Replace(s))
+	s = strings.TrimSpace(strings.Trim(s, "$"))
+	if s == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	var rows [][]string
+	switch {
+	case strings.HasPrefix(s, `\`):
+		m := latexMatrixRe.FindStringSubmatch(s)
+		if m == nil {
+			return nil, fmt.Errorf("unsupported LaTeX (want bmatrix, pmatrix or matrix)")
+		}
+		if m[1] != m[3] {
+			return nil, fmt.Errorf(`\begin{%s} closed by \end{%s}`, m[1], m[3])
+		}
+		for _, line := range strings.Split(m[2], `\\`) {
+			if line = strings.TrimSpace(line); line != "" {
+				rows = append(rows, strings.Split(line, "&"))
+			}
+		}
+	default:
+		inner, wrapped := unwrapMatrixBrackets(s)
+		if wrapped {
+			if r, ok := splitBracketRows(inner); ok {
+				rows = r
+				break
+			}
+			s = inner
+		}
+		for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+			line = strings.TrimSpace(line)
+			if in, ok := unwrapMatrixBrackets(line); ok {
+				line = in
+			}
+			rows = append(rows, splitMatrixCells(line))
+		}
+	}
+	return matrixFromCells(rows)
+}
+
+// unwrapMatrixBrackets 去掉首尾互相匹配的一对 [] 或 ()。
+func unwrapMatrixBrackets(s string) (string, bool) {
+	if len(s) < 2 || !(s[0] == '[' && s[len(s)-1] == ']' || s[0] == '(' && s[len(s)-1] == ')') {
+		return s, false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+			if depth == 0 && i < len(s)-1 {
+				return s, false
+			}
+		}
+	}
+	return strings.TrimSpace(s[1 : len(s)-1]), true
+}
+
+// splitBracketRows 把 "[1,2],[3,4]" 拆成行；不是逐行括号的写法时返回 false。
+func splitBracketRows(s string) ([][]string, bool) {
+	var rows [][]string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ',' || c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '[' || c == '(':
+			want := byte(']')
+			if c == '(' {
+				want = ')'
+			}
+			end := strings.IndexAny(s[i+1:], "[]()")
+			if end < 0 || s[i+1+end] != want {
+				return nil, false
+			}
+			rows = append(rows, splitMatrixCells(s[i+1:i+1+end]))
+			i += end + 2
+		default:
+			return nil, false
+		}
+	}
+	return rows, len(rows) > 0
+}
+
+// splitMatrixCells 行内有逗号时按逗号分隔，否则按空白分隔。
+func splitMatrixCells(row string) []string {
+	if strings.Contains(row, ",") {
+		return strings.Split(row, ",")
+	}
+	return strings.Fields(row)
+}
+
+func matrixFromCells(rows [][]string) (*MatrixRat, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("empty")
+	}
+	m := NewMatrixRat(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != m.C {
+			return nil, fmt.Errorf("row %d has %d entries, want %d", i+1, len(row), m.C)
+		}
+		for j, cell := range row {
+			cell = latexCellReplacer.Replace(latexFracRe.ReplaceAllString(strings.TrimSpace(cell), "${1}${2}/${3}${4}"))
+			if cell == "" {
+				return nil, fmt.Errorf("row %d col %d: empty entry", i+1, j+1)
+			}
+			r, err := ParseUserRational(cell)
+			if err != nil {
+				return nil, fmt.Errorf("row %d col %d: %v", i+1, j+1, err)
+			}
+			m.A[i][j] = r
+		}
+	}
+	return m, nil
+}
+
+// MatrixAnswersEqual 比较标准矩阵与用户输入的整个矩阵；形状一致时 cells 给出逐格结果。
+func MatrixAnswersEqual(expected interface{}, submitted string) (bool, string, []CellJudgement) {
+	ex, ok := matrixRatOf(expected)
+	if !ok {
+		return false, fmt.Sprintf("unsupported answer type %T", expected), nil
+	}
+	if strings.TrimSpace(submitted) == "" {
+		return false, "empty", nil
+	}
+	got, err := ParseUserMatrix(submitted)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	if got.R != ex.R || got.C != ex.C {
+		return false, fmt.Sprintf("shape %d×%d, want %d×%d", got.R, got.C, ex.R, ex.C), nil
+	}
+	cells := make([]CellJudgement, 0, ex.R*ex.C)
+	wrong := 0
+	for i := 0; i < ex.R; i++ {
+		for j := 0; j < ex.C; j++ {
+			c := CellJudgement{
+				Row:       i + 1,
+				Col:       j + 1,
+				Correct:   ex.A[i][j].Cmp(got.A[i][j]) == 0,
+				Expected:  ex.A[i][j].RatString(),
+				Submitted: got.A[i][j].RatString(),
+			}
+			if !c.Correct {
+				wrong++
+			}
+			cells = append(cells, c)
+		}
+	}
+	if wrong > 0 {
+		return false, fmt.Sprintf("%d of %d entries mismatch", wrong, len(cells)), cells
+	}
+	return true, "", cells
+}
+
+func matrixRatOf(v interface{}) (*MatrixRat, bool) {
+	switch t := v.(type) {
+	case *MatrixRat:
+		return t, true
+	case *MatrixInt:
+		return MatrixIntToRat(t), true
+	}
+	return nil, false
+}
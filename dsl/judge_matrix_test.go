@@ -0,0 +1,112 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseUserMatrix(t *testing.T) {
+	for in, want := range map[string]string{
+		"[[1,2],[3,4]]":                        "[1 2]; [3 4]",
+		"[[1, -1/2], [0, 0.25]]":               "[1 -1/2]; [0 1/4]",
+		"((1 2) (3 4))":                        "[1 2]; [3 4]",
+		"1 2; 3 4":                             "[1 2]; [3 4]",
+		"[1 2; 3 4]":                           "[1 2]; [3 4]",
+		"[1 2]; [3 4]":                         "[1 2]; [3 4]",
+		"1，−2；3，4":                             "[1 -2]; [3 4]",
+		"1 2\n3 4\n":                           "[1 2]; [3 4]",
+		"[1, 2, 3]":                            "[1 2 3]",
+		"[(1/2), 3]":                           "[1/2 3]",
+		`\begin{bmatrix}1&2\\3&4\end{bmatrix}`: "[1 2]; [3 4]",
+		`$\begin{pmatrix} -\frac{1}{2} & 0 \\ \dfrac{3}{4} & 1 \\ \end{pmatrix}$`: "[-1/2 0]; [3/4 1]",
+		`\left[\begin{matrix}{-1}&2\end{matrix}\right]`:                           "[-1 2]",
+	} {
+		m, err := ParseUserMatrix(in)
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if got := ValueToCanonicalString(m); got != want {
+			t.Fatalf("%q: got %s, want %s", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"":                                     "empty",
+		"1 2; 3":                               "row 2 has 1 entries, want 2",
+		"[[1,2],[3,]]":                         "row 2 col 2: empty entry",
+		"1 x; 3 4":                             "row 1 col 2: cannot parse",
+		`\begin{vmatrix}1&2\\3&4\end{vmatrix}`: "unsupported LaTeX",
+		`\begin{bmatrix}1\end{pmatrix}`:        `\begin{bmatrix} closed by \end{pmatrix}`,
+	} {
+		if _, err := ParseUserMatrix(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestJudgeMatrixField(t *testing.T) {
+	inv := NewMatrixRat(2, 2)
+	inv.A[0][0].SetInt64(1)
+	inv.A[0][1] = big.NewRat(-1, 2)
+	inv.A[1][1] = big.NewRat(1, 2)
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "inv", Value: inv, Judge: &AnswerJudgeSpec{Kind: "matrix"}},
+		{ID: "a", Value: &MatrixInt{R: 1, C: 2, A: [][]int64{{3, -1}}}, Judge: &AnswerJudgeSpec{Kind: "matrix"}},
+		{ID: "d", Value: int64(2)},
+	}}
+	r := JudgeGeneratedQuestion(g, map[string]string{"inv": `\begin{bmatrix}1&-\frac12\\0&\frac{1}{2}\end{bmatrix}`, "a": "[[3, -1]]", "d": "2"}, nil)
+	if !r.AllCorrect || len(r.Fields[0].Cells) != 4 || r.Fields[0].Submitted != "[1 -1/2]; [0 1/2]" {
+		t.Fatalf("%+v", r)
+	}
+	r = JudgeGeneratedQuestion(g, map[string]string{"inv": "1 -1/2; 1 1/2", "a": "3 -1 0"}, nil)
+	f := r.Fields[0]
+	if f.Correct || f.DetailNote != "1 of 4 entries mismatch" || f.Expected != "[1 -1/2]; [0 1/2]" {
+		t.Fatalf("%+v", f)
+	}
+	for _, c := range f.Cells {
+		if c.Correct != (c.Row != 2 || c.Col != 1) {
+			t.Fatalf("cell %+v", c)
+		}
+	}
+	if c := f.Cells[2]; c.Expected != "0" || c.Submitted != "1" {
+		t.Fatalf("cell %+v", c)
+	}
+	if f := r.Fields[1]; f.Correct || f.DetailNote != "shape 1×3, want 1×2" || f.Cells != nil || f.Submitted != "[3 -1 0]" {
+		t.Fatalf("%+v", f)
+	}
+	if h := FieldInputHintsFromGenerated(g); h[0].Kind != FieldAnswerKindMatrix || h[1].Kind != FieldAnswerKindMatrix {
+		t.Fatalf("%+v", h)
+	}
+}
+
+func TestInputConventionOf(t *testing.T) {
+	p := compileTestProblem()
+	if InputConventionOf(p) != AnswerInputConventionV1 {
+		t.Fatal("scalar problem should stay on V1")
+	}
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: "m", Expr: "A", Judge: &AnswerJudgeSpec{Kind: "matrix"}})
+	if InputConventionOf(p) != AnswerInputConventionV2 || AnswerInputContractV2().ID != AnswerInputConventionV2 {
+		t.Fatal("matrix blank should need V2")
+	}
+	// 每类扩展各有标识：只支持整矩阵的客户端不会收到含根号或整向量空的题
+	p.Derived["u"] = "1/norm(col(A, 1))"
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs,
+		AnswerFieldDef{ID: "u", Expr: "u"},
+		AnswerFieldDef{ID: "v", Expr: "col(A, 1)", Judge: &AnswerJudgeSpec{Kind: "vector"}})
+	want := []string{AnswerInputConventionV1, AnswerInputConventionV2, AnswerInputConventionVector, AnswerInputConventionSurd}
+	if got := InputConventionsOf(p); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("conventions %v", got)
+	}
+	if got := InputConventionOf(p); got != strings.Join(want[1:], "+") {
+		t.Fatalf("convention %q", got)
+	}
+	for _, d := range AnswerInputContracts() {
+		if d.ID == AnswerInputConventionV1 {
+			continue
+		}
+		v1 := AnswerInputContractV1()
+		if len(d.RejectedFormats) <= len(v1.RejectedFormats) || d.RejectedFormats[0] != v1.RejectedFormats[0] || !strings.HasPrefix(d.Summary, "标量空同 V1") {
+			t.Fatalf("%s should extend V1: %+v", d.ID, d)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// ParseUserPolynomial 解析用户输入的一元多项式，变量可写作 λ、\lambda、lambda、x 或 t（一题内统一）。
+// 支持展开式与因式积：λ^3-2λ^2+1、λ³-λ、(λ-1)^2(λ+2)、1/2λ^2、2*x-1；
+// 相邻因子之间可省略乘号，整数指数写作 ^k 或上标 ²³。
+func ParseUserPolynomial(s string) (*Polynomial, error) {
+	s = NormalizeUserAnswer(s)
+	r := strings.NewReplacer(
+		`\lambda`, "λ", "lambda", "λ", "Λ", "λ",
+		"²", "^2", "³", "^3", "⁴", "^4", "⁵", "^5", "⁶", "^6",
+		"**", "^", "·", "*", "×", "*", "{", "", "}", "",
+		`\left`, "", `\right`, "", `\cdot`, "*",
+	)
+	s = r.Replace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	ps := &polyParser{src: []rune(s)}
+	p, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if ps.i < len(ps.src) {
+		return nil, fmt.Errorf("unexpected %q at %d", ps.src[ps.i], ps.i+1)
+	}
+	return p, nil
+}
+
+// PolynomialAnswersEqual 比较标准多项式与用户输入（逐系数相等）。
+func PolynomialAnswersEqual(expected *Polynomial, submitted string) (bool, string) {
+	if NormalizeUserAnswer(submitted) == "" {
+		return false, "empty"
+	}
+	got, err := ParseUserPolynomial(submitted)
+	if err != nil {
+		return false, err.Error()
+	}
+	if got.Equal(expected) {
+		return true, ""
+	}
+	return false, "polynomial mismatch"
+}
+
+type polyParser struct {
+	src     []rune
+	i       int
+	varName rune
+}
+
+func (ps *polyParser) peek() rune {
+	if ps.i < len(ps.src) {
+		return ps.src[ps.i]
+	}
+	return 0
+}
+
+func isPolyVar(r rune) bool { return r == 'λ' || r == 'x' || r == 't' }
+
+// expr := ['+'|'-'] term (('+'|'-') term)*
+func (ps *polyParser) parseExpr() (*Polynomial, error) {
+	sign := 1
+	switch ps.peek() {
+	case '+':
+		ps.i++
+	case '-':
+		ps.i++
+		sign = -1
+	}
+	out, err := ps.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	if sign < 0 {
+		out = polyAdd(&Polynomial{}, out, -1)
+	}
+	for ps.peek() == '+' || ps.peek() == '-' {
+		op := ps.peek()
+		ps.i++
+		t, err := ps.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if op == '+' {
+			out = polyAdd(out, t, 1)
+		} else {
+			out = polyAdd(out, t, -1)
+		}
+	}
+	return out, nil
+}
+
+// term := factor (('*' | '/' | 省略乘号) factor)*；'/' 的除数须为非零常数。
+func (ps *polyParser) parseTerm() (*Polynomial, error) {
+	out, err := ps.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c := ps.peek()
+		switch {
+		case c == '*':
+			ps.i++
+		case c == '/':
+			ps.i++
+			d, err := ps.parseFactor()
+			if err != nil {
+				return nil, err
+			}
+			if d.Degree() != 0 {
+				return nil, fmt.Errorf("division by non-constant")
+			}
+			out = polyMul(out, NewPolynomial(new(big.Rat).Inv(d.Coeffs[0])))
+			continue
+		case c == '(' || isPolyVar(c):
+		default:
+			return out, nil
+		}
+		f, err := ps.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		out = polyMul(out, f)
+	}
+}
+
+// factor := base ('^' 非负整数)?
+func (ps *polyParser) parseFactor() (*Polynomial, error) {
+	base, err := ps.parseBase()
+	if err != nil {
+		return nil, err
+	}
+	if ps.peek() != '^' {
+		return base, nil
+	}
+	ps.i++
+	start := ps.i
+	for ps.i < len(ps.src) && unicode.IsDigit(ps.src[ps.i]) {
+		ps.i++
+	}
+	if start == ps.i || ps.i-start > 2 {
+		return nil, fmt.Errorf("bad exponent at %d", start+1)
+	}
+	n := 0
+	for _, d := range ps.src[start:ps.i] {
+		n = n*10 + int(d-'0')
+	}
+	out := NewPolynomial(big.NewRat(1, 1))
+	for k := 0; k < n; k++ {
+		out = polyMul(out, base)
+	}
+	return out, nil
+}
+
+// base := 数字 | 变量 | '(' expr ')'
+func (ps *polyParser) parseBase() (*Polynomial, error) {
+	c := ps.peek()
+	switch {
+	case c == '(':
+		ps.i++
+		p, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if ps.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at %d", ps.i+1)
+		}
+		ps.i++
+		return p, nil
+	case isPolyVar(c):
+		if ps.varName != 0 && ps.varName != c {
+			return nil, fmt.Errorf("mixed variables %c and %c", ps.varName, c)
+		}
+		ps.varName = c
+		ps.i++
+		return NewPolynomial(new(big.Rat), big.NewRat(1, 1)), nil
+	case unicode.IsDigit(c) || c == '.':
+		start := ps.i
+		for ps.i < len(ps.src) && (unicode.IsDigit(ps.src[ps.i]) || ps.src[ps.i] == '.') {
+			ps.i++
+		}
+		v, ok := new(big.Rat).SetString(string(ps.src[start:ps.i]))
+		if !ok {
+			return nil, fmt.Errorf("bad number %q", string(ps.src[start:ps.i]))
+		}
+		return NewPolynomial(v), nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", c, ps.i+1)
+}
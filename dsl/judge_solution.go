@@ -0,0 +1,68 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// 通解判题（judge kind "general_solution"）：同 SolutionGroup 的空给出 x = x₀ + k₁ξ₁ + … + k_rξ_r 中的特解 x₀ 与基础解系 ξ₁…ξ_r，
+// 特解与基础解系分别判分，任取特解、任取一组基础解系都判对。
+
+// generalSolutionParts 把组内的空分为特解部分与基础解系部分：按 AnswerFields 顺序，
+// 前 SpanDim 个空（SpanDim 为 0 时为第一个整向量空）是 x₀，其余为 ξ。
+func generalSolutionParts(g *GeneratedQuestion, idxs []int) (particular, basis []int) {
+	n := g.AnswerFields[idxs[0]].Judge.SpanDim
+	if n == 0 {
+		n = 1
+	}
+	if len(idxs) < n {
+		return idxs, nil
+	}
+	return idxs[:n], idxs[n:]
+}
+
+// judgeGeneralSolutionGroup 验证 MatrixVar·x₀ = BVecVar，以及 ξ 个数为 n − rank(A)、线性无关且都在 ker A 内。
+func judgeGeneralSolutionGroup(g *GeneratedQuestion, inst *Instance, user map[string]string, particular, basis []int) (okP bool, noteP string, okB bool, noteB string) {
+	j0 := g.AnswerFields[particular[0]].Judge
+	if j0.MatrixVar == "" || j0.BVecVar == "" {
+		return false, "missing matrix/b", false, "missing matrix/b"
+	}
+	A, ok := inst.Vars[j0.MatrixVar].(*MatrixInt)
+	if !ok {
+		return false, "matrix type", false, "matrix type"
+	}
+	b, ok := inst.Vars[j0.BVecVar].(*VectorInt)
+	if !ok {
+		return false, "b type", false, "b type"
+	}
+	_, x0, err := spanGroupVectors(g, user, particular, j0.SpanDim)
+	if err != nil {
+		noteP = err.Error()
+	} else {
+		okP, noteP = affineSolutionOK(A, b, x0[0])
+	}
+	okB, noteB = generalSolutionBasisOK(g, user, A, basis, j0.SpanDim)
+	return okP, noteP, okB, noteB
+}
+
+func generalSolutionBasisOK(g *GeneratedQuestion, user map[string]string, A *MatrixInt, basis []int, dim int) (bool, string) {
+	r := A.C - matrixRankRat(A)
+	var xi [][]*big.Rat
+	if len(basis) > 0 {
+		var err error
+		if _, xi, err = spanGroupVectors(g, user, basis, dim); err != nil {
+			return false, err.Error()
+		}
+	}
+	if len(xi) != r {
+		return false, fmt.Sprintf("want %d vectors, got %d", r, len(xi))
+	}
+	if r == 0 {
+		return true, ""
+	}
+	ref, err := NullspaceBasisRational(A)
+	if err != nil {
+		return false, err.Error()
+	}
+	return spanEqualOK(ref, xi)
+}
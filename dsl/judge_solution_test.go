@@ -0,0 +1,112 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// solutionTestProblem：Ax = b 的通解为 (1,0,1,0) + k₁(-2,1,0,0) + k₂(-1,0,-1,1)。
+func solutionTestProblem(j *AnswerJudgeSpec) Problem {
+	p := Problem{
+		ID: 1,
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 2, Cols: 4, Fixed: [][]interface{}{{1, 2, -1, 0}, {2, 4, 0, 2}}},
+			"x": {Kind: "vector", Size: 4, Fixed: []int{1, 0, 1, 0}},
+		},
+		Derived: map[string]string{"b": "A * x"},
+	}
+	add := func(id, expr string) {
+		p.Title += "{{blank:" + id + "}} "
+		p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: id, Expr: expr, Judge: j})
+	}
+	for c := 1; c <= 4; c++ {
+		add(fmt.Sprintf("x0%d", c), fmt.Sprintf("x[%d]", c))
+	}
+	for k := 1; k <= 2; k++ {
+		for c := 1; c <= 4; c++ {
+			add(fmt.Sprintf("x%d%d", k, c), fmt.Sprintf("nullbasis_comp(A,%d,%d)", k, c))
+		}
+	}
+	return p
+}
+
+func TestJudgeGeneralSolution(t *testing.T) {
+	j := &AnswerJudgeSpec{Kind: "general_solution", SolutionGroup: "x", SpanDim: 4, MatrixVar: "A", BVecVar: "b"}
+	p := solutionTestProblem(j)
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	if InputConventionOf(p) != AnswerInputConventionV1 {
+		t.Fatal("component blanks should stay V1")
+	}
+	inst, err := InstantiateProblem(p, "s1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for vecs, want := range map[[3]string][2]string{
+		{"1 0 1 0", "-2 1 0 0", "-1 0 -1 1"}:   {"", ""},
+		{"-1 1 1 0", "-3 1 -1 1", "4 -2 0 0"}:  {"", ""}, // 另取特解与基础解系
+		{"1 0 0 0", "-2 1 0 0", "-1 0 -1 1"}:   {"Ax!=b", ""},
+		{"1 0 1 0", "-2 1 0 0", "2 -1 0 0"}:    {"", "linearly dependent"},
+		{"1 0 1 0", "1 0 1 0", "-2 1 0 0"}:     {"", "not in subspace"},
+		{"1/2 x 1 0", "-2 1 0 0", "-1 0 -1 1"}: {"vector 1: comp 1: cannot parse", ""},
+	} {
+		user := map[string]string{}
+		for k, v := range vecs {
+			for c, s := range strings.Fields(v) {
+				user[fmt.Sprintf("x%d%d", k, c+1)] = s
+			}
+		}
+		r := JudgeGeneratedQuestionContext(g, &p, inst, user, nil)
+		fp, fb := r.Fields[0], r.Fields[4]
+		if fp.Correct != (want[0] == "") || !strings.HasPrefix(fp.DetailNote, want[0]) || fb.Correct != (want[1] == "") || fb.DetailNote != want[1] {
+			t.Fatalf("%v: %+v / %+v", vecs, fp, fb)
+		}
+		if r.Fields[3].Correct != fp.Correct || r.Fields[11].Correct != fb.Correct {
+			t.Fatalf("%v: %+v", vecs, r.Fields)
+		}
+	}
+	if r := JudgeGeneratedQuestionContext(g, &p, nil, map[string]string{}, nil); r.Fields[0].DetailNote != "need instance for general_solution judge" {
+		t.Fatalf("%+v", r.Fields[0])
+	}
+}
+
+func TestJudgeGeneralSolution_wholeVectors(t *testing.T) {
+	j := &AnswerJudgeSpec{Kind: "general_solution", SolutionGroup: "x", MatrixVar: "A", BVecVar: "b"}
+	p := solutionTestProblem(nil)
+	p.Title = "{{blank:x0}} {{blank:xi}}"
+	p.Answer.FieldDefs = []AnswerFieldDef{{ID: "x0", Expr: "x", Judge: j}, {ID: "xi", Expr: "nullbasis_vec(A,1)", Judge: j}}
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	if InputConventionOf(p) != AnswerInputConventionVector {
+		t.Fatal("whole-vector blanks should need V2")
+	}
+	inst, err := InstantiateProblem(p, "s1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := JudgeGeneratedQuestionContext(g, &p, inst, map[string]string{"x0": "(-1, 1, 1, 0)", "xi": "(-2, 1, 0, 0)"}, nil)
+	if !r.Fields[0].Correct || r.Fields[1].Correct || r.Fields[1].DetailNote != "want 2 vectors, got 1" {
+		t.Fatalf("%+v", r.Fields)
+	}
+
+	p.Answer.FieldDefs[1].Judge = &AnswerJudgeSpec{Kind: "general_solution", SolutionGroup: "y", SpanDim: 4}
+	want := []string{
+		"error answer.field_defs[1]: judge general_solution: answer is vector(?); with span_dim each blank is one component",
+		"error answer.field_defs[1]: judge general_solution: needs matrix_var and bvec_var",
+		`error answer.field_defs[1]: solution_group "y" has 1 fields, not a multiple of span_dim 4`,
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
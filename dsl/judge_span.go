@@ -0,0 +1,127 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// 子空间判题（judge kind "span_equal"）：同 SpanGroup 的空给出一组向量，须线性无关且恰好张成参考子空间，
+// 因此与标准答案选取哪一组基无关（如基础解系中自由变量的取法不同）。
+
+// spanGroupVectors 收集组内的标准向量与用户向量：dim 为 0 时每空填写一个整向量（ParseUserVector），
+// 否则按 AnswerFields 顺序每 dim 个空组成一个向量。
+func spanGroupVectors(g *GeneratedQuestion, user map[string]string, idxs []int, dim int) (exp, got [][]*big.Rat, err error) {
+	subs := make([]string, len(idxs))
+	for k, i := range idxs {
+		if user != nil {
+			subs[k] = user[g.AnswerFields[i].ID]
+		}
+	}
+	if dim == 0 {
+		for k, i := range idxs {
+			ev, ok := vectorRatOf(g.AnswerFields[i].Value)
+			if !ok {
+				return nil, nil, fmt.Errorf("field %s is not a vector; set span_dim for component blanks", g.AnswerFields[i].ID)
+			}
+			uv, err := ParseUserVector(subs[k])
+			if err != nil {
+				return nil, nil, fmt.Errorf("vector %d: %w", k+1, err)
+			}
+			exp, got = append(exp, ev), append(got, uv.V)
+		}
+		return exp, got, nil
+	}
+	if len(idxs)%dim != 0 {
+		return nil, nil, fmt.Errorf("group size %d is not a multiple of span_dim %d", len(idxs), dim)
+	}
+	for k := 0; k < len(idxs); k += dim {
+		expVals := make([]interface{}, dim)
+		for c := 0; c < dim; c++ {
+			expVals[c] = g.AnswerFields[idxs[k+c]].Value
+		}
+		ev, err := interfaceSliceToRatVec(expVals)
+		if err != nil {
+			return nil, nil, err
+		}
+		uv, err := parseUserRatVector(subs[k : k+dim])
+		if err != nil {
+			return nil, nil, fmt.Errorf("vector %d: %w", k/dim+1, err)
+		}
+		exp, got = append(exp, ev), append(got, uv)
+	}
+	return exp, got, nil
+}
+
+// spanReference 返回参考子空间的一组生成向量：SpanOf 为 "nullspace" 时取 MatrixVar 的零空间基（NullspaceBasisRational），
+// 为 "column" 时取 MatrixVar 的各列，为空时即组内的标准答案向量。
+func spanReference(inst *Instance, j *AnswerJudgeSpec, exp [][]*big.Rat) ([][]*big.Rat, error) {
+	if j.SpanOf == "" {
+		return exp, nil
+	}
+	if inst == nil || j.MatrixVar == "" {
+		return nil, fmt.Errorf("missing matrix")
+	}
+	vA, ok := inst.Vars[j.MatrixVar]
+	if !ok {
+		return nil, fmt.Errorf("no matrix var")
+	}
+	switch j.SpanOf {
+	case "nullspace":
+		A, ok := vA.(*MatrixInt)
+		if !ok {
+			return nil, fmt.Errorf("matrix type")
+		}
+		return NullspaceBasisRational(A)
+	case "column":
+		M, ok := matrixRatOf(vA)
+		if !ok {
+			return nil, fmt.Errorf("matrix type")
+		}
+		cols := make([][]*big.Rat, M.C)
+		for c := range cols {
+			cols[c] = make([]*big.Rat, M.R)
+			for r := 0; r < M.R; r++ {
+				cols[c][r] = M.A[r][c]
+			}
+		}
+		return cols, nil
+	}
+	return nil, fmt.Errorf("unknown span_of %q", j.SpanOf)
+}
+
+// spanEqualOK 判断 got 线性无关且与 ref 张成同一子空间：个数等于 rank(ref)，rank(got) 满秩，且 rank(ref ∪ got) = rank(ref)。
+func spanEqualOK(ref, got [][]*big.Rat) (bool, string) {
+	if len(got) == 0 {
+		return false, "empty group"
+	}
+	n := len(got[0])
+	for _, v := range append(append([][]*big.Rat(nil), ref...), got...) {
+		if len(v) != n {
+			return false, "dim mismatch"
+		}
+	}
+	r := MatrixRatRank(ratColumnsToMatrix(ref, n))
+	if len(got) != r {
+		return false, fmt.Sprintf("want %d vectors, got %d", r, len(got))
+	}
+	if MatrixRatRank(ratColumnsToMatrix(got, n)) != len(got) {
+		return false, "linearly dependent"
+	}
+	if MatrixRatRank(ratColumnsToMatrix(append(append([][]*big.Rat(nil), ref...), got...), n)) != r {
+		return false, "not in subspace"
+	}
+	return true, ""
+}
+
+func judgeSpanEqualGroup(g *GeneratedQuestion, inst *Instance, user map[string]string, idxs []int) (bool, string) {
+	j0 := g.AnswerFields[idxs[0]].Judge
+	exp, got, err := spanGroupVectors(g, user, idxs, j0.SpanDim)
+	if err != nil {
+		return false, err.Error()
+	}
+	ref, err := spanReference(inst, j0, exp)
+	if err != nil {
+		return false, err.Error()
+	}
+	return spanEqualOK(ref, got)
+}
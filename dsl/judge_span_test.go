@@ -0,0 +1,136 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// spanTestProblem：A 的零空间为二维，标准基为 (-2,1,0,0)、(-1,0,-1,1)。
+func spanTestProblem(j *AnswerJudgeSpec) Problem {
+	p := Problem{
+		ID:        1,
+		Variables: map[string]Variable{"A": {Kind: "matrix", Rows: 2, Cols: 4, Fixed: [][]interface{}{{1, 2, -1, 0}, {2, 4, 0, 2}}}},
+	}
+	for k := 1; k <= 2; k++ {
+		for c := 1; c <= 4; c++ {
+			id := fmt.Sprintf("x%d%d", k, c)
+			p.Title += "{{blank:" + id + "}} "
+			p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: id, Expr: fmt.Sprintf("nullbasis_comp(A,%d,%d)", k, c), Judge: j})
+		}
+	}
+	return p
+}
+
+func spanUser(vecs ...string) map[string]string {
+	user := map[string]string{}
+	for k, v := range vecs {
+		for c, s := range strings.Fields(v) {
+			user[fmt.Sprintf("x%d%d", k+1, c+1)] = s
+		}
+	}
+	return user
+}
+
+func TestJudgeSpanEqual_nullspace(t *testing.T) {
+	p := spanTestProblem(&AnswerJudgeSpec{Kind: "span_equal", SpanGroup: "ker", SpanDim: 4, SpanOf: "nullspace", MatrixVar: "A"})
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	if InputConventionOf(p) != AnswerInputConventionV1 {
+		t.Fatal("component blanks should stay V1")
+	}
+	inst, err := InstantiateProblem(p, "s1", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GenerateQuestionFromInstance(p, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for vecs, note := range map[[2]string]string{
+		{"-2 1 0 0", "-1 0 -1 1"}: "",
+		{"-3 1 -1 1", "4 -2 0 0"}: "", // 另一组自由变量取法
+		{"1 0 1 -1", "-2 1 0 0"}:  "",
+		{"-2 1 0 0", "-4 2 0 0"}:  "linearly dependent",
+		{"1 0 0 0", "-2 1 0 0"}:   "not in subspace",
+		{"0 0 0 0", "-2 1 0 0"}:   "linearly dependent",
+		{"-2 1 0 0", "-1 0 -1 x"}: "vector 2: comp 3: cannot parse",
+	} {
+		r := JudgeGeneratedQuestionContext(g, &p, inst, spanUser(vecs[0], vecs[1]), nil)
+		if r.AllCorrect != (note == "") || r.Fields[0].DetailNote != note && !strings.HasPrefix(r.Fields[0].DetailNote, note) {
+			t.Fatalf("%v: %+v", vecs, r.Fields[0])
+		}
+	}
+	r := JudgeGeneratedQuestionContext(g, &p, nil, spanUser("-2 1 0 0", "-1 0 -1 1"), nil)
+	if r.CorrectCount != 0 || r.Fields[0].DetailNote != "need instance for span_equal judge" {
+		t.Fatalf("%+v", r.Fields[0])
+	}
+}
+
+func TestJudgeSpanEqual_wholeVectors(t *testing.T) {
+	A := &MatrixInt{R: 2, C: 3, A: [][]int64{{1, 0, 1}, {0, 1, 1}}}
+	inst := &Instance{Vars: map[string]interface{}{"A": A}}
+	// 特征子空间等以标准答案为参考：span{(1,0,0), (0,1,1)}
+	own := &AnswerJudgeSpec{Kind: "span_equal", SpanGroup: "e"}
+	col := &AnswerJudgeSpec{Kind: "span_equal", SpanGroup: "c", SpanOf: "column", MatrixVar: "A"}
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "e1", Value: &VectorInt{N: 3, V: []int64{1, 0, 0}}, Judge: own},
+		{ID: "e2", Value: &VectorInt{N: 3, V: []int64{0, 1, 1}}, Judge: own},
+		{ID: "c1", Value: &VectorInt{N: 2, V: []int64{1, 0}}, Judge: col},
+		{ID: "c2", Value: &VectorInt{N: 2, V: []int64{0, 1}}, Judge: col},
+	}}
+	user := map[string]string{"e1": "(1, 1, 1)", "e2": "(2, -1, -1)", "c1": "(1, 1)", "c2": "(1/2, -1)"}
+	for _, in := range []*Instance{inst, nil} {
+		r := JudgeGeneratedQuestionContext(g, nil, in, user, nil)
+		if !r.Fields[0].Correct || !r.Fields[1].Correct || r.Fields[0].Submitted != "(1, 1, 1)" {
+			t.Fatalf("%+v", r)
+		}
+		if r.AllCorrect != (in != nil) {
+			t.Fatalf("%+v", r)
+		}
+	}
+	user = map[string]string{"e1": "(1, 1, 0)", "e2": "(0, 1, 1)", "c1": "(1, 1)", "c2": "(1)"}
+	r := JudgeGeneratedQuestionContext(g, nil, inst, user, nil)
+	if r.Fields[0].DetailNote != "not in subspace" || r.Fields[2].DetailNote != "dim mismatch" {
+		t.Fatalf("%+v", r)
+	}
+	if ok, note := spanEqualOK(g3(1, 0, 0), g3(2, 0, 0, 0, 1, 0)); ok || note != "want 1 vectors, got 2" {
+		t.Fatalf("%v %s", ok, note)
+	}
+
+	p := Problem{
+		ID:        1,
+		Title:     "{{blank:u}} {{blank:v}}",
+		Variables: map[string]Variable{"A": {Kind: "matrix", Rows: 2, Cols: 4, Fixed: [][]interface{}{{1, 2, -1, 0}, {2, 4, 0, 2}}}},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{
+			{ID: "u", Expr: "nullbasis_vec(A,1)", Judge: own},
+			{ID: "v", Expr: "nullbasis_vec(A,2)", Judge: own},
+		}},
+	}
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("diagnostics:\n%s", diagStrings(ds))
+	}
+	if InputConventionOf(p) != AnswerInputConventionVector {
+		t.Fatal("whole-vector span blanks should need V2")
+	}
+	p.Answer.FieldDefs[1].Judge = &AnswerJudgeSpec{Kind: "span_equal", SpanGroup: "n", SpanDim: 3, SpanOf: "row"}
+	want := []string{
+		"error answer.field_defs[1]: judge span_equal: answer is vector(?); with span_dim each blank is one component",
+		`error answer.field_defs[1]: judge span_equal: unknown span_of "row"`,
+		`error answer.field_defs[1]: span_group "n" has 1 fields, not a multiple of span_dim 3`,
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
+
+// g3 把分量按 3 个一组拆成向量。
+func g3(xs ...int64) [][]*big.Rat {
+	var out [][]*big.Rat
+	for i := 0; i < len(xs); i += 3 {
+		out = append(out, vectorIntToRat(&VectorInt{N: 3, V: xs[i : i+3]}))
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+var surdInputReplacer = strings.NewReplacer(
+	"$", "", `\left`, "", `\right`, "", `\cdot`, "*", `\times`, "*", "·", "*", "×", "*", "**", "^",
+	`\sqrt`, "√", "sqrt", "√", `\dfrac`, "frac", `\tfrac`, "frac", `\frac`, "frac",
+	"{", "(", "}", ")",
+)
+
+// ParseUserSurd 解析用户输入的含二次根式的数，结果为精确值（*Surd）：
+// 1/sqrt(2)、√3/3、sqrt(6)/6、2√3、(1+√5)/2、1/(√3-√2)、\frac{\sqrt{6}}{6}、-\frac{1}{\sqrt2}。
+// 支持 + - * / ^（整数指数）与括号，相邻因子之间可省略乘号；√ 后为数字或括号，被开方数须为非负有理数。
+func ParseUserSurd(s string) (*Surd, error) {
+	s = surdInputReplacer.Replace(NormalizeUserAnswer(s))
+	if s == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	ps := &surdParser{src: []rune(s)}
+	v, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if ps.i < len(ps.src) {
+		return nil, fmt.Errorf("unexpected %q at %d", ps.src[ps.i], ps.i+1)
+	}
+	return v, nil
+}
+
+// SurdAnswersEqual 比较含根号的标准值与用户输入（精确相等）。
+func SurdAnswersEqual(expected *Surd, submitted string) (bool, string) {
+	if NormalizeUserAnswer(submitted) == "" {
+		return false, "empty"
+	}
+	got, err := ParseUserSurd(submitted)
+	if err != nil {
+		return false, err.Error()
+	}
+	if got.Equal(expected) {
+		return true, ""
+	}
+	return false, "value mismatch"
+}
+
+type surdParser struct {
+	src []rune
+	i   int
+}
+
+func (ps *surdParser) peek() rune {
+	if ps.i < len(ps.src) {
+		return ps.src[ps.i]
+	}
+	return 0
+}
+
+func (ps *surdParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(ps.src[ps.i:]), s)
+}
+
+// expr := ['+'|'-'] term (('+'|'-') term)*
+func (ps *surdParser) parseExpr() (*Surd, error) {
+	neg := false
+	switch ps.peek() {
+	case '+':
+		ps.i++
+	case '-':
+		ps.i++
+		neg = true
+	}
+	out, err := ps.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	if neg {
+		out = out.Neg()
+	}
+	for ps.peek() == '+' || ps.peek() == '-' {
+		op := ps.peek()
+		ps.i++
+		t, err := ps.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if op == '+' {
+			out = out.Add(t)
+		} else {
+			out = out.Sub(t)
+		}
+	}
+	return out, nil
+}
+
+// term := factor (('*' | '/' | 省略乘号) factor)*
+func (ps *surdParser) parseTerm() (*Surd, error) {
+	out, err := ps.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		div := false
+		switch c := ps.peek(); {
+		case c == '*':
+			ps.i++
+		case c == '/':
+			ps.i++
+			div = true
+		case c == '(' || c == '√' || ps.hasPrefix("frac"):
+		default:
+			return out, nil
+		}
+		f, err := ps.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		if div {
+			out, err = out.Quo(f)
+		} else {
+			out, err = out.Mul(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// factor := base ('^' ['-'] 整数)?
+func (ps *surdParser) parseFactor() (*Surd, error) {
+	base, err := ps.parseBase()
+	if err != nil {
+		return nil, err
+	}
+	if ps.peek() != '^' {
+		return base, nil
+	}
+	ps.i++
+	neg := false
+	if ps.peek() == '-' {
+		ps.i++
+		neg = true
+	}
+	start := ps.i
+	for ps.i < len(ps.src) && unicode.IsDigit(ps.src[ps.i]) {
+		ps.i++
+	}
+	if start == ps.i || ps.i-start > 2 {
+		return nil, fmt.Errorf("bad exponent at %d", start+1)
+	}
+	n := int64(0)
+	for _, d := range ps.src[start:ps.i] {
+		n = n*10 + int64(d-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return base.pow(n)
+}
+
+// base := 数字 | '(' expr ')' | '√' (数字 | '(' expr ')') | frac 分组 分组
+func (ps *surdParser) parseBase() (*Surd, error) {
+	c := ps.peek()
+	switch {
+	case c == '(':
+		return ps.parseGroup()
+	case c == '√':
+		ps.i++
+		x, err := ps.parseRadicand(false)
+		if err != nil {
+			return nil, err
+		}
+		q, ok := x.Rat()
+		if !ok {
+			return nil, fmt.Errorf("√ of irrational value %s", x)
+		}
+		return SurdSqrt(q)
+	case ps.hasPrefix("frac"):
+		ps.i += len("frac")
+		num, err := ps.parseRadicand(true)
+		if err != nil {
+			return nil, err
+		}
+		den, err := ps.parseRadicand(true)
+		if err != nil {
+			return nil, err
+		}
+		return num.Quo(den)
+	case unicode.IsDigit(c) || c == '.':
+		start := ps.i
+		for ps.i < len(ps.src) && (unicode.IsDigit(ps.src[ps.i]) || ps.src[ps.i] == '.') {
+			ps.i++
+		}
+		v, ok := new(big.Rat).SetString(string(ps.src[start:ps.i]))
+		if !ok {
+			return nil, fmt.Errorf("bad number %q", string(ps.src[start:ps.i]))
+		}
+		return SurdFromRat(v), nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", c, ps.i+1)
+}
+
+// parseRadicand 读取 √ 或 frac 的参数：括号分组，或省略括号的整数（√12）；oneDigit 时只取一位数字（\frac12）。
+func (ps *surdParser) parseRadicand(oneDigit bool) (*Surd, error) {
+	if ps.peek() == '(' {
+		return ps.parseGroup()
+	}
+	if !unicode.IsDigit(ps.peek()) {
+		return ps.parseBase()
+	}
+	start := ps.i
+	for ps.i < len(ps.src) && unicode.IsDigit(ps.src[ps.i]) && !(oneDigit && ps.i > start) {
+		ps.i++
+	}
+	v, _ := new(big.Rat).SetString(string(ps.src[start:ps.i]))
+	return SurdFromRat(v), nil
+}
+
+func (ps *surdParser) parseGroup() (*Surd, error) {
+	ps.i++
+	v, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if ps.peek() != ')' {
+		return nil, fmt.Errorf("missing ) at %d", ps.i+1)
+	}
+	ps.i++
+	return v, nil
+}
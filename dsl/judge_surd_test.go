@@ -0,0 +1,116 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseUserSurd(t *testing.T) {
+	for in, want := range map[string]string{
+		"1/sqrt(2)":               "sqrt(2)/2",
+		"√3/3":                    "sqrt(3)/3",
+		"sqrt(6)/6":               "sqrt(6)/6",
+		"2√3":                     "2*sqrt(3)",
+		"√12":                     "2*sqrt(3)",
+		"(1+√5)/2":                "(1+sqrt(5))/2",
+		"1/(√3-√2)":               "sqrt(2)+sqrt(3)",
+		"√2√3":                    "sqrt(6)",
+		"-1/√2":                   "-sqrt(2)/2",
+		"（√2）^3":                  "2*sqrt(2)",
+		"sqrt(1/2)":               "sqrt(2)/2",
+		`$\frac{\sqrt{6}}{6}$`:    "sqrt(6)/6",
+		`-\dfrac{1}{\sqrt2}`:      "-sqrt(2)/2",
+		`\frac12\sqrt{3}`:         "sqrt(3)/2",
+		`2\cdot\sqrt{2}-\sqrt{8}`: "0",
+		"3/4":                     "3/4",
+	} {
+		s, err := ParseUserSurd(in)
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if got := s.String(); got != want {
+			t.Fatalf("%q: got %s, want %s", in, got, want)
+		}
+		if back, err := ParseUserSurd(s.String()); err != nil || !back.Equal(s) {
+			t.Fatalf("%q: canonical %s does not parse back: %v", in, s, err)
+		}
+	}
+	for in, want := range map[string]string{
+		"":          "empty",
+		"√(-2)":     "sqrt of negative",
+		"√(√2)":     "irrational",
+		"1/(√2-√2)": "division by zero",
+		"√2+":       "unexpected end",
+		"∛2":        "unexpected",
+	} {
+		if _, err := ParseUserSurd(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestJudgeSurdField(t *testing.T) {
+	u, _ := SurdSqrt(big.NewRat(1, 6))
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "u1", Value: u},
+		{ID: "u2", Value: u.Neg()},
+		{ID: "k", Value: big.NewRat(1, 2)},
+	}}
+	r := JudgeGeneratedQuestion(g, map[string]string{"u1": "1/√6", "u2": `-\frac{\sqrt{6}}{6}`, "k": "1/2"}, nil)
+	if !r.AllCorrect || r.Fields[0].Expected != "sqrt(6)/6" {
+		t.Fatalf("%+v", r)
+	}
+	r = JudgeGeneratedQuestion(g, map[string]string{"u1": "0.408", "u2": "√6/6", "k": "sqrt(1/4)"}, nil)
+	if r.Fields[0].Correct || r.Fields[1].Correct || r.Fields[1].DetailNote != "value mismatch" || r.Fields[2].Correct {
+		t.Fatalf("%+v", r)
+	}
+	if h := FieldInputHintsFromGenerated(g); h[0].Kind != FieldAnswerKindSurd || h[2].Kind != FieldAnswerKindRational {
+		t.Fatalf("%+v", h)
+	}
+	p := compileTestProblem()
+	if InputConventionOf(p) != AnswerInputConventionV1 {
+		t.Fatal("rational problem should stay on V1")
+	}
+	p.Title += ", {{blank:u}}"
+	p.Derived["u"] = "mget(A, 1, 1)/norm(col(A, 1))"
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: "u", Expr: "u"})
+	if InputConventionOf(p) != AnswerInputConventionSurd {
+		t.Fatal("surd answer should need the surd convention")
+	}
+	gq, err := GenerateQuestion(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := JudgeGeneratedQuestion(gq, map[string]string{"d": "5", "u": "sqrt(10)/10"}, nil); !r.Fields[1].Correct {
+		t.Fatalf("%+v", r)
+	}
+}
+
+// 自定义函数按签名的返回类型决定是否需要根式输入约定，不看函数名。
+func TestInputConventionOf_registeredSurdFunc(t *testing.T) {
+	RegisterFunction("test_root2", MustParseSignature("scalar -> surd"), func(c *Call) (interface{}, error) {
+		r, err := c.Rat(0)
+		if err != nil {
+			return nil, err
+		}
+		s, err := SurdSqrt(new(big.Rat).Mul(r, big.NewRat(2, 1)))
+		if err != nil {
+			return nil, err
+		}
+		return surdValue(s), nil
+	})
+	defer delete(exprFuncs, "test_root2")
+
+	p := compileTestProblem()
+	p.Title += ", {{blank:u}}"
+	p.Derived["u"] = "test_root2(mget(A, 1, 1))"
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs, AnswerFieldDef{ID: "u", Expr: "u"})
+	if got := InputConventionsOf(p); len(got) != 2 || got[1] != AnswerInputConventionSurd {
+		t.Fatalf("conventions %v", got)
+	}
+	p.Derived["u"] = "abs(mget(A, 1, 1))"
+	if got := InputConventionsOf(p); len(got) != 1 {
+		t.Fatalf("rational answer: conventions %v", got)
+	}
+}
@@ -12,6 +12,18 @@ package dsl
 //     1) 每列 j 的向量 v_j 非零，且 MatrixVar · v_j == λ_j · v_j
 //     2) 用户给出的全部 λ 与标准答案特征值 multiset 相等
 //     RefLambdaGroup 允许 vec-only 的组（如 Q 或 α 的第二分组）借用另一组的 λ 字段。
+//   - "matrix"：单个空填写整个矩阵（表达式值须为矩阵），逐元素按有理数比较，FieldJudgement.Cells 给出逐格结果；
+//     输入写法见 ParseUserMatrix，含此类空的题目使用输入约定 AnswerInputConventionV2。
+//   - "vector"：单个空填写整个向量（表达式值须为向量），逐分量按有理数比较，Cells 的 Row 为分量下标；输入写法见 ParseUserVector。
+//     rational_line、affine_rational 组内只有一个空且表达式值为向量时，同样整向量填写，再按组的规则判分。
+//   - "interval"：单个空填写取值范围（表达式值须为 *IntervalSet，如 interval_gt(a)），区间或不等式写法均可，
+//     按集合相等判分（端点开闭须一致）；输入写法见 ParseUserInterval。
+//   - "span_equal"：同 SpanGroup 的空给出子空间的一组基（SpanDim 个空组成一个向量；SpanDim 为 0 时每空填写一个整向量），
+//     要求向量线性无关、个数等于子空间维数且都落在参考子空间内，与标准答案选取哪组基无关。参考子空间由 SpanOf 决定：
+//     "" 为组内标准答案向量张成的子空间（如特征子空间），"nullspace" 为 MatrixVar 的零空间（基础解系），"column" 为 MatrixVar 的列空间。
+//   - "general_solution"：同 SolutionGroup 的空给出非齐次方程组的通解 x = x₀ + k₁ξ₁ + … + k_rξ_r，按 AnswerFields 顺序先 x₀ 后各 ξ，
+//     每个向量占 SpanDim 个空（为 0 时每空一个整向量）。x₀ 的空验证 MatrixVar·x₀ == BVecVar，ξ 的空验证个数为 n − rank(A)、
+//     线性无关且都在 A 的零空间内；两部分分别判分，特解与基础解系均可任取。
 type AnswerJudgeSpec struct {
 	Kind string `json:"kind,omitempty"`
 
@@ -33,4 +45,12 @@ type AnswerJudgeSpec struct {
 	EigenColumn    int    `json:"eigen_column,omitempty"`     // 1-based：该字段对应第几个特征对
 	EigenComponent int    `json:"eigen_component,omitempty"`  // role=vec 时：向量第几个分量（1-based）
 	RefLambdaGroup string `json:"ref_lambda_group,omitempty"` // 仅 vec 组：借用另一组的 λ 字段
+
+	// span_equal 相关字段（"nullspace"、"column" 另需 MatrixVar）；SpanDim 也用于 general_solution。
+	SpanGroup string `json:"span_group,omitempty"`
+	SpanDim   int    `json:"span_dim,omitempty"`
+	SpanOf    string `json:"span_of,omitempty"`
+
+	// general_solution：同组为一个通解，另需 MatrixVar、BVecVar。
+	SolutionGroup string `json:"solution_group,omitempty"`
 }
@@ -0,0 +1,206 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// 整向量填空：一个空填写整个向量。judge kind "vector" 逐分量精确比较；
+// rational_line / affine_rational 组内只有这一个空时，按整向量判共线或 Mx=b。
+
+// transposeSuffixRe 匹配列向量常见的转置记号 ^T、^{T}、ᵀ。
+var transposeSuffixRe = regexp.MustCompile(`\s*(?:\^\s*\{?\s*T\s*\}?|ᵀ)$`)
+
+// ParseUserVector 解析用户输入的整个向量，分量为整数、分数或小数：
+// (1, -2, 3/4)、[1,-2,3/4]、1 -2 3/4、（1，−2，3/4）、1、-2、3/4，可带转置记号 (1,2,3)^T；
+// 也接受单行或单列的矩阵写法（见 ParseUserMatrix），如 \begin{pmatrix}1\\2\end{pmatrix}。
+func ParseUserVector(s string) (*VectorRat, error) {
+	s = strings.TrimSpace(strings.Trim(strings.TrimSpace(s), "$"))
+	s = transposeSuffixRe.ReplaceAllString(s, "")
+	m, err := ParseUserMatrix(s)
+	if err != nil {
+		return nil, err
+	}
+	if m.R != 1 && m.C != 1 {
+		return nil, fmt.Errorf("%d×%d matrix, want a vector", m.R, m.C)
+	}
+	v := NewVectorRat(m.R * m.C)
+	for i := range v.V {
+		if m.R == 1 {
+			v.V[i] = m.A[0][i]
+		} else {
+			v.V[i] = m.A[i][0]
+		}
+	}
+	return v, nil
+}
+
+// VectorAnswersEqual 逐分量比较标准向量与用户输入；维数一致时 cells 给出逐分量结果（Row 为分量下标，Col 为 1）。
+func VectorAnswersEqual(expected interface{}, submitted string) (bool, string, []CellJudgement) {
+	ex, ok := vectorRatOf(expected)
+	if !ok {
+		return false, fmt.Sprintf("unsupported answer type %T", expected), nil
+	}
+	if strings.TrimSpace(submitted) == "" {
+		return false, "empty", nil
+	}
+	got, err := ParseUserVector(submitted)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	if got.N != len(ex) {
+		return false, fmt.Sprintf("dim %d, want %d", got.N, len(ex)), nil
+	}
+	cells := make([]CellJudgement, len(ex))
+	wrong := 0
+	for i, e := range ex {
+		cells[i] = CellJudgement{
+			Row:       i + 1,
+			Col:       1,
+			Correct:   e.Cmp(got.V[i]) == 0,
+			Expected:  e.RatString(),
+			Submitted: got.V[i].RatString(),
+		}
+		if !cells[i].Correct {
+			wrong++
+		}
+	}
+	if wrong > 0 {
+		return false, fmt.Sprintf("%d of %d entries mismatch", wrong, len(cells)), cells
+	}
+	return true, "", cells
+}
+
+// vectorRatOf 把向量值（*VectorInt、*VectorRat、[]*big.Rat）转为分量列表。
+func vectorRatOf(v interface{}) ([]*big.Rat, bool) {
+	switch t := v.(type) {
+	case *VectorRat:
+		return t.V, true
+	case *VectorInt:
+		return vectorIntToRat(t), true
+	case []*big.Rat:
+		return t, true
+	}
+	return nil, false
+}
+
+// groupVectors 收集一个判题组的标准分量与用户分量：组内只有一个空且标准值为向量时，
+// 该空填写整个向量（ParseUserVector），否则每空一个分量。
+func groupVectors(g *GeneratedQuestion, user map[string]string, idxs []int) (exp, got []*big.Rat, err error) {
+	subs := make([]string, len(idxs))
+	for k, i := range idxs {
+		if user != nil {
+			subs[k] = user[g.AnswerFields[i].ID]
+		}
+	}
+	if len(idxs) == 1 {
+		if ev, ok := vectorRatOf(g.AnswerFields[idxs[0]].Value); ok {
+			uv, err := ParseUserVector(subs[0])
+			if err != nil {
+				return ev, nil, err
+			}
+			return ev, uv.V, nil
+		}
+	}
+	expVals := make([]interface{}, len(idxs))
+	for k, i := range idxs {
+		expVals[k] = g.AnswerFields[i].Value
+	}
+	if exp, err = interfaceSliceToRatVec(expVals); err != nil {
+		return nil, nil, err
+	}
+	got, err = parseUserRatVector(subs)
+	return exp, got, err
+}
+
+// submittedString 为判分结果中的 Submitted：整矩阵、整向量与区间空为解析后的规范写法（解析失败时为原输入），其余同 NormalizeUserAnswer。
+func submittedString(f AnswerField, sub string) string {
+	if _, ok := matrixRatOf(f.Value); ok {
+		if m, err := ParseUserMatrix(sub); err == nil {
+			return ValueToCanonicalString(m)
+		}
+		return strings.TrimSpace(sub)
+	}
+	if _, ok := vectorRatOf(f.Value); ok {
+		if v, err := ParseUserVector(sub); err == nil {
+			return ValueToCanonicalString(v)
+		}
+		return strings.TrimSpace(sub)
+	}
+	if _, ok := f.Value.(*IntervalSet); ok {
+		if s, err := ParseUserInterval(sub); err == nil {
+			return s.String()
+		}
+		return strings.TrimSpace(sub)
+	}
+	return NormalizeUserAnswer(sub)
+}
+
+// vectorGroupKey 返回可由一个整向量空构成的判题组的键（rational_line、affine_rational），其他判题类型为 ""。
+func vectorGroupKey(j *AnswerJudgeSpec) string {
+	switch {
+	case j == nil:
+		return ""
+	case j.Kind == "rational_line" && j.LineGroup != "":
+		return "line_group " + j.LineGroup
+	case j.Kind == "affine_rational" && j.AffineGroup != "":
+		return "affine_group " + j.AffineGroup
+	}
+	return ""
+}
+
+// wholeValueKind 返回标准值对应的整体填空判题类型："matrix"、"vector"、"interval"，其他值为 ""。
+func wholeValueKind(v interface{}) string {
+	if _, ok := matrixRatOf(v); ok {
+		return "matrix"
+	}
+	if _, ok := vectorRatOf(v); ok {
+		return "vector"
+	}
+	if _, ok := v.(*IntervalSet); ok {
+		return "interval"
+	}
+	return ""
+}
+
+// judgeWholeField 按 Judge.Kind 判一个整矩阵、整向量空（逐元素精确比较）或区间空（集合相等）。
+// 判题类型与标准值不符、或未声明这三类却得到矩阵、向量、区间值时判错并在 DetailNote 中说明；
+// 其余字段 ok 为 false，交由标量判分。
+func judgeWholeField(f AnswerField, sub string, w float64) (fj FieldJudgement, ok bool) {
+	fj = FieldJudgement{ID: f.ID, Expected: ValueToCanonicalString(f.Value), Submitted: submittedString(f, sub), Weight: w}
+	kind := ""
+	if f.Judge != nil {
+		kind = f.Judge.Kind
+	}
+	got := wholeValueKind(f.Value)
+	switch kind {
+	case "matrix", "vector", "interval":
+		if got != kind {
+			if got == "" {
+				got = fmt.Sprintf("%T", f.Value)
+			}
+			fj.DetailNote = fmt.Sprintf("judge %s: answer is %s", kind, got)
+			return fj, true
+		}
+	default:
+		if got == "" {
+			return fj, false
+		}
+		fj.DetailNote = fmt.Sprintf("answer is a %s; judge kind %q required", got, got)
+		return fj, true
+	}
+	switch kind {
+	case "matrix":
+		fj.Correct, fj.DetailNote, fj.Cells = MatrixAnswersEqual(f.Value, sub)
+	case "vector":
+		fj.Correct, fj.DetailNote, fj.Cells = VectorAnswersEqual(f.Value, sub)
+	case "interval":
+		fj.Correct, fj.DetailNote = IntervalAnswersEqual(f.Value.(*IntervalSet), sub)
+	}
+	if fj.Correct {
+		fj.Score = w
+	}
+	return fj, true
+}
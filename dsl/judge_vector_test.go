@@ -0,0 +1,84 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseUserVector(t *testing.T) {
+	for in, want := range map[string]string{
+		"(1, -2, 3/4)":  "(1, -2, 3/4)",
+		"[1,-2,0.75]":   "(1, -2, 3/4)",
+		"1 -2 3/4":      "(1, -2, 3/4)",
+		"（1，−2，3/4）":    "(1, -2, 3/4)",
+		"1、-2、3/4":      "(1, -2, 3/4)",
+		"(1,2,3)^T":     "(1, 2, 3)",
+		"$(1,2,3)^{T}$": "(1, 2, 3)",
+		"（1，2）ᵀ":        "(1, 2)",
+		"1; 2; 3":       "(1, 2, 3)",
+		"[[1],[2],[3]]": "(1, 2, 3)",
+		"5":             "(5)",
+		`\begin{pmatrix}1\\-\frac{1}{2}\end{pmatrix}`: "(1, -1/2)",
+	} {
+		v, err := ParseUserVector(in)
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if got := ValueToCanonicalString(v); got != want {
+			t.Fatalf("%q: got %s, want %s", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"":           "empty",
+		"1 2; 3 4":   "2×2 matrix, want a vector",
+		"(1, , 3)":   "row 1 col 2: empty entry",
+		"(1, 2x, 3)": "row 1 col 2: cannot parse",
+	} {
+		if _, err := ParseUserVector(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestJudgeVectorField(t *testing.T) {
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "v", Value: &VectorInt{N: 3, V: []int64{1, -2, 0}}, Judge: &AnswerJudgeSpec{Kind: "vector"}},
+		{ID: "r", Value: []*big.Rat{big.NewRat(1, 2), big.NewRat(3, 1)}, Judge: &AnswerJudgeSpec{Kind: "vector"}},
+		// 组内只有一个向量空：按共线判分
+		{ID: "w", Value: &VectorInt{N: 2, V: []int64{1, -2}}, Judge: &AnswerJudgeSpec{Kind: "rational_line", LineGroup: "w"}},
+	}}
+	user := map[string]string{"v": "（1，−2，0）", "r": "[0.5, 3]", "w": "(-3, 6)"}
+	r := JudgeGeneratedQuestionContext(g, nil, &Instance{}, user, nil)
+	if !r.AllCorrect || len(r.Fields[0].Cells) != 3 || r.Fields[0].Submitted != "(1, -2, 0)" || r.Fields[2].Submitted != "(-3, 6)" {
+		t.Fatalf("%+v", r)
+	}
+	user = map[string]string{"v": "1 2 0", "r": "(1/2, 3, 0)", "w": "(1, 2)"}
+	r = JudgeGeneratedQuestionContext(g, nil, &Instance{}, user, nil)
+	if f := r.Fields[0]; f.Correct || f.DetailNote != "1 of 3 entries mismatch" || f.Cells[1].Correct || f.Cells[1].Row != 2 || f.Cells[1].Col != 1 {
+		t.Fatalf("%+v", f)
+	}
+	if f := r.Fields[1]; f.Correct || f.DetailNote != "dim 3, want 2" || f.Expected != "[1/2, 3]" {
+		t.Fatalf("%+v", f)
+	}
+	if f := r.Fields[2]; f.Correct || f.DetailNote == "" {
+		t.Fatalf("%+v", f)
+	}
+	if h := FieldInputHintsFromGenerated(g); h[0].Kind != FieldAnswerKindVector || h[1].Kind != FieldAnswerKindVector {
+		t.Fatalf("%+v", h)
+	}
+
+	// 按 Judge.Kind 判分：未声明 vector 的向量值、或判题类型与值不符时判错，而不是按值的类型猜
+	g = &GeneratedQuestion{AnswerFields: []AnswerField{
+		{ID: "u", Value: &VectorInt{N: 2, V: []int64{1, 2}}},
+		{ID: "m", Value: &VectorInt{N: 2, V: []int64{1, 2}}, Judge: &AnswerJudgeSpec{Kind: "matrix"}},
+		{ID: "s", Value: int64(3), Judge: &AnswerJudgeSpec{Kind: "vector"}},
+	}}
+	user = map[string]string{"u": "(1, 2)", "m": "1 2", "s": "3"}
+	r = JudgeGeneratedQuestionContext(g, nil, &Instance{}, user, nil)
+	for i, want := range []string{`answer is a vector; judge kind "vector" required`, "judge matrix: answer is vector", "judge vector: answer is int64"} {
+		if f := r.Fields[i]; f.Correct || f.DetailNote != want {
+			t.Fatalf("%+v", f)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package dsl
 
 import (
 	"fmt"
-	"math/rand"
 	"sort"
 	"strings"
 )
@@ -17,17 +16,17 @@ import (
 //
 //	R(A-λᵢI) = 5-mᵢ  (since nullity(A-λᵢI) = mᵢ)
 //
-// Stores _eigen_rank_lambdas, _eigen_rank_mults, _eigen_rank_ranks in inst.Vars.
-func genScalarEigenRankInference5(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -8))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 8))
+// Publishes outputs lambdas, mults and ranks (vectors, aligned by index).
+func genScalarEigenRankInference5(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	lmin := int64(params.Int("lambda_min", -8))
+	lmax := int64(params.Int("lambda_max", 8))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
 
 	for attempt := 0; attempt < 200; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(200)
+		attemptRng := inst.subRand(rng)
 
 		// Pick 3 distinct nonzero eigenvalues
 		lambdas := make([]int64, 3)
@@ -113,12 +112,9 @@ func genScalarEigenRankInference5(rng *rand.Rand, v Variable, inst *Instance) (i
 		}
 
 		// Store data for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_eigen_rank_lambdas"] = lambdas
-		inst.Vars["_eigen_rank_mults"] = mults
-		inst.Vars["_eigen_rank_ranks"] = ranks
+		out.Set("lambdas", intVector(lambdas))
+		out.Set("mults", intVector(mults))
+		out.Set("ranks", intVector(ranks))
 
 		// Return a placeholder scalar (the student never sees a matrix)
 		return int64(0), nil
@@ -139,17 +135,16 @@ func genScalarEigenRankInference5(rng *rand.Rand, v Variable, inst *Instance) (i
 // For rank=3: 0, s, -k, λ₄ (need an extra eigenvalue)
 // We only generate rank=2 cases (matching the HTML template structure).
 //
-// Stores _eigen_rowsum_lambdas (sorted), _eigen_rowsum_s, _eigen_rowsum_r,
-// _eigen_rowsum_k in inst.Vars.
-func genScalarEigenRowSumRank4(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	smin := int64(defaultInt(v.Generator, "row_sum_min", 2))
-	smax := int64(defaultInt(v.Generator, "row_sum_max", 6))
-	kmin := int64(defaultInt(v.Generator, "k_min", 2))
-	kmax := int64(defaultInt(v.Generator, "k_max", 10))
+// Publishes outputs lambdas (sorted vector), s, r and k.
+func genScalarEigenRowSumRank4(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	smin := int64(params.Int("row_sum_min", 2))
+	smax := int64(params.Int("row_sum_max", 6))
+	kmin := int64(params.Int("k_min", 2))
+	kmax := int64(params.Int("k_max", 10))
 
 	for attempt := 0; attempt < 200; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(200)
+		attemptRng := inst.subRand(rng)
 
 		// Pick row sum s (eigenvalue from row-sum property, must be nonzero)
 		s := int64(attemptRng.Intn(int(smax-smin+1)) + int(smin))
@@ -178,13 +173,10 @@ func genScalarEigenRowSumRank4(rng *rand.Rand, v Variable, inst *Instance) (inte
 		sort.Slice(lambdas, func(i, j int) bool { return lambdas[i] < lambdas[j] })
 
 		// Store
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_eigen_rowsum_lambdas"] = lambdas
-		inst.Vars["_eigen_rowsum_s"] = s
-		inst.Vars["_eigen_rowsum_r"] = int64(r)
-		inst.Vars["_eigen_rowsum_k"] = k
+		out.Set("lambdas", intVector(lambdas))
+		out.Set("s", s)
+		out.Set("r", int64(r))
+		out.Set("k", k)
 
 		return int64(0), nil
 	}
@@ -211,29 +203,21 @@ func formatRankConditionList(lambdas []int64, ranks []int64) string {
 }
 
 // eigenval_rank(S,i): returns the i-th eigenvalue (1-based, sorted ascending)
-// from _eigen_rank_lambdas/mults stored by genScalarEigenRankInference5
-func evalEigenvalRank(inst *Instance, i int) (int64, error) {
-	lv, ok := inst.Vars["_eigen_rank_lambdas"]
-	if !ok {
-		return 0, fmt.Errorf("eigenval_rank: data not found")
-	}
-	lambdas, ok := lv.([]int64)
-	if !ok {
-		return 0, fmt.Errorf("eigenval_rank: unexpected type %T", lv)
+// from the lambdas/mults outputs of genScalarEigenRankInference5
+func evalEigenvalRank(out GeneratorOutputs, i int) (int64, error) {
+	lambdas, err := out.vectorValue("eigenval_rank", "lambdas")
+	if err != nil {
+		return 0, err
 	}
-	mv, ok2 := inst.Vars["_eigen_rank_mults"]
-	if !ok2 {
-		return 0, fmt.Errorf("eigenval_rank: multiplicities not found")
-	}
-	mults, ok2 := mv.([]int64)
-	if !ok2 {
-		return 0, fmt.Errorf("eigenval_rank: multiplicities type %T", mv)
+	mults, err := out.vectorValue("eigenval_rank", "mults")
+	if err != nil {
+		return 0, err
 	}
 	// Build full list of eigenvalues (with multiplicities), sorted ascending
 	var full []int64
-	for j := 0; j < len(lambdas); j++ {
-		for t := 0; t < int(mults[j]); t++ {
-			full = append(full, lambdas[j])
+	for j := 0; j < lambdas.N && j < mults.N; j++ {
+		for t := 0; t < int(mults.V[j]); t++ {
+			full = append(full, lambdas.V[j])
 		}
 	}
 	sort.Slice(full, func(a, b int) bool { return full[a] < full[b] })
@@ -244,68 +228,45 @@ func evalEigenvalRank(inst *Instance, i int) (int64, error) {
 }
 
 // eigenval_rowsum(S,i): returns the i-th eigenvalue (1-based, sorted ascending)
-// from _eigen_rowsum_lambdas stored by genScalarEigenRowSumRank4
-func evalEigenvalRowsum(inst *Instance, i int) (int64, error) {
-	lv, ok := inst.Vars["_eigen_rowsum_lambdas"]
-	if !ok {
-		return 0, fmt.Errorf("eigenval_rowsum: data not found")
-	}
-	lambdas, ok := lv.([]int64)
-	if !ok {
-		return 0, fmt.Errorf("eigenval_rowsum: unexpected type %T", lv)
+// from the lambdas output of genScalarEigenRowSumRank4
+func evalEigenvalRowsum(out GeneratorOutputs, i int) (int64, error) {
+	lambdas, err := out.vectorValue("eigenval_rowsum", "lambdas")
+	if err != nil {
+		return 0, err
 	}
-	if i < 1 || i > len(lambdas) {
+	if i < 1 || i > lambdas.N {
 		return 0, fmt.Errorf("eigenval_rowsum: index %d out of range", i)
 	}
-	return lambdas[i-1], nil
+	return lambdas.V[i-1], nil
 }
 
 // evalEigenRankConditionText returns LaTeX string of rank conditions
-func evalEigenRankConditionText(inst *Instance) (string, error) {
-	lv, ok := inst.Vars["_eigen_rank_lambdas"]
-	if !ok {
-		return "", fmt.Errorf("eigen_rank_condition_text: data not found")
+func evalEigenRankConditionText(out GeneratorOutputs) (string, error) {
+	lambdas, err := out.vectorValue("eigen_rank_condition_text", "lambdas")
+	if err != nil {
+		return "", err
 	}
-	lambdas, ok := lv.([]int64)
-	if !ok {
-		return "", fmt.Errorf("eigen_rank_condition_text: type %T", lv)
+	ranks, err := out.vectorValue("eigen_rank_condition_text", "ranks")
+	if err != nil {
+		return "", err
 	}
-	rv, ok2 := inst.Vars["_eigen_rank_ranks"]
-	if !ok2 {
-		return "", fmt.Errorf("eigen_rank_condition_text: ranks not found")
-	}
-	ranks, ok2 := rv.([]int64)
-	if !ok2 {
-		return "", fmt.Errorf("eigen_rank_condition_text: ranks type %T", rv)
-	}
-	return formatRankConditionList(lambdas, ranks), nil
+	return formatRankConditionList(lambdas.V, ranks.V), nil
 }
 
 // evalEigenRowsumConditionText generates the condition text for rowsum-rank problems
-func evalEigenRowsumConditionText(inst *Instance) (string, error) {
-	sv, ok := inst.Vars["_eigen_rowsum_s"]
-	if !ok {
-		return "", fmt.Errorf("eigen_rowsum_condition: s not found")
-	}
-	s, ok := sv.(int64)
-	if !ok {
-		return "", fmt.Errorf("eigen_rowsum_condition: s type %T", sv)
-	}
-	rv, ok2 := inst.Vars["_eigen_rowsum_r"]
-	if !ok2 {
-		return "", fmt.Errorf("eigen_rowsum_condition: r not found")
-	}
-	r, ok2 := rv.(int64)
-	if !ok2 {
-		return "", fmt.Errorf("eigen_rowsum_condition: r type %T", rv)
+func evalEigenRowsumConditionText(out GeneratorOutputs) (string, error) {
+	const fname = "eigen_rowsum_condition_text"
+	s, err := out.int64Value(fname, "s")
+	if err != nil {
+		return "", err
 	}
-	kv, ok3 := inst.Vars["_eigen_rowsum_k"]
-	if !ok3 {
-		return "", fmt.Errorf("eigen_rowsum_condition: k not found")
+	r, err := out.int64Value(fname, "r")
+	if err != nil {
+		return "", err
 	}
-	k, ok3 := kv.(int64)
-	if !ok3 {
-		return "", fmt.Errorf("eigen_rowsum_condition: k type %T", kv)
+	k, err := out.int64Value(fname, "k")
+	if err != nil {
+		return "", err
 	}
 	return fmt.Sprintf("R(A)=%d，A 的各行元素之和都等于 %d，且 |A+%dE|=0", r, s, k), nil
 }
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"math/rand"
 )
 
 // genMatrixParamInfinitSolution generates a 3×3 coefficient matrix A and 3×1 RHS vector b
@@ -17,29 +16,29 @@ import (
 //  3. Choose b such that when λ makes rank(A)=2, b is in the column space (rank(A|b)=2 too)
 //  4. Specifically: b[3] = μ which equals the same linear combo of b[1], b[2]
 //
-// The specific values λ_val and μ_val are stored in inst.Vars.
+// The specific values λ_val and μ_val, b, the RREF and the solution are published as outputs.
 // The instantiated matrix (with λ_val, μ_val substituted) is returned.
-func genMatrixParamInfinitSolution(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
+func genMatrixParamInfinitSolution(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	r := v.Rows
 	c := v.Cols
 	if r != 3 || c != 3 {
 		return nil, errors.New("param_infinit_solution: need 3×3")
 	}
 
-	lmin := int64(defaultInt(v.Generator, "entry_min", -5))
-	lmax := int64(defaultInt(v.Generator, "entry_max", 5))
+	lmin := int64(params.Int("entry_min", -5))
+	lmax := int64(params.Int("entry_max", 5))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
-	lambdaMin := int64(defaultInt(v.Generator, "lambda_min", -10))
-	lambdaMax := int64(defaultInt(v.Generator, "lambda_max", 10))
+	lambdaMin := int64(params.Int("lambda_min", -10))
+	lambdaMax := int64(params.Int("lambda_max", 10))
 	if lambdaMax < lambdaMin {
 		lambdaMin, lambdaMax = lambdaMax, lambdaMin
 	}
 
 	for attempt := 0; attempt < 500; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
 
 		// Generate first 2 rows of A (3×3), ensuring row 1 and row 2 are linearly independent
 		A := NewMatrixInt(3, 3)
@@ -103,7 +102,7 @@ func genMatrixParamInfinitSolution(rng *rand.Rand, v Variable, inst *Instance) (
 		}
 
 		// Compute RREF of augmented matrix [A|b]
-		rref, err := RrefRatSafe(aug)
+		rref, err := rrefRat(aug, false)
 		if err != nil {
 			continue
 		}
@@ -162,19 +161,15 @@ func genMatrixParamInfinitSolution(rng *rand.Rand, v Variable, inst *Instance) (
 		}
 
 		// Store all data
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_param_lambda_val"] = lambdaVal
-		inst.Vars["_param_mu_val"] = muVal
-		inst.Vars["_param_b"] = b
-		inst.Vars["_param_rref"] = rrefMatrix
-		inst.Vars["_param_x0"] = ratVecToIntVec(x0)
-		inst.Vars["_param_nb1"] = ratVecToIntVec(nb[0])
-		inst.Vars["_param_colLambda"] = int64(colLambda + 1) // 1-based
-		inst.Vars["_param_k1"] = k1
-		inst.Vars["_param_k2"] = k2
-		inst.Vars["_param_A"] = A
+		out.Set("lambda", lambdaVal)
+		out.Set("mu", muVal)
+		out.Set("b", b)
+		out.Set("rref", rrefMatrix)
+		out.Set("x0", ratVecToIntVec(x0))
+		out.Set("nb1", ratVecToIntVec(nb[0]))
+		out.Set("lambda_col", int64(colLambda+1)) // 1-based
+		out.Set("k1", k1)
+		out.Set("k2", k2)
 
 		return A, nil
 	}
@@ -261,55 +256,18 @@ func ratVecToIntVec(ratVec []*big.Rat) *VectorInt {
 
 // RrefRat computes the reduced row echelon form of a rational matrix.
 func RrefRat(M *MatrixInt) [][]*big.Rat {
-	r, c := M.R, M.C
-	mat := make([][]*big.Rat, r)
-	for i := 0; i < r; i++ {
-		mat[i] = make([]*big.Rat, c)
-		for j := 0; j < c; j++ {
-			mat[i][j] = new(big.Rat).SetInt64(M.A[i][j])
-		}
-	}
-
-	row := 0
-	for col := 0; col < c && row < r; col++ {
-		pivotRow := -1
-		for i := row; i < r; i++ {
-			if mat[i][col].Sign() != 0 {
-				pivotRow = i
-				break
-			}
-		}
-		if pivotRow == -1 {
-			continue
-		}
-		if pivotRow != row {
-			mat[pivotRow], mat[row] = mat[pivotRow], mat[row]
-		}
-		pv := new(big.Rat).Set(mat[row][col])
-		for j := col; j < c; j++ {
-			mat[row][j] = new(big.Rat).Quo(mat[row][j], pv)
-		}
-		for i := 0; i < r; i++ {
-			if i == row {
-				continue
-			}
-			f := new(big.Rat).Set(mat[i][col])
-			if f.Sign() == 0 {
-				continue
-			}
-			for j := col; j < c; j++ {
-				tmp := new(big.Rat).Mul(f, mat[row][j])
-				mat[i][j] = new(big.Rat).Sub(mat[i][j], tmp)
-			}
-		}
-		row++
-	}
-
+	mat, _ := rrefRat(M, true)
 	return mat
 }
 
 // RrefRatSafe computes RREF with explicit new allocations to avoid aliasing issues.
 func RrefRatSafe(M *MatrixInt) ([][]*big.Rat, error) {
+	return rrefRat(M, true)
+}
+
+// rrefRat 为 RREF 的公共实现。allowSwap=false 时，若某列主元需要行交换则返回错误：
+// param_infinit_solution 的尝试流依赖这一拒绝条件，保留它以免已发题目变化。
+func rrefRat(M *MatrixInt, allowSwap bool) ([][]*big.Rat, error) {
 	r, c := M.R, M.C
 	mat := make([][]*big.Rat, r)
 	for i := 0; i < r; i++ {
@@ -332,12 +290,12 @@ func RrefRatSafe(M *MatrixInt) ([][]*big.Rat, error) {
 			continue
 		}
 		if pivotRow != row {
-			mat[pivotRow], mat[row] = mat[pivotRow], mat[row]
+			if !allowSwap {
+				return nil, fmt.Errorf("RREF: zero pivot")
+			}
+			mat[pivotRow], mat[row] = mat[row], mat[pivotRow]
 		}
 		pv := new(big.Rat).Set(mat[row][col])
-		if pv.Sign() == 0 {
-			return nil, fmt.Errorf("RREF: zero pivot")
-		}
 		for j := col; j < c; j++ {
 			old := new(big.Rat).Set(mat[row][j])
 			mat[row][j] = new(big.Rat).Quo(old, pv)
@@ -3,7 +3,6 @@ package dsl
 import (
 	"fmt"
 	"math/big"
-	"math/rand"
 	"strings"
 )
 
@@ -110,17 +109,18 @@ func polynomialSchmidt(polys [][]int64) [][]*big.Rat {
 //
 // Strategy: pick 3 polynomials of degree 0, 1, 2 with small integer coefficients,
 // verify they are linearly independent under the integral inner product.
-// Store: _poly_schmidt_input_polys, _poly_schmidt_output_polys (rational coeffs)
-func genPolySchmidtIntegral(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
-	cmin := int64(defaultInt(v.Generator, "coef_min", -5))
-	cmax := int64(defaultInt(v.Generator, "coef_max", 5))
+// Publishes outputs input and output: 3×3 matrices whose row j holds the
+// coefficients (constant, x, x²) of f_j and of the integer-scaled g_j.
+func genPolySchmidtIntegral(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
+	cmin := int64(params.Int("coef_min", -5))
+	cmax := int64(params.Int("coef_max", 5))
 	if cmax < cmin {
 		cmin, cmax = cmax, cmin
 	}
 
 	for attempt := 0; attempt < 500; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
 
 		// f1(x) = a0 (constant polynomial, nonzero)
 		a0 := int64(0)
@@ -209,11 +209,8 @@ func genPolySchmidtIntegral(rng *rand.Rand, v Variable, inst *Instance) (interfa
 		}
 
 		// Store
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_poly_schmidt_input"] = polys
-		inst.Vars["_poly_schmidt_output"] = intResults
+		out.Set("input", coeffRows(polys, 3))
+		out.Set("output", coeffRows(intResults, 3))
 
 		// Return a placeholder matrix (not shown)
 		return NewMatrixInt(3, 3), nil
@@ -317,61 +314,58 @@ func formatPolyLatex(coeffs []int64) string {
 	return result
 }
 
+// coeffRows packs polynomial coefficient lists into a matrix with n columns,
+// padding missing high-degree coefficients with 0.
+func coeffRows(polys [][]int64, n int) *MatrixInt {
+	m := NewMatrixInt(len(polys), n)
+	for i, p := range polys {
+		copy(m.A[i], p)
+	}
+	return m
+}
+
 // evalPolySchmidtComp returns the k-th coefficient of the j-th Schmidt polynomial
 // k=1 is constant, k=2 is x-coeff, k=3 is x^2-coeff; j=1,2,3 for g1,g2,g3
-func evalPolySchmidtComp(inst *Instance, j, k int) (int64, error) {
-	ov, ok := inst.Vars["_poly_schmidt_output"]
-	if !ok {
-		return 0, fmt.Errorf("poly_schmidt_comp: data not found")
-	}
-	output, ok := ov.([][]int64)
-	if !ok {
-		return 0, fmt.Errorf("poly_schmidt_comp: unexpected type %T", ov)
+func evalPolySchmidtComp(out GeneratorOutputs, j, k int) (int64, error) {
+	output, err := out.matrixValue("poly_schmidt_comp", "output")
+	if err != nil {
+		return 0, err
 	}
-	if j < 1 || j > len(output) {
+	if j < 1 || j > output.R {
 		return 0, fmt.Errorf("poly_schmidt_comp: j out of range")
 	}
-	if k < 1 || k > len(output[j-1]) {
+	if k < 1 || k > output.C {
 		return 0, fmt.Errorf("poly_schmidt_comp: k out of range")
 	}
-	return output[j-1][k-1], nil
+	return output.A[j-1][k-1], nil
 }
 
 // evalPolySchmidtInputComp returns the k-th coefficient of the j-th input polynomial
-func evalPolySchmidtInputComp(inst *Instance, j, k int) (int64, error) {
-	iv, ok := inst.Vars["_poly_schmidt_input"]
-	if !ok {
-		return 0, fmt.Errorf("poly_schmidt_input_comp: data not found")
+func evalPolySchmidtInputComp(out GeneratorOutputs, j, k int) (int64, error) {
+	input, err := out.matrixValue("poly_schmidt_input_comp", "input")
+	if err != nil {
+		return 0, err
 	}
-	input, ok := iv.([][]int64)
-	if !ok {
-		return 0, fmt.Errorf("poly_schmidt_input_comp: unexpected type %T", iv)
-	}
-	if j < 1 || j > len(input) {
+	if j < 1 || j > input.R {
 		return 0, fmt.Errorf("poly_schmidt_input_comp: j out of range")
 	}
-	poly := input[j-1]
-	if k < 1 || k > len(poly) {
-		if k > len(poly) {
-			return 0, nil // higher degree terms are 0
-		}
+	if k < 1 {
 		return 0, fmt.Errorf("poly_schmidt_input_comp: k out of range")
 	}
-	return poly[k-1], nil
+	if k > input.C {
+		return 0, nil // higher degree terms are 0
+	}
+	return input.A[j-1][k-1], nil
 }
 
 // evalPolySchmidtInputText renders the input polynomials as LaTeX
-func evalPolySchmidtInputText(inst *Instance) (string, error) {
-	iv, ok := inst.Vars["_poly_schmidt_input"]
-	if !ok {
-		return "", fmt.Errorf("poly_schmidt_input_text: data not found")
-	}
-	input, ok := iv.([][]int64)
-	if !ok {
-		return "", fmt.Errorf("poly_schmidt_input_text: unexpected type %T", iv)
+func evalPolySchmidtInputText(out GeneratorOutputs) (string, error) {
+	input, err := out.matrixValue("poly_schmidt_input_text", "input")
+	if err != nil {
+		return "", err
 	}
-	parts := make([]string, len(input))
-	for i, poly := range input {
+	parts := make([]string, input.R)
+	for i, poly := range input.A {
 		parts[i] = fmt.Sprintf("f_{%d}(x)=%s", i+1, formatPolyLatex(poly))
 	}
 	return strings.Join(parts, "，"), nil
@@ -5,7 +5,7 @@ import (
 	"math/big"
 )
 
-// SymmetricDefClass 对称矩阵的 Sylvester 定号分类（3 阶）。
+// SymmetricDefClass 对称矩阵的 Sylvester 定号分类。
 type SymmetricDefClass int
 
 const (
@@ -32,21 +32,78 @@ func ClassifySymmetric3(S *MatrixInt) (SymmetricDefClass, error) {
 	if S.R != 3 || S.C != 3 {
 		return SymDefInd, fmt.Errorf("classify: need 3×3")
 	}
-	for i := 0; i < 3; i++ {
-		for j := 0; j < 3; j++ {
-			if S.A[i][j] != S.A[j][i] {
-				return SymDefInd, fmt.Errorf("classify: matrix not symmetric")
-			}
+	return ClassifySymmetric(S)
+}
+
+// ClassifySymmetric 用 Sylvester 判据判定 n 阶整数对称矩阵的定号类：
+// 各阶顺序主子式全正为正定，Δₖ 的符号为 (-1)ᵏ 时为负定，其余归为不定。
+func ClassifySymmetric(S *MatrixInt) (SymmetricDefClass, error) {
+	if err := checkSymmetric(S, "classify"); err != nil {
+		return SymDefInd, err
+	}
+	pd, nd := true, true
+	for k := 1; k <= S.R; k++ {
+		sign := principalMinorDet(S, k).Sign()
+		if sign <= 0 {
+			pd = false
+		}
+		if k%2 == 1 && sign >= 0 || k%2 == 0 && sign <= 0 {
+			nd = false
 		}
 	}
-	d1 := principalMinorDet(S, 1)
-	d2 := principalMinorDet(S, 2)
-	d3 := principalMinorDet(S, 3)
-	if d1.Sign() > 0 && d2.Sign() > 0 && d3.Sign() > 0 {
+	switch {
+	case pd:
 		return SymDefPD, nil
-	}
-	if d1.Sign() < 0 && d2.Sign() > 0 && d3.Sign() < 0 {
+	case nd:
 		return SymDefND, nil
 	}
 	return SymDefInd, nil
 }
+
+// Inertia 返回 n 阶整数对称矩阵正、负、零特征值的个数（按重数计）。
+// 实对称阵的特征多项式只有实根，故 Descartes 符号法则给出的正根个数是精确的；
+// 负根个数取 p(-λ) 的变号数，零根个数为 λ 因子的重数。
+func Inertia(S *MatrixInt) (pos, neg, zero int, err error) {
+	if err := checkSymmetric(S, "inertia"); err != nil {
+		return 0, 0, 0, err
+	}
+	p, err := CharPoly(MatrixIntToRat(S))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for zero < len(p.Coeffs) && p.Coeffs[zero].Sign() == 0 {
+		zero++
+	}
+	signChanges := func(flip bool) int {
+		n, last := 0, 0
+		for k := zero; k < len(p.Coeffs); k++ {
+			sign := p.Coeffs[k].Sign()
+			if flip && k%2 == 1 {
+				sign = -sign
+			}
+			if sign == 0 {
+				continue
+			}
+			if last != 0 && sign != last {
+				n++
+			}
+			last = sign
+		}
+		return n
+	}
+	return signChanges(false), signChanges(true), zero, nil
+}
+
+func checkSymmetric(S *MatrixInt, fname string) error {
+	if S.R != S.C {
+		return fmt.Errorf("%s: need square matrix", fname)
+	}
+	for i := 0; i < S.R; i++ {
+		for j := i + 1; j < S.R; j++ {
+			if S.A[i][j] != S.A[j][i] {
+				return fmt.Errorf("%s: matrix not symmetric", fname)
+			}
+		}
+	}
+	return nil
+}
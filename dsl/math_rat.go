@@ -0,0 +1,396 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// 有理矩阵 / 向量运算。表达式求值时，结果若全部为 int64 范围内的整数则收窄回
+// *MatrixInt / *VectorInt（与标量运算的收窄规则一致），因此整数题目的结果类型不受影响。
+
+// normalizeMatrixRat 全部元素为 int64 整数时返回 *MatrixInt，否则原样返回。
+func normalizeMatrixRat(m *MatrixRat) interface{} {
+	out := NewMatrixInt(m.R, m.C)
+	for i := 0; i < m.R; i++ {
+		for j := 0; j < m.C; j++ {
+			x := m.A[i][j]
+			if !x.IsInt() || !x.Num().IsInt64() {
+				return m
+			}
+			out.A[i][j] = x.Num().Int64()
+		}
+	}
+	return out
+}
+
+// normalizeVectorRat 全部分量为 int64 整数时返回 *VectorInt，否则原样返回。
+func normalizeVectorRat(v *VectorRat) interface{} {
+	out := NewVectorInt(v.N)
+	for i, x := range v.V {
+		if !x.IsInt() || !x.Num().IsInt64() {
+			return v
+		}
+		out.V[i] = x.Num().Int64()
+	}
+	return out
+}
+
+// matrixOperand 将 *MatrixInt / *MatrixRat 统一为 *MatrixRat；非矩阵返回 false。
+func matrixOperand(v interface{}) (*MatrixRat, bool) {
+	switch t := v.(type) {
+	case *MatrixInt:
+		return MatrixIntToRat(t), true
+	case *MatrixRat:
+		return t, true
+	}
+	return nil, false
+}
+
+// matrixRatArith 处理含有理矩阵（或非整数数乘、负指数幂）的矩阵运算；结果按 normalizeMatrixRat 收窄。
+func matrixRatArith(op string, l, r interface{}) (interface{}, bool, error) {
+	lm, lok := matrixOperand(l)
+	rm, rok := matrixOperand(r)
+	var out *MatrixRat
+	var err error
+	switch {
+	case lok && rok:
+		switch op {
+		case "+":
+			out, err = matrixRatAdd(lm, rm)
+		case "-":
+			out, err = matrixRatSub(lm, rm)
+		case "*":
+			out, err = matrixRatMul(lm, rm)
+		default:
+			return nil, false, nil
+		}
+	case lok && op == "^":
+		n, e := scalarToInt64(r)
+		if e != nil {
+			return nil, true, e
+		}
+		out, err = matrixRatPow(lm, n)
+	case lok && (op == "*" || op == "/"):
+		if k, ok := scalarRat(r); ok {
+			if op == "/" {
+				if k.Sign() == 0 {
+					return nil, true, fmt.Errorf("division by zero")
+				}
+				k.Inv(k)
+			}
+			out = matrixRatScale(k, lm)
+			break
+		}
+		if vec, _, ok := vectorOperand(r); ok && op == "*" {
+			v, err := matrixRatTimesVector(lm, vec)
+			if err != nil {
+				return nil, true, err
+			}
+			return normalizeVectorRat(v), true, nil
+		}
+		return nil, false, nil
+	case rok && op == "*":
+		k, ok := scalarRat(l)
+		if !ok {
+			return nil, false, nil
+		}
+		out = matrixRatScale(k, rm)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	return normalizeMatrixRat(out), true, nil
+}
+
+func matrixRatMul(a, b *MatrixRat) (*MatrixRat, error) {
+	if a.C != b.R {
+		return nil, errors.New("matrix dimension mismatch in mul")
+	}
+	res := NewMatrixRat(a.R, b.C)
+	t := new(big.Rat)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < b.C; j++ {
+			for k := 0; k < a.C; k++ {
+				res.A[i][j].Add(res.A[i][j], t.Mul(a.A[i][k], b.A[k][j]))
+			}
+		}
+	}
+	return res, nil
+}
+
+func matrixRatAdd(a, b *MatrixRat) (*MatrixRat, error) {
+	if a.R != b.R || a.C != b.C {
+		return nil, errors.New("matrix add: shape mismatch")
+	}
+	res := NewMatrixRat(a.R, a.C)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < a.C; j++ {
+			res.A[i][j].Add(a.A[i][j], b.A[i][j])
+		}
+	}
+	return res, nil
+}
+
+func matrixRatSub(a, b *MatrixRat) (*MatrixRat, error) {
+	if a.R != b.R || a.C != b.C {
+		return nil, errors.New("matrix sub: shape mismatch")
+	}
+	res := NewMatrixRat(a.R, a.C)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < a.C; j++ {
+			res.A[i][j].Sub(a.A[i][j], b.A[i][j])
+		}
+	}
+	return res, nil
+}
+
+func matrixRatScale(k *big.Rat, a *MatrixRat) *MatrixRat {
+	res := NewMatrixRat(a.R, a.C)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < a.C; j++ {
+			res.A[i][j].Mul(k, a.A[i][j])
+		}
+	}
+	return res
+}
+
+func matrixRatTranspose(a *MatrixRat) *MatrixRat {
+	res := NewMatrixRat(a.C, a.R)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < a.C; j++ {
+			res.A[j][i].Set(a.A[i][j])
+		}
+	}
+	return res
+}
+
+// matrixRatPow 计算 A^n；n<0 时为 (A⁻¹)^|n|。
+func matrixRatPow(a *MatrixRat, n int64) (*MatrixRat, error) {
+	if a.R != a.C {
+		return nil, errors.New("matrix power expects square matrix")
+	}
+	base := a
+	if n < 0 {
+		inv, err := MatrixRatInverse(a)
+		if err != nil {
+			return nil, err
+		}
+		base, n = inv, -n
+	}
+	res := NewMatrixRat(a.R, a.C)
+	for i := 0; i < a.R; i++ {
+		res.A[i][i].SetInt64(1)
+	}
+	for n > 0 {
+		var err error
+		if n&1 == 1 {
+			if res, err = matrixRatMul(res, base); err != nil {
+				return nil, err
+			}
+		}
+		n >>= 1
+		if n > 0 {
+			if base, err = matrixRatMul(base, base); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+func matrixRatTimesVector(a *MatrixRat, x []*big.Rat) (*VectorRat, error) {
+	if a.C != len(x) {
+		return nil, fmt.Errorf("dimension mismatch in matrix * vector (%d×%d, %d)", a.R, a.C, len(x))
+	}
+	out := NewVectorRat(a.R)
+	t := new(big.Rat)
+	for i := 0; i < a.R; i++ {
+		for j := 0; j < a.C; j++ {
+			out.V[i].Add(out.V[i], t.Mul(a.A[i][j], x[j]))
+		}
+	}
+	return out, nil
+}
+
+// ratEchelon 对 M 的拷贝做 Gauss 消元，返回行阶梯形、秩与行交换次数。
+func ratEchelon(a *MatrixRat) ([][]*big.Rat, int, int) {
+	M := make([][]*big.Rat, a.R)
+	for i := range M {
+		M[i] = make([]*big.Rat, a.C)
+		for j := range M[i] {
+			M[i][j] = new(big.Rat).Set(a.A[i][j])
+		}
+	}
+	row, swaps := 0, 0
+	for col := 0; col < a.C && row < a.R; col++ {
+		pivot := -1
+		for r := row; r < a.R; r++ {
+			if M[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			continue
+		}
+		if pivot != row {
+			M[pivot], M[row] = M[row], M[pivot]
+			swaps++
+		}
+		for r := row + 1; r < a.R; r++ {
+			if M[r][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Quo(M[r][col], M[row][col])
+			for j := col; j < a.C; j++ {
+				M[r][j].Sub(M[r][j], new(big.Rat).Mul(f, M[row][j]))
+			}
+		}
+		row++
+	}
+	return M, row, swaps
+}
+
+// ratRREF 返回 a 的简化行阶梯形（拷贝）与主元列下标（0-based）。
+func ratRREF(a *MatrixRat) ([][]*big.Rat, []int) {
+	M := make([][]*big.Rat, a.R)
+	for i := range M {
+		M[i] = make([]*big.Rat, a.C)
+		for j := range M[i] {
+			M[i][j] = new(big.Rat).Set(a.A[i][j])
+		}
+	}
+	var pivots []int
+	row := 0
+	for col := 0; col < a.C && row < a.R; col++ {
+		pivot := -1
+		for r := row; r < a.R; r++ {
+			if M[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			continue
+		}
+		M[pivot], M[row] = M[row], M[pivot]
+		pv := new(big.Rat).Set(M[row][col])
+		for j := col; j < a.C; j++ {
+			M[row][j].Quo(M[row][j], pv)
+		}
+		for r := 0; r < a.R; r++ {
+			if r == row || M[r][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(M[r][col])
+			for j := col; j < a.C; j++ {
+				M[r][j].Sub(M[r][j], new(big.Rat).Mul(f, M[row][j]))
+			}
+		}
+		pivots = append(pivots, col)
+		row++
+	}
+	return M, pivots
+}
+
+// MatrixRatDet 计算有理方阵的行列式。
+func MatrixRatDet(a *MatrixRat) (*big.Rat, error) {
+	if a.R != a.C {
+		return nil, errors.New("det: need square matrix")
+	}
+	M, rank, swaps := ratEchelon(a)
+	d := big.NewRat(1, 1)
+	if rank < a.R {
+		return d.SetInt64(0), nil
+	}
+	for i := 0; i < a.R; i++ {
+		d.Mul(d, M[i][i])
+	}
+	if swaps%2 == 1 {
+		d.Neg(d)
+	}
+	return d, nil
+}
+
+// MatrixRatRank 计算有理矩阵的秩。
+func MatrixRatRank(a *MatrixRat) int {
+	_, rank, _ := ratEchelon(a)
+	return rank
+}
+
+// MatrixRatInverse 用 Gauss-Jordan 消元求有理方阵的逆；奇异时报错。
+func MatrixRatInverse(a *MatrixRat) (*MatrixRat, error) {
+	n := a.R
+	if n != a.C {
+		return nil, fmt.Errorf("inverse: need square matrix")
+	}
+	M := make([][]*big.Rat, n)
+	for i := 0; i < n; i++ {
+		M[i] = make([]*big.Rat, 2*n)
+		for j := 0; j < n; j++ {
+			M[i][j] = new(big.Rat).Set(a.A[i][j])
+			M[i][n+j] = new(big.Rat)
+		}
+		M[i][n+i].SetInt64(1)
+	}
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if M[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, fmt.Errorf("inverse: matrix is singular")
+		}
+		M[pivot], M[col] = M[col], M[pivot]
+		pv := new(big.Rat).Set(M[col][col])
+		for j := col; j < 2*n; j++ {
+			M[col][j].Quo(M[col][j], pv)
+		}
+		for r := 0; r < n; r++ {
+			if r == col || M[r][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(M[r][col])
+			for j := col; j < 2*n; j++ {
+				M[r][j].Sub(M[r][j], new(big.Rat).Mul(f, M[col][j]))
+			}
+		}
+	}
+	out := NewMatrixRat(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			out.A[i][j].Set(M[i][n+j])
+		}
+	}
+	return out, nil
+}
+
+// ratColumnsToMatrix 将若干列向量拼成矩阵（每个 cols[k] 为第 k+1 列）。
+func ratColumnsToMatrix(cols [][]*big.Rat, rows int) *MatrixRat {
+	out := NewMatrixRat(rows, len(cols))
+	for j, col := range cols {
+		for i := 0; i < rows && i < len(col); i++ {
+			out.A[i][j].Set(col[i])
+		}
+	}
+	return out
+}
+
+// FormatRatLatex 将有理数格式化为 LaTeX：整数原样输出，分数用 \frac，负号提到最前。
+func FormatRatLatex(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	num := new(big.Int).Abs(r.Num())
+	s := `\frac{` + num.String() + `}{` + r.Denom().String() + `}`
+	if r.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func ratTestInst() *Instance {
+	A := NewMatrixInt(2, 2) // det(A) = 3
+	A.A[0] = []int64{2, 1}
+	A.A[1] = []int64{1, 2}
+	U := NewMatrixInt(2, 2) // det(U) = 1
+	U.A[0] = []int64{2, 1}
+	U.A[1] = []int64{1, 1}
+	x := NewVectorInt(2)
+	x.V[0], x.V[1] = 1, 1
+	return &Instance{Vars: map[string]interface{}{"A": A, "U": U, "x": x}}
+}
+
+func TestMatrixRat_expressions(t *testing.T) {
+	inst := ratTestInst()
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"inv(A)[1,1]", "2/3"},
+		{"mget(inv(A), 1, 2)", "-1/3"},
+		{"det(inv(A))", "1/3"},
+		{"rank(inv(A))", "2"},
+		{"transpose(inv(A))[2,1]", "-1/3"},
+		{"(A^-1)[2,2]", "2/3"},
+		{"(inv(A) * 3)[1,1]", "2"},
+		{"(A / 2)[1,2]", "1/2"},
+		{"(inv(A) * x)[1]", "1/3"},
+		{"matadd(inv(A), inv(A))[1,1]", "4/3"},
+		{"det(matmul(inv(A), A))", "1"},
+		{"gs(A)[1,2]", "-3/5"},
+	}
+	for _, tc := range cases {
+		v, err := EvaluateExpression(tc.expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := ValueToCanonicalString(v); got != tc.want {
+			t.Fatalf("%s = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestMatrixRat_normalizesToInt(t *testing.T) {
+	inst := ratTestInst()
+	for _, expr := range []string{"inv(U)", "inv(A) * A", "matmul(A, inv(A))", "inv(A) * 3"} {
+		v, err := EvaluateExpression(expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if _, ok := v.(*MatrixInt); !ok {
+			t.Fatalf("%s: want *MatrixInt, got %T", expr, v)
+		}
+	}
+	v, _ := EvaluateExpression("inv(A)", inst)
+	if _, ok := v.(*MatrixRat); !ok {
+		t.Fatalf("inv(A): want *MatrixRat, got %T", v)
+	}
+	if _, err := EvaluateExpression("inv(A - A)", inst); err == nil {
+		t.Fatal("inverse of singular matrix should fail")
+	}
+}
+
+func TestMatrixRat_latex(t *testing.T) {
+	v, err := EvaluateExpression("inv(A)", ratTestInst())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := FormatValueForTitle(v)
+	want := `\begin{bmatrix}\frac{2}{3}&-\frac{1}{3}\\-\frac{1}{3}&\frac{2}{3}\end{bmatrix}`
+	if got != want {
+		t.Fatalf("latex = %s", got)
+	}
+	if s := ValueToExplainString(v); !strings.HasPrefix(s, "[2/3 -1/3]; ") {
+		t.Fatalf("explain = %s", s)
+	}
+}
+
+// RrefRat / RrefRatSafe 在主元不在当前行时交换行；param_infinit_solution 用的 rrefRat(M, false) 仍拒绝需要交换的矩阵。
+func TestRrefRat_rowSwap(t *testing.T) {
+	M := NewMatrixInt(3, 3)
+	M.A[0] = []int64{0, 2, 4}
+	M.A[1] = []int64{1, 1, 1}
+	M.A[2] = []int64{2, 2, 2}
+	want := [][]string{{"1", "0", "-1"}, {"0", "1", "2"}, {"0", "0", "0"}}
+	check := func(name string, got [][]*big.Rat) {
+		for i := range want {
+			for j := range want[i] {
+				if got[i][j].RatString() != want[i][j] {
+					t.Fatalf("%s[%d][%d] = %s, want %s", name, i, j, got[i][j].RatString(), want[i][j])
+				}
+			}
+		}
+	}
+	check("RrefRat", RrefRat(M))
+	safe, err := RrefRatSafe(M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("RrefRatSafe", safe)
+	if M.A[0][0] != 0 || M.A[1][0] != 1 {
+		t.Fatal("input matrix modified")
+	}
+	if _, err := rrefRat(M, false); err == nil {
+		t.Fatal("rrefRat without swap: expect err")
+	}
+}
@@ -3,7 +3,6 @@ package dsl
 import (
 	"fmt"
 	"math/big"
-	"math/rand"
 	"strings"
 )
 
@@ -96,12 +95,12 @@ func SylvesterPositiveRangeSingleT(Sbase *MatrixInt, tRow int) (lower *big.Rat,
 //   - The result is always a single-sided bound: t > lower
 //
 // The answer has one blank: the lower bound.
-func genMatrixSylvesterRange(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
+func genMatrixSylvesterRange(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	if v.Rows != 3 || v.Cols != 3 {
 		return nil, fmt.Errorf("sylvester_range: need 3×3")
 	}
-	emin := int64(defaultInt(v.Generator, "entry_min", -5))
-	emax := int64(defaultInt(v.Generator, "entry_max", 5))
+	emin := int64(params.Int("entry_min", -5))
+	emax := int64(params.Int("entry_max", 5))
 	if emax < emin {
 		emin, emax = emax, emin
 	}
@@ -110,8 +109,8 @@ func genMatrixSylvesterRange(rng *rand.Rand, v Variable, inst *Instance) (interf
 	tRow := 2
 
 	for attempt := 0; attempt < 500; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
 
 		// S[1][1] > 0: small positive integer
 		s11 := int64(0)
@@ -203,21 +202,10 @@ func genMatrixSylvesterRange(rng *rand.Rand, v Variable, inst *Instance) (interf
 			continue
 		}
 
-		// Store for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_sylvester_Sbase"] = Sbase
-		inst.Vars["_sylvester_lower"] = lower
-		inst.Vars["_sylvester_s11"] = s11
-		inst.Vars["_sylvester_s12"] = s12
-		inst.Vars["_sylvester_s13"] = s13
-		inst.Vars["_sylvester_s22"] = s22
-		inst.Vars["_sylvester_s23"] = s23
-		inst.Vars["_sylvester_tRow"] = tRow
-		// For formatQuadraticExprWithParam: t at position (2,2) i.e. S[3][3] = t
-		inst.Vars["_param_t_pos"] = []interface{}{float64(tRow), float64(tRow)}
-		// No second t position — single t only
+		// Store for answer extraction; upper is +∞ and is not published
+		out.Set("lower", lower)
+		// For formatQuadraticExprWithParam: t at S[tRow+1][tRow+1] (1-based)
+		out.Set("t_row", int64(tRow+1))
 
 		return Sbase, nil
 	}
@@ -238,18 +226,15 @@ func copyMatrix(m *MatrixInt) *MatrixInt {
 // formatQuadraticExprWithParam renders a quadratic form f(x₁,x₂,x₃)=... as LaTeX,
 // with parameter t appearing at one diagonal position in the matrix.
 //
-// inst.Vars["_param_t_pos"]: 0-based (row,col) pair where S has t (e.g., (2,2) for S[3][3]=t)
+// out["t_row"]: 1-based diagonal position where S has t (e.g., 3 for S[3][3]=t)
 // In the Sbase matrix, the placeholder position has value 0.
-func formatQuadraticExprWithParam(Sbase *MatrixInt, inst *Instance) string {
+func formatQuadraticExprWithParam(Sbase *MatrixInt, out GeneratorOutputs) string {
 	n := 3
 
 	var tRow int = 2 // default: S[3][3] = t (0-based index 2)
 
-	if tp, ok := inst.Vars["_param_t_pos"]; ok {
-		switch t := tp.(type) {
-		case []interface{}:
-			tRow = int(t[0].(float64))
-		}
+	if r, err := out.int64Value("quad_expr_param", "t_row"); err == nil {
+		tRow = int(r) - 1
 	}
 
 	xi := func(k int) string {
@@ -316,21 +301,21 @@ func formatQuadraticExprWithParam(Sbase *MatrixInt, inst *Instance) string {
 //
 //	t = (λ₁+λ₂+λ₃) - S[1][1] - S[2][2]
 //
-// Stores _param_Q, _param_Lambda, _param_lambdas, _param_t_val in inst.Vars.
-func genMatrixParamOrthogonalDiag(rng *rand.Rand, v Variable, inst *Instance) (interface{}, error) {
+// Publishes outputs Q, Lambda, lambdas, t and t_row.
+func genMatrixParamOrthogonalDiag(rng Rand, v Variable, inst *Instance, params GeneratorParams, out GeneratorOutputs) (interface{}, error) {
 	if v.Rows != 3 || v.Cols != 3 {
 		return nil, fmt.Errorf("param_orthogonal_diag: need 3×3")
 	}
-	lmin := int64(defaultInt(v.Generator, "lambda_min", -5))
-	lmax := int64(defaultInt(v.Generator, "lambda_max", 5))
+	lmin := int64(params.Int("lambda_min", -5))
+	lmax := int64(params.Int("lambda_max", 5))
 	if lmax < lmin {
 		lmin, lmax = lmax, lmin
 	}
-	maxEntry := int64(defaultInt(v.Generator, "max_entry", 15))
+	maxEntry := int64(params.Int("max_entry", 15))
 
 	for attempt := 0; attempt < 500; attempt++ {
-		attemptSeed := rng.Int63()
-		attemptRng := rand.New(rand.NewSource(attemptSeed))
+		inst.noteAttempt(500)
+		attemptRng := inst.subRand(rng)
 
 		// Pick 3 distinct nonzero eigenvalues
 		lambdas := make([]int64, 3)
@@ -424,17 +409,11 @@ func genMatrixParamOrthogonalDiag(rng *rand.Rand, v Variable, inst *Instance) (i
 		Sbase.A[2][2] = 0
 
 		// Store for answer extraction
-		if inst.Vars == nil {
-			inst.Vars = map[string]interface{}{}
-		}
-		inst.Vars["_param_Q"] = Q
-		inst.Vars["_param_Lambda"] = Lambda
-		inst.Vars["_param_lambda1"] = lambdas[0]
-		inst.Vars["_param_lambda2"] = lambdas[1]
-		inst.Vars["_param_lambda3"] = lambdas[2]
-		inst.Vars["_param_t_val"] = tVal
-		inst.Vars["_param_Sbase"] = Sbase
-		inst.Vars["_param_t_pos"] = []interface{}{float64(2), float64(2)}
+		out.Set("Q", Q)
+		out.Set("Lambda", Lambda)
+		out.Set("lambdas", intVector(lambdas))
+		out.Set("t", tVal)
+		out.Set("t_row", int64(3))
 
 		return Sbase, nil
 	}
@@ -1,5 +1,7 @@
 package dsl
 
+import "math/big"
+
 // MatrixInt 使用 int64 存储矩阵元素，生成阶段使用
 type MatrixInt struct {
 	R int
@@ -27,3 +29,59 @@ func NewVectorInt(n int) *VectorInt {
 	v.V = make([]int64, n)
 	return v
 }
+
+// MatrixRat 使用 *big.Rat 存储矩阵元素，用于逆矩阵、正交化等可能含分数的结果
+type MatrixRat struct {
+	R int
+	C int
+	A [][]*big.Rat
+}
+
+// NewMatrixRat 返回 r×c 零矩阵
+func NewMatrixRat(r, c int) *MatrixRat {
+	m := &MatrixRat{R: r, C: c}
+	m.A = make([][]*big.Rat, r)
+	for i := 0; i < r; i++ {
+		m.A[i] = make([]*big.Rat, c)
+		for j := 0; j < c; j++ {
+			m.A[i][j] = new(big.Rat)
+		}
+	}
+	return m
+}
+
+// VectorRat 使用 *big.Rat 存储向量元素
+type VectorRat struct {
+	N int
+	V []*big.Rat
+}
+
+// NewVectorRat 返回 n 维零向量
+func NewVectorRat(n int) *VectorRat {
+	v := &VectorRat{N: n}
+	v.V = make([]*big.Rat, n)
+	for i := 0; i < n; i++ {
+		v.V[i] = new(big.Rat)
+	}
+	return v
+}
+
+// MatrixIntToRat 将整数矩阵提升为有理矩阵（深拷贝）
+func MatrixIntToRat(m *MatrixInt) *MatrixRat {
+	out := NewMatrixRat(m.R, m.C)
+	for i := 0; i < m.R; i++ {
+		for j := 0; j < m.C; j++ {
+			out.A[i][j].SetInt64(m.A[i][j])
+		}
+	}
+	return out
+}
+
+// VectorIntToRat 将整数向量提升为有理向量（深拷贝）
+func VectorIntToRat(v *VectorInt) *VectorRat {
+	out := NewVectorRat(v.N)
+	for i := 0; i < v.N; i++ {
+		out.V[i].SetInt64(v.V[i])
+	}
+	return out
+}
@@ -0,0 +1,319 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Polynomial 为 ℚ 上的一元多项式（变量记作 λ），Coeffs[k] 为 λ^k 的系数。
+// 最高次系数非零；零多项式的 Coeffs 为空。
+type Polynomial struct {
+	Coeffs []*big.Rat
+}
+
+// NewPolynomial 由升幂系数构造多项式（拷贝并去掉高次零系数）。
+func NewPolynomial(coeffs ...*big.Rat) *Polynomial {
+	p := &Polynomial{Coeffs: make([]*big.Rat, len(coeffs))}
+	for i, c := range coeffs {
+		p.Coeffs[i] = new(big.Rat).Set(c)
+	}
+	return p.trim()
+}
+
+func (p *Polynomial) trim() *Polynomial {
+	n := len(p.Coeffs)
+	for n > 0 && p.Coeffs[n-1].Sign() == 0 {
+		n--
+	}
+	p.Coeffs = p.Coeffs[:n]
+	return p
+}
+
+// Degree 返回次数；零多项式为 -1。
+func (p *Polynomial) Degree() int { return len(p.Coeffs) - 1 }
+
+// Coeff 返回 λ^k 的系数（超出次数为 0）。
+func (p *Polynomial) Coeff(k int) *big.Rat {
+	if k < 0 || k >= len(p.Coeffs) {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(p.Coeffs[k])
+}
+
+// Equal 判断两多项式系数完全相同。
+func (p *Polynomial) Equal(q *Polynomial) bool {
+	if len(p.Coeffs) != len(q.Coeffs) {
+		return false
+	}
+	for i := range p.Coeffs {
+		if p.Coeffs[i].Cmp(q.Coeffs[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval 用 Horner 法求 p(x)。
+func (p *Polynomial) Eval(x *big.Rat) *big.Rat {
+	out := new(big.Rat)
+	for k := len(p.Coeffs) - 1; k >= 0; k-- {
+		out.Mul(out, x)
+		out.Add(out, p.Coeffs[k])
+	}
+	return out
+}
+
+func polyAdd(p, q *Polynomial, sign int) *Polynomial {
+	n := len(p.Coeffs)
+	if len(q.Coeffs) > n {
+		n = len(q.Coeffs)
+	}
+	out := &Polynomial{Coeffs: make([]*big.Rat, n)}
+	for k := 0; k < n; k++ {
+		b := q.Coeff(k)
+		if sign < 0 {
+			b.Neg(b)
+		}
+		out.Coeffs[k] = b.Add(b, p.Coeff(k))
+	}
+	return out.trim()
+}
+
+func polyMul(p, q *Polynomial) *Polynomial {
+	if len(p.Coeffs) == 0 || len(q.Coeffs) == 0 {
+		return &Polynomial{}
+	}
+	out := &Polynomial{Coeffs: make([]*big.Rat, len(p.Coeffs)+len(q.Coeffs)-1)}
+	for k := range out.Coeffs {
+		out.Coeffs[k] = new(big.Rat)
+	}
+	t := new(big.Rat)
+	for i, a := range p.Coeffs {
+		for j, b := range q.Coeffs {
+			out.Coeffs[i+j].Add(out.Coeffs[i+j], t.Mul(a, b))
+		}
+	}
+	return out.trim()
+}
+
+// polyDivLinear 计算 p / (λ - r)，要求 r 为根（余数为 0）。
+func polyDivLinear(p *Polynomial, r *big.Rat) *Polynomial {
+	n := len(p.Coeffs)
+	out := &Polynomial{Coeffs: make([]*big.Rat, n-1)}
+	carry := new(big.Rat)
+	for k := n - 1; k >= 1; k-- {
+		carry = new(big.Rat).Add(p.Coeffs[k], new(big.Rat).Mul(carry, r))
+		out.Coeffs[k-1] = carry
+	}
+	return out.trim()
+}
+
+// CharPoly 返回方阵 A 的特征多项式 |λE - A|（首一），用 Faddeev–LeVerrier 递推在 ℚ 上计算。
+func CharPoly(a *MatrixRat) (*Polynomial, error) {
+	n := a.R
+	if n != a.C {
+		return nil, fmt.Errorf("charpoly: need square matrix")
+	}
+	c := make([]*big.Rat, n+1)
+	c[n] = big.NewRat(1, 1)
+	M := NewMatrixRat(n, n)
+	for k := 1; k <= n; k++ {
+		AM, err := matrixRatMul(a, M)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			AM.A[i][i].Add(AM.A[i][i], c[n-k+1])
+		}
+		M = AM
+		AM, _ = matrixRatMul(a, M)
+		tr := new(big.Rat)
+		for i := 0; i < n; i++ {
+			tr.Add(tr, AM.A[i][i])
+		}
+		c[n-k] = tr.Quo(tr, big.NewRat(int64(-k), 1))
+	}
+	return &Polynomial{Coeffs: c}, nil
+}
+
+// MinPoly 返回方阵 A 的最小多项式（首一）：找最小的 k 使 A^k 落在 E, A, …, A^{k-1} 的张成中。
+func MinPoly(a *MatrixRat) (*Polynomial, error) {
+	n := a.R
+	if n != a.C {
+		return nil, fmt.Errorf("minpoly: need square matrix")
+	}
+	powers := []*MatrixRat{NewMatrixRat(n, n)}
+	for i := 0; i < n; i++ {
+		powers[0].A[i][i].SetInt64(1)
+	}
+	for k := 1; k <= n; k++ {
+		next, err := matrixRatMul(powers[k-1], a)
+		if err != nil {
+			return nil, err
+		}
+		powers = append(powers, next)
+		// 以 vec(A^0..A^k) 为列，RREF 后最后一列非主元即出现线性相关
+		M := NewMatrixRat(n*n, k+1)
+		for j, P := range powers {
+			for r := 0; r < n; r++ {
+				for s := 0; s < n; s++ {
+					M.A[r*n+s][j].Set(P.A[r][s])
+				}
+			}
+		}
+		R, pivots := ratRREF(M)
+		if len(pivots) == k+1 {
+			continue
+		}
+		coeffs := make([]*big.Rat, k+1)
+		for i := 0; i < k; i++ {
+			coeffs[i] = new(big.Rat).Neg(R[i][k])
+		}
+		coeffs[k] = big.NewRat(1, 1)
+		return NewPolynomial(coeffs...), nil
+	}
+	return nil, fmt.Errorf("minpoly: no dependency up to degree %d", n)
+}
+
+// RationalRoots 返回多项式的全部有理根（按重数重复列出，升序）。
+func RationalRoots(p *Polynomial) []*big.Rat {
+	var roots []*big.Rat
+	if p.Degree() <= 0 {
+		return roots
+	}
+	// 去分母得到整系数多项式，再用有理根定理枚举 ±d(a0)/d(an)
+	q := NewPolynomial(p.Coeffs...)
+	for len(q.Coeffs) > 1 && q.Coeffs[0].Sign() == 0 {
+		roots = append(roots, new(big.Rat))
+		q = &Polynomial{Coeffs: q.Coeffs[1:]}
+	}
+	for q.Degree() > 0 {
+		ints := integerCoeffs(q)
+		found := false
+		for _, num := range positiveDivisors(ints[0]) {
+			for _, den := range positiveDivisors(ints[len(ints)-1]) {
+				for _, sign := range []int64{1, -1} {
+					r := new(big.Rat).SetFrac(new(big.Int).Mul(num, big.NewInt(sign)), den)
+					if q.Eval(r).Sign() == 0 {
+						roots = append(roots, r)
+						q = polyDivLinear(q, r)
+						found = true
+						break
+					}
+				}
+				if found {
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Cmp(roots[j]) < 0 })
+	return roots
+}
+
+// integerCoeffs 将系数乘以分母的最小公倍数，得到整系数。
+func integerCoeffs(p *Polynomial) []*big.Int {
+	lcm := big.NewInt(1)
+	for _, c := range p.Coeffs {
+		g := new(big.Int).GCD(nil, nil, lcm, c.Denom())
+		lcm.Mul(lcm, new(big.Int).Quo(c.Denom(), g))
+	}
+	out := make([]*big.Int, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		v := new(big.Int).Mul(c.Num(), lcm)
+		out[i] = v.Quo(v, c.Denom())
+	}
+	return out
+}
+
+// positiveDivisors 返回 |n| 的全部正因子（n ≠ 0，升序）。
+func positiveDivisors(n *big.Int) []*big.Int {
+	m := new(big.Int).Abs(n)
+	var small, large []*big.Int
+	for d := big.NewInt(1); new(big.Int).Mul(d, d).Cmp(m) <= 0; d = new(big.Int).Add(d, big.NewInt(1)) {
+		q, r := new(big.Int).QuoRem(m, d, new(big.Int))
+		if r.Sign() != 0 {
+			continue
+		}
+		small = append(small, new(big.Int).Set(d))
+		if q.Cmp(d) != 0 {
+			large = append([]*big.Int{q}, large...)
+		}
+	}
+	return append(small, large...)
+}
+
+// polyEvalMatrix 用 Horner 法计算 p(A)。
+func polyEvalMatrix(p *Polynomial, a *MatrixRat) (*MatrixRat, error) {
+	if a.R != a.C {
+		return nil, fmt.Errorf("polyeval: need square matrix")
+	}
+	out := NewMatrixRat(a.R, a.C)
+	for k := len(p.Coeffs) - 1; k >= 0; k-- {
+		var err error
+		if out, err = matrixRatMul(out, a); err != nil {
+			return nil, err
+		}
+		for i := 0; i < a.R; i++ {
+			out.A[i][i].Add(out.A[i][i], p.Coeffs[k])
+		}
+	}
+	return out, nil
+}
+
+// String 返回降幂排列的纯文本形式，如 λ^3 - 2λ^2 + (1/2)λ - 3；与 ParseUserPolynomial 互逆。
+func (p *Polynomial) String() string {
+	return p.format("λ", func(r *big.Rat) string {
+		if r.IsInt() {
+			return r.Num().String()
+		}
+		return "(" + r.RatString() + ")"
+	}, func(k int) string { return fmt.Sprintf("^%d", k) }, " ")
+}
+
+// Latex 返回 LaTeX 形式，如 \lambda^{3}-2\lambda^{2}+\frac{1}{2}\lambda-3。
+func (p *Polynomial) Latex() string {
+	return p.format(`\lambda`, FormatRatLatex, func(k int) string { return fmt.Sprintf("^{%d}", k) }, "")
+}
+
+func (p *Polynomial) format(v string, coef func(*big.Rat) string, exp func(int) string, sp string) string {
+	if len(p.Coeffs) == 0 {
+		return "0"
+	}
+	var b strings.Builder
+	for k := len(p.Coeffs) - 1; k >= 0; k-- {
+		c := p.Coeffs[k]
+		if c.Sign() == 0 {
+			continue
+		}
+		abs := new(big.Rat).Abs(c)
+		switch {
+		case b.Len() == 0 && c.Sign() < 0:
+			b.WriteString("-")
+		case b.Len() > 0 && c.Sign() < 0:
+			b.WriteString(sp + "-" + sp)
+		case b.Len() > 0:
+			b.WriteString(sp + "+" + sp)
+		}
+		if k == 0 {
+			b.WriteString(coef(abs))
+			continue
+		}
+		if abs.Cmp(big.NewRat(1, 1)) != 0 {
+			b.WriteString(coef(abs))
+		}
+		b.WriteString(v)
+		if k > 1 {
+			b.WriteString(exp(k))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package dsl
+
+import (
+	"math/big"
+	"testing"
+)
+
+func polyTestInst() *Instance {
+	A := NewMatrixInt(3, 3) // 特征值 1,1,2，且不可对角化
+	A.A[0] = []int64{1, 1, 0}
+	A.A[1] = []int64{0, 1, 0}
+	A.A[2] = []int64{0, 0, 2}
+	D := NewMatrixInt(3, 3) // diag(2,2,3)：最小多项式次数 2
+	D.A[0][0], D.A[1][1], D.A[2][2] = 2, 2, 3
+	B := NewMatrixInt(2, 2) // B/2 的特征值为 ±1/2
+	B.A[0] = []int64{0, 1}
+	B.A[1] = []int64{1, 0}
+	return &Instance{Vars: map[string]interface{}{"A": A, "D": D, "B": B}}
+}
+
+func TestPolynomial_expressions(t *testing.T) {
+	inst := polyTestInst()
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"charpoly(A)", "λ^3 - 4λ^2 + 5λ - 2"},
+		{"minpoly(A)", "λ^3 - 4λ^2 + 5λ - 2"},
+		{"minpoly(D)", "λ^2 - 5λ + 6"},
+		{"charpoly(D)", "λ^3 - 7λ^2 + 16λ - 12"},
+		{"coeff(charpoly(A), 1)", "5"},
+		{"coeff(charpoly(A), 7)", "0"},
+		{"roots_rational(charpoly(A))[2]", "1"},
+		{"roots_rational(charpoly(A))[3]", "2"},
+		{"roots_rational(charpoly(B / 2))[1]", "-1/2"},
+		{"charpoly(inv(A))", "λ^3 - (5/2)λ^2 + 2λ - (1/2)"},
+		{"polyeval(charpoly(A), 3)", "4"},
+		{"rank(polyeval(charpoly(A), A))", "0"},
+		{"rank(polyeval(minpoly(D), D))", "0"},
+	}
+	for _, tc := range cases {
+		v, err := EvaluateExpression(tc.expr, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := ValueToCanonicalString(v); got != tc.want {
+			t.Fatalf("%s = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestPolynomial_latex(t *testing.T) {
+	p := NewPolynomial(big.NewRat(-3, 1), big.NewRat(1, 2), big.NewRat(0, 1), big.NewRat(-1, 1))
+	if got := FormatValueForTitle(p); got != `-\lambda^{3}+\frac{1}{2}\lambda-3` {
+		t.Fatalf("latex = %s", got)
+	}
+	if got := FormatValueForTitle(&Polynomial{}); got != "0" {
+		t.Fatalf("zero = %s", got)
+	}
+}
+
+func TestParseUserPolynomial(t *testing.T) {
+	want, _ := EvaluateExpression("charpoly(A)", polyTestInst())
+	for _, in := range []string{
+		"λ^3-4λ^2+5λ-2",
+		"λ³ − 4λ² + 5λ − 2",
+		"(λ-1)^2(λ-2)",
+		`(\lambda-1)^{2}(\lambda-2)`,
+		"x^3-4x^2+5x-2",
+		"(lambda - 2)*(lambda-1)**2",
+		"-(1-λ)(λ-1)(λ-2)",
+	} {
+		if ok, note := PolynomialAnswersEqual(want.(*Polynomial), in); !ok {
+			t.Fatalf("%q should match: %s", in, note)
+		}
+	}
+	for _, in := range []string{"λ^3-4λ^2+5λ+2", "(λ-1)(λ-2)", "x^3-4t^2", "λ^", "(λ-1", ""} {
+		if ok, _ := PolynomialAnswersEqual(want.(*Polynomial), in); ok {
+			t.Fatalf("%q should not match", in)
+		}
+	}
+	p := NewPolynomial(big.NewRat(-1, 2), big.NewRat(2, 3), big.NewRat(1, 1))
+	back, err := ParseUserPolynomial(p.String())
+	if err != nil || !back.Equal(p) {
+		t.Fatalf("round trip %s: %v", p.String(), err)
+	}
+}
+
+func TestJudge_polynomialField(t *testing.T) {
+	want, _ := EvaluateExpression("charpoly(A)", polyTestInst())
+	g := &GeneratedQuestion{AnswerFields: []AnswerField{{ID: "p", Value: want}}}
+	res := JudgeGeneratedQuestion(g, map[string]string{"p": "(λ-2)(λ-1)^2"}, nil)
+	if !res.AllCorrect {
+		t.Fatalf("judge: %+v", res)
+	}
+	if h := FieldInputHintsFromGenerated(g); h[0].Kind != FieldAnswerKindPolynomial {
+		t.Fatalf("hint %s", h[0].Kind)
+	}
+}
@@ -0,0 +1,172 @@
+package dsl
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"math/rand"
+)
+
+// 随机数算法版本，由 Problem.RNG 选择；已发布题目的版本不可更改，否则已出的题会变。
+const (
+	// RNGv1 为默认版本：math/rand 的种子源，全部随机变量按名字顺序共用一条流，
+	// 增删或改名一个变量会改变其余变量的取值。
+	RNGv1 = "v1"
+	// RNGv2 使用 dsl 自有的 xoshiro256**，每个变量一条由变量名按域分离哈希派生的独立子流，
+	// 增删其他变量不影响已有变量的取值。
+	RNGv2 = "v2"
+)
+
+// prngAlgo 为实例化时使用的随机数算法。
+type prngAlgo int
+
+const (
+	prngV1 prngAlgo = iota
+	prngV2
+)
+
+// lookupPRNG 解析 Problem.RNG；空串视为 v1。
+func lookupPRNG(version string) (prngAlgo, error) {
+	switch version {
+	case "", RNGv1:
+		return prngV1, nil
+	case RNGv2:
+		return prngV2, nil
+	}
+	return 0, fmt.Errorf("rng: unknown version %q (want %q or %q)", version, RNGv1, RNGv2)
+}
+
+// Rand 为生成规则取随机数的来源，方法语义同 math/rand.Rand。
+// v1 下为 *rand.Rand；v2 下为 dsl 自有的 xoshiro，有界整数、浮点、排列与洗牌都直接在其输出上实现，
+// 序列不依赖 math/rand 的任何内部实现。
+type Rand interface {
+	Int63() int64
+	Int63n(n int64) int64
+	Intn(n int) int
+	Float64() float64
+	Perm(n int) []int
+	Shuffle(n int, swap func(i, j int))
+}
+
+// newRand 返回以 seed 初始化的 RNG。
+func (a prngAlgo) newRand(seed int64) Rand {
+	if a == prngV2 {
+		return newXoshiro(seed)
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// subRand 为生成规则的逐次尝试创建独立 RNG：种子取 rng 的下一个 Int63，算法与实例一致。
+func (inst *Instance) subRand(rng Rand) Rand {
+	var a prngAlgo
+	if inst != nil {
+		a = inst.prng
+	}
+	return a.newRand(rng.Int63())
+}
+
+// streamSeed 用带域标签的 SHA-256 从 seed 派生子流种子；domain 与 name 均带长度前缀，
+// 不同 (domain, name) 的子流互不相关，也不会因拼接产生碰撞。
+func streamSeed(seed int64, domain, name string) int64 {
+	buf := make([]byte, 0, 32+len(domain)+len(name))
+	buf = append(buf, "la-dsl/rng/v2"...)
+	buf = binary.AppendUvarint(buf, uint64(len(domain)))
+	buf = append(buf, domain...)
+	buf = binary.AppendUvarint(buf, uint64(len(name)))
+	buf = append(buf, name...)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(seed))
+	h := sha256.Sum256(buf)
+	return int64(binary.LittleEndian.Uint64(h[:8]) >> 1)
+}
+
+// xoshiro 为 xoshiro256**（Blackman & Vigna），状态由 SplitMix64 从种子展开，实现 Rand。
+type xoshiro struct {
+	s [4]uint64
+}
+
+func newXoshiro(seed int64) *xoshiro {
+	x := &xoshiro{}
+	x.Seed(seed)
+	return x
+}
+
+// Seed 按 SplitMix64 展开 256 位状态；SplitMix64 的输出不会全为零。
+func (x *xoshiro) Seed(seed int64) {
+	z := uint64(seed)
+	for i := range x.s {
+		z += 0x9e3779b97f4a7c15
+		v := z
+		v = (v ^ (v >> 30)) * 0xbf58476d1ce4e5b9
+		v = (v ^ (v >> 27)) * 0x94d049bb133111eb
+		x.s[i] = v ^ (v >> 31)
+	}
+}
+
+func (x *xoshiro) Uint64() uint64 {
+	s := &x.s
+	out := bits.RotateLeft64(s[1]*5, 7) * 9
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = bits.RotateLeft64(s[3], 45)
+	return out
+}
+
+func (x *xoshiro) Int63() int64 {
+	return int64(x.Uint64() >> 1)
+}
+
+// bounded 返回 [0, n) 内均匀分布的整数（Lemire 的乘法拒绝法），n 须为正。
+func (x *xoshiro) bounded(n uint64) uint64 {
+	hi, lo := bits.Mul64(x.Uint64(), n)
+	if lo < n {
+		thresh := -n % n
+		for lo < thresh {
+			hi, lo = bits.Mul64(x.Uint64(), n)
+		}
+	}
+	return hi
+}
+
+func (x *xoshiro) Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63n")
+	}
+	return int64(x.bounded(uint64(n)))
+}
+
+func (x *xoshiro) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	return int(x.bounded(uint64(n)))
+}
+
+// Float64 取高 53 位，返回 [0, 1) 内的浮点数。
+func (x *xoshiro) Float64() float64 {
+	return float64(x.Uint64()>>11) / (1 << 53)
+}
+
+// Shuffle 为 Fisher–Yates 洗牌：i 从 n-1 递减，与 [0, i] 内的随机下标交换。
+func (x *xoshiro) Shuffle(n int, swap func(i, j int)) {
+	if n < 0 {
+		panic("invalid argument to Shuffle")
+	}
+	for i := n - 1; i > 0; i-- {
+		swap(i, int(x.bounded(uint64(i+1))))
+	}
+}
+
+// Perm 返回 0..n-1 的一个随机排列（对恒等排列做 Shuffle）。
+func (x *xoshiro) Perm(n int) []int {
+	m := make([]int, n)
+	for i := range m {
+		m[i] = i
+	}
+	x.Shuffle(n, func(i, j int) { m[i], m[j] = m[j], m[i] })
+	return m
+}
@@ -0,0 +1,166 @@
+package dsl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXoshiro_referenceVector(t *testing.T) {
+	x := &xoshiro{s: [4]uint64{1, 2, 3, 4}}
+	want := []uint64{11520, 0, 1509978240, 1215971899390074240}
+	for i, w := range want {
+		if got := x.Uint64(); got != w {
+			t.Fatalf("output %d: got %d want %d", i, got, w)
+		}
+	}
+}
+
+// 有界整数、排列、浮点直接由 xoshiro 输出导出，以下取值固定，不随 Go 版本的 math/rand 变化。
+func TestXoshiro_derivedGolden(t *testing.T) {
+	x := newXoshiro(42)
+	got := fmt.Sprint(x.Intn(10), x.Intn(1000), x.Int63n(1<<40), x.Perm(6), x.Perm(6), x.Float64())
+	if want := "0 378 747715637817 [0 1 2 3 4 5] [3 1 0 5 2 4] 0.3214116333153503"; got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	counts := make([]int, 6)
+	for i := 0; i < 6000; i++ {
+		counts[x.Intn(6)]++
+	}
+	for k, c := range counts {
+		if c < 850 || c > 1150 {
+			t.Fatalf("Intn(6) bucket %d: %d of 6000", k, c)
+		}
+	}
+}
+
+// v2 实例取值的金标准：生成规则覆盖 Intn（range、full_rank）、Shuffle（orthogonal_signed_perm）与 Float64（sparse）。
+// 改动 xoshiro、子流派生或这些规则的取数方式都会令此测试失败；已发布的 v2 题目不允许这样的改动。
+func TestInstantiate_rngV2Golden(t *testing.T) {
+	p := prngTestProblem(RNGv2, "B")
+	p.Variables["Q"] = Variable{Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "orthogonal_signed_perm"}}
+	p.Variables["S"] = Variable{Kind: "matrix", Rows: 2, Cols: 3, Generator: map[string]interface{}{"rule": "sparse", "values": []interface{}{-2, 1, 3}, "density": 0.5}}
+	want := map[string]map[string]string{
+		"a": {"A": "[-5 1 5]; [5 -3 3]; [-1 -1 -3]", "B": "(-8, -5, 1)", "Q": "[1 0 0]; [0 0 -1]; [0 -1 0]", "S": "[0 1 1]; [0 1 0]", "k": "18"},
+		"b": {"A": "[-1 2 0]; [-4 3 -5]; [0 -3 5]", "B": "(7, -9, 4)", "Q": "[0 -1 0]; [1 0 0]; [0 0 1]", "S": "[1 1 0]; [0 3 3]", "k": "-44"},
+	}
+	for seed, vars := range want {
+		inst, err := InstantiateProblem(p, seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, w := range vars {
+			if got := ValueToCanonicalString(inst.Vars[name]); got != w {
+				t.Fatalf("seed %s %s: got %s, want %s", seed, name, got, w)
+			}
+		}
+	}
+}
+
+func TestStreamSeed_domainSeparated(t *testing.T) {
+	seen := map[int64]string{}
+	for _, c := range [][2]string{{"var", "A"}, {"var", "B"}, {"varA", ""}, {"var", "A\x00"}, {"constraints", "1"}} {
+		s := streamSeed(42, c[0], c[1])
+		if s < 0 {
+			t.Fatalf("%q: negative seed", c)
+		}
+		if prev, dup := seen[s]; dup {
+			t.Fatalf("%q collides with %s", c, prev)
+		}
+		seen[s] = c[0] + "|" + c[1]
+	}
+	if streamSeed(42, "var", "A") != streamSeed(42, "var", "A") || streamSeed(42, "var", "A") == streamSeed(43, "var", "A") {
+		t.Fatal("streamSeed not a function of (seed, domain, name)")
+	}
+}
+
+func prngTestProblem(rng string, extra ...string) Problem {
+	p := Problem{
+		ID: 9,
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "full_rank", "min": -5, "max": 5}},
+			"k": {Kind: "scalar", Generator: map[string]interface{}{"rule": "range", "min": -99, "max": 99}},
+		},
+		Answer: AnswerSchema{Expression: "det(A) + k"},
+		RNG:    rng,
+	}
+	for _, name := range extra {
+		p.Variables[name] = Variable{Kind: "vector", Size: 3, Generator: map[string]interface{}{"rule": "range", "min": -9, "max": 9}}
+	}
+	return p
+}
+
+func TestInstantiate_rngV1Default(t *testing.T) {
+	for _, seed := range []string{"a", "b", "c"} {
+		def, err := InstantiateProblem(prngTestProblem(""), seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v1, err := InstantiateProblem(prngTestProblem(RNGv1), seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(def.Vars, v1.Vars) {
+			t.Fatalf("seed %s: default %v, v1 %v", seed, def.Vars, v1.Vars)
+		}
+	}
+}
+
+// v2 下增删其他变量不改变已有变量的取值。
+func TestInstantiate_rngV2IndependentStreams(t *testing.T) {
+	for _, seed := range []string{"a", "b", "c", "d"} {
+		base, err := InstantiateProblem(prngTestProblem(RNGv2), seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		grown, err := InstantiateProblem(prngTestProblem(RNGv2, "B", "a0"), seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"A", "k"} {
+			if !reflect.DeepEqual(base.Vars[name], grown.Vars[name]) {
+				t.Fatalf("seed %s: %s changed from %v to %v", seed, name, base.Vars[name], grown.Vars[name])
+			}
+		}
+		v1, err := InstantiateProblem(prngTestProblem(RNGv1), seed, "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.DeepEqual(base.Vars, v1.Vars) {
+			t.Fatalf("seed %s: v2 reproduced the v1 stream", seed)
+		}
+	}
+}
+
+func TestInstantiate_rngV2Constraints(t *testing.T) {
+	p := constraintTestProblem("det(A) != 0", "d > 20")
+	p.RNG = RNGv2
+	first, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := InstantiateProblem(p, "s", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := scalarToInt64(first.Vars["d"]); d <= 20 {
+		t.Fatalf("det %d", d)
+	}
+	if ValueToCanonicalString(first.Vars["A"]) != ValueToCanonicalString(again.Vars["A"]) {
+		t.Fatal("not deterministic")
+	}
+}
+
+func TestInstantiate_rngUnknown(t *testing.T) {
+	p := prngTestProblem("v9")
+	if _, err := Compile(p); err == nil || !strings.Contains(err.Error(), `rng: unknown version "v9"`) {
+		t.Fatalf("compile: %v", err)
+	}
+	if _, err := InstantiateProblem(p, "s", "salt"); err == nil {
+		t.Fatal("expect err")
+	}
+	if got := diagStrings(ValidateProblem(p)); got != `error rng: unknown version "v9" (want "v1" or "v2")` {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
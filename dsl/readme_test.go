@@ -0,0 +1,126 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var readmeJSONBlock = regexp.MustCompile("(?s)```json\n(.*?)```")
+
+// decodeStrict 按 DisallowUnknownFields 解码，README 中拼错的字段名直接报错。
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// TestReadmeJSONExamples 校验 README 中的每个 JSON 示例：完整题目须通过 ValidateProblem 且能出题，
+// 片段（变量、生成规则、答案字段、快照）按所属类型严格解码，变量与生成规则另按规则声明的参数检查，
+// 以免文档与校验器再次不一致。
+func TestReadmeJSONExamples(t *testing.T) {
+	src, err := os.ReadFile("../README.MD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := readmeJSONBlock.FindAllSubmatchIndex(src, -1)
+	if len(blocks) == 0 {
+		t.Fatal("no json examples in README")
+	}
+	for _, m := range blocks {
+		line := bytes.Count(src[:m[0]], []byte("\n")) + 1
+		body := src[m[2]:m[3]]
+		if bytes.Contains(body, []byte("...")) {
+			continue // 有省略号的是示意，不是可解析的示例
+		}
+		if err := checkReadmeExample(body); err != nil {
+			t.Errorf("README.MD:%d: %v", line, err)
+		}
+	}
+}
+
+func checkReadmeExample(body []byte) error {
+	data := body
+	var top map[string]json.RawMessage
+	if json.Unmarshal(data, &top) != nil {
+		// 片段，如 "generator": {...} 或 "A": {...}
+		data = append(append([]byte("{"), body...), '}')
+		if err := json.Unmarshal(data, &top); err != nil {
+			return err
+		}
+	}
+	switch {
+	case top["problem_id"] != nil:
+		var inst Instance
+		return json.Unmarshal(data, &inst)
+	case top["expr"] != nil:
+		var fd AnswerFieldDef
+		return decodeStrict(data, &fd)
+	case len(top) == 1 && top["generator"] != nil:
+		var gen map[string]interface{}
+		if err := json.Unmarshal(top["generator"], &gen); err != nil {
+			return err
+		}
+		var errs []string
+		for _, kind := range []string{"scalar", "vector", "matrix"} {
+			v := Variable{Kind: kind, Size: 3, Rows: 3, Cols: 3, Generator: gen}
+			ds := readmeErrors(ValidateProblem(Problem{Variables: map[string]Variable{"X": v}}), "variables.")
+			if len(ds) == 0 {
+				return nil
+			}
+			errs = append(errs, ds...)
+		}
+		return errorList(errs)
+	}
+	var p Problem
+	if top["title"] == nil && top["variables"] == nil && top["derived"] == nil && top["render"] == nil &&
+		top["answer"] == nil && top["constraints"] == nil && top["field_defs"] == nil {
+		// 变量定义片段，如 "A": {...}, "b": {...}
+		p.Variables = map[string]Variable{}
+		if err := decodeStrict(data, &p.Variables); err != nil {
+			return err
+		}
+	} else if top["field_defs"] != nil {
+		if err := decodeStrict(data, &p.Answer); err != nil {
+			return err
+		}
+	} else if err := decodeStrict(data, &p); err != nil {
+		return err
+	}
+	ds := ValidateProblem(p)
+	if p.Title == "" || len(p.Answer.FieldDefs) == 0 {
+		// 片段只检查自身的变量定义
+		return errorList(readmeErrors(ds, "variables."))
+	}
+	if errs := readmeErrors(ds, ""); len(errs) > 0 {
+		return errorList(errs)
+	}
+	_, err := GenerateQuestion(p, "readme", "salt")
+	return err
+}
+
+// readmeErrors 取 location 以 prefix 开头的 error 级诊断，忽略片段缺少上下文引起的引用错误。
+func readmeErrors(ds []Diagnostic, prefix string) []string {
+	var out []string
+	for _, d := range ds {
+		if d.Severity != SeverityError || !strings.HasPrefix(d.Location, prefix) {
+			continue
+		}
+		if prefix != "" && strings.Contains(d.Message, "undefined") {
+			continue
+		}
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func errorList(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
@@ -3,6 +3,7 @@ package dsl
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
 	"sort"
@@ -11,14 +12,54 @@ import (
 
 // InstantiateProblem 根据 DSL Problem 与 seed 实例化一道题
 func InstantiateProblem(p Problem, seedStr string, serverSalt string) (*Instance, error) {
+	return compileLenient(p).Instantiate(seedStr, serverSalt)
+}
+
+// Instantiate 根据 seed 实例化一道题；派生量按编译期排好的依赖顺序求值。
+// 题目带 Constraints 时按种子确定性地重抽，直到约束全部成立（见 constraint.go）。
+func (cp *CompiledProblem) Instantiate(seedStr string, serverSalt string) (*Instance, error) {
+	return cp.instantiate(seedStr, serverSalt, nil)
+}
+
+// instantiate 为 Instantiate 的实现；tr 非 nil 时记录生成统计（见 SampleGeneratorStats）。
+func (cp *CompiledProblem) instantiate(seedStr, serverSalt string, tr *generationTrace) (*Instance, error) {
+	p := cp.Problem
+	if cp.prngErr != nil {
+		return nil, cp.prngErr
+	}
+	if cp.paramsErr != nil {
+		return nil, cp.paramsErr
+	}
+	if cp.constraintsErr != nil {
+		return nil, cp.constraintsErr
+	}
+	seed := deriveSeed(seedStr, fmt.Sprintf("%d", p.ID), p.Version, serverSalt)
+	if len(cp.constraints) > 0 {
+		return cp.instantiateConstrained(seed, seedStr, serverSalt, tr)
+	}
+	inst, _, err := cp.instantiateAttempt(seed, seedStr, tr)
+	return inst, err
+}
+
+// instantiateAttempt 以 seed 做一次实例化。有约束不成立时返回其在 Problem.Constraints 中的下标，
+// 否则返回 -1（约束求值出错时同时返回下标与错误，不成立的尝试由调用方重抽）；只引用变量的约束在派生量求值前检查，以便先排除会令派生量出错的样本（如 det(A) != 0）。
+// v1 下全部变量按名字顺序共用一条以 seed 初始化的流；v2 下每个变量使用由 seed 与变量名派生的独立子流。
+func (cp *CompiledProblem) instantiateAttempt(seed int64, seedStr string, tr *generationTrace) (*Instance, int, error) {
+	p := cp.Problem
 	inst := &Instance{
 		ProblemID: p.ID,
 		Seed:      seedStr,
+		Version:   p.Version,
 		Vars:      map[string]interface{}{},
 		Derived:   map[string]interface{}{},
+		Outputs:   map[string]GeneratorOutputs{},
+		prng:      cp.prng,
+		trace:     tr,
+	}
+	var shared Rand
+	if cp.prng == prngV1 {
+		shared = rand.New(rand.NewSource(seed))
 	}
-	seed := deriveSeed(seedStr, fmt.Sprintf("%d", p.ID), p.Version, serverSalt)
-	rng := rand.New(rand.NewSource(seed))
 
 	// 对变量名排序，确保确定性的生成顺序（Go map 迭代顺序是随机的）
 	varNames := make([]string, 0, len(p.Variables))
@@ -33,159 +74,61 @@ func InstantiateProblem(p Problem, seedStr string, serverSalt string) (*Instance
 			// 将 fixed 值转换为正确的类型
 			fixedVal, err := convertFixed(v)
 			if err != nil {
-				return nil, fmt.Errorf("convert fixed variable %s error: %w", name, err)
+				return nil, -1, fmt.Errorf("convert fixed variable %s error: %w", name, err)
 			}
 			inst.Vars[name] = fixedVal
 			continue
 		}
+		if rule, _ := v.Generator["rule"].(string); rule == "integer_solution" {
+			// 由依赖图在 A、x 就绪后计算
+			continue
+		}
+		rng := shared
+		if rng == nil {
+			rng = cp.prng.newRand(streamSeed(seed, "var", name))
+		}
 		val, err := generateVariable(rng, name, v, inst)
 		if err != nil {
-			if v.Generator != nil {
-				if rule, ok := v.Generator["rule"].(string); ok && rule == "integer_solution" {
-					continue
-				}
-			}
-			return nil, fmt.Errorf("generate variable %s error: %w", name, err)
+			return nil, -1, fmt.Errorf("generate variable %s error: %w", name, err)
 		}
 		inst.Vars[name] = val
 	}
 
-	// 派生变量多轮求值，支持依赖链（如先 transpose 再 matmul）
-	pending := make(map[string]string, len(p.Derived))
-	for name, expr := range p.Derived {
-		pending[name] = strings.TrimSpace(expr)
-	}
-	for len(pending) > 0 {
-		names := make([]string, 0, len(pending))
-		for k := range pending {
-			names = append(names, k)
-		}
-		sort.Strings(names)
-		progress := 0
-		var lastErr error
-		for _, name := range names {
-			expr := pending[name]
-			if strings.HasPrefix(expr, "integer_solution(") {
-				ins := insideParens(expr)
-				parts := splitArgs(ins)
-				if len(parts) != 2 {
-					return nil, fmt.Errorf("integer_solution expects 2 args A,x")
-				}
-				Aname := strings.TrimSpace(parts[0])
-				xname := strings.TrimSpace(parts[1])
-				if _, ok := inst.Vars[xname]; !ok {
-					if xv, ok := p.Variables[xname]; ok {
-						val, err := generateVariable(rng, xname, xv, inst)
-						if err != nil {
-							lastErr = err
-							continue
-						}
-						inst.Vars[xname] = val
-					} else {
-						return nil, fmt.Errorf("x var %s not found", xname)
-					}
-				}
-				if _, ok := inst.Vars[Aname]; !ok {
-					if Av, ok := p.Variables[Aname]; ok {
-						val, err := generateVariable(rng, Aname, Av, inst)
-						if err != nil {
-							lastErr = err
-							continue
-						}
-						inst.Vars[Aname] = val
-					} else {
-						return nil, fmt.Errorf("matrix var %s not found", Aname)
-					}
-				}
-				A, ok1 := inst.Vars[Aname].(*MatrixInt)
-				x, ok2 := inst.Vars[xname].(*VectorInt)
-				if !ok1 || !ok2 {
-					return nil, fmt.Errorf("integer_solution expects matrix and vector")
-				}
-				b := NewVectorInt(A.R)
-				for i := 0; i < A.R; i++ {
-					var s int64
-					for j := 0; j < A.C; j++ {
-						s += A.A[i][j] * x.V[j]
-					}
-					b.V[i] = s
-				}
-				inst.Vars[name] = b
-				inst.Derived[name] = b
-				delete(pending, name)
-				progress++
-				continue
-			}
-			val, err := EvaluateExpression(expr, inst)
-			if err != nil {
-				lastErr = err
-				continue
-			}
-			inst.Vars[name] = val
-			inst.Derived[name] = val
-			delete(pending, name)
-			progress++
-		}
-		if progress == 0 {
-			if lastErr != nil {
-				return nil, fmt.Errorf("derived evaluation stuck: %w", lastErr)
-			}
-			return nil, fmt.Errorf("derived evaluation stuck on %d names", len(pending))
-		}
+	if failed, err := cp.checkConstraints(inst, false); err != nil || failed >= 0 {
+		return nil, failed, err
 	}
 
-	for name, v := range p.Variables {
-		if v.Generator != nil {
-			if rule, ok := v.Generator["rule"].(string); ok && rule == "integer_solution" {
-				Aref, _ := v.Generator["A"].(string)
-				Xref, _ := v.Generator["x"].(string)
-				if Aref == "" || Xref == "" {
-					return nil, fmt.Errorf("integer_solution var %s missing A/x refs", name)
-				}
-				if _, ok := inst.Vars[Aref]; !ok {
-					if Av, ok := p.Variables[Aref]; ok {
-						val, err := generateVariable(rng, Aref, Av, inst)
-						if err != nil {
-							return nil, err
-						}
-						inst.Vars[Aref] = val
-					} else {
-						return nil, fmt.Errorf("aref %s not found", Aref)
-					}
-				}
-				if _, ok := inst.Vars[Xref]; !ok {
-					if Xv, ok := p.Variables[Xref]; ok {
-						val, err := generateVariable(rng, Xref, Xv, inst)
-						if err != nil {
-							return nil, err
-						}
-						inst.Vars[Xref] = val
-					} else {
-						return nil, fmt.Errorf("xref %s not found", Xref)
-					}
-				}
-				A := inst.Vars[Aref].(*MatrixInt)
-				x := inst.Vars[Xref].(*VectorInt)
-				b := NewVectorInt(A.R)
-				for i := 0; i < A.R; i++ {
-					var s int64
-					for j := 0; j < A.C; j++ {
-						s += A.A[i][j] * x.V[j]
-					}
-					b.V[i] = s
-				}
-				inst.Vars[name] = b
-			}
+	// 派生量按依赖图的拓扑序求值（如先 transpose 再 matmul）
+	if cp.derivedErr != nil {
+		return nil, -1, cp.derivedErr
+	}
+	inst.Graph = cp.graph
+	for _, node := range cp.graph.Nodes {
+		val, err := cp.eval(node.Expr, inst)
+		if err != nil {
+			return nil, -1, fmt.Errorf("derived %s: %w", node.Name, err)
 		}
+		inst.Vars[node.Name] = val
+		if _, ok := p.Derived[node.Name]; ok {
+			inst.Derived[node.Name] = val
+		}
+	}
+	if failed, err := cp.checkConstraints(inst, true); err != nil || failed >= 0 {
+		return nil, failed, err
 	}
-	return inst, nil
+	return inst, -1, nil
 }
 
 // RenderInst 根据 Problem.Render 生成前端可用的渲染变量
 func RenderInst(p Problem, inst *Instance) (map[string]interface{}, error) {
+	return compileLenient(p).RenderInst(inst)
+}
+
+// RenderInst 根据 Problem.Render 生成前端可用的渲染变量
+func (cp *CompiledProblem) RenderInst(inst *Instance) (map[string]interface{}, error) {
 	out := map[string]interface{}{}
-	for key, expr := range p.Render {
-		val, err := EvaluateExpression(expr, inst)
+	for key, expr := range cp.Problem.Render {
+		val, err := cp.eval(expr, inst)
 		if err != nil {
 			if v, ok := inst.Vars[expr]; ok {
 				out[key] = v
@@ -244,9 +187,15 @@ func ExtractAnswer(p Problem, inst *Instance) (interface{}, error) {
 // - 对于 FieldDefs（推荐），使用 fieldDef.ID 与 fieldDef.Expr
 // - 对于老的 Fields，自动生成 ID：field_1, field_2, ...
 func ExtractAnswerWithMeta(p Problem, inst *Instance) ([]AnswerField, error) {
+	return compileLenient(p).ExtractAnswerWithMeta(inst)
+}
+
+// ExtractAnswerWithMeta 同 dsl.ExtractAnswerWithMeta，但复用预编译结果。
+func (cp *CompiledProblem) ExtractAnswerWithMeta(inst *Instance) ([]AnswerField, error) {
+	p := cp.Problem
 	// 1) Expression 场景：单个答案
 	if p.Answer.Expression != "" {
-		val, err := EvaluateExpression(p.Answer.Expression, inst)
+		val, err := cp.eval(p.Answer.Expression, inst)
 		if err != nil {
 			return nil, err
 		}
@@ -281,7 +230,7 @@ func ExtractAnswerWithMeta(p Problem, inst *Instance) ([]AnswerField, error) {
 			if fd.Expr == "" {
 				continue
 			}
-			val, err := EvaluateExpression(fd.Expr, inst)
+			val, err := cp.eval(fd.Expr, inst)
 			if err != nil {
 				return nil, err
 			}
@@ -392,7 +341,9 @@ func solveLinearSystemRat(A *MatrixInt, b *VectorInt) ([]*big.Rat, error) {
 	return x, nil
 }
 
-// convertFixed 将 Variable.Fixed 从 interface{} 转换为正确的类型
+// convertFixed 将 Variable.Fixed 转换为实例中的值。除 *MatrixInt / *VectorInt 外，Fixed 可写成线上格式：
+// 标量为整数，向量为整数数组，矩阵为按行的二维整数数组（[][]interface{}、JSON 解码得到的 []interface{}、
+// 或 []int / [][]int64 等均可）；非整数、形状与 Rows / Cols / Size 不符时报错。
 func convertFixed(v Variable) (interface{}, error) {
 	if v.Fixed == nil {
 		return nil, errors.New("fixed is nil")
@@ -400,60 +351,45 @@ func convertFixed(v Variable) (interface{}, error) {
 
 	switch v.Kind {
 	case "scalar":
-		// 可能是 int, int64, float64 等
-		switch val := v.Fixed.(type) {
-		case int:
-			return int64(val), nil
-		case int64:
-			return val, nil
-		case float64:
-			return int64(val), nil
-		default:
-			return nil, fmt.Errorf("unsupported scalar fixed type: %T", v.Fixed)
+		n, err := fixedInt(v.Fixed)
+		if err != nil {
+			return nil, fmt.Errorf("scalar fixed: %w", err)
 		}
+		return n, nil
 
 	case "vector":
-		// Fixed 应该是 []interface{} 或 *VectorInt
 		if vec, ok := v.Fixed.(*VectorInt); ok {
 			return vec, nil
 		}
-		arr, ok := v.Fixed.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("vector fixed expects []interface{}, got %T", v.Fixed)
-		}
-		size := v.Size
-		if size == 0 {
-			size = len(arr)
+		arr, err := fixedInts(v.Fixed)
+		if err != nil {
+			return nil, fmt.Errorf("vector fixed: %w", err)
 		}
-		vec := NewVectorInt(size)
-		for i := 0; i < size && i < len(arr); i++ {
-			vec.V[i] = toInt64(arr[i])
+		if v.Size != 0 && len(arr) != v.Size {
+			return nil, fmt.Errorf("vector fixed has %d entries, want size %d", len(arr), v.Size)
 		}
-		return vec, nil
+		return &VectorInt{N: len(arr), V: arr}, nil
 
 	case "matrix":
-		// Fixed 应该是 [][]interface{} 或 *MatrixInt
 		if mat, ok := v.Fixed.(*MatrixInt); ok {
 			return mat, nil
 		}
-		arr2d, ok := v.Fixed.([][]interface{})
-		if !ok {
-			return nil, fmt.Errorf("matrix fixed expects [][]interface{}, got %T", v.Fixed)
+		rows, err := fixedRows(v.Fixed)
+		if err != nil {
+			return nil, fmt.Errorf("matrix fixed: %w", err)
 		}
-		rows := v.Rows
-		cols := v.Cols
-		if rows == 0 {
-			rows = len(arr2d)
+		r, c := v.Rows, v.Cols
+		if r == 0 {
+			r = len(rows)
 		}
-		if cols == 0 && rows > 0 {
-			cols = len(arr2d[0])
+		if c == 0 && len(rows) > 0 {
+			c = len(rows[0])
 		}
-		mat := NewMatrixInt(rows, cols)
-		for i := 0; i < rows && i < len(arr2d); i++ {
-			for j := 0; j < cols && j < len(arr2d[i]); j++ {
-				mat.A[i][j] = toInt64(arr2d[i][j])
-			}
+		if err := checkCells(len(rows), r, c, func(i int) int { return len(rows[i]) }); err != nil {
+			return nil, fmt.Errorf("matrix fixed: %w", err)
 		}
+		mat := NewMatrixInt(r, c)
+		copy(mat.A, rows)
 		return mat, nil
 
 	default:
@@ -461,16 +397,94 @@ func convertFixed(v Variable) (interface{}, error) {
 	}
 }
 
-// toInt64 将 interface{} 转换为 int64
-func toInt64(v interface{}) int64 {
+// FixedWire 返回 Variable.Fixed 的线上格式（int64 / []int64 / [][]int64），json.Marshal 后可由 convertFixed 原样读回。
+func FixedWire(v Variable) (interface{}, error) {
+	val, err := convertFixed(v)
+	if err != nil {
+		return nil, err
+	}
+	switch t := val.(type) {
+	case *VectorInt:
+		return append([]int64{}, t.V...), nil
+	case *MatrixInt:
+		rows := make([][]int64, t.R)
+		for i := range rows {
+			rows[i] = append([]int64{}, t.A[i]...)
+		}
+		return rows, nil
+	}
+	return val, nil
+}
+
+// fixedInt 读取一个整数；float64（JSON 解码所得）须为整数值。
+func fixedInt(v interface{}) (int64, error) {
 	switch val := v.(type) {
 	case int:
-		return int64(val)
+		return int64(val), nil
 	case int64:
-		return val
+		return val, nil
 	case float64:
-		return int64(val)
+		if val != math.Trunc(val) || math.Abs(val) > 1<<53 {
+			return 0, fmt.Errorf("%v is not an integer", val)
+		}
+		return int64(val), nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
+}
+
+// fixedInts 读取整数数组。
+func fixedInts(v interface{}) ([]int64, error) {
+	switch arr := v.(type) {
+	case []int64:
+		return append([]int64{}, arr...), nil
+	case []int:
+		out := make([]int64, len(arr))
+		for i, n := range arr {
+			out[i] = int64(n)
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]int64, len(arr))
+		for i, e := range arr {
+			n, err := fixedInt(e)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i+1, err)
+			}
+			out[i] = n
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("expects an integer array, got %T", v)
+}
+
+// fixedRows 读取按行的二维整数数组。
+func fixedRows(v interface{}) ([][]int64, error) {
+	var rows []interface{}
+	switch arr := v.(type) {
+	case [][]interface{}:
+		for _, r := range arr {
+			rows = append(rows, r)
+		}
+	case [][]int64:
+		for _, r := range arr {
+			rows = append(rows, r)
+		}
+	case [][]int:
+		for _, r := range arr {
+			rows = append(rows, r)
+		}
+	case []interface{}:
+		rows = arr
 	default:
-		return 0
+		return nil, fmt.Errorf("expects an array of rows, got %T", v)
 	}
+	out := make([][]int64, len(rows))
+	for i, r := range rows {
+		row, err := fixedInts(r)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		out[i] = row
+	}
+	return out, nil
 }
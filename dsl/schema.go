@@ -0,0 +1,295 @@
+package dsl
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONSchemaDialect 为 ProblemJSONSchema 使用的 JSON Schema 版本。
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// judgeKinds 为 AnswerJudgeSpec.Kind 的取值；新增判题类型时在此追加。
+var judgeKinds = []string{"", "scalar", "rational_line", "affine_rational", "sorted_basis_columns", "permutation_multiset", "eigen_pair", "matrix", "vector", "interval", "span_equal", "general_solution"}
+
+// schemaFields 为个别字段在反射结果之上的补充约束（键为 "类型名.json 字段名"）。
+var schemaFields = map[string]map[string]interface{}{
+	"Problem.rng":                   {"enum": []interface{}{RNGv1, RNGv2}},
+	"Problem.max_attempts":          {"minimum": 0},
+	"Problem.meta":                  {"properties": map[string]interface{}{"solution_zh": map[string]interface{}{"type": "string"}}},
+	"Variable.kind":                 {"enum": []interface{}{"scalar", "vector", "matrix"}},
+	"Variable.rows":                 {"minimum": 0},
+	"Variable.cols":                 {"minimum": 0},
+	"Variable.size":                 {"minimum": 0},
+	"AnswerJudgeSpec.eigen_role":    {"enum": []interface{}{"lambda", "vec"}},
+	"AnswerFieldLayout.schema":      {"const": AnswerLayoutSchema},
+	"AnswerFieldLayout.kind":        {"enum": []interface{}{LayoutKindMatrixCell, LayoutKindVectorComponent, LayoutKindMatrix, LayoutKindVector}},
+	"AnswerFieldDef.note":           {"description": "学生可见的解题提示"},
+	"Problem.constraints":           {"description": "出题约束，布尔表达式"},
+	"Problem.derived":               {"description": "派生量：名字 -> 表达式"},
+	"Problem.render":                {"description": "题面占位符 {{key}} -> 表达式"},
+	"AnswerFieldDef.expr":           {"description": "标准答案表达式"},
+	"AnswerJudgeSpec.kind":          {"description": "判题类型，空为标量相等"},
+	"AnswerFieldLayout.group_label": {"description": "多块矩阵时的版块标签"},
+}
+
+// schemaRequired 为各结构体的必填字段。
+var schemaRequired = map[string][]string{
+	"Variable":          {"kind"},
+	"AnswerFieldLayout": {"kind"},
+}
+
+// ProblemJSONSchema 返回 Problem 的 JSON Schema（draft 2020-12）：Problem、Variable、AnswerSchema、
+// AnswerFieldDef、AnswerJudgeSpec、AnswerFieldLayout 各在 $defs 中一项，未知字段不允许；
+// 每个已注册的生成规则一项 "generator.<kind>.<rule>"，列出参数的类型、默认值与说明，同样不允许未知参数。
+// 每次调用返回新的 map，json.Marshal 即得 schema 文档。
+func ProblemJSONSchema() map[string]interface{} {
+	b := &schemaBuilder{defs: map[string]interface{}{}}
+	root := b.typeSchema(reflect.TypeOf(Problem{}))
+	b.variableGenerators()
+	return map[string]interface{}{
+		"$schema": JSONSchemaDialect,
+		"title":   "la-dsl Problem",
+		"$ref":    root["$ref"],
+		"$defs":   b.defs,
+	}
+}
+
+type schemaBuilder struct {
+	defs map[string]interface{}
+}
+
+// typeSchema 按 json 标签反射出类型的 schema；结构体放入 $defs 并返回引用。
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		name := t.Name()
+		ref := map[string]interface{}{"$ref": "#/$defs/" + name}
+		if _, ok := b.defs[name]; ok {
+			return ref
+		}
+		props := map[string]interface{}{}
+		def := map[string]interface{}{"type": "object", "properties": props, "additionalProperties": false}
+		b.defs[name] = def
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			s := b.fieldSchema(name, tag, f.Type)
+			for k, v := range schemaFields[name+"."+tag] {
+				s[k] = v
+			}
+			props[tag] = s
+		}
+		if req := schemaRequired[name]; req != nil {
+			def["required"] = req
+		}
+		return ref
+	}
+	panic(fmt.Sprintf("ProblemJSONSchema: unsupported type %s", t))
+}
+
+func (b *schemaBuilder) fieldSchema(typeName, field string, t reflect.Type) map[string]interface{} {
+	switch typeName + "." + field {
+	case "Variable.generator":
+		// rule 仅 scalar 必填（见 variableGenerators），vector / matrix 省略时即 range
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"rule": map[string]interface{}{"type": "string"}},
+		}
+	case "Variable.fixed":
+		ints := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}
+		return map[string]interface{}{
+			"description": "固定值：标量为整数，向量为整数数组，矩阵为按行的二维整数数组",
+			"anyOf":       []interface{}{map[string]interface{}{"type": "integer"}, ints, map[string]interface{}{"type": "array", "items": ints}},
+		}
+	case "AnswerJudgeSpec.kind":
+		enum := make([]interface{}, len(judgeKinds))
+		for i, k := range judgeKinds {
+			enum[i] = k
+		}
+		return map[string]interface{}{"type": "string", "enum": enum}
+	case "AnswerJudgeSpec.span_of":
+		return map[string]interface{}{"type": "string", "enum": []interface{}{"", "nullspace", "column"}}
+	}
+	return b.typeSchema(t)
+}
+
+// variableGenerators 为每个生成规则加入 $defs，并在 Variable 上按 kind、rule 选择对应的参数 schema。
+func (b *schemaBuilder) variableGenerators() {
+	var conds []interface{}
+	rulesByKind := map[string][]interface{}{}
+	for _, g := range Generators() {
+		rules := []interface{}{g.Rule}
+		if g.Rule == "range" && g.Kind != "scalar" {
+			// vector / matrix 的空 rule 即 range
+			rules = append(rules, "")
+		}
+		name := "generator." + g.Kind + "." + g.Rule
+		props := map[string]interface{}{"rule": map[string]interface{}{"enum": rules}}
+		for _, p := range g.Params {
+			s := paramSchema(p.Type)
+			if p.Default != nil {
+				s["default"] = p.Default
+			}
+			if p.Doc != "" {
+				s["description"] = p.Doc
+			}
+			props[p.Name] = s
+		}
+		b.defs[name] = map[string]interface{}{
+			"description":          fmt.Sprintf("%s 生成规则 %s 的参数", g.Kind, g.Rule),
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		rulesByKind[g.Kind] = append(rulesByKind[g.Kind], rules...)
+		ruleCond := map[string]interface{}{
+			"properties": map[string]interface{}{"rule": map[string]interface{}{"enum": rules}},
+		}
+		if len(rules) == 1 {
+			ruleCond["required"] = []interface{}{"rule"}
+		}
+		conds = append(conds, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"kind": map[string]interface{}{"const": g.Kind}, "generator": ruleCond},
+				"required":   []interface{}{"kind", "generator"},
+			},
+			"then": map[string]interface{}{"properties": map[string]interface{}{"generator": map[string]interface{}{"$ref": "#/$defs/" + name}}},
+		})
+	}
+	kinds := make([]string, 0, len(rulesByKind))
+	for k := range rulesByKind {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		gen := map[string]interface{}{
+			"properties": map[string]interface{}{"rule": map[string]interface{}{"enum": rulesByKind[kind]}},
+		}
+		if kind == "scalar" {
+			gen["required"] = []interface{}{"rule"}
+		}
+		conds = append(conds, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"kind": map[string]interface{}{"const": kind}},
+				"required":   []interface{}{"kind"},
+			},
+			"then": map[string]interface{}{"properties": map[string]interface{}{"generator": gen}},
+		})
+	}
+	b.defs["Variable"].(map[string]interface{})["allOf"] = conds
+}
+
+func paramSchema(typ string) map[string]interface{} {
+	switch typ {
+	case "int":
+		return map[string]interface{}{"type": "integer"}
+	case "float":
+		return map[string]interface{}{"type": "number"}
+	case "int_list":
+		return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}, "minItems": 1}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// checkGeneratorParams 按规则声明的参数检查 Variable.Generator：未声明的键与类型不符的值均报错。
+func checkGeneratorParams(e generatorEntry, params map[string]interface{}) []string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs []string
+	for _, k := range keys {
+		if k == "rule" {
+			continue
+		}
+		p, ok := e.param(k)
+		if !ok {
+			known := make([]string, len(e.params))
+			for i, p := range e.params {
+				known[i] = p.Name
+			}
+			if len(known) == 0 {
+				errs = append(errs, fmt.Sprintf("unknown key %q (rule takes no parameters)", k))
+			} else {
+				errs = append(errs, fmt.Sprintf("unknown key %q (known: %s)", k, strings.Join(known, ", ")))
+			}
+			continue
+		}
+		if !paramTypeOK(p.Type, params[k]) {
+			errs = append(errs, fmt.Sprintf("%s: want %s, got %v", k, p.Type, params[k]))
+		}
+	}
+	return errs
+}
+
+// param 查找规则声明的参数。
+func (e generatorEntry) param(name string) (GeneratorParam, bool) {
+	for _, p := range e.params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return GeneratorParam{}, false
+}
+
+func paramTypeOK(typ string, v interface{}) bool {
+	isInt := func(v interface{}) bool {
+		switch n := v.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	}
+	switch typ {
+	case "int":
+		return isInt(v)
+	case "float":
+		switch v.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "int_list":
+		switch arr := v.(type) {
+		case []int, []int64:
+			return true
+		case []interface{}:
+			for _, e := range arr {
+				if !isInt(e) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	return true
+}
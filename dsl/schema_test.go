@@ -0,0 +1,199 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProblemJSONSchema(t *testing.T) {
+	s := ProblemJSONSchema()
+	if s["$schema"] != JSONSchemaDialect || s["$ref"] != "#/$defs/Problem" {
+		t.Fatalf("header: %v %v", s["$schema"], s["$ref"])
+	}
+	defs := s["$defs"].(map[string]interface{})
+	for _, name := range []string{"Problem", "Variable", "AnswerSchema", "AnswerFieldDef", "AnswerJudgeSpec", "AnswerFieldLayout"} {
+		def, ok := defs[name].(map[string]interface{})
+		if !ok || def["additionalProperties"] != false {
+			t.Fatalf("$defs.%s: %v", name, defs[name])
+		}
+	}
+	props := func(name string) map[string]interface{} {
+		return defs[name].(map[string]interface{})["properties"].(map[string]interface{})
+	}
+	if _, ok := props("Problem")["max_attempts"]; !ok {
+		t.Fatal("Problem.max_attempts missing")
+	}
+	if ref := props("AnswerFieldDef")["judge"].(map[string]interface{})["$ref"]; ref != "#/$defs/AnswerJudgeSpec" {
+		t.Fatalf("AnswerFieldDef.judge: %v", ref)
+	}
+	gen := props("generator.matrix.lambda_linear_det_zero")
+	if _, ok := gen["min"]; ok {
+		t.Fatal("lambda_linear_det_zero must not accept min")
+	}
+	if em := gen["entry_min"].(map[string]interface{}); em["type"] != "integer" || em["default"] != -5 {
+		t.Fatalf("entry_min: %v", em)
+	}
+	for _, g := range Generators() {
+		if _, ok := defs["generator."+g.Kind+"."+g.Rule]; !ok {
+			t.Fatalf("no schema for %s rule %s", g.Kind, g.Rule)
+		}
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"then":{"properties":{"generator":{"$ref":"#/$defs/generator.matrix.full_rank"}}}`) {
+		t.Fatal("Variable does not select generator sub-schema by rule")
+	}
+}
+
+// schemaCheck 为测试用的最小 JSON Schema 校验器，只支持 ProblemJSONSchema 用到的关键字。
+func schemaCheck(root, s map[string]interface{}, v interface{}, path string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		def := root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		return schemaCheck(root, def, v, path)
+	}
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	switch s["type"] {
+	case "object":
+		if _, ok := v.(map[string]interface{}); !ok {
+			fail("want object")
+			return errs
+		}
+	case "array":
+		if _, ok := v.([]interface{}); !ok {
+			fail("want array")
+			return errs
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			fail("want string")
+		}
+	case "integer":
+		if f, ok := v.(float64); !ok || f != float64(int64(f)) {
+			fail("want integer")
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			fail("want number")
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == v
+		}
+		if !found {
+			fail("%v not in enum", v)
+		}
+	}
+	if c, ok := s["const"]; ok && c != v {
+		fail("want %v", c)
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		props, _ := s["properties"].(map[string]interface{})
+		for k, fv := range obj {
+			if ps, ok := props[k].(map[string]interface{}); ok {
+				errs = append(errs, schemaCheck(root, ps, fv, path+"."+k)...)
+			} else if ap, ok := s["additionalProperties"].(map[string]interface{}); ok {
+				errs = append(errs, schemaCheck(root, ap, fv, path+"."+k)...)
+			} else if s["additionalProperties"] == false {
+				fail("unknown property %q", k)
+			}
+		}
+		req, _ := s["required"].([]string)
+		if r, ok := s["required"].([]interface{}); ok {
+			for _, k := range r {
+				req = append(req, k.(string))
+			}
+		}
+		for _, k := range req {
+			if _, ok := obj[k]; !ok {
+				fail("missing %q", k)
+			}
+		}
+	}
+	if arr, ok := v.([]interface{}); ok {
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, e := range arr {
+				errs = append(errs, schemaCheck(root, items, e, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	if branches, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range branches {
+			matched = matched || len(schemaCheck(root, sub.(map[string]interface{}), v, path)) == 0
+		}
+		if !matched {
+			fail("matches no anyOf branch")
+		}
+	}
+	for _, sub := range asList(s["allOf"]) {
+		sub := sub.(map[string]interface{})
+		if cond, ok := sub["if"].(map[string]interface{}); ok {
+			if len(schemaCheck(root, cond, v, path)) == 0 {
+				errs = append(errs, schemaCheck(root, sub["then"].(map[string]interface{}), v, path)...)
+			}
+			continue
+		}
+		errs = append(errs, schemaCheck(root, sub, v, path)...)
+	}
+	return errs
+}
+
+func asList(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func TestProblemJSONSchema_validates(t *testing.T) {
+	// 经 JSON 往返，使 schema 中的 Go 类型与解码结果一致
+	raw, err := json.Marshal(ProblemJSONSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		t.Fatal(err)
+	}
+	check := func(doc string) string {
+		var v interface{}
+		if err := json.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(schemaCheck(root, root, v, "$"), "\n")
+	}
+	good := `{"id": 1, "title": "{{A}}", "rng": "v2",
+		"variables": {
+			"A": {"kind": "matrix", "rows": 3, "cols": 3, "generator": {"rule": "lambda_linear_det_zero", "entry_min": -3, "param_var": "k"}},
+			"B": {"kind": "matrix", "rows": 2, "cols": 2, "generator": {"rule": "", "min": 0}},
+			"C": {"kind": "matrix", "rows": 2, "cols": 2, "generator": {"min": 0}},
+			"x": {"kind": "vector", "size": 2, "fixed": [1, 2]}},
+		"render": {"A": "A"},
+		"answer": {"field_defs": [{"id": "a", "expr": "det(A)", "judge": {"kind": "eigen_pair", "eigen_role": "vec"},
+			"layout": {"schema": "la-dsl.answer_layout.v1", "kind": "matrix_cell", "row": 1}}]},
+		"meta": {"solution_zh": "…", "bank_topic": "x"}}`
+	if errs := check(good); errs != "" {
+		t.Fatalf("valid problem rejected:\n%s", errs)
+	}
+	for doc, want := range map[string]string{
+		`{"variables": {"A": {"kind": "matrix", "generator": {"rule": "lambda_linear_det_zero", "min": -10}}}}`: `$.variables.A.generator: unknown property "min"`,
+		`{"variables": {"A": {"kind": "tensor"}}}`:                                                              "$.variables.A.kind: tensor not in enum",
+		`{"varaibles": {}}`: `$: unknown property "varaibles"`,
+		`{"variables": {"s": {"kind": "scalar", "generator": {"rule": "range"}, "fixed": [[1.5]]}}}`: "$.variables.s.fixed: matches no anyOf branch",
+		`{"variables": {"s": {"kind": "scalar", "generator": {"rule": "full_rank"}}}}`:               "$.variables.s.generator.rule: full_rank not in enum",
+		`{"variables": {"s": {"kind": "scalar", "generator": {"min": 1}}}}`:                          `$.variables.s.generator: missing "rule"`,
+		`{"variables": {"C": {"kind": "matrix", "generator": {"min": 0, "mni": 1}}}}`:                `$.variables.C.generator: unknown property "mni"`,
+		`{"answer": {"field_defs": [{"judge": {"kind": "nope"}}]}}`:                                  "$.answer.field_defs[0].judge.kind: nope not in enum",
+	} {
+		if errs := check(doc); !strings.Contains(errs, want) {
+			t.Fatalf("%s: got\n%s\nwant %q", doc, errs, want)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package dsl
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Surd 为有理数与二次根式之和 Σ qᵢ√rᵢ，rᵢ 为互不相同的无平方因子正整数（rᵢ = 1 即有理部分），
+// 即 ℚ(√d₁, √d₂, …) 中的元素，用于单位化向量、正交矩阵等含根号的精确答案。
+// 项按 rᵢ 升序保存，系数为零的项删去，因此同一个数只有一种表示；四则运算与比较均为精确运算。
+// 表达式中由 sqrt、norm 产生；结果为有理数时求值会收窄回 int64 / *big.Rat。
+type Surd struct {
+	Terms []SurdTerm
+}
+
+// SurdTerm 为一项 Coeff·√Radicand。
+type SurdTerm struct {
+	Coeff    *big.Rat
+	Radicand int64
+}
+
+// NewSurd 由若干项构造 Surd：根号内提出平方因子、合并同类项；被开方数须为正。
+func NewSurd(terms ...SurdTerm) (*Surd, error) {
+	acc := map[int64]*big.Rat{}
+	for _, t := range terms {
+		if t.Radicand <= 0 {
+			return nil, fmt.Errorf("radicand %d must be positive", t.Radicand)
+		}
+		outer, inner := squareFreeSplit(t.Radicand)
+		addSurdTerm(acc, new(big.Rat).Mul(t.Coeff, new(big.Rat).SetInt64(outer)), inner)
+	}
+	return surdFromTerms(acc), nil
+}
+
+// SurdFromRat 把有理数视为 Surd。
+func SurdFromRat(q *big.Rat) *Surd {
+	if q.Sign() == 0 {
+		return &Surd{}
+	}
+	return &Surd{Terms: []SurdTerm{{Coeff: new(big.Rat).Set(q), Radicand: 1}}}
+}
+
+// SurdSqrt 返回非负有理数 q 的算术平方根：√(n/d) = √(n·d)/d。
+func SurdSqrt(q *big.Rat) (*Surd, error) {
+	if q.Sign() < 0 {
+		return nil, fmt.Errorf("sqrt of negative number %s", q.RatString())
+	}
+	nd := new(big.Int).Mul(q.Num(), q.Denom())
+	if !nd.IsInt64() {
+		return nil, fmt.Errorf("sqrt: radicand %s too large", q.RatString())
+	}
+	if nd.Sign() == 0 {
+		return &Surd{}, nil
+	}
+	return NewSurd(SurdTerm{Coeff: new(big.Rat).SetFrac(big.NewInt(1), q.Denom()), Radicand: nd.Int64()})
+}
+
+// squareFreeSplit 把正整数 n 写成 outer²·inner，inner 无平方因子。
+func squareFreeSplit(n int64) (outer, inner int64) {
+	outer, inner = 1, 1
+	for p := int64(2); p*p <= n; p++ {
+		for n%(p*p) == 0 {
+			n /= p * p
+			outer *= p
+		}
+		if n%p == 0 {
+			n /= p
+			inner *= p
+		}
+	}
+	return outer, inner * n
+}
+
+func addSurdTerm(acc map[int64]*big.Rat, c *big.Rat, r int64) {
+	if old, ok := acc[r]; ok {
+		old.Add(old, c)
+		return
+	}
+	acc[r] = new(big.Rat).Set(c)
+}
+
+func surdFromTerms(acc map[int64]*big.Rat) *Surd {
+	s := &Surd{}
+	for r, c := range acc {
+		if c.Sign() != 0 {
+			s.Terms = append(s.Terms, SurdTerm{Coeff: c, Radicand: r})
+		}
+	}
+	sort.Slice(s.Terms, func(i, j int) bool { return s.Terms[i].Radicand < s.Terms[j].Radicand })
+	return s
+}
+
+// Rat 在 s 为有理数时返回其值。
+func (s *Surd) Rat() (*big.Rat, bool) {
+	switch {
+	case len(s.Terms) == 0:
+		return new(big.Rat), true
+	case len(s.Terms) == 1 && s.Terms[0].Radicand == 1:
+		return new(big.Rat).Set(s.Terms[0].Coeff), true
+	}
+	return nil, false
+}
+
+// Neg 返回 -s。
+func (s *Surd) Neg() *Surd {
+	out := &Surd{Terms: make([]SurdTerm, len(s.Terms))}
+	for i, t := range s.Terms {
+		out.Terms[i] = SurdTerm{Coeff: new(big.Rat).Neg(t.Coeff), Radicand: t.Radicand}
+	}
+	return out
+}
+
+// Add 返回 s + t。
+func (s *Surd) Add(t *Surd) *Surd {
+	acc := map[int64]*big.Rat{}
+	for _, x := range s.Terms {
+		addSurdTerm(acc, x.Coeff, x.Radicand)
+	}
+	for _, x := range t.Terms {
+		addSurdTerm(acc, x.Coeff, x.Radicand)
+	}
+	return surdFromTerms(acc)
+}
+
+// Sub 返回 s - t。
+func (s *Surd) Sub(t *Surd) *Surd {
+	return s.Add(t.Neg())
+}
+
+// Mul 返回 s·t：√a·√b = g·√(a/g·b/g)，g = gcd(a, b)；乘积的被开方数超出 int64 时报错。
+func (s *Surd) Mul(t *Surd) (*Surd, error) {
+	acc := map[int64]*big.Rat{}
+	for _, x := range s.Terms {
+		for _, y := range t.Terms {
+			g := gcdInt64(x.Radicand, y.Radicand)
+			a, b := x.Radicand/g, y.Radicand/g
+			if a > math.MaxInt64/b {
+				return nil, fmt.Errorf("surd radicand overflow (√%d·√%d)", x.Radicand, y.Radicand)
+			}
+			c := new(big.Rat).Mul(x.Coeff, y.Coeff)
+			addSurdTerm(acc, c.Mul(c, new(big.Rat).SetInt64(g)), a*b)
+		}
+	}
+	return surdFromTerms(acc), nil
+}
+
+func gcdInt64(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// splitPrime 取最大被开方数的最小素因子 p，把 s 写成 a + b√p（a、b 不再含 √p）。s 为有理数时 p 为 0。
+func (s *Surd) splitPrime() (a, b *Surd, p int64) {
+	r := s.Terms[len(s.Terms)-1].Radicand
+	if r == 1 {
+		return s, &Surd{}, 0
+	}
+	p = r
+	for q := int64(2); q*q <= r; q++ {
+		if r%q == 0 {
+			p = q
+			break
+		}
+	}
+	a, b = &Surd{}, &Surd{}
+	for _, t := range s.Terms {
+		if t.Radicand%p == 0 {
+			b.Terms = append(b.Terms, SurdTerm{Coeff: t.Coeff, Radicand: t.Radicand / p})
+		} else {
+			a.Terms = append(a.Terms, t)
+		}
+	}
+	return a, b, p
+}
+
+// surdNorm 返回 (a + b√p)(a - b√p) = a² - p·b²，其中不再含 √p。
+func surdNorm(a, b *Surd, p int64) (*Surd, error) {
+	a2, err := a.Mul(a)
+	if err != nil {
+		return nil, err
+	}
+	b2, err := b.Mul(b)
+	if err != nil {
+		return nil, err
+	}
+	pb2, _ := b2.Mul(SurdFromRat(new(big.Rat).SetInt64(p)))
+	return a2.Sub(pb2), nil
+}
+
+// Inv 返回 1/s：逐个素数有理化分母，1/(a + b√p) = (a - b√p)/(a² - p·b²)。
+func (s *Surd) Inv() (*Surd, error) {
+	if len(s.Terms) == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if q, ok := s.Rat(); ok {
+		return SurdFromRat(q.Inv(q)), nil
+	}
+	a, b, p := s.splitPrime()
+	n, err := surdNorm(a, b, p)
+	if err != nil {
+		return nil, err
+	}
+	ninv, err := n.Inv()
+	if err != nil {
+		return nil, err
+	}
+	acc := map[int64]*big.Rat{}
+	for _, t := range a.Terms {
+		addSurdTerm(acc, t.Coeff, t.Radicand)
+	}
+	for _, t := range b.Terms {
+		addSurdTerm(acc, new(big.Rat).Neg(t.Coeff), t.Radicand*p)
+	}
+	return surdFromTerms(acc).Mul(ninv)
+}
+
+// Quo 返回 s/t。
+func (s *Surd) Quo(t *Surd) (*Surd, error) {
+	ti, err := t.Inv()
+	if err != nil {
+		return nil, err
+	}
+	return s.Mul(ti)
+}
+
+// Sign 返回 s 的符号。写成 a + b√p：a、b 同号时即为该符号，异号时比较 a² 与 p·b²。
+func (s *Surd) Sign() int {
+	if len(s.Terms) == 0 {
+		return 0
+	}
+	if len(s.Terms) == 1 {
+		return s.Terms[0].Coeff.Sign()
+	}
+	a, b, p := s.splitPrime()
+	sa, sb := a.Sign(), b.Sign()
+	if sa == 0 || sa == sb {
+		return sb
+	}
+	if sb == 0 {
+		return sa
+	}
+	n, err := surdNorm(a, b, p)
+	if err != nil {
+		// 被开方数溢出时退回高精度近似；s ≠ 0，符号由近似值给出
+		return s.approx().Sign()
+	}
+	return sa * n.Sign()
+}
+
+// approx 返回 s 的 256 位精度近似值。
+func (s *Surd) approx() *big.Float {
+	sum := new(big.Float).SetPrec(256)
+	for _, t := range s.Terms {
+		x := new(big.Float).SetPrec(256).SetInt64(t.Radicand)
+		x.Sqrt(x)
+		sum.Add(sum, x.Mul(x, new(big.Float).SetPrec(256).SetRat(t.Coeff)))
+	}
+	return sum
+}
+
+// Cmp 比较 s 与 t 的大小。
+func (s *Surd) Cmp(t *Surd) int {
+	return s.Sub(t).Sign()
+}
+
+// Equal 判断 s 与 t 是否相等（规范形式下逐项比较）。
+func (s *Surd) Equal(t *Surd) bool {
+	if len(s.Terms) != len(t.Terms) {
+		return false
+	}
+	for i := range s.Terms {
+		if s.Terms[i].Radicand != t.Terms[i].Radicand || s.Terms[i].Coeff.Cmp(t.Terms[i].Coeff) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回可被 ParseUserSurd 读回的写法：通分后分子为整系数，如 sqrt(6)/6、-3*sqrt(2)/4、(1+sqrt(2))/2。
+func (s *Surd) String() string {
+	return s.format("*", func(r int64) string { return fmt.Sprintf("sqrt(%d)", r) }, func(num, den string, single bool) string {
+		if !single {
+			num = "(" + num + ")"
+		}
+		return num + "/" + den
+	})
+}
+
+// Latex 返回 LaTeX 形式，如 \frac{\sqrt{6}}{6}、-\frac{3\sqrt{2}}{4}、\frac{1+\sqrt{2}}{2}。
+func (s *Surd) Latex() string {
+	return s.format("", func(r int64) string { return fmt.Sprintf(`\sqrt{%d}`, r) }, func(num, den string, single bool) string {
+		if single && strings.HasPrefix(num, "-") {
+			return `-\frac{` + num[1:] + `}{` + den + `}`
+		}
+		return `\frac{` + num + `}{` + den + `}`
+	})
+}
+
+// format 通分后输出分子各项；mul 为系数与根号之间的乘号，frac 组合分子与分母（single 表示分子只有一项）。
+func (s *Surd) format(mul string, root func(int64) string, frac func(num, den string, single bool) string) string {
+	if len(s.Terms) == 0 {
+		return "0"
+	}
+	den := big.NewInt(1)
+	for _, t := range s.Terms {
+		g := new(big.Int).GCD(nil, nil, den, t.Coeff.Denom())
+		den.Mul(den, new(big.Int).Quo(t.Coeff.Denom(), g))
+	}
+	var b strings.Builder
+	for i, t := range s.Terms {
+		n := new(big.Int).Mul(t.Coeff.Num(), new(big.Int).Quo(den, t.Coeff.Denom()))
+		switch {
+		case n.Sign() < 0:
+			b.WriteString("-")
+			n.Neg(n)
+		case i > 0:
+			b.WriteString("+")
+		}
+		switch {
+		case t.Radicand == 1:
+			b.WriteString(n.String())
+		case n.IsInt64() && n.Int64() == 1:
+			b.WriteString(root(t.Radicand))
+		default:
+			b.WriteString(n.String() + mul + root(t.Radicand))
+		}
+	}
+	if den.IsInt64() && den.Int64() == 1 {
+		return b.String()
+	}
+	return frac(b.String(), den.String(), len(s.Terms) == 1)
+}
+
+// surdOperand 把标量或 *Surd 转为 Surd；其他类型返回 false。
+func surdOperand(v interface{}) (*Surd, bool) {
+	if s, ok := v.(*Surd); ok {
+		return s, true
+	}
+	if r, ok := scalarRat(v); ok {
+		return SurdFromRat(r), true
+	}
+	return nil, false
+}
+
+// surdValue 收窄运算结果：有理时返回 int64 / *big.Rat（同 ratToScalar），否则返回 *Surd。
+func surdValue(s *Surd) interface{} {
+	if q, ok := s.Rat(); ok {
+		return ratToScalar(q, false)
+	}
+	return s
+}
+
+// surdArith 实现含 *Surd 的标量四则运算与整数次幂。
+func surdArith(op string, l, r interface{}) (interface{}, error) {
+	a, ok1 := surdOperand(l)
+	b, ok2 := surdOperand(r)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("unsupported operands for %s: %T and %T", op, l, r)
+	}
+	var out *Surd
+	var err error
+	switch op {
+	case "+":
+		out = a.Add(b)
+	case "-":
+		out = a.Sub(b)
+	case "*":
+		out, err = a.Mul(b)
+	case "/":
+		out, err = a.Quo(b)
+	case "^":
+		n, ok := b.Rat()
+		if !ok || !n.IsInt() || !n.Num().IsInt64() {
+			return nil, fmt.Errorf("exponent must be integer")
+		}
+		out, err = a.pow(n.Num().Int64())
+	default:
+		return nil, fmt.Errorf("unknown operator %s", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return surdValue(out), nil
+}
+
+// pow 返回 s 的 n 次幂（n 可为负）。
+func (s *Surd) pow(n int64) (*Surd, error) {
+	base := s
+	if n < 0 {
+		inv, err := s.Inv()
+		if err != nil {
+			return nil, err
+		}
+		base, n = inv, -n
+	}
+	out := SurdFromRat(big.NewRat(1, 1))
+	for ; n > 0; n >>= 1 {
+		var err error
+		if n&1 == 1 {
+			if out, err = out.Mul(base); err != nil {
+				return nil, err
+			}
+		}
+		if n > 1 {
+			if base, err = base.Mul(base); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
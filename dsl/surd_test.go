@@ -0,0 +1,94 @@
+package dsl
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSurdArith(t *testing.T) {
+	sq := func(n int64) *Surd {
+		s, err := SurdSqrt(big.NewRat(n, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	half, _ := SurdSqrt(big.NewRat(1, 2))
+	if half.String() != "sqrt(2)/2" || half.Latex() != `\frac{\sqrt{2}}{2}` {
+		t.Fatalf("√(1/2) = %s, %s", half, half.Latex())
+	}
+	if s := sq(12); s.String() != "2*sqrt(3)" || s.Latex() != `2\sqrt{3}` {
+		t.Fatalf("√12 = %s", s)
+	}
+	// (√3 + √2)(√3 - √2) = 1
+	p, _ := sq(3).Add(sq(2)).Mul(sq(3).Sub(sq(2)))
+	if q, ok := p.Rat(); !ok || q.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Fatalf("product %s", p)
+	}
+	// 1/(1 + √2 + √3) = (2 + √2 - √6)/4
+	x := SurdFromRat(big.NewRat(1, 1)).Add(sq(2)).Add(sq(3))
+	inv, err := x.Inv()
+	if err != nil || inv.String() != "(2+sqrt(2)-sqrt(6))/4" {
+		t.Fatalf("inv %s %v", inv, err)
+	}
+	if one, _ := inv.Mul(x); !one.Equal(SurdFromRat(big.NewRat(1, 1))) {
+		t.Fatalf("x·x⁻¹ = %s", one)
+	}
+	neg := SurdFromRat(big.NewRat(-3, 2)).Add(sq(2)).Add(sq(3)).Neg()
+	if neg.String() != "(3-2*sqrt(2)-2*sqrt(3))/2" || neg.Latex() != `\frac{3-2\sqrt{2}-2\sqrt{3}}{2}` {
+		t.Fatalf("%s %s", neg, neg.Latex())
+	}
+	// 3 < √2 + √3 < √10，√5 - 2 < 1/4
+	if sq(2).Add(sq(3)).Cmp(sq(10)) >= 0 || sq(2).Add(sq(3)).Cmp(SurdFromRat(big.NewRat(3, 1))) <= 0 ||
+		sq(5).Sub(SurdFromRat(big.NewRat(2, 1))).Cmp(SurdFromRat(big.NewRat(1, 4))) >= 0 {
+		t.Fatal("compare")
+	}
+	if _, err := SurdSqrt(big.NewRat(-1, 1)); err == nil {
+		t.Fatal("sqrt(-1) accepted")
+	}
+	if _, err := (&Surd{}).Inv(); err == nil {
+		t.Fatal("1/0 accepted")
+	}
+}
+
+func TestEvaluateExpression_surd(t *testing.T) {
+	inst := &Instance{Vars: map[string]interface{}{"v": &VectorInt{N: 3, V: []int64{1, 2, -1}}}}
+	for src, want := range map[string]string{
+		"v[2]/norm(v)":          "sqrt(6)/3",
+		"sqrt(8)":               "2*sqrt(2)",
+		"sqrt(9/4)":             "3/2",
+		"1/sqrt(2) * sqrt(2)":   "1",
+		"(1 + sqrt(5))^2":       "6+2*sqrt(5)",
+		"-sqrt(3)/3":            "-sqrt(3)/3",
+		"abs(1 - sqrt(2))":      "-1+sqrt(2)",
+		"sqrt(2) + sqrt(3) > 3": "1",
+		"sqrt(8) == 2*sqrt(2)":  "1",
+		"norm(v)^2 == 6":        "1",
+	} {
+		v, err := EvaluateExpression(src, inst)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if got := ValueToCanonicalString(v); got != want {
+			t.Fatalf("%s = %s, want %s", src, got, want)
+		}
+	}
+	if v, _ := EvaluateExpression("sqrt(2)^2", inst); v != int64(2) {
+		t.Fatalf("sqrt(2)^2 = %T %v", v, v)
+	}
+	if _, err := EvaluateExpression("sqrt(sqrt(2))", inst); err == nil {
+		t.Fatal("sqrt of surd accepted")
+	}
+	if got := FormatValueForTitle(mustEval(t, "v[1]/norm(v)", inst)); got != `\frac{\sqrt{6}}{6}` {
+		t.Fatalf("title %s", got)
+	}
+}
+
+func mustEval(t *testing.T, src string, inst *Instance) interface{} {
+	t.Helper()
+	v, err := EvaluateExpression(src, inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -9,14 +9,19 @@ import (
 // RenderTitle 根据 Problem.Title 和 RenderInst 的结果输出完整题面
 // Title 中使用 {{key}} 占位符，key 来自 Problem.Render 的键。
 func RenderTitle(p Problem, inst *Instance) (string, error) {
-	if p.Title == "" {
+	return compileLenient(p).RenderTitle(inst)
+}
+
+// RenderTitle 同 dsl.RenderTitle，但复用预编译结果。
+func (cp *CompiledProblem) RenderTitle(inst *Instance) (string, error) {
+	if cp.Problem.Title == "" {
 		return "", nil
 	}
-	rendered, err := RenderInst(p, inst)
+	rendered, err := cp.RenderInst(inst)
 	if err != nil {
 		return "", err
 	}
-	title := p.Title
+	title := cp.Problem.Title
 	for k, v := range rendered {
 		ph := "{{" + k + "}}"
 		title = strings.ReplaceAll(title, ph, FormatValueForTitle(v))
@@ -45,6 +50,28 @@ func FormatValueForTitle(v interface{}) string {
 			rows = append(rows, fmt.Sprintf("%d", t.V[i]))
 		}
 		return `\begin{bmatrix}` + strings.Join(rows, `\\`) + `\end{bmatrix}`
+	case *MatrixRat:
+		rows := make([]string, 0, t.R)
+		for i := 0; i < t.R; i++ {
+			cols := make([]string, t.C)
+			for j := 0; j < t.C; j++ {
+				cols[j] = FormatRatLatex(t.A[i][j])
+			}
+			rows = append(rows, strings.Join(cols, "&"))
+		}
+		return `\begin{bmatrix}` + strings.Join(rows, `\\`) + `\end{bmatrix}`
+	case *VectorRat:
+		rows := make([]string, t.N)
+		for i := 0; i < t.N; i++ {
+			rows[i] = FormatRatLatex(t.V[i])
+		}
+		return `\begin{bmatrix}` + strings.Join(rows, `\\`) + `\end{bmatrix}`
+	case *Polynomial:
+		return t.Latex()
+	case *Surd:
+		return t.Latex()
+	case *IntervalSet:
+		return t.Latex()
 	case *big.Int:
 		return t.String()
 	case string:
@@ -302,33 +329,14 @@ func formatQuadraticExpr(S *MatrixInt) string {
 
 // evalParamSystemTitle 生成含参方程组的 LaTeX 题面字符串。
 // 格式：\begin{cases} a₁x₁ + a₂x₂ + a₃x₃ = b₁ \\ ... \\ -4x₁+15x₂+\\lambda x₃=\mu \end{cases}
-func evalParamSystemTitle(inst *Instance) (interface{}, error) {
-	Av, ok := inst.Vars["_param_A"]
-	if !ok {
-		return nil, fmt.Errorf("param_system_title: A not found")
-	}
-	A, ok := Av.(*MatrixInt)
-	if !ok {
-		return nil, fmt.Errorf("param_system_title: A not matrix")
-	}
-	bv, ok := inst.Vars["_param_b"]
-	if !ok {
-		return nil, fmt.Errorf("param_system_title: b not found")
-	}
-	b, ok := bv.(*VectorInt)
-	if !ok {
-		return nil, fmt.Errorf("param_system_title: b not vector")
-	}
-	colLambdaV, ok := inst.Vars["_param_colLambda"]
-	if !ok {
-		return nil, fmt.Errorf("param_system_title: colLambda not found")
+func evalParamSystemTitle(A *MatrixInt, out GeneratorOutputs) (interface{}, error) {
+	b, err := out.vectorValue("param_system_title", "b")
+	if err != nil {
+		return nil, err
 	}
-	colLambda := int64(0)
-	switch t := colLambdaV.(type) {
-	case int64:
-		colLambda = t
-	case int:
-		colLambda = int64(t)
+	colLambda, err := out.int64Value("param_system_title", "lambda_col")
+	if err != nil {
+		return nil, err
 	}
 
 	formatCoeff := func(val int64, varIdx int, isFirst bool) string {
@@ -10,6 +10,13 @@ type Problem struct {
 	Answer    AnswerSchema           `json:"answer"`
 	Meta      map[string]interface{} `json:"meta,omitempty"`
 	Version   string                 `json:"version,omitempty"`
+	// Constraints 为出题约束：布尔表达式，可引用变量与派生量，如 "-30 <= det(A) <= 30"。
+	// 实例化时由种子确定性地重抽全部随机变量，直到每条约束都成立。
+	Constraints []string `json:"constraints,omitempty"`
+	// MaxAttempts 为满足约束的尝试次数上限（含第一次），0 表示 DefaultMaxAttempts。
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// RNG 为随机数算法版本（RNGv1 / RNGv2），空串为 RNGv1。新题宜用 RNGv2：各变量取独立子流。
+	RNG string `json:"rng,omitempty"`
 }
 
 // Variable 描述一个随机变量（标量 / 向量 / 矩阵）
@@ -18,7 +25,7 @@ type Variable struct {
 	Rows      int                    `json:"rows,omitempty"`
 	Cols      int                    `json:"cols,omitempty"`
 	Size      int                    `json:"size,omitempty"`
-	Generator map[string]interface{} `json:"generator"`
+	Generator map[string]interface{} `json:"generator,omitempty"`
 	// Fixed 为可选的固定值（不走生成器）
 	Fixed interface{} `json:"fixed,omitempty"`
 }
@@ -45,6 +52,13 @@ type AnswerFieldDef struct {
 type Instance struct {
 	ProblemID int64
 	Seed      string
+	Version   string                 // 出题时的 Problem.Version，随快照存档，判分时据此取同一修订的题目
 	Vars      map[string]interface{} // variable name -> value (matrix/vector/scalar)
 	Derived   map[string]interface{} // derived vars
+	// Outputs 为生成器的附带输出，按变量名分组（表达式中写作 A.key）；不在 Vars 中，因而不会进入公开载荷
+	Outputs map[string]GeneratorOutputs
+	Graph   *DerivedGraph // 派生量依赖图（求值顺序），与 CompiledProblem 共享，只读
+
+	prng  prngAlgo         // 随机数算法，见 Problem.RNG
+	trace *generationTrace // 生成统计，仅 SampleGeneratorStats 使用
 }
@@ -0,0 +1,450 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity 为诊断级别：error 表示题目必然出错，warning 表示可疑但不影响出题。
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic 为 ValidateProblem 报告的一条问题。
+// Location 形如 "title"、"derived.b"、"render.A"、"answer.field_defs[2]"、"meta.solution_zh"；
+// Col 为表达式内的列号（从 1 开始），与位置无关时为 0。
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Location string   `json:"location"`
+	Col      int      `json:"col,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Col > 0 {
+		return fmt.Sprintf("%s %s col %d: %s", d.Severity, d.Location, d.Col, d.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", d.Severity, d.Location, d.Message)
+}
+
+// HasErrors 判断诊断中是否含 error 级别。
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+var titlePlaceholderRe = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// ValidateProblem 静态检查题目，不做实例化：
+//   - 题干 {{blank:X}} 找不到对应的答案空 ID、{{key}} 没有 render 定义；
+//   - 表达式语法错误、未知函数、引用了既不是变量也不是派生量的名字、生成规则未声明的附带输出 A.key、派生量循环依赖；
+//   - 按变量声明的维数推断形状，报告 matmul 内维不符、非方阵求逆等错误，以及结果不是标量的约束；
+//   - render 键未在题干中使用（warning）。
+//
+// 结果按位置排序，便于在测试中直接比较。
+func ValidateProblem(p Problem) []Diagnostic {
+	v := &validator{p: p, names: map[string]bool{}, shapes: map[string]shape{}}
+	v.checkVariables()
+	v.checkDerived()
+	v.checkConstraints()
+	v.checkRender()
+	v.checkAnswer()
+	v.checkSolution()
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		if v.diags[i].Location != v.diags[j].Location {
+			return v.diags[i].Location < v.diags[j].Location
+		}
+		return v.diags[i].Col < v.diags[j].Col
+	})
+	return v.diags
+}
+
+type validator struct {
+	p      Problem
+	names  map[string]bool  // 可在表达式中引用的名字
+	shapes map[string]shape // 已推断出的形状
+	diags  []Diagnostic
+}
+
+func (v *validator) report(sev Severity, loc string, col int, format string, args ...interface{}) {
+	v.diags = append(v.diags, Diagnostic{Severity: sev, Location: loc, Col: col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) checkVariables() {
+	if _, err := lookupPRNG(v.p.RNG); err != nil {
+		v.report(SeverityError, "rng", 0, "unknown version %q (want %q or %q)", v.p.RNG, RNGv1, RNGv2)
+	}
+	for _, name := range sortedVarNames(v.p.Variables) {
+		vr := v.p.Variables[name]
+		v.names[name] = true
+		loc := "variables." + name
+		switch vr.Kind {
+		case "scalar":
+			v.shapes[name] = shape{kind: shapeScalar}
+		case "vector":
+			v.shapes[name] = shape{kind: shapeVector, rows: vr.Size}
+		case "matrix":
+			v.shapes[name] = shape{kind: shapeMatrix, rows: vr.Rows, cols: vr.Cols}
+		default:
+			v.report(SeverityError, loc, 0, "unknown kind %q", vr.Kind)
+		}
+		if vr.Fixed == nil {
+			// 与 generateVariable 一致：vector / matrix 省略 rule 或写空串即 range，scalar 必须写 rule
+			rule, ok := vr.Generator["rule"].(string)
+			if !ok && (vr.Kind == "scalar" || vr.Generator["rule"] != nil) {
+				v.report(SeverityError, loc, 0, "generator rule missing")
+			} else if _, known := lookupGenerator(vr.Kind, rule); !known && generators[vr.Kind] != nil && (rule != "" || vr.Kind == "scalar") {
+				v.report(SeverityError, loc, 0, "unknown %s generator rule %q", vr.Kind, rule)
+			}
+			if rule == "" && vr.Kind != "scalar" {
+				rule = "range"
+			}
+			if e, known := lookupGenerator(vr.Kind, rule); known {
+				for _, msg := range checkGeneratorParams(e, vr.Generator) {
+					v.report(SeverityError, loc+".generator", 0, "%s", msg)
+				}
+			}
+		} else if _, err := convertFixed(vr); err != nil {
+			v.report(SeverityError, loc+".fixed", 0, "%v", err)
+		}
+		// 生成器可把附带的参数写入实例，如 scalar_identity 的 lambda_var
+		if s, ok := vr.Generator["lambda_var"].(string); ok && s != "" {
+			v.names[s] = true
+			v.shapes[s] = shape{kind: shapeScalar}
+		}
+		if vr.Generator["rule"] == "lambda_linear_det_zero" {
+			s, _ := vr.Generator["param_var"].(string)
+			if s == "" {
+				s = "lambda"
+			}
+			v.names[s] = true
+			v.shapes[s] = shape{kind: shapeScalar}
+		}
+	}
+	for name := range v.p.Derived {
+		v.names[name] = true
+	}
+}
+
+func (v *validator) checkDerived() {
+	sources := graphSources(v.p)
+	names := sortedKeys(sources)
+	deps := map[string][]string{}
+	parsed := map[string]*Expr{}
+	locs := map[string]string{}
+	for _, name := range names {
+		loc := "derived." + name
+		if _, ok := v.p.Derived[name]; !ok {
+			loc = "variables." + name // rule 为 integer_solution 的变量
+		}
+		locs[name] = loc
+		e := v.parse(loc, sources[name])
+		if e == nil {
+			continue
+		}
+		parsed[name] = e
+		deps[name] = nodeDeps(e, name, sources, v.p.Variables)
+	}
+	order, err := topoOrder(names, deps)
+	if err != nil {
+		v.report(SeverityError, "derived", 0, "%v", err)
+		order = names
+	}
+	for _, name := range order {
+		if e, ok := parsed[name]; ok {
+			if s := v.infer(locs[name], e.root); s.kind != shapeUnknown {
+				v.shapes[name] = s
+			}
+		}
+	}
+}
+
+func (v *validator) checkConstraints() {
+	for i, src := range v.p.Constraints {
+		loc := fmt.Sprintf("constraints[%d]", i)
+		e := v.parse(loc, src)
+		if e == nil {
+			continue
+		}
+		if s := v.infer(loc, e.root); s.kind != shapeUnknown && s.kind != shapeScalar {
+			v.report(SeverityError, loc, 0, "constraint is %s, want a boolean (scalar) expression", s)
+		}
+	}
+}
+
+func (v *validator) checkRender() {
+	title := v.p.Title
+	used := map[string]bool{}
+	for _, m := range titlePlaceholderRe.FindAllStringSubmatch(title, -1) {
+		used[m[1]] = true
+	}
+	for _, key := range sortedKeys(v.p.Render) {
+		loc := "render." + key
+		if e := v.parse(loc, v.p.Render[key]); e != nil {
+			v.infer(loc, e.root)
+		}
+		if title != "" && !used[key] {
+			v.report(SeverityWarning, loc, 0, "render key not used in title")
+		}
+	}
+	blanks := map[string]bool{}
+	for _, id := range v.blankIDs() {
+		blanks[id] = true
+	}
+	for _, m := range titlePlaceholderRe.FindAllStringSubmatch(title, -1) {
+		key := m[1]
+		if id, ok := strings.CutPrefix(key, "blank:"); ok {
+			if !blanks[id] {
+				v.report(SeverityError, "title", 0, "{{blank:%s}} has no matching answer field", id)
+			}
+			continue
+		}
+		if _, ok := v.p.Render[key]; !ok && isIdentName(key) {
+			v.report(SeverityError, "title", 0, "{{%s}} has no render entry", key)
+		}
+	}
+}
+
+// blankIDs 返回答案空的 ID，编号规则与 ExtractAnswerWithMeta 一致。
+func (v *validator) blankIDs() []string {
+	a := v.p.Answer
+	switch {
+	case a.Expression != "":
+		if len(a.FieldDefs) > 0 && a.FieldDefs[0].ID != "" {
+			return []string{a.FieldDefs[0].ID}
+		}
+		return []string{"ans"}
+	case len(a.FieldDefs) > 0:
+		var ids []string
+		for i, fd := range a.FieldDefs {
+			if fd.Expr == "" {
+				continue
+			}
+			id := fd.ID
+			if id == "" {
+				id = fmt.Sprintf("field_%d", i+1)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	ids := make([]string, len(a.Fields))
+	for i := range a.Fields {
+		ids[i] = fmt.Sprintf("field_%d", i+1)
+	}
+	return ids
+}
+
+func (v *validator) checkAnswer() {
+	a := v.p.Answer
+	if a.Expression == "" && len(a.FieldDefs) == 0 && len(a.Fields) == 0 {
+		v.report(SeverityError, "answer", 0, "no answer schema")
+		return
+	}
+	if a.Expression != "" {
+		if e := v.parse("answer.expression", a.Expression); e != nil {
+			var j *AnswerJudgeSpec
+			if len(a.FieldDefs) > 0 {
+				j = a.FieldDefs[0].Judge
+			}
+			v.checkAnswerShape("answer.expression", j, 1, v.infer("answer.expression", e.root))
+		}
+	}
+	for i, f := range a.Fields {
+		loc := fmt.Sprintf("answer.fields[%d]", i)
+		if e := v.parse(loc, f); e != nil {
+			v.infer(loc, e.root)
+		}
+	}
+	if a.Expression != "" {
+		return
+	}
+	ids := map[string]int{}
+	groupSize := map[string]int{}
+	for _, fd := range a.FieldDefs {
+		if fd.Expr != "" {
+			groupSize[vectorGroupKey(fd.Judge)]++
+		}
+	}
+	for i, fd := range a.FieldDefs {
+		loc := fmt.Sprintf("answer.field_defs[%d]", i)
+		if fd.Expr == "" {
+			continue
+		}
+		if fd.ID != "" {
+			if j, dup := ids[fd.ID]; dup {
+				v.report(SeverityError, loc, 0, "duplicate id %q (also field_defs[%d])", fd.ID, j)
+			}
+			ids[fd.ID] = i
+		}
+		if e := v.parse(loc, fd.Expr); e != nil {
+			v.checkAnswerShape(loc, fd.Judge, groupSize[vectorGroupKey(fd.Judge)], v.infer(loc, e.root))
+		}
+	}
+	v.checkSpanGroups(a.FieldDefs)
+}
+
+// checkSpanGroups 检查 span_equal、general_solution 组：span_of 取值与 matrix_var、bvec_var，设 span_dim 时组内空数须为其整数倍。
+func (v *validator) checkSpanGroups(fds []AnswerFieldDef) {
+	size := map[string]int{}
+	first := map[string]int{}
+	for i, fd := range fds {
+		j := fd.Judge
+		if j == nil || j.Kind != "span_equal" && j.Kind != "general_solution" {
+			continue
+		}
+		loc := fmt.Sprintf("answer.field_defs[%d]", i)
+		field, group := "span_group", j.SpanGroup
+		if j.Kind == "general_solution" {
+			field, group = "solution_group", j.SolutionGroup
+		}
+		if group == "" {
+			v.report(SeverityError, loc, 0, "judge %s: missing %s", j.Kind, field)
+			continue
+		}
+		key := field + " " + group
+		if _, ok := first[key]; !ok {
+			first[key] = i
+			switch {
+			case j.Kind == "general_solution" && (j.MatrixVar == "" || j.BVecVar == ""):
+				v.report(SeverityError, loc, 0, "judge general_solution: needs matrix_var and bvec_var")
+			case j.Kind == "general_solution":
+			case j.SpanOf != "" && j.SpanOf != "nullspace" && j.SpanOf != "column":
+				v.report(SeverityError, loc, 0, "judge span_equal: unknown span_of %q", j.SpanOf)
+			case j.SpanOf != "" && j.MatrixVar == "":
+				v.report(SeverityError, loc, 0, "judge span_equal: span_of %q needs matrix_var", j.SpanOf)
+			}
+		}
+		size[key]++
+	}
+	for key, i := range first {
+		if d := fds[i].Judge.SpanDim; d > 0 && size[key]%d != 0 {
+			name, g, _ := strings.Cut(key, " ")
+			v.report(SeverityError, fmt.Sprintf("answer.field_defs[%d]", i), 0, "%s %q has %d fields, not a multiple of span_dim %d", name, g, size[key], d)
+		}
+	}
+}
+
+// checkAnswerShape 整矩阵、整向量、区间空的表达式须为矩阵、向量、区间；反之矩阵、区间值的空须声明 judge kind "matrix"、"interval"，
+// 向量值的空应声明 "vector"，或用于 rational_line、affine_rational 且独占一组（groupSize 为组内空数），或用于不设 span_dim 的 span_equal、general_solution。
+func (v *validator) checkAnswerShape(loc string, j *AnswerJudgeSpec, groupSize int, s shape) {
+	kind := ""
+	if j != nil {
+		kind = j.Kind
+	}
+	known := s.kind != shapeUnknown
+	spanKind := kind == "span_equal" || kind == "general_solution"
+	switch {
+	case kind == "matrix" && known && s.kind != shapeMatrix:
+		v.report(SeverityError, loc, 0, "judge matrix: answer is %s, want matrix", s)
+	case kind == "vector" && known && s.kind != shapeVector:
+		v.report(SeverityError, loc, 0, "judge vector: answer is %s, want vector", s)
+	case kind == "interval" && known && s.kind != shapeInterval:
+		v.report(SeverityError, loc, 0, "judge interval: answer is %s, want interval", s)
+	case kind != "interval" && s.kind == shapeInterval:
+		v.report(SeverityError, loc, 0, "answer is an interval; use judge kind \"interval\"")
+	case kind != "matrix" && s.kind == shapeMatrix:
+		v.report(SeverityError, loc, 0, "answer is a %s matrix; use judge kind \"matrix\" for a whole-matrix blank", s)
+	case s.kind == shapeVector && vectorGroupKey(j) != "" && groupSize > 1:
+		v.report(SeverityError, loc, 0, "answer is %s; a whole-vector blank must be the only field in its %s", s, vectorGroupKey(j))
+	case spanKind && j.SpanDim == 0 && known && s.kind != shapeVector:
+		v.report(SeverityError, loc, 0, "judge %s: answer is %s, want vector (set span_dim for component blanks)", kind, s)
+	case spanKind && j.SpanDim > 0 && s.kind == shapeVector:
+		v.report(SeverityError, loc, 0, "judge %s: answer is %s; with span_dim each blank is one component", kind, s)
+	case s.kind == shapeVector && kind != "vector" && !spanKind && vectorGroupKey(j) == "":
+		// 整体填空按 Judge.Kind 判分（见 judgeWholeField），未声明时判错
+		v.report(SeverityError, loc, 0, "answer is %s; use judge kind \"vector\" for a whole-vector blank", s)
+	}
+}
+
+func (v *validator) checkSolution() {
+	sol, ok := v.p.Meta["solution_zh"].(string)
+	if !ok {
+		return
+	}
+	for _, src := range exprPlaceholders(sol) {
+		if e := v.parse("meta.solution_zh", src); e != nil {
+			v.infer("meta.solution_zh", e.root)
+		}
+	}
+}
+
+// parse 解析表达式并检查函数名与引用的名字；失败时记诊断并返回 nil。
+func (v *validator) parse(loc, src string) *Expr {
+	e, err := ParseExpr(strings.TrimSpace(src))
+	if err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			v.report(SeverityError, loc, pe.Col, "%s", pe.Msg)
+		} else {
+			v.report(SeverityError, loc, 0, "%v", err)
+		}
+		return nil
+	}
+	walkExpr(e.root, func(n exprNode) {
+		switch t := n.(type) {
+		case *callExpr:
+			if _, ok := exprFuncs[t.name]; !ok {
+				v.report(SeverityError, loc, t.col, "unknown function %s", t.name)
+			}
+		case *identRef:
+			if !v.names[t.name] {
+				v.report(SeverityError, loc, t.col, "undefined name %s", t.name)
+			}
+		case *outputRef:
+			if _, err := v.output(t); err != nil {
+				v.report(SeverityError, loc, t.col, "%v", err)
+			}
+		}
+	})
+	return e
+}
+
+// output 查找 A.key 所引用的生成器附带输出声明。
+func (v *validator) output(t *outputRef) (GeneratorOutput, error) {
+	vr, ok := v.p.Variables[t.name]
+	if !ok {
+		return GeneratorOutput{}, fmt.Errorf("%s.%s: %s is not a variable", t.name, t.key, t.name)
+	}
+	rule, _ := vr.Generator["rule"].(string)
+	if rule == "" && vr.Kind != "scalar" {
+		rule = "range"
+	}
+	e, ok := lookupGenerator(vr.Kind, rule)
+	if vr.Fixed != nil || !ok {
+		return GeneratorOutput{}, fmt.Errorf("%s.%s: %s has no generator outputs", t.name, t.key, t.name)
+	}
+	o, ok := e.output(t.key)
+	if !ok {
+		return GeneratorOutput{}, fmt.Errorf("%s.%s: %s generator rule %s has no output %s", t.name, t.key, vr.Kind, rule, t.key)
+	}
+	return o, nil
+}
+
+func isIdentName(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func sortedVarNames(m map[string]Variable) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,334 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type shapeKind int
+
+const (
+	shapeUnknown shapeKind = iota
+	shapeScalar
+	shapeVector
+	shapeMatrix
+	shapePoly
+	shapeString
+	shapeInterval
+)
+
+// shape 为静态推断的值形状；维数为 0 表示未知。向量的长度记在 rows。
+type shape struct {
+	kind       shapeKind
+	rows, cols int
+}
+
+var (
+	unknownShape = shape{}
+	scalarShape  = shape{kind: shapeScalar}
+)
+
+func (s shape) String() string {
+	dim := func(n int) string {
+		if n == 0 {
+			return "?"
+		}
+		return fmt.Sprint(n)
+	}
+	switch s.kind {
+	case shapeScalar:
+		return "scalar"
+	case shapeVector:
+		return "vector(" + dim(s.rows) + ")"
+	case shapeMatrix:
+		return dim(s.rows) + "×" + dim(s.cols)
+	case shapePoly:
+		return "polynomial"
+	case shapeString:
+		return "string"
+	case shapeInterval:
+		return "interval"
+	}
+	return "unknown"
+}
+
+// dimsDiffer 仅在两个维数都已知且不等时为真。
+func dimsDiffer(a, b int) bool { return a != 0 && b != 0 && a != b }
+
+func (s shape) square() bool { return !dimsDiffer(s.rows, s.cols) }
+
+// valueShape 返回运行期值的形状；不是表达式可用的类型时为 unknown。
+func valueShape(v interface{}) shape {
+	switch t := v.(type) {
+	case int64, int, *big.Int, *big.Rat, *Surd:
+		return scalarShape
+	case *VectorInt:
+		return shape{kind: shapeVector, rows: t.N}
+	case *VectorRat:
+		return shape{kind: shapeVector, rows: t.N}
+	case *MatrixInt:
+		return shape{kind: shapeMatrix, rows: t.R, cols: t.C}
+	case *MatrixRat:
+		return shape{kind: shapeMatrix, rows: t.R, cols: t.C}
+	case *Polynomial:
+		return shape{kind: shapePoly}
+	case string:
+		return shape{kind: shapeString}
+	case *IntervalSet:
+		return shape{kind: shapeInterval}
+	}
+	return unknownShape
+}
+
+// infer 自底向上推断表达式形状，遇到维数冲突时记 error 诊断；无法确定的一律返回 unknown，不报错。
+func (v *validator) infer(loc string, n exprNode) shape {
+	bad := func(format string, args ...interface{}) shape {
+		v.report(SeverityError, loc, n.pos(), format, args...)
+		return unknownShape
+	}
+	switch t := n.(type) {
+	case *numLit:
+		return scalarShape
+	case *identRef:
+		return v.shapes[t.name]
+	case *outputRef:
+		o, err := v.output(t)
+		if err != nil {
+			return unknownShape
+		}
+		return o.Type.shape()
+	case *unaryExpr:
+		x := v.infer(loc, t.x)
+		if t.op == "!" {
+			if x.kind != shapeUnknown && x.kind != shapeScalar {
+				return bad("!: operand is %s, want scalar", x)
+			}
+			return scalarShape
+		}
+		return x
+	case *indexExpr:
+		x := v.infer(loc, t.x)
+		for _, i := range t.idxs {
+			v.infer(loc, i)
+		}
+		switch {
+		case x.kind == shapeVector && len(t.idxs) == 1:
+			if i, ok := literalIndex(t.idxs[0]); ok && x.rows != 0 && (i < 1 || i > x.rows) {
+				return bad("index %d out of range for %s", i, x)
+			}
+			return scalarShape
+		case x.kind == shapeMatrix && len(t.idxs) == 2:
+			i, iok := literalIndex(t.idxs[0])
+			j, jok := literalIndex(t.idxs[1])
+			if iok && x.rows != 0 && (i < 1 || i > x.rows) || jok && x.cols != 0 && (j < 1 || j > x.cols) {
+				return bad("index [%d,%d] out of range for %s", i, j, x)
+			}
+			return scalarShape
+		case x.kind == shapeVector || x.kind == shapeMatrix || x.kind == shapeScalar:
+			return bad("cannot index %s with %d subscripts", x, len(t.idxs))
+		}
+		return unknownShape
+	case *binaryExpr:
+		return inferBinary(t, v.infer(loc, t.l), v.infer(loc, t.r), bad)
+	case *callExpr:
+		args := make([]shape, len(t.args))
+		for i, a := range t.args {
+			args[i] = v.infer(loc, a)
+		}
+		e, ok := exprFuncs[t.name]
+		if !ok {
+			return unknownShape // 未知函数已在 parse 中报告
+		}
+		if len(args) != len(e.sig.Args) {
+			return bad("%s expects %d args, got %d", t.name, len(e.sig.Args), len(args))
+		}
+		for i, want := range e.sig.Args {
+			if !want.accepts(args[i]) {
+				return bad("%s: argument %d is %s, want %s", t.name, i+1, args[i], want)
+			}
+			if want == TypeInt {
+				if lit, ok := t.args[i].(*numLit); ok && !lit.val.IsInt() {
+					return bad("%s: argument %d must be an integer", t.name, i+1)
+				}
+			}
+		}
+		if s := inferCall(t.name, args, bad); s.kind != shapeUnknown {
+			return s
+		}
+		return e.sig.Returns.shape()
+	}
+	return unknownShape
+}
+
+func inferBinary(t *binaryExpr, l, r shape, bad func(string, ...interface{}) shape) shape {
+	nonScalar := func(s shape) bool { return s.kind != shapeUnknown && s.kind != shapeScalar }
+	switch {
+	case t.op == "&&" || t.op == "||" || t.op == "<" || t.op == "<=" || t.op == ">" || t.op == ">=":
+		if nonScalar(l) || nonScalar(r) {
+			return bad("%s: %s and %s, want scalars", t.op, l, r)
+		}
+		return scalarShape
+	case t.op == "==" || t.op == "!=":
+		if l.kind != shapeUnknown && r.kind != shapeUnknown &&
+			(l.kind != r.kind || dimsDiffer(l.rows, r.rows) || dimsDiffer(l.cols, r.cols)) {
+			return bad("%s: %s and %s", t.op, l, r)
+		}
+		return scalarShape
+	}
+	if l.kind == shapeUnknown || r.kind == shapeUnknown {
+		return unknownShape
+	}
+	switch t.op {
+	case "+", "-":
+		if l.kind == shapeScalar && r.kind == shapeScalar {
+			return scalarShape
+		}
+		if l.kind != r.kind || dimsDiffer(l.rows, r.rows) || dimsDiffer(l.cols, r.cols) {
+			return bad("%s: %s and %s", t.op, l, r)
+		}
+		return mergeShape(l, r)
+	case "*":
+		switch {
+		case l.kind == shapeScalar:
+			return r
+		case r.kind == shapeScalar:
+			return l
+		case l.kind == shapeMatrix && r.kind == shapeMatrix:
+			if dimsDiffer(l.cols, r.rows) {
+				return bad("*: %s times %s (inner dims %d≠%d)", l, r, l.cols, r.rows)
+			}
+			return shape{kind: shapeMatrix, rows: l.rows, cols: r.cols}
+		case l.kind == shapeMatrix && r.kind == shapeVector:
+			if dimsDiffer(l.cols, r.rows) {
+				return bad("*: %s times %s (inner dims %d≠%d)", l, r, l.cols, r.rows)
+			}
+			return shape{kind: shapeVector, rows: l.rows}
+		}
+		return unknownShape
+	case "/":
+		if r.kind == shapeScalar {
+			return l
+		}
+		return unknownShape
+	case "^":
+		if l.kind == shapeMatrix && !l.square() {
+			return bad("^: power of non-square %s", l)
+		}
+		return l
+	}
+	return unknownShape
+}
+
+func mergeShape(a, b shape) shape {
+	if a.rows == 0 {
+		a.rows = b.rows
+	}
+	if a.cols == 0 {
+		a.cols = b.cols
+	}
+	return a
+}
+
+// inferCall 对形状规则明确的通用函数推断维数；其余返回 unknown，由调用方按签名的返回类型处理。
+func inferCall(name string, args []shape, bad func(string, ...interface{}) shape) shape {
+	arg := func(i int) shape {
+		if i < len(args) {
+			return args[i]
+		}
+		return unknownShape
+	}
+	isMatrix := func(s shape) bool { return s.kind == shapeMatrix }
+	needSquare := func(s shape) bool { return isMatrix(s) && !s.square() }
+	a, b := arg(0), arg(1)
+	switch name {
+	case "matmul":
+		if isMatrix(a) && isMatrix(b) {
+			if dimsDiffer(a.cols, b.rows) {
+				return bad("matmul: %s times %s (inner dims %d≠%d)", a, b, a.cols, b.rows)
+			}
+			return shape{kind: shapeMatrix, rows: a.rows, cols: b.cols}
+		}
+	case "matadd", "matsub":
+		if isMatrix(a) && isMatrix(b) {
+			if dimsDiffer(a.rows, b.rows) || dimsDiffer(a.cols, b.cols) {
+				return bad("%s: %s and %s", name, a, b)
+			}
+			return mergeShape(a, b)
+		}
+	case "smmul":
+		if isMatrix(b) {
+			return b
+		}
+	case "transpose":
+		if isMatrix(a) {
+			return shape{kind: shapeMatrix, rows: a.cols, cols: a.rows}
+		}
+	case "inv", "pow":
+		if needSquare(a) {
+			return bad("%s: non-square %s", name, a)
+		}
+		if isMatrix(a) {
+			return a
+		}
+	case "det", "charpoly", "minpoly":
+		if needSquare(a) {
+			return bad("%s: non-square %s", name, a)
+		}
+		if name == "det" {
+			return scalarShape
+		}
+		return shape{kind: shapePoly}
+	case "eye":
+		return shape{kind: shapeMatrix}
+	case "rank", "nullity", "mget", "coeff", "cofactor", "abs":
+		return scalarShape
+	case "rank_hstack":
+		if isMatrix(a) && b.kind == shapeVector && dimsDiffer(a.rows, b.rows) {
+			return bad("rank_hstack: %s with %s", a, b)
+		}
+		return scalarShape
+	case "solve":
+		if isMatrix(a) && b.kind == shapeVector {
+			if dimsDiffer(a.rows, b.rows) {
+				return bad("solve: %s with right-hand side %s", a, b)
+			}
+			return shape{kind: shapeVector, rows: a.cols}
+		}
+	case "integer_solution":
+		if isMatrix(a) && b.kind == shapeVector {
+			if dimsDiffer(a.cols, b.rows) {
+				return bad("integer_solution: %s times %s (inner dims %d≠%d)", a, b, a.cols, b.rows)
+			}
+			return shape{kind: shapeVector, rows: a.rows}
+		}
+	case "col":
+		if isMatrix(a) {
+			return shape{kind: shapeVector, rows: a.rows}
+		}
+	case "gs":
+		if isMatrix(a) {
+			return a
+		}
+	case "nullbasis":
+		if isMatrix(a) {
+			return shape{kind: shapeMatrix, rows: a.cols}
+		}
+	case "polyeval":
+		if needSquare(b) {
+			return bad("polyeval: non-square %s", b)
+		}
+		if b.kind != shapeUnknown {
+			return b
+		}
+	}
+	return unknownShape
+}
+
+// literalIndex 取整数字面量下标。
+func literalIndex(n exprNode) (int, bool) {
+	lit, ok := n.(*numLit)
+	if !ok || !lit.val.IsInt() || !lit.val.Num().IsInt64() {
+		return 0, false
+	}
+	return int(lit.val.Num().Int64()), true
+}
@@ -0,0 +1,244 @@
+package dsl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func diagStrings(ds []Diagnostic) string {
+	out := make([]string, len(ds))
+	for i, d := range ds {
+		out[i] = d.String()
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestValidateProblem_clean(t *testing.T) {
+	if ds := ValidateProblem(compileTestProblem()); len(ds) != 0 {
+		t.Fatalf("unexpected diagnostics:\n%s", diagStrings(ds))
+	}
+}
+
+func TestValidateProblem_reportsEachKind(t *testing.T) {
+	p := Problem{
+		Title: "{{A}} {{B}}，求 {{blank:x}}",
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 4, Generator: map[string]interface{}{"rule": "range"}},
+			"C": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "range"}},
+		},
+		Derived: map[string]string{
+			"M": "matmul(A, C)",
+			"w": "q + 1",
+		},
+		Render: map[string]string{"A": "A", "vC": "C"},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{
+			{ID: "y", Expr: "det(A)"},
+			{ID: "z", Expr: "frob(C)"},
+		}},
+	}
+	want := []string{
+		"error answer.field_defs[0] col 1: det: non-square 3×4",
+		"error answer.field_defs[1] col 1: unknown function frob",
+		"error derived.M col 1: matmul: 3×4 times 3×3 (inner dims 4≠3)",
+		"error derived.w col 1: undefined name q",
+		"warning render.vC: render key not used in title",
+		"error title: {{B}} has no render entry",
+		"error title: {{blank:x}} has no matching answer field",
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
+
+func TestValidateProblem_cycleAndParseError(t *testing.T) {
+	p := compileTestProblem()
+	p.Derived = map[string]string{"w": "MT + 1", "MT": "transpose(w)", "bad": "det(A"}
+	ds := ValidateProblem(p)
+	if !HasErrors(ds) {
+		t.Fatal("expected errors")
+	}
+	got := diagStrings(ds)
+	if !strings.Contains(got, "derived cycle: MT -> w -> MT") {
+		t.Fatalf("missing cycle:\n%s", got)
+	}
+	if !strings.Contains(got, "error derived.bad col 6:") {
+		t.Fatalf("missing parse error column:\n%s", got)
+	}
+}
+
+func TestValidateProblem_generatorDeclaredNames(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "scalar_identity", "lambda_var": "k"}},
+			"B": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "lambda_linear_det_zero"}},
+		},
+		Answer: AnswerSchema{Expression: "k + B.lambda_row"},
+	}
+	if ds := ValidateProblem(p); len(ds) != 0 {
+		t.Fatalf("unexpected diagnostics:\n%s", diagStrings(ds))
+	}
+}
+
+func TestValidateProblem_generatorOutputs(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "eigen_reverse"}},
+			"M": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "range"}},
+		},
+		Render: map[string]string{"p": "A.P", "d": "det(A.lambdas)"},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{
+			{ID: "a", Expr: "A.lambdas[1] + A.P_inv[1,1]"},
+			{ID: "b", Expr: "A.Q"},
+			{ID: "c", Expr: "M.lambdas"},
+			{ID: "d", Expr: "x.lambdas"},
+			{ID: "e", Expr: "_lambda_param_row"},
+		}},
+	}
+	got := diagStrings(ValidateProblem(p))
+	for _, want := range []string{
+		"error answer.field_defs[1] col 1: A.Q: matrix generator rule eigen_reverse has no output Q",
+		"error answer.field_defs[2] col 1: M.lambdas: matrix generator rule range has no output lambdas",
+		"error answer.field_defs[3] col 1: x.lambdas: x is not a variable",
+		"error answer.field_defs[4] col 1: undefined name _lambda_param_row",
+		"error render.d col 1: det: argument 1 is vector(?), want matrix",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "field_defs[0]") || strings.Contains(got, "render.p") {
+		t.Fatalf("unexpected diagnostics:\n%s", got)
+	}
+}
+
+func TestValidateProblem_generatorParams(t *testing.T) {
+	p := Problem{
+		Variables: map[string]Variable{
+			// README 曾把 entry_min / entry_max 写成 min / max，参数被静默忽略
+			"A": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "lambda_linear_det_zero", "min": -10, "max": 10, "lambda_min": -20}},
+			"B": {Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"rule": "", "min": "-3", "max": 3.5}},
+			"P": {Kind: "matrix", Rows: 3, Cols: 3, Generator: map[string]interface{}{"rule": "orthogonal_signed_perm", "min": 1}},
+			"v": {Kind: "vector", Size: 3, Generator: map[string]interface{}{"rule": "from_set", "set": []interface{}{1.0, 2.5}}},
+			"s": {Kind: "scalar", Generator: map[string]interface{}{"rule": "from_set", "set": []int{1, 2}}},
+			// vector / matrix 省略 rule 即 range，scalar 须写 rule
+			"C": {Kind: "matrix", Rows: 2, Cols: 2, Generator: map[string]interface{}{"min": -1, "max": 1}},
+			"t": {Kind: "scalar", Generator: map[string]interface{}{"min": 1}},
+		},
+		Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{{ID: "x", Expr: "det(A) + det(B) + det(C) + det(P) + s + t"}}},
+	}
+	want := []string{
+		`error variables.A.generator: unknown key "max" (known: param_var, param_row, param_col, entry_min, entry_max, lambda_min, lambda_max, max_attempts)`,
+		`error variables.A.generator: unknown key "min" (known: param_var, param_row, param_col, entry_min, entry_max, lambda_min, lambda_max, max_attempts)`,
+		"error variables.B.generator: max: want int, got 3.5",
+		"error variables.B.generator: min: want int, got -3",
+		`error variables.P.generator: unknown key "min" (rule takes no parameters)`,
+		"error variables.t: generator rule missing",
+		"error variables.v.generator: set: want int_list, got [1 2.5]",
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+
+	// Compile 与旧入口 InstantiateProblem 同样拒绝未声明的键
+	delete(p.Variables, "t")
+	p.Answer.FieldDefs[0].Expr = "det(A) + det(B) + det(C) + det(P) + s"
+	if _, err := Compile(p); err == nil || !strings.Contains(err.Error(), `variable A generator: unknown key "max"`) {
+		t.Fatalf("Compile: %v", err)
+	}
+	if _, err := InstantiateProblem(p, "s", "salt"); err == nil || !strings.Contains(err.Error(), `variable P generator: unknown key "min"`) {
+		t.Fatalf("InstantiateProblem: %v", err)
+	}
+	p.Variables = map[string]Variable{"C": p.Variables["C"]}
+	p.Answer.FieldDefs[0].Expr = "det(C)"
+	if _, err := Compile(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixedWire(t *testing.T) {
+	vars := map[string]Variable{
+		"A": {Kind: "matrix", Rows: 2, Cols: 2, Fixed: [][]interface{}{{1, -2}, {3, 4}}},
+		"v": {Kind: "vector", Size: 3, Fixed: []int{1, 0, -1}},
+		"s": {Kind: "scalar", Fixed: 5},
+	}
+	for name, v := range vars {
+		wire, err := FixedWire(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(wire)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back interface{}
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		want, _ := convertFixed(v)
+		v.Fixed = back
+		got, err := convertFixed(v)
+		if err != nil || ValueToCanonicalString(got) != ValueToCanonicalString(want) {
+			t.Fatalf("%s: %s -> %v, %v", name, data, got, err)
+		}
+	}
+}
+
+func TestValidateProblem_fixed(t *testing.T) {
+	p := Problem{Variables: map[string]Variable{
+		"A": {Kind: "matrix", Rows: 2, Cols: 2, Fixed: []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0}}},
+		"v": {Kind: "vector", Size: 2, Fixed: []interface{}{1.0, 0.5}},
+		"s": {Kind: "scalar", Fixed: "7"},
+	}, Answer: AnswerSchema{FieldDefs: []AnswerFieldDef{{ID: "x", Expr: "1"}}}}
+	want := []string{
+		"error variables.A.fixed: matrix fixed: matrix row 2 has 1 cells, want 2",
+		"error variables.s.fixed: scalar fixed: unsupported type string",
+		"error variables.v.fixed: vector fixed: entry 2: 0.5 is not an integer",
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
+
+func TestValidateProblem_matrixJudge(t *testing.T) {
+	p := compileTestProblem()
+	p.Title += " {{blank:m}} {{blank:n}} {{blank:t}}"
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs,
+		AnswerFieldDef{ID: "m", Expr: "b", Judge: &AnswerJudgeSpec{Kind: "matrix"}},
+		AnswerFieldDef{ID: "n", Expr: "det(A)", Judge: &AnswerJudgeSpec{Kind: "matrix"}},
+		AnswerFieldDef{ID: "t", Expr: "transpose(A)"},
+	)
+	want := []string{
+		"error answer.field_defs[2]: judge matrix: answer is scalar, want matrix",
+		`error answer.field_defs[3]: answer is a 2×2 matrix; use judge kind "matrix" for a whole-matrix blank`,
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+}
+
+func TestValidateProblem_vectorJudge(t *testing.T) {
+	p := compileTestProblem()
+	p.Variables["x"] = Variable{Kind: "vector", Size: 2, Fixed: []int{1, -1}}
+	p.Title += " {{blank:v}} {{blank:n}} {{blank:l1}} {{blank:l2}} {{blank:a}} {{blank:w}}"
+	line := &AnswerJudgeSpec{Kind: "rational_line", LineGroup: "g"}
+	p.Answer.FieldDefs = append(p.Answer.FieldDefs,
+		AnswerFieldDef{ID: "v", Expr: "x", Judge: &AnswerJudgeSpec{Kind: "vector"}},
+		AnswerFieldDef{ID: "n", Expr: "det(A)", Judge: &AnswerJudgeSpec{Kind: "vector"}},
+		AnswerFieldDef{ID: "l1", Expr: "x", Judge: line},
+		AnswerFieldDef{ID: "l2", Expr: "x[1]", Judge: line},
+		AnswerFieldDef{ID: "a", Expr: "x", Judge: &AnswerJudgeSpec{Kind: "affine_rational", AffineGroup: "p", MatrixVar: "A", BVecVar: "x"}},
+		AnswerFieldDef{ID: "w", Expr: "x"},
+	)
+	want := []string{
+		"error answer.field_defs[2]: judge vector: answer is scalar, want vector",
+		"error answer.field_defs[3]: answer is vector(2); a whole-vector blank must be the only field in its line_group g",
+		`error answer.field_defs[6]: answer is vector(2); use judge kind "vector" for a whole-vector blank`,
+	}
+	if got := diagStrings(ValidateProblem(p)); got != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s", got)
+	}
+	if InputConventionOf(Problem{Answer: AnswerSchema{FieldDefs: p.Answer.FieldDefs[5:6]}}) != AnswerInputConventionVector {
+		t.Fatal("single-field affine group should need the vector convention")
+	}
+}
@@ -0,0 +1,585 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// YAMLToJSON 将 YAML 文档转换为等价的 JSON，之后按 JSON 解码（字段名与 json 标签一致）。
+// 只支持描述题目所需的子集：
+//   - 缩进表示的映射与序列（含 "- key: value" 形式的序列项）；
+//   - 单行或跨行的流式 [a, b] / {k: v}；
+//   - 普通、单引号、双引号标量，以及 | / > 块标量（可带 - / + 截断标记）；
+//   - # 注释与开头的 --- 文档标记。
+//
+// 标量按 YAML 1.2 core schema 取类型：null / ~ / 空为 null，true / false 为布尔，整数与小数为数，其余为字符串。
+// 锚点、别名、标签与多文档不支持，遇到时报错。
+func YAMLToJSON(data []byte) ([]byte, error) {
+	v, err := parseYAML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func parseYAML(src string) (interface{}, error) {
+	src = strings.TrimPrefix(src, "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	p := &yamlParser{lines: lines}
+	p.skipBlank()
+	if p.pos < len(lines) && strings.TrimRight(stripYAMLComment(lines[p.pos]), " ") == "---" {
+		p.pos++
+	}
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	v, err := p.parseBlock(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		if t := strings.TrimSpace(stripYAMLComment(p.lines[p.pos])); t == "..." {
+			p.pos++
+			p.skipBlank()
+		}
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected content %q", strings.TrimSpace(p.lines[p.pos]))
+	}
+	return v, nil
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skipBlank 跳过空行与纯注释行。
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && strings.TrimSpace(stripYAMLComment(p.lines[p.pos])) == "" {
+		p.pos++
+	}
+}
+
+// peek 返回下一非空行的缩进与去掉缩进、注释后的内容；没有下一行时 ok 为 false。
+func (p *yamlParser) peek() (indent int, text string, ok bool, err error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return 0, "", false, nil
+	}
+	line := p.lines[p.pos]
+	indent = len(line) - len(strings.TrimLeft(line, " "))
+	if indent < len(line) && line[indent] == '\t' {
+		return 0, "", false, p.errorf("tab in indentation")
+	}
+	return indent, strings.TrimRight(stripYAMLComment(line[indent:]), " "), true, nil
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseBlock 解析缩进不小于 minIndent 的块结点。
+func (p *yamlParser) parseBlock(minIndent int) (interface{}, error) {
+	indent, text, ok, err := p.peek()
+	if err != nil || !ok {
+		return nil, err
+	}
+	if indent < minIndent {
+		return nil, nil
+	}
+	if isSeqItem(text) {
+		return p.parseSeq(indent)
+	}
+	if _, _, isKey := splitYAMLKey(text); isKey {
+		return p.parseMap(indent)
+	}
+	p.pos++
+	return p.parseInline(text, indent)
+}
+
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+	out := []interface{}{}
+	for {
+		ind, text, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || ind != indent || !isSeqItem(text) {
+			if ok && ind > indent {
+				return nil, p.errorf("bad indentation")
+			}
+			return out, nil
+		}
+		rest := strings.TrimLeft(text[1:], " ")
+		if rest == "" {
+			p.pos++
+			v, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		// 把 "- " 换成空格，序列项的内容就成为缩进更深的普通块
+		col := indent + len(text) - len(rest)
+		p.lines[p.pos] = strings.Repeat(" ", col) + p.lines[p.pos][col:]
+		v, err := p.parseBlock(col)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	out := map[string]interface{}{}
+	for {
+		ind, text, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || ind < indent || (ind == indent && isSeqItem(text)) {
+			return out, nil
+		}
+		if ind > indent {
+			return nil, p.errorf("bad indentation")
+		}
+		key, rest, isKey := splitYAMLKey(text)
+		if !isKey {
+			return nil, p.errorf("expected \"key: value\", got %q", text)
+		}
+		k, err := p.parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := out[k]; dup {
+			return nil, p.errorf("duplicate key %q", k)
+		}
+		p.pos++
+		var v interface{}
+		switch {
+		case rest == "":
+			// 值在下面的缩进块中；序列可与键同缩进
+			if ind2, text2, ok2, _ := p.peek(); ok2 && ind2 == indent && isSeqItem(text2) {
+				v, err = p.parseSeq(indent)
+			} else {
+				v, err = p.parseNested(indent)
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			v, err = p.parseBlockScalar(rest, indent)
+		default:
+			v, err = p.parseInline(rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+}
+
+// parseNested 解析缩进大于 parent 的块；没有时为 null。
+func (p *yamlParser) parseNested(parent int) (interface{}, error) {
+	ind, _, ok, err := p.peek()
+	if err != nil || !ok || ind <= parent {
+		return nil, err
+	}
+	return p.parseBlock(ind)
+}
+
+func (p *yamlParser) parseKey(key string) (string, error) {
+	if key != "" && (key[0] == '"' || key[0] == '\'') {
+		s, rest, err := parseYAMLQuoted(key)
+		if err != nil {
+			return "", p.errorf("%v", err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return "", p.errorf("unexpected %q after key", rest)
+		}
+		return s, nil
+	}
+	return key, nil
+}
+
+// parseInline 解析写在同一行（流式集合可跨行）的值；当前行已消费。
+func (p *yamlParser) parseInline(text string, indent int) (interface{}, error) {
+	switch text[0] {
+	case '&', '*', '!':
+		return nil, p.errorf("anchors, aliases and tags are not supported")
+	case '"', '\'':
+		s, rest, err := parseYAMLQuoted(text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, p.errorf("unexpected %q after quoted scalar", rest)
+		}
+		return s, nil
+	case '[', '{':
+		// 括号未闭合时并入后续行
+		start := p.pos - 1
+		for flowDepth(text) > 0 {
+			if p.pos >= len(p.lines) {
+				p.pos = start
+				return nil, p.errorf("unterminated flow collection")
+			}
+			text += " " + strings.TrimSpace(stripYAMLComment(p.lines[p.pos]))
+			p.pos++
+		}
+		f := &yamlFlow{s: text}
+		v, err := f.value()
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if f.skipSpace(); f.i < len(f.s) {
+			return nil, p.errorf("unexpected %q after flow collection", f.s[f.i:])
+		}
+		return v, nil
+	case '|', '>':
+		return p.parseBlockScalar(text, indent)
+	}
+	return yamlPlainScalar(text), nil
+}
+
+// parseBlockScalar 解析 | / > 块标量；header 为指示符及截断标记，内容为随后缩进大于 parent 的行。
+func (p *yamlParser) parseBlockScalar(header string, parent int) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(header[1:])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, p.errorf("unsupported block scalar header %q", header)
+	}
+	var lines []string
+	contentIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		trimmed := strings.TrimLeft(line, " ")
+		ind := len(line) - len(trimmed)
+		if trimmed == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if contentIndent < 0 {
+			if ind <= parent {
+				break
+			}
+			contentIndent = ind
+		}
+		if ind < contentIndent {
+			break
+		}
+		lines = append(lines, line[contentIndent:])
+		p.pos++
+	}
+	// 末尾空行按截断标记处理，且不属于下一结点
+	n := len(lines)
+	for n > 0 && lines[n-1] == "" {
+		n--
+	}
+	trailing := len(lines) - n
+	lines = lines[:n]
+	var sb strings.Builder
+	for i, l := range lines {
+		switch {
+		case !folded:
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+		case l == "":
+			// 折叠时每个空行是一个换行
+			sb.WriteByte('\n')
+		case i == 0 || lines[i-1] == "":
+		case strings.HasPrefix(l, " ") || strings.HasPrefix(lines[i-1], " "):
+			// 缩进更深的行保留换行
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(l)
+	}
+	s := sb.String()
+	switch chomp {
+	case "-":
+	case "+":
+		if n > 0 {
+			s += "\n"
+		}
+		s += strings.Repeat("\n", trailing)
+	default:
+		if n > 0 {
+			s += "\n"
+		}
+	}
+	return s, nil
+}
+
+// splitYAMLKey 在引号与括号外找 "key:" 分隔（冒号后为空白或行尾）。
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	i := 0
+	if text[0] == '"' || text[0] == '\'' {
+		_, after, err := parseYAMLQuoted(text)
+		if err != nil {
+			return "", "", false
+		}
+		i = len(text) - len(after)
+	}
+	for ; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment 去掉引号外、前面是空白或行首的 # 注释。
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" [{,:-", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// flowDepth 返回引号外未闭合的 [ { 层数。
+func flowDepth(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// parseYAMLQuoted 解析 s 开头的引号标量，返回内容与其后的剩余部分。
+func parseYAMLQuoted(s string) (string, string, error) {
+	q := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if q == '\'' {
+			if c == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i++
+					continue
+				}
+				return sb.String(), s[i+1:], nil
+			}
+			sb.WriteByte(c)
+			continue
+		}
+		switch c {
+		case '"':
+			return sb.String(), s[i+1:], nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", fmt.Errorf("unterminated escape")
+			}
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '0':
+				sb.WriteByte(0)
+			case '"', '\\', '/', ' ':
+				sb.WriteByte(e)
+			case 'u', 'U', 'x':
+				n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+				if i+n >= len(s) {
+					return "", "", fmt.Errorf("short \\%c escape", e)
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+				if err != nil {
+					return "", "", fmt.Errorf("bad \\%c escape", e)
+				}
+				sb.WriteRune(rune(r))
+				i += n
+			default:
+				return "", "", fmt.Errorf("unknown escape \\%c", e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted scalar")
+}
+
+// yamlPlainScalar 按 core schema 解析普通标量。
+func yamlPlainScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if c := s[0]; c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') {
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !strings.ContainsAny(s, "xXpP_") {
+			return f
+		}
+	}
+	return s
+}
+
+// yamlFlow 为流式集合的递归下降解析器。
+type yamlFlow struct {
+	s string
+	i int
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.skipSpace()
+	if f.i >= len(f.s) {
+		return nil, fmt.Errorf("unexpected end of flow collection")
+	}
+	switch c := f.s[f.i]; c {
+	case '[':
+		f.i++
+		out := []interface{}{}
+		for {
+			f.skipSpace()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				return out, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			if err := f.sep(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.i++
+		out := map[string]interface{}{}
+		for {
+			f.skipSpace()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				return out, nil
+			}
+			k, err := f.scalar(true)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				key = fmt.Sprint(k)
+			}
+			f.skipSpace()
+			if f.i >= len(f.s) || f.s[f.i] != ':' {
+				return nil, fmt.Errorf("expected ':' after key %q", key)
+			}
+			f.i++
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := out[key]; dup {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			out[key] = v
+			if err := f.sep('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+	return f.scalar(false)
+}
+
+// sep 消费 ',' 或（不消费）结束括号。
+func (f *yamlFlow) sep(end byte) error {
+	f.skipSpace()
+	if f.i < len(f.s) {
+		switch f.s[f.i] {
+		case ',':
+			f.i++
+			return nil
+		case end:
+			return nil
+		}
+	}
+	return fmt.Errorf("expected ',' or '%c'", end)
+}
+
+func (f *yamlFlow) scalar(isKey bool) (interface{}, error) {
+	f.skipSpace()
+	if f.i < len(f.s) && (f.s[f.i] == '"' || f.s[f.i] == '\'') {
+		s, rest, err := parseYAMLQuoted(f.s[f.i:])
+		if err != nil {
+			return nil, err
+		}
+		f.i = len(f.s) - len(rest)
+		return s, nil
+	}
+	start := f.i
+	for f.i < len(f.s) {
+		c := f.s[f.i]
+		if c == ',' || c == ']' || c == '}' || c == '[' || c == '{' {
+			break
+		}
+		if isKey && c == ':' {
+			break
+		}
+		f.i++
+	}
+	return yamlPlainScalar(strings.TrimSpace(f.s[start:f.i])), nil
+}
@@ -0,0 +1,132 @@
+package dsl
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	src := `---
+# 题目
+id: 12
+title: '计算 $\det(A)$，A = {{A}}'
+note: "a\tb \u00e9"
+empty:
+ratio: 0.5
+neg: -3
+flag: true
+name: plain text # 注释
+variables:
+  A:
+    kind: matrix
+    rows: 2
+    cols: 2
+    generator: {rule: range, min: -5, max: 5}
+  B:
+    kind: matrix
+    fixed: [[1, 2],
+            [3, 4]]
+list:
+- a
+- key: x
+  other: 'it''s'
+- - 1
+  - 2
+literal: |
+  line1
+    indented
+  line2
+
+folded: >-
+  one
+  two
+
+  three
+keep: |+
+  x
+
+end: ~
+`
+	got, err := YAMLToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(got, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id": 12.0, "title": `计算 $\det(A)$，A = {{A}}`, "note": "a\tb é", "empty": nil, "ratio": 0.5, "neg": -3.0,
+		"flag": true, "name": "plain text",
+		"variables": map[string]interface{}{
+			"A": map[string]interface{}{"kind": "matrix", "rows": 2.0, "cols": 2.0,
+				"generator": map[string]interface{}{"rule": "range", "min": -5.0, "max": 5.0}},
+			"B": map[string]interface{}{"kind": "matrix", "fixed": []interface{}{
+				[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}}},
+		},
+		"list":    []interface{}{"a", map[string]interface{}{"key": "x", "other": "it's"}, []interface{}{1.0, 2.0}},
+		"literal": "line1\n  indented\nline2\n",
+		"folded":  "one two\nthree",
+		"keep":    "x\n\n",
+		"end":     nil,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got  %s", got)
+	}
+}
+
+func TestYAMLToJSON_errors(t *testing.T) {
+	for src, want := range map[string]string{
+		"a: 1\na: 2":        "line 2: duplicate key \"a\"",
+		"a: &x 1":           "anchors",
+		"a: [1, 2":          "unterminated flow collection",
+		"a: 'x":             "unterminated quoted scalar",
+		"a:\n\tb: 1":        "tab in indentation",
+		"a: 1\n  b: 2":      "bad indentation",
+		"a: {b 1}":          "expected ':'",
+		"- a\nb: 1":         "unexpected content",
+		"a: \"\\q\"":        "unknown escape",
+		"a: |2\n  x":        "unsupported block scalar header",
+		"a: 1\n- b":         "unexpected content",
+		"a: [1] x":          "after flow collection",
+		"x: \"a\" trailing": "after quoted scalar",
+	} {
+		_, err := YAMLToJSON([]byte(src))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", src, err, want)
+		}
+	}
+}
+
+func TestYAMLToJSON_problem(t *testing.T) {
+	src := `
+title: 'A = {{A}}，求 $|A|$'
+variables:
+  A:
+    kind: matrix
+    rows: 2
+    cols: 2
+    generator: {rule: range, min: 2, max: 2}
+answer:
+  field_defs:
+    - id: d
+      expr: det(A)
+`
+	data, err := YAMLToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Problem
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	g, err := GenerateQuestion(p, "s", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.AnswerFields) != 1 || ValueToCanonicalString(g.AnswerFields[0].Value) != "0" {
+		t.Fatalf("%+v", g.AnswerFields)
+	}
+}
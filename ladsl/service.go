@@ -20,6 +20,11 @@ func NewService(serverSalt string) *Service {
 	return &Service{serverSalt: serverSalt}
 }
 
+// Preload 预编译全部题目；建议在服务启动时调用，题目定义有误时直接返回错误而不是等到学生抽题。
+func (s *Service) Preload() error {
+	return bank.CompileAll()
+}
+
 // QuestionKeys 返回题库中全部逻辑题键（可随机抽题）。
 func QuestionKeys() []string {
 	return append([]string(nil), bank.AllQuestionKeys...)
@@ -48,51 +53,66 @@ func DescribeQuestion(questionKey string) (*BlankDescriptor, error) {
 	if err != nil {
 		return nil, err
 	}
+	base, _, _ := strings.Cut(questionKey, bank.VersionSep)
 	return &BlankDescriptor{
-		QuestionKey:       questionKey,
-		ProblemID:         p.ID,
-		Version:           p.Version,
-		InputConventionID: dsl.AnswerInputConventionV1,
-		BlankCount:        len(blanks),
-		Blanks:            blanks,
+		QuestionKey:        base,
+		ProblemID:          p.ID,
+		Version:            p.Version,
+		InputConventionID:  dsl.InputConventionOf(p),
+		InputConventionIDs: dsl.InputConventionsOf(p),
+		BlankCount:         len(blanks),
+		Blanks:             blanks,
 	}, nil
 }
 
 // RollQuestion 生成一题随机实例的对外数据（题面 + 空位 id，不含答案与表达式）。
+// 不带版本的题键按最新版出题，QuestionPublic.Version 为实际使用的版本。
 func (s *Service) RollQuestion(questionKey, seed string) (*QuestionPublic, error) {
-	p, err := bank.BuildProblem(questionKey)
+	cp, err := bank.CompiledProblem(questionKey)
 	if err != nil {
 		return nil, err
 	}
-	g, err := bank.GenerateBankQuestion(questionKey, seed, s.serverSalt)
+	g, err := cp.GenerateQuestion(seed, s.serverSalt)
 	if err != nil {
 		return nil, err
 	}
-	return publicFromGenerated(questionKey, p, seed, g), nil
+	return publicFromGenerated(questionKey, cp.Problem, seed, g), nil
 }
 
 // RollQuestionServer 一次生成：对外题面 + 完整标准答案（Private 仅服务端使用）。
 func (s *Service) RollQuestionServer(questionKey, seed string) (*QuestionServerBundle, error) {
-	p, err := bank.BuildProblem(questionKey)
+	cp, err := bank.CompiledProblem(questionKey)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := cp.Instantiate(seed, s.serverSalt)
 	if err != nil {
 		return nil, err
 	}
-	g, err := bank.GenerateBankQuestion(questionKey, seed, s.serverSalt)
+	g, err := cp.GenerateQuestionFromInstance(inst)
 	if err != nil {
 		return nil, err
 	}
 	return &QuestionServerBundle{
-		Public:  publicFromGenerated(questionKey, p, seed, g),
-		Private: g,
+		Public:   publicFromGenerated(questionKey, cp.Problem, seed, g),
+		Private:  g,
+		Instance: inst,
 	}, nil
 }
 
 // Judge 根据与用户出题时相同的 key、seed、serverSalt 重算标准答案并判分。
+// questionKey 宜带出题时的版本（QuestionKey@Version，见 bank.VersionedKey），题目修订后仍按原版本判分；
+// 不带版本时按 bank.InitialVersion 判分（早于版本字段的后端只存了题键与 seed），只有 RollQuestion 默认取最新版。
 func (s *Service) Judge(questionKey, seed string, userAnswers map[string]string, opts *dsl.JudgeOptions) (*dsl.JudgeResult, error) {
 	return bank.JudgeBankQuestion(questionKey, seed, s.serverSalt, userAnswers, opts)
 }
 
-// Explain 生成结构化解析（与 Judge 同源数据）。
+// JudgeSnapshot 在 RollQuestionServer 返回并存档的实例快照上判分，不依赖 serverSalt 与当前生成规则。
+func (s *Service) JudgeSnapshot(questionKey string, snap *dsl.Instance, userAnswers map[string]string, opts *dsl.JudgeOptions) (*dsl.JudgeResult, error) {
+	return bank.JudgeBankSnapshot(questionKey, snap, userAnswers, opts)
+}
+
+// Explain 生成结构化解析（与 Judge 同源数据），questionKey 的版本规则同 Judge。
 func (s *Service) Explain(questionKey, seed string) (*dsl.QuestionExplanation, error) {
 	return bank.GenerateBankExplanation(questionKey, seed, s.serverSalt)
 }
@@ -102,20 +122,32 @@ func InputContractV1() dsl.AnswerInputContractDoc {
 	return dsl.AnswerInputContractV1()
 }
 
+// InputContractV2 返回含整矩阵空的输入约定文档；题目的 InputConventionID 为 V2 时按此说明收题。
+func InputContractV2() dsl.AnswerInputContractDoc {
+	return dsl.AnswerInputContractV2()
+}
+
+// InputContracts 返回全部输入约定文档（V1 与各扩展），题目的 InputConventionIDs 中每项都可在此查到说明。
+func InputContracts() []dsl.AnswerInputContractDoc {
+	return dsl.AnswerInputContracts()
+}
+
 func publicFromGenerated(key string, p dsl.Problem, seed string, g *dsl.GeneratedQuestion) *QuestionPublic {
 	blanks := make([]BlankInfo, len(g.AnswerFields))
 	for i, f := range g.AnswerFields {
 		blanks[i] = BlankInfo{ID: f.ID, Order: i + 1, Layout: f.Layout}
 	}
+	base, _, _ := strings.Cut(key, bank.VersionSep)
 	return &QuestionPublic{
-		QuestionKey:       key,
-		ProblemID:         p.ID,
-		Version:           p.Version,
-		InputConventionID: dsl.AnswerInputConventionV1,
-		FieldHints:          dsl.FieldInputHintsFromGenerated(g),
-		Seed:                seed,
-		Title:               g.Title,
-		Blanks:              blanks,
+		QuestionKey:        base,
+		ProblemID:          p.ID,
+		Version:            p.Version,
+		InputConventionID:  dsl.InputConventionOf(p),
+		InputConventionIDs: dsl.InputConventionsOf(p),
+		FieldHints:         dsl.FieldInputHintsFromGenerated(g),
+		Seed:               seed,
+		Title:              g.Title,
+		Blanks:             blanks,
 	}
 }
 
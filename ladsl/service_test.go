@@ -1,9 +1,11 @@
 package ladsl
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/neumathe/la-dsl/bank"
+	"github.com/neumathe/la-dsl/dsl"
 )
 
 func TestDescribeQuestionMatchesRoll(t *testing.T) {
@@ -28,6 +30,28 @@ func TestDescribeQuestionMatchesRoll(t *testing.T) {
 			t.Fatalf("id[%d]: %q vs %q", i, pub.Blanks[i].ID, desc.Blanks[i].ID)
 		}
 	}
+	if len(pub.InputConventionIDs) != 1 || pub.InputConventionIDs[0] != dsl.AnswerInputConventionV1 || desc.InputConventionID != dsl.AnswerInputConventionV1 {
+		t.Fatalf("conventions: %+v / %+v", pub.InputConventionIDs, desc)
+	}
+}
+
+// TestInputContractsCoverConventions 题库中出现的每个输入约定标识都有对应的说明文档。
+func TestInputContractsCoverConventions(t *testing.T) {
+	docs := map[string]bool{}
+	for _, d := range InputContracts() {
+		docs[d.ID] = true
+	}
+	for _, key := range bank.AllQuestionKeys {
+		desc, err := DescribeQuestion(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range desc.InputConventionIDs {
+			if !docs[id] {
+				t.Fatalf("%s: no contract for %s", key, id)
+			}
+		}
+	}
 }
 
 func TestDescribeAllKeysCatalog(t *testing.T) {
@@ -73,6 +97,52 @@ func TestRollQuestionServer(t *testing.T) {
 	if len(b.Private.AnswerFields) != len(b.Public.Blanks) {
 		t.Fatal("bundle mismatch")
 	}
+	// 存档的快照可在其他服务实例（不同 salt）上判分
+	data, err := json.Marshal(b.Instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap dsl.Instance
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]string{}
+	for _, f := range b.Private.AnswerFields {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	res, err := NewService("other-salt").JudgeSnapshot("Chapter1_1", &snap, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.AllCorrect {
+		t.Fatalf("%+v", res.Fields)
+	}
+}
+
+func TestJudge_versionedKey(t *testing.T) {
+	s := NewService("srv-salt")
+	b, err := s.RollQuestionServer("Chapter4_8", "ver-seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Public.QuestionKey != "Chapter4_8" || b.Public.Version != bank.InitialVersion {
+		t.Fatalf("%s %s", b.Public.QuestionKey, b.Public.Version)
+	}
+	user := map[string]string{}
+	for _, f := range b.Private.AnswerFields {
+		user[f.ID] = dsl.ValueToCanonicalString(f.Value)
+	}
+	key := bank.VersionedKey(b.Public.QuestionKey, b.Public.Version)
+	res, err := s.Judge(key, "ver-seed", user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.AllCorrect {
+		t.Fatalf("%+v", res.Fields)
+	}
+	if _, err := s.Explain(key, "ver-seed"); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestValidQuestionKey(t *testing.T) {
@@ -80,3 +150,9 @@ func TestValidQuestionKey(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestPreload(t *testing.T) {
+	if err := NewService("test-salt").Preload(); err != nil {
+		t.Fatal(err)
+	}
+}